@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	cconfig "astral/internal/config"
+	"astral/internal/keyring"
+	ppostgresClient "astral/internal/storage/postgres_client"
+)
+
+// runCommand executes an administrative subcommand instead of starting the http server.
+func runCommand(ctx context.Context, args []string, config *cconfig.Config, kr *keyring.Keyring, logger *zap.Logger) error {
+	switch args[0] {
+	case "rewrap-keys":
+		return rewrapKeys(ctx, config, kr, logger)
+
+	case "migrate":
+		return runMigrate(ctx, args[1:], config, logger)
+
+	default:
+		return fmt.Errorf("unknown command: %s", args[0])
+	}
+}
+
+// rewrapKeys re-wraps every document data key under ENCRYPTION_ACTIVE_KEY.
+// Old master keys have to stay in the keyring until the command finishes.
+func rewrapKeys(ctx context.Context, config *cconfig.Config, kr *keyring.Keyring, logger *zap.Logger) error {
+	postgresClient, err := ppostgresClient.New(ctx, &config.Postgres, kr, logger, pathToMigrations)
+	if err != nil {
+		return fmt.Errorf("failed to initialize postgres client: %w", err)
+	}
+	defer postgresClient.Close()
+
+	count, err := postgresClient.RewrapDataKeys(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to rewrap data keys: %w", err)
+	}
+
+	logger.Info("rewrapped data keys", zap.Int("count", count), zap.String("key_id", kr.ActiveKeyID()))
+	return nil
+}
@@ -31,7 +31,6 @@ import (
 	"github.com/swaggo/http-swagger"
 
 	_ "astral/docs"
-	rredisClient "astral/internal/storage/redis_client"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -40,9 +39,15 @@ import (
 	"astral/internal/api/handler"
 	mmiddleware "astral/internal/api/middleware"
 	"astral/internal/auth"
+	ccompressor "astral/internal/compressor"
 	cconfig "astral/internal/config"
+	eexpiry "astral/internal/expiry"
+	kkeyring "astral/internal/keyring"
 	llogger "astral/internal/logger"
-	ppostgresClient "astral/internal/storage/postgres_client"
+	mmimeSniffer "astral/internal/mime_sniffer"
+	sschemaValidator "astral/internal/schema_validator"
+	ttextExtractor "astral/internal/text_extractor"
+	ttrash "astral/internal/trash"
 )
 
 const (
@@ -70,14 +75,21 @@ func main() {
 	}
 	defer logger.Sync()
 
-	postgresClient, err := ppostgresClient.New(ctx, &config.Postgres, logger, pathToMigrations)
+	keyring, err := kkeyring.New(&config.Encryption, logger)
 	if err != nil {
-		logger.Fatal("failed to initialize postgres client", zap.Error(err))
+		logger.Fatal("failed to initialize keyring", zap.Error(err))
 	}
 
-	redisClient, err := rredisClient.New(ctx, &config.Redis, logger)
+	if len(os.Args) > 1 {
+		if err = runCommand(ctx, os.Args[1:], config, keyring, logger); err != nil {
+			logger.Fatal("command failed", zap.String("command", os.Args[1]), zap.Error(err))
+		}
+		return
+	}
+
+	postgresClient, redisClient, err := newStorage(ctx, config, keyring, logger)
 	if err != nil {
-		logger.Fatal("failed to initialize redis client", zap.Error(err))
+		logger.Fatal("failed to initialize storage", zap.Error(err))
 	}
 
 	router := chi.NewRouter()
@@ -89,15 +101,82 @@ func main() {
 	router.Use(middleware.URLFormat)
 
 	authService := auth.New(&config.Auth, logger)
+	mimeSniffer := mmimeSniffer.New(&config.Mime, logger)
+	schemaValidator := sschemaValidator.New(&config.Schema, logger)
+	textExtractor := ttextExtractor.New(&config.Search, logger)
+
+	compressor, err := ccompressor.New(&config.Compression, logger)
+	if err != nil {
+		logger.Fatal("failed to initialize compressor", zap.Error(err))
+	}
 
 	router.With(mmiddleware.RequireAdminToken(authService, logger)).
 		Post("/api/register", handler.Register(postgresClient, authService, logger))
+	router.Group(func(r chi.Router) {
+		r.Use(mmiddleware.RequireAdminToken(authService, logger))
+
+		r.Put("/api/users/{login}/quota", handler.SetQuota(postgresClient, logger))
+
+		r.Post("/api/holds", handler.CreateHold(postgresClient, redisClient, logger))
+		r.Get("/api/holds", handler.ListHolds(postgresClient, logger))
+		r.Delete("/api/holds/{id}", handler.DeleteHold(postgresClient, redisClient, logger))
+
+		r.Put("/api/retention/{name}", handler.SaveRetentionRule(postgresClient, logger))
+		r.Get("/api/retention", handler.ListRetentionRules(postgresClient, logger))
+		r.Delete("/api/retention/{name}", handler.DeleteRetentionRule(postgresClient, logger))
+
+		r.Delete("/api/locks/{id}", handler.BreakLock(redisClient, logger))
+
+		r.Get("/api/cache/stats", handler.GetCacheStats(redisClient, logger))
+		r.Get("/api/db/stats", handler.GetPoolStats(postgresClient, logger))
+	})
 
 	router.Post("/api/auth", handler.Auth(postgresClient, redisClient, authService, logger))
-	router.Post("/api/docs", handler.LoadDocs(postgresClient, redisClient, authService, logger))
+	router.Post("/api/docs", handler.LoadDocs(postgresClient, redisClient, authService, mimeSniffer, compressor, schemaValidator, textExtractor, logger))
+
+	router.Group(func(r chi.Router) {
+		r.Use(mmiddleware.RequireToken(redisClient, authService, logger))
+
+		r.Get("/api/docs", handler.ListDocs(postgresClient, redisClient, logger))
+		r.Get("/api/docs/search", handler.SearchDocs(postgresClient, logger))
+		r.Get("/api/docs/stats", handler.GetStats(postgresClient, logger))
+		r.Get("/api/docs/{id}", handler.GetDoc(postgresClient, redisClient, compressor, logger))
+		r.Put("/api/docs/{id}", handler.UpdateDoc(postgresClient, redisClient, mimeSniffer, compressor, schemaValidator, textExtractor, logger))
+		r.Put("/api/docs/{id}/metadata", handler.UpdateLabels(postgresClient, redisClient, logger))
+		r.Put("/api/docs/{id}/expiry", handler.UpdateExpiry(postgresClient, redisClient, logger))
+		r.Post("/api/docs/{id}/move", handler.MoveDoc(postgresClient, redisClient, logger))
+		r.Patch("/api/docs/{id}", handler.PatchDoc(postgresClient, redisClient, compressor, schemaValidator, textExtractor, logger))
+		r.Delete("/api/docs/{id}", handler.DeleteDoc(postgresClient, redisClient, logger))
+		r.Post("/api/docs/{id}/lock", handler.LockDoc(postgresClient, redisClient, logger))
+		r.Delete("/api/docs/{id}/lock", handler.UnlockDoc(postgresClient, redisClient, logger))
+
+		r.Get("/api/docs/{id}/versions", handler.ListVersions(postgresClient, logger))
+		r.Get("/api/docs/{id}/versions/{version}", handler.GetVersion(postgresClient, compressor, logger))
+		r.Post("/api/docs/{id}/versions/{version}/restore", handler.RestoreVersion(postgresClient, redisClient, logger))
+
+		r.Post("/api/folders", handler.CreateFolder(postgresClient, logger))
+		r.Get("/api/folders", handler.ListFolders(postgresClient, logger))
+		r.Patch("/api/folders/{id}", handler.UpdateFolder(postgresClient, redisClient, logger))
+		r.Put("/api/folders/{id}/grant", handler.ShareFolder(postgresClient, redisClient, logger))
+
+		r.Get("/api/users/me/usage", handler.GetUsage(postgresClient, logger))
+
+		r.Get("/api/trash", handler.ListTrash(postgresClient, logger))
+		r.Post("/api/trash/{id}/restore", handler.RestoreTrash(postgresClient, redisClient, logger))
+		r.Delete("/api/trash/{id}", handler.PurgeTrash(postgresClient, redisClient, logger))
+
+		r.Post("/api/schemas", handler.SaveSchema(postgresClient, schemaValidator, logger))
+		r.Get("/api/schemas/{name}", handler.GetSchema(postgresClient, logger))
+	})
 
 	router.Get("/swagger/*", httpSwagger.WrapHandler)
 
+	purger := ttrash.New(&config.Trash, postgresClient, redisClient, logger)
+	go purger.Run(ctx)
+
+	sweeper := eexpiry.New(&config.Expiry, postgresClient, redisClient, logger)
+	go sweeper.Run(ctx)
+
 	server := http.Server{
 		Addr:    fmt.Sprintf("%s:%d", config.HttpServer.Host, config.HttpServer.Port),
 		Handler: router,
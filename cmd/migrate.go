@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	cconfig "astral/internal/config"
+	sstorage "astral/internal/storage"
+	ppostgresClient "astral/internal/storage/postgres_client"
+)
+
+const migrateUsage = "usage: migrate up [N] | down N | status | force VERSION | create NAME"
+
+// runMigrate manages the Postgres schema:
+//
+//	migrate up [N]         apply the next N or all pending migrations
+//	migrate down N         revert the last N migrations
+//	migrate status         print the applied and the latest version
+//	migrate force VERSION  set the version after fixing a failed migration by hand
+//	migrate create NAME    add an empty timestamped pair of migration files
+func runMigrate(ctx context.Context, args []string, config *cconfig.Config, logger *zap.Logger) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%s", migrateUsage)
+	}
+
+	if args[0] == "create" {
+		if len(args) != 2 {
+			return fmt.Errorf("%s", migrateUsage)
+		}
+
+		paths, err := ppostgresClient.CreateMigration(pathToMigrations, args[1], time.Now())
+		if err != nil {
+			return err
+		}
+
+		for _, path := range paths {
+			fmt.Println(path)
+		}
+
+		return nil
+	}
+
+	if config.Storage.Backend != sstorage.BackendPostgres {
+		return fmt.Errorf("migrate manages the postgres schema, the %s backend migrates itself on start", config.Storage.Backend)
+	}
+
+	migrator, err := ppostgresClient.NewMigrator(ctx, &config.Postgres, pathToMigrations, logger)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	switch args[0] {
+	case "up":
+		steps, err := migrateSteps(args, false)
+		if err != nil {
+			return err
+		}
+
+		return migrator.Up(steps)
+
+	case "down":
+		steps, err := migrateSteps(args, true)
+		if err != nil {
+			return err
+		}
+
+		return migrator.Down(steps)
+
+	case "status":
+		status, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("version: %d\ndirty: %t\nlatest: %d\npending: %d\n", status.Version, status.Dirty, status.Latest, status.Pending)
+		return nil
+
+	case "force":
+		if len(args) != 2 {
+			return fmt.Errorf("%s", migrateUsage)
+		}
+
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid version %q: %w", args[1], err)
+		}
+
+		return migrator.Force(version)
+
+	default:
+		return fmt.Errorf("unknown migrate command: %s, %s", args[0], migrateUsage)
+	}
+}
+
+// migrateSteps parses the optional step count of up and down, zero stands for all.
+func migrateSteps(args []string, required bool) (int, error) {
+	if len(args) == 1 && !required {
+		return 0, nil
+	}
+
+	if len(args) != 2 {
+		return 0, fmt.Errorf("%s", migrateUsage)
+	}
+
+	steps, err := strconv.Atoi(args[1])
+	if err != nil || steps <= 0 {
+		return 0, fmt.Errorf("invalid number of steps %q", args[1])
+	}
+
+	return steps, nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	cconfig "astral/internal/config"
+	"astral/internal/keyring"
+	llocalCache "astral/internal/local_cache"
+	sstorage "astral/internal/storage"
+	mmemoryStorage "astral/internal/storage/memory_storage"
+	ppostgresClient "astral/internal/storage/postgres_client"
+	rredisClient "astral/internal/storage/redis_client"
+	ssqliteClient "astral/internal/storage/sqlite_client"
+)
+
+// newStorage creates the clients of the backend chosen by STORAGE. Background work
+// of the clients runs until ctx is done.
+func newStorage(ctx context.Context, config *cconfig.Config, kr *keyring.Keyring, logger *zap.Logger) (ppostgresClient.PostgresClient, rredisClient.RedisClient, error) {
+	switch config.Storage.Backend {
+	case sstorage.BackendPostgres:
+		postgresClient, err := ppostgresClient.New(ctx, &config.Postgres, kr, logger, pathToMigrations)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to initialize postgres client: %w", err)
+		}
+
+		localCache := llocalCache.New(&config.LocalCache, logger)
+
+		// A listing invalidated by a write is loaded from the primary until the write reached
+		// the replicas, so that grantees do not get the old listing cached for REDIS_CACHE_TTL.
+		redisConfig := config.Redis
+		if len(config.Postgres.ReplicaURLs) > 0 {
+			redisConfig.FreshWindow = config.Postgres.PinPrimary
+		}
+
+		redisClient, err := rredisClient.New(ctx, &redisConfig, localCache, logger)
+		if err != nil {
+			postgresClient.Close()
+			return nil, nil, fmt.Errorf("failed to initialize redis client: %w", err)
+		}
+
+		go redisClient.Listen(ctx)
+
+		return postgresClient, redisClient, nil
+
+	case sstorage.BackendSQLite:
+		sqliteClient, err := ssqliteClient.New(ctx, &config.SQLite, &config.Postgres, kr, logger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to initialize sqlite client: %w", err)
+		}
+
+		// A single process has nobody to share a cache with, so it stays in memory.
+		return sqliteClient, mmemoryStorage.NewCache(&config.Redis, logger), nil
+
+	case sstorage.BackendMemory:
+		logger.Warn("using in-memory storage, all data is lost on shutdown")
+
+		return mmemoryStorage.NewStore(&config.Postgres, logger), mmemoryStorage.NewCache(&config.Redis, logger), nil
+
+	default:
+		return nil, nil, fmt.Errorf("unknown storage backend: %s", config.Storage.Backend)
+	}
+}
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_doc_grants_grantee;
+DROP INDEX IF EXISTS idx_doc_grants_doc_id;
+DROP INDEX IF EXISTS idx_documents_owner;
+
+DROP TABLE IF EXISTS documents_grants;
+DROP TABLE IF EXISTS documents;
+DROP TABLE IF EXISTS users;
@@ -1,15 +1,14 @@
-CREATE SCHEMA IF NOT EXISTS schema_astral;
 
-CREATE TABLE IF NOT EXISTS schema_astral.users
+CREATE TABLE IF NOT EXISTS users
 (
     login TEXT PRIMARY KEY,
     password_hash TEXT
 );
 
-CREATE TABLE IF NOT EXISTS schema_astral.documents
+CREATE TABLE IF NOT EXISTS documents
 (
     id UUID PRIMARY KEY,
-    login TEXT NOT NULL REFERENCES schema_astral.users(login) ON DELETE CASCADE,
+    login TEXT NOT NULL REFERENCES users(login) ON DELETE CASCADE,
     name TEXT,
 	mime TEXT,
 	is_file BOOLEAN NOT NULL DEFAULT FALSE,
@@ -19,15 +18,15 @@ CREATE TABLE IF NOT EXISTS schema_astral.documents
     created_at TIMESTAMP
 );
 
-CREATE TABLE IF NOT EXISTS schema_astral.documents_grants
+CREATE TABLE IF NOT EXISTS documents_grants
 (
     id SERIAL PRIMARY KEY,
-    doc_id UUID NOT NULL REFERENCES schema_astral.documents(id) ON DELETE CASCADE,
-    grantee_login TEXT NOT NULL REFERENCES schema_astral.users(login) ON DELETE CASCADE
+    doc_id UUID NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+    grantee_login TEXT NOT NULL REFERENCES users(login) ON DELETE CASCADE
 );
 
-CREATE INDEX IF NOT EXISTS idx_documents_owner ON schema_astral.documents(login);
-CREATE INDEX IF NOT EXISTS idx_doc_grants_doc_id ON schema_astral.documents_grants(doc_id);
-CREATE INDEX IF NOT EXISTS idx_doc_grants_grantee ON schema_astral.documents_grants(grantee_login);
+CREATE INDEX IF NOT EXISTS idx_documents_owner ON documents(login);
+CREATE INDEX IF NOT EXISTS idx_doc_grants_doc_id ON documents_grants(doc_id);
+CREATE INDEX IF NOT EXISTS idx_doc_grants_grantee ON documents_grants(grantee_login);
 
 
@@ -0,0 +1,3 @@
+ALTER TABLE documents
+    DROP COLUMN IF EXISTS codec,
+    DROP COLUMN IF EXISTS size;
@@ -0,0 +1,5 @@
+ALTER TABLE documents
+    ADD COLUMN IF NOT EXISTS codec TEXT NOT NULL DEFAULT 'identity',
+    ADD COLUMN IF NOT EXISTS size BIGINT;
+
+UPDATE documents SET size = octet_length(content) WHERE size IS NULL;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_documents_key_id;
+
+ALTER TABLE documents
+    DROP COLUMN IF EXISTS data_key,
+    DROP COLUMN IF EXISTS key_id,
+    DROP COLUMN IF EXISTS json_sealed;
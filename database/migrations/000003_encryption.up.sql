@@ -0,0 +1,6 @@
+ALTER TABLE documents
+    ADD COLUMN IF NOT EXISTS data_key BYTEA,
+    ADD COLUMN IF NOT EXISTS key_id TEXT,
+    ADD COLUMN IF NOT EXISTS json_sealed BYTEA;
+
+CREATE INDEX IF NOT EXISTS idx_documents_key_id ON documents(key_id) WHERE key_id IS NOT NULL;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS document_versions;
+
+ALTER TABLE documents
+    DROP COLUMN IF EXISTS version,
+    DROP COLUMN IF EXISTS updated_at;
@@ -0,0 +1,25 @@
+ALTER TABLE documents
+    ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS document_versions
+(
+    doc_id UUID NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+    version INT NOT NULL,
+    name TEXT,
+    mime TEXT,
+    is_file BOOLEAN NOT NULL DEFAULT FALSE,
+    content BYTEA,
+    json JSONB,
+    json_sealed BYTEA,
+    codec TEXT NOT NULL DEFAULT 'identity',
+    size BIGINT,
+    data_key BYTEA,
+    key_id TEXT,
+    created_at TIMESTAMP,
+    archived_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (doc_id, version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_document_versions_archived_at ON document_versions(archived_at);
+CREATE INDEX IF NOT EXISTS idx_document_versions_key_id ON document_versions(key_id) WHERE key_id IS NOT NULL;
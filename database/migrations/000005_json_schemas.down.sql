@@ -0,0 +1,7 @@
+ALTER TABLE document_versions
+    DROP COLUMN IF EXISTS schema_name;
+
+ALTER TABLE documents
+    DROP COLUMN IF EXISTS schema_name;
+
+DROP TABLE IF EXISTS json_schemas;
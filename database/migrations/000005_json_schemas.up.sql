@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS json_schemas
+(
+    login TEXT NOT NULL REFERENCES users(login) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    schema JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP,
+    PRIMARY KEY (login, name)
+);
+
+ALTER TABLE documents
+    ADD COLUMN IF NOT EXISTS schema_name TEXT;
+
+ALTER TABLE document_versions
+    ADD COLUMN IF NOT EXISTS schema_name TEXT;
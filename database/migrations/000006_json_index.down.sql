@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_documents_created_at;
+DROP INDEX IF EXISTS idx_documents_json;
@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS idx_documents_json ON documents USING GIN (json jsonb_path_ops);
+CREATE INDEX IF NOT EXISTS idx_documents_created_at ON documents(created_at DESC, id);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_documents_search;
+
+ALTER TABLE document_versions
+    DROP COLUMN IF EXISTS search_text;
+
+ALTER TABLE documents
+    DROP COLUMN IF EXISTS search_vector,
+    DROP COLUMN IF EXISTS search_text;
@@ -0,0 +1,11 @@
+ALTER TABLE documents
+    ADD COLUMN IF NOT EXISTS search_text TEXT,
+    ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', COALESCE(name, '')), 'A') ||
+        setweight(to_tsvector('simple', COALESCE(search_text, '')), 'B')
+    ) STORED;
+
+ALTER TABLE document_versions
+    ADD COLUMN IF NOT EXISTS search_text TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_documents_search ON documents USING GIN (search_vector);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_documents_metadata;
+DROP INDEX IF EXISTS idx_documents_tags;
+
+ALTER TABLE documents
+    DROP COLUMN IF EXISTS metadata,
+    DROP COLUMN IF EXISTS tags;
@@ -0,0 +1,6 @@
+ALTER TABLE documents
+    ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_documents_tags ON documents USING GIN (tags);
+CREATE INDEX IF NOT EXISTS idx_documents_metadata ON documents USING GIN (metadata jsonb_path_ops);
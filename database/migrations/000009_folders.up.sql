@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS folders
+(
+    id UUID PRIMARY KEY,
+    login TEXT NOT NULL REFERENCES users(login) ON DELETE CASCADE,
+    parent_id UUID REFERENCES folders(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_folders_unique_name
+    ON folders(login, parent_id, name) NULLS NOT DISTINCT;
+CREATE INDEX IF NOT EXISTS idx_folders_parent_id ON folders(parent_id);
+
+CREATE TABLE IF NOT EXISTS folder_grants
+(
+    folder_id UUID NOT NULL REFERENCES folders(id) ON DELETE CASCADE,
+    grantee_login TEXT NOT NULL REFERENCES users(login) ON DELETE CASCADE,
+    PRIMARY KEY (folder_id, grantee_login)
+);
+
+CREATE INDEX IF NOT EXISTS idx_folder_grants_grantee ON folder_grants(grantee_login);
+
+ALTER TABLE documents
+    ADD COLUMN IF NOT EXISTS folder_id UUID REFERENCES folders(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_documents_folder_id ON documents(folder_id);
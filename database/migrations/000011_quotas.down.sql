@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS user_usage;
+
+ALTER TABLE document_versions
+    DROP COLUMN IF EXISTS usage_bytes;
+ALTER TABLE documents
+    DROP COLUMN IF EXISTS usage_bytes;
@@ -0,0 +1,25 @@
+ALTER TABLE documents
+    ADD COLUMN IF NOT EXISTS usage_bytes BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE document_versions
+    ADD COLUMN IF NOT EXISTS usage_bytes BIGINT NOT NULL DEFAULT 0;
+
+UPDATE documents
+SET usage_bytes = COALESCE(size, 0) + COALESCE(octet_length(json::text), octet_length(json_sealed), 0);
+UPDATE document_versions
+SET usage_bytes = COALESCE(size, 0) + COALESCE(octet_length(json::text), octet_length(json_sealed), 0);
+
+CREATE TABLE IF NOT EXISTS user_usage
+(
+    login TEXT PRIMARY KEY REFERENCES users(login) ON DELETE CASCADE,
+    documents BIGINT NOT NULL DEFAULT 0,
+    bytes BIGINT NOT NULL DEFAULT 0,
+    max_documents BIGINT,
+    max_bytes BIGINT
+);
+
+INSERT INTO user_usage (login, documents, bytes)
+SELECT u.login, count(d.id), COALESCE(sum(d.usage_bytes), 0)
+FROM users u
+LEFT JOIN documents d ON d.login = u.login
+GROUP BY u.login
+ON CONFLICT (login) DO NOTHING;
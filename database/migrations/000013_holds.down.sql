@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS retention_rules;
+DROP TABLE IF EXISTS legal_holds;
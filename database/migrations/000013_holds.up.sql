@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS legal_holds
+(
+    id UUID PRIMARY KEY,
+    doc_id UUID REFERENCES documents(id) ON DELETE CASCADE,
+    login TEXT REFERENCES users(login) ON DELETE CASCADE,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    CHECK ((doc_id IS NULL) <> (login IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_legal_holds_doc_id ON legal_holds(doc_id) WHERE doc_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_legal_holds_login ON legal_holds(login) WHERE login IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS retention_rules
+(
+    name TEXT PRIMARY KEY,
+    tag TEXT,
+    schema_name TEXT,
+    keep_days INT NOT NULL CHECK (keep_days > 0),
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    CHECK (tag IS NOT NULL OR schema_name IS NOT NULL)
+);
@@ -0,0 +1,5 @@
+UPDATE user_usage u
+SET bytes = COALESCE((SELECT sum(d.usage_bytes) FROM documents d WHERE d.login = u.login), 0);
+
+ALTER TABLE documents
+    DROP COLUMN IF EXISTS versions_bytes;
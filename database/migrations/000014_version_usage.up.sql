@@ -0,0 +1,10 @@
+ALTER TABLE documents
+    ADD COLUMN IF NOT EXISTS versions_bytes BIGINT NOT NULL DEFAULT 0;
+
+UPDATE documents d
+SET versions_bytes = v.bytes
+FROM (SELECT doc_id, sum(usage_bytes) AS bytes FROM document_versions GROUP BY doc_id) v
+WHERE v.doc_id = d.id;
+
+UPDATE user_usage u
+SET bytes = COALESCE((SELECT sum(d.usage_bytes + d.versions_bytes) FROM documents d WHERE d.login = u.login), 0);
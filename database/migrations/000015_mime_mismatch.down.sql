@@ -0,0 +1,5 @@
+ALTER TABLE document_versions
+    DROP COLUMN IF EXISTS detected_mime;
+
+ALTER TABLE documents
+    DROP COLUMN IF EXISTS detected_mime;
@@ -72,7 +72,190 @@ const docTemplate = `{
                 }
             }
         },
+        "/api/cache/stats": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns how many downloads of this instance were served from the content cache and how many went to the database, since the start of the process. Requires the admin token.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Content cache statistics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin token (or Authorization: Bearer \u003ctoken\u003e)",
+                        "name": "token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Cache statistics",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/db/stats": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the connections of the primary and of every read replica, how many reads each one served, replica failures, and reads kept on the primary after a write or after a replica failed. Counters start with the process. The list is empty for storage backends without connection pools. Requires the admin token.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Database pool statistics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin token (or Authorization: Bearer \u003ctoken\u003e)",
+                        "name": "token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Pool statistics",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/api/docs": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists documents the current user can read, newest first. Results are cached per user and query for REDIS_CACHE_TTL and dropped whenever a document visible to the user changes. JSON filters are combined with AND: \"json\" keeps documents whose JSON contains the given value (@\u003e), every \"jsonpath\" is a predicate like \"$.amount \u003e 100\", \"$.lines[0].sku == \\\"A-1\\\"\" or \"exists($.customer.email)\". Every \"tag\" must be set on the document and every \"meta.\u003ckey\u003e\" parameter must match its metadata, e.g. \"tag=invoice\u0026meta.project=apollo\". JSON filters are refused with 400 while encryption is enabled: sealed documents have no plain JSON to filter on.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "docs"
+                ],
+                "summary": "List documents",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User token (or Authorization: Bearer \u003ctoken\u003e)",
+                        "name": "token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only documents of this owner",
+                        "name": "login",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only documents directly inside this folder of the owner (login or the current user), / for the root",
+                        "name": "path",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON object or array the document JSON must contain",
+                        "name": "json",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "JSONPath predicate, can be repeated",
+                        "name": "jsonpath",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Tag the document must have, can be repeated",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, 100 by default, at most 1000",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of documents to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Documents",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter or paging parameters / JSON filter with encryption enabled",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Folder not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    }
+                }
+            },
             "post": {
                 "description": "Upload a document (file or JSON). The request is multipart/form-data.",
                 "consumes": [
@@ -114,7 +297,7 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Invalid form data / missing meta / missing file",
+                        "description": "Invalid form data / missing meta / missing file / invalid json / json does not match schema / invalid tags or metadata / invalid expiry / unknown grantee",
                         "schema": {
                             "$ref": "#/definitions/api.mainResponse"
                         }
@@ -125,53 +308,146 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.mainResponse"
                         }
                     },
+                    "403": {
+                        "description": "Folder belongs to another user",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Folder not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Document is larger than the storage quota",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Mime does not match file content or is not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
                     "500": {
                         "description": "Server error (DB/Redis/IO)",
                         "schema": {
                             "$ref": "#/definitions/api.mainResponse"
                         }
+                    },
+                    "507": {
+                        "description": "Storage quota exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
                     }
                 }
             }
         },
-        "/api/register": {
-            "post": {
+        "/api/docs/search": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Create a new user. This endpoint is protected by an admin token middleware.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Searches document names, JSON values and text of text-like files (text/*, JSON, CSV, Markdown) among the documents the current user can read. The query uses web search syntax: words, \"quoted phrases\", OR and -excluded words. Results are ranked and carry snippets with matches wrapped in \u003cb\u003e\u003c/b\u003e. Filters of the docs listing can be combined with the query. Search is refused with 400 while encryption is enabled: sealed documents have no extracted text to search.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "docs"
                 ],
-                "summary": "Create a new user",
+                "summary": "Full-text search",
                 "parameters": [
                     {
-                        "description": "User credentials (login + password)",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/api.User"
-                        }
+                        "type": "string",
+                        "description": "User token (or Authorization: Bearer \u003ctoken\u003e)",
+                        "name": "token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search query",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only documents of this owner",
+                        "name": "login",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only documents directly inside this folder of the owner (login or the current user), / for the root",
+                        "name": "path",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON object or array the document JSON must contain",
+                        "name": "json",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "JSONPath predicate, can be repeated",
+                        "name": "jsonpath",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Tag the document must have, can be repeated",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, 100 by default, at most 1000",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of documents to skip",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Returns created login",
+                        "description": "Ranked documents with snippets",
                         "schema": {
                             "$ref": "#/definitions/api.mainResponse"
                         }
                     },
                     "400": {
-                        "description": "Validation error or duplicate login",
+                        "description": "Missing query / invalid filter or paging parameters / search with encryption enabled",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Folder not found",
                         "schema": {
                             "$ref": "#/definitions/api.mainResponse"
                         }
@@ -184,42 +460,2737 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "api.Data": {
-            "type": "object",
-            "properties": {
-                "file": {
-                    "type": "string"
-                },
-                "json": {}
-            }
-        },
-        "api.ErrorResponse": {
-            "type": "object",
-            "properties": {
-                "code": {
-                    "type": "integer"
-                },
-                "text": {
-                    "type": "string"
-                }
-            }
         },
-        "api.Response": {
-            "type": "object",
-            "properties": {
-                "login": {
-                    "type": "string"
-                },
-                "token": {
-                    "type": "string"
+        "/api/docs/stats": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns number of documents, original and stored content size and the compression ratio.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "docs"
+                ],
+                "summary": "Storage statistics of the current user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User token (or Authorization: Bearer \u003ctoken\u003e)",
+                        "name": "token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Storage statistics",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    }
                 }
             }
         },
-        "api.User": {
-            "type": "object",
+        "/api/docs/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns file content with its mime type or the JSON payload of the document. Compressed content is passed through as is when the client accepts its encoding. Small files (up to REDIS_CONTENT_CACHE_SIZE bytes) are served from the cache, X-Cache tells whether it was a HIT or a MISS; Cache-Control: no-cache skips the cache. Files uploaded with a mime that does not match their content under MIME_POLICY=flag carry the detected type in X-Detected-Mime.",
+                "produces": [
+                    "application/json",
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "docs"
+                ],
+                "summary": "Download a document",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User token (or Authorization: Bearer \u003ctoken\u003e)",
+                        "name": "token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Encodings the client accepts",
+                        "name": "Accept-Encoding",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "no-cache reads the content from the database",
+                        "name": "Cache-Control",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "File content or document JSON",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Access denied",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Document not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replaces the content of a document, the previous content is kept as a version. Sharing settings of the document stay unchanged, tags and metadata are only replaced when given in meta. The JSON payload is validated against the schema from meta or, without one, the schema the document is bound to.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "versions"
+                ],
+                "summary": "Upload a new version of a document",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User token (or Authorization: Bearer \u003ctoken\u003e)",
+                        "name": "token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being replaced",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON string with metadata. Example: {\\",
+                        "name": "meta",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "File to upload (required if meta.file is true)",
+                        "name": "file",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Optional JSON payload",
+                        "name": "json",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns document id, JSON (if any) and file name",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid form data / missing meta / missing file / invalid json / json does not match schema / invalid tags or metadata",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Access denied",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Document not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Document was changed since the given ETag",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Document is larger than the storage quota",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Mime does not match file content or is not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "423": {
+                        "description": "Document is locked by another user",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "507": {
+                        "description": "Storage quota exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Moves a document of the current user to the trash. Trashed documents are hidden from reads and listings, can be restored and are purged for good after the retention period (TRASH_RETENTION). Documents under a legal hold or a retention rule can not be deleted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Move a document to the trash",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User token (or Authorization: Bearer \u003ctoken\u003e)",
+                        "name": "token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns the trashed document",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Access denied",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Document not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Document is under legal hold or retention",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "423": {
+                        "description": "Document is locked by another user",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Applies a JSON Patch (application/json-patch+json, RFC 6902) or a JSON Merge Patch (application/merge-patch+json, RFC 7396) to the document JSON. The change is stored as a new version. The patched JSON must match the schema the document is bound to.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "docs"
+                ],
+                "summary": "Patch the JSON payload of a document",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User token (or Authorization: Bearer \u003ctoken\u003e)",
+                        "name": "token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being patched",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Patch document",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns patched JSON",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid patch document / patched json does not match schema",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Access denied",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Document not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Patch test operation failed",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Document was changed since the given ETag",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Document is larger than the storage quota",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported patch content type",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Patch cannot be applied to the document",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "423": {
+                        "description": "Document is locked by another user",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "507": {
+                        "description": "Storage quota exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/docs/{id}/expiry": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Sets when a document expires, either absolute (expires_at, RFC 3339) or relative to now (ttl like \"24h\"). An empty body without both clears the expiry. Expired documents are gone for everybody and are removed by a background sweeper. Only the owner can change the expiry.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "docs"
+                ],
+                "summary": "Extend or clear the expiry of a document",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User token (or Authorization: Bearer \u003ctoken\u003e)",
+                        "name": "token",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Example: {\\",
+                        "name": "expiry",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.ExpiryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns the document",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid expiry",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Access denied",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Document not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Document is under legal hold or retention",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "423": {
+                        "description": "Document is locked by another user",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/docs/{id}/lock": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Locks a document for the current user. While it is locked, writes by other users are rejected with 423 Locked. The lock expires after ttl (REDIS_LOCK_TTL by default, at most REDIS_LOCK_MAX_TTL); locking again by the holder extends it. The body is optional.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "docs"
+                ],
+                "summary": "Check out a document",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User token (or Authorization: Bearer \u003ctoken\u003e)",
+                        "name": "token",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Example: {\\",
+                        "name": "lock",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/api.LockRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns the lock",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ttl",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Access denied",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Document not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "423": {
+                        "description": "Document is locked by another user",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Releases the lock the current user holds on a document.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "docs"
+                ],
+                "summary": "Release a document lock",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User token (or Authorization: Bearer \u003ctoken\u003e)",
+                        "name": "token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Lock released",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Access denied",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Document or lock not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "423": {
+                        "description": "Document is locked by another user",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/docs/{id}/metadata": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replaces tags and/or key/value metadata of a document. A field that is left out stays unchanged. Labels are not versioned.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "docs"
+                ],
+                "summary": "Edit tags and metadata of a document",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User token (or Authorization: Bearer \u003ctoken\u003e)",
+                        "name": "token",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Example: {\\",
+                        "name": "labels",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.Labels"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns the document",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid tags or metadata",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Access denied",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Document not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "423": {
+                        "description": "Document is locked by another user",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/docs/{id}/move": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Puts a document of the current user into one of his folders. An empty folder moves the document to the root.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "folders"
+                ],
+                "summary": "Move a document into a folder",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User token (or Authorization: Bearer \u003ctoken\u003e)",
+                        "name": "token",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Example: {\\",
+                        "name": "move",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.MoveRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns the document",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Access denied",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Document or folder not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "423": {
+                        "description": "Document is locked by another user",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/docs/{id}/versions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns archived versions of a document, newest first. The current version is not included.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "versions"
+                ],
+                "summary": "List versions of a document",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User token (or Authorization: Bearer \u003ctoken\u003e)",
+                        "name": "token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Archived versions",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Access denied",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Document not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/docs/{id}/versions/{version}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns file content or JSON payload of an archived version.",
+                "produces": [
+                    "application/json",
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "versions"
+                ],
+                "summary": "Download a version of a document",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Version number",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User token (or Authorization: Bearer \u003ctoken\u003e)",
+                        "name": "token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "File content or document JSON",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid version",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Access denied",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Document or version not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/docs/{id}/versions/{version}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Makes an archived version current again. The replaced content is kept as a new version.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "versions"
+                ],
+                "summary": "Restore a version of a document",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Version number",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User token (or Authorization: Bearer \u003ctoken\u003e)",
+                        "name": "token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns restored document id, JSON (if any) and file name",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid version",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Access denied",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Document or version not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Version is larger than the storage quota",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "423": {
+                        "description": "Document is locked by another user",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "507": {
+                        "description": "Storage quota exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/folders": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Resolves a path like /projects/apollo among the folders of an owner and returns the folder with its subfolders. The root \"/\" can only be listed by its owner, other folders by everybody they are shared with. Documents of a folder are listed with GET /api/docs?path=...",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "folders"
+                ],
+                "summary": "Get a folder by path",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User token (or Authorization: Bearer \u003ctoken\u003e)",
+                        "name": "token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Folder path, / by default",
+                        "name": "path",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Owner of the folders, the current user by default",
+                        "name": "login",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Folder and its subfolders",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid path",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Access denied",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Folder not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Creates a folder of the current user in the root or inside one of his folders. Names are unique per parent.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "folders"
+                ],
+                "summary": "Create a folder",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User token (or Authorization: Bearer \u003ctoken\u003e)",
+                        "name": "token",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Example: {\\",
+                        "name": "folder",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.FolderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns the folder",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid name or parent",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Parent folder belongs to another user",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Parent folder not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Folder with this name already exists",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/folders/{id}": {
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Changes the name and/or the parent of a folder of the current user. An empty parent moves the folder to the root. A folder can not be moved into itself or its subfolders.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "folders"
+                ],
+                "summary": "Rename or move a folder",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Folder id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User token (or Authorization: Bearer \u003ctoken\u003e)",
+                        "name": "token",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Example: {\\",
+                        "name": "folder",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.FolderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns the folder",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid name or parent",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Access denied",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Folder not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Name is taken or folder is moved into itself",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/folders/{id}/grant": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replaces the logins a folder of the current user is shared with. They get access to all documents and subfolders inside it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "folders"
+                ],
+                "summary": "Share a folder",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Folder id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User token (or Authorization: Bearer \u003ctoken\u003e)",
+                        "name": "token",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Example: {\\",
+                        "name": "grant",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.GrantRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns the folder",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or unknown login",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Access denied",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Folder not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/holds": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists all legal holds, oldest first. This endpoint is protected by an admin token middleware.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "holds"
+                ],
+                "summary": "List legal holds",
+                "responses": {
+                    "200": {
+                        "description": "Legal holds",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Puts a legal hold on a single document (doc) or on all documents of a user (login). Held documents can not be deleted, purged or expired and keep all their versions; such requests fail with 409 and the reason. This endpoint is protected by an admin token middleware.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "holds"
+                ],
+                "summary": "Put a legal hold",
+                "parameters": [
+                    {
+                        "description": "Example: {\\",
+                        "name": "hold",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.HoldRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns the hold",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid hold",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Document or user not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/holds/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Releases a legal hold. Retention rules still apply to the documents. This endpoint is protected by an admin token middleware.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "holds"
+                ],
+                "summary": "Release a legal hold",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hold id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns the released hold id",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Hold not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/locks/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Removes the lock of a document whoever holds it. Requires the admin token.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Break a document lock",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Admin token (or Authorization: Bearer \u003ctoken\u003e)",
+                        "name": "token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Lock removed",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Lock not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/register": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new user. This endpoint is protected by an admin token middleware.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Create a new user",
+                "parameters": [
+                    {
+                        "description": "User credentials (login + password)",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.User"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns created login",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation error or duplicate login",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/retention": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists all retention rules by name. This endpoint is protected by an admin token middleware.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "holds"
+                ],
+                "summary": "List retention rules",
+                "responses": {
+                    "200": {
+                        "description": "Retention rules",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/retention/{name}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Keeps documents with the tag and/or schema for keep_days after they were created, e.g. invoices for 7 years. A rule with both a tag and a schema only matches documents that have both. Deleting, purging or expiring a kept document fails with 409 and its versions are not pruned. This endpoint is protected by an admin token middleware.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "holds"
+                ],
+                "summary": "Create or replace a retention rule",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Rule name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Example: {\\",
+                        "name": "rule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.RetentionRuleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns the rule",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid rule",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deletes a retention rule, the documents it kept can be deleted again unless another rule or a legal hold applies. This endpoint is protected by an admin token middleware.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "holds"
+                ],
+                "summary": "Delete a retention rule",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Rule name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns the deleted rule name",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Retention rule not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/schemas": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Stores a named JSON Schema (draft 2020-12 unless $schema says otherwise) of the current user. Documents are bound to it with \"schema\" in meta. A schema with the same name is replaced.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schemas"
+                ],
+                "summary": "Register a JSON schema",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User token (or Authorization: Bearer \u003ctoken\u003e)",
+                        "name": "token",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Example: {\\",
+                        "name": "schema",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns the stored schema",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid name or schema",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/schemas/{name}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns a named JSON schema of the current user.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schemas"
+                ],
+                "summary": "Get a JSON schema",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Schema name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User token (or Authorization: Bearer \u003ctoken\u003e)",
+                        "name": "token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns the schema",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Schema not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/trash": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists trashed documents of the current user, most recently deleted first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "List the trash",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User token (or Authorization: Bearer \u003ctoken\u003e)",
+                        "name": "token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Trashed documents",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/trash/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Removes a trashed document of the current user for good, together with its content and versions. This can not be undone.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Purge a document from the trash",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User token (or Authorization: Bearer \u003ctoken\u003e)",
+                        "name": "token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns the purged document id",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Document not found in trash",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Document is under legal hold or retention",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/trash/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Takes a trashed document of the current user out of the trash.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Restore a document from the trash",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User token (or Authorization: Bearer \u003ctoken\u003e)",
+                        "name": "token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns the restored document",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Document not found in trash",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/me/usage": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns how many documents and bytes the current user stores and the quota. Bytes are the original content size plus the JSON of every document, trashed documents count until they are purged, versions do not count. A zero limit means unlimited.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Storage usage of the current user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User token (or Authorization: Bearer \u003ctoken\u003e)",
+                        "name": "token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Usage and quota",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{login}/quota": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Overrides QUOTA_DEFAULT_DOCUMENTS and QUOTA_DEFAULT_BYTES for one user. A null limit falls back to the default, zero means unlimited. This endpoint is protected by an admin token middleware.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Set the quota of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User login",
+                        "name": "login",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Example: {\\",
+                        "name": "quota",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.QuotaRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Usage and the new quota",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.mainResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "api.CacheStats": {
+            "type": "object",
+            "properties": {
+                "content_hits": {
+                    "type": "integer"
+                },
+                "content_misses": {
+                    "type": "integer"
+                },
+                "hit_ratio": {
+                    "type": "number"
+                }
+            }
+        },
+        "api.Data": {
+            "type": "object",
+            "properties": {
+                "cache": {
+                    "$ref": "#/definitions/api.CacheStats"
+                },
+                "doc": {
+                    "$ref": "#/definitions/api.Doc"
+                },
+                "docs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.Doc"
+                    }
+                },
+                "file": {
+                    "type": "string"
+                },
+                "folder": {
+                    "$ref": "#/definitions/api.Folder"
+                },
+                "folders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.Folder"
+                    }
+                },
+                "hold": {
+                    "$ref": "#/definitions/api.Hold"
+                },
+                "holds": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.Hold"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "json": {},
+                "lock": {
+                    "$ref": "#/definitions/api.Lock"
+                },
+                "pools": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.PoolStats"
+                    }
+                },
+                "rule": {
+                    "$ref": "#/definitions/api.RetentionRule"
+                },
+                "rules": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.RetentionRule"
+                    }
+                },
+                "schema": {
+                    "$ref": "#/definitions/api.Schema"
+                },
+                "stats": {
+                    "$ref": "#/definitions/api.Stats"
+                },
+                "usage": {
+                    "$ref": "#/definitions/api.Usage"
+                },
+                "versions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.Version"
+                    }
+                }
+            }
+        },
+        "api.Doc": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "string"
+                },
+                "deleted": {
+                    "type": "string"
+                },
+                "detected_mime": {
+                    "type": "string"
+                },
+                "expires": {
+                    "type": "string"
+                },
+                "file": {
+                    "type": "boolean"
+                },
+                "folder": {
+                    "type": "string"
+                },
+                "grant": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "mime": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "owner": {
+                    "type": "string"
+                },
+                "public": {
+                    "type": "boolean"
+                },
+                "rank": {
+                    "type": "number"
+                },
+                "schema": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "snippet": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updated": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.ErrorDetail": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "details": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.ErrorDetail"
+                    }
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.ExpiryRequest": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "ttl": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.Folder": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "string"
+                },
+                "grant": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "owner": {
+                    "type": "string"
+                },
+                "parent": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.FolderRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "parent": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.GrantRequest": {
+            "type": "object",
+            "properties": {
+                "grant": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "api.Hold": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "string"
+                },
+                "doc": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "login": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.HoldRequest": {
+            "type": "object",
+            "properties": {
+                "doc": {
+                    "type": "string"
+                },
+                "login": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.Labels": {
+            "type": "object",
+            "properties": {
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "api.Lock": {
+            "type": "object",
+            "properties": {
+                "doc": {
+                    "type": "string"
+                },
+                "expires": {
+                    "type": "string"
+                },
+                "owner": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.LockRequest": {
+            "type": "object",
+            "properties": {
+                "ttl": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.MoveRequest": {
+            "type": "object",
+            "properties": {
+                "folder": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.PoolStats": {
+            "type": "object",
+            "properties": {
+                "acquire_count": {
+                    "type": "integer"
+                },
+                "acquire_duration_ms": {
+                    "type": "number"
+                },
+                "acquired_conns": {
+                    "type": "integer"
+                },
+                "down": {
+                    "type": "boolean"
+                },
+                "empty_acquires": {
+                    "type": "integer"
+                },
+                "failures": {
+                    "type": "integer"
+                },
+                "fallbacks": {
+                    "type": "integer"
+                },
+                "idle_conns": {
+                    "type": "integer"
+                },
+                "max_conns": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "pinned": {
+                    "type": "integer"
+                },
+                "reads": {
+                    "type": "integer"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "total_conns": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.QuotaRequest": {
+            "type": "object",
+            "properties": {
+                "bytes": {
+                    "type": "integer"
+                },
+                "documents": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.Response": {
+            "type": "object",
+            "properties": {
+                "login": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.RetentionRule": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "string"
+                },
+                "keep_days": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "schema": {
+                    "type": "string"
+                },
+                "tag": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.RetentionRuleRequest": {
+            "type": "object",
+            "properties": {
+                "keep_days": {
+                    "type": "integer"
+                },
+                "schema": {
+                    "type": "string"
+                },
+                "tag": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.Schema": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "schema": {}
+            }
+        },
+        "api.Stats": {
+            "type": "object",
+            "properties": {
+                "documents": {
+                    "type": "integer"
+                },
+                "ratio": {
+                    "type": "number"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "stored_size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.Usage": {
+            "type": "object",
+            "properties": {
+                "bytes": {
+                    "type": "integer"
+                },
+                "documents": {
+                    "type": "integer"
+                },
+                "max_bytes": {
+                    "type": "integer"
+                },
+                "max_documents": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.User": {
+            "type": "object",
             "properties": {
                 "login": {
                     "type": "string"
@@ -229,6 +3200,29 @@ const docTemplate = `{
                 }
             }
         },
+        "api.Version": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "string"
+                },
+                "file": {
+                    "type": "boolean"
+                },
+                "mime": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
         "api.mainResponse": {
             "type": "object",
             "properties": {
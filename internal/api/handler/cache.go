@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"astral/internal/api"
+	"astral/internal/storage/redis_client"
+)
+
+// GetCacheStats godoc
+// @Summary      Content cache statistics
+// @Description  Returns how many downloads of this instance were served from the content cache and how many went to the database, since the start of the process. Requires the admin token.
+// @Tags         admin
+// @Produce      json
+// @Param        token  query     string  false  "Admin token (or Authorization: Bearer <token>)"
+// @Success      200   {object}  api.mainResponse  "Cache statistics"
+// @Failure      401   {object}  api.mainResponse  "Invalid admin token"
+// @Security     BearerAuth
+// @Router       /api/cache/stats [get]
+func GetCacheStats(rc redisClient.RedisClient, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stats := rc.ContentCacheStats()
+
+		resp := &api.CacheStats{
+			ContentHits:   stats.Hits,
+			ContentMisses: stats.Misses,
+		}
+
+		if total := stats.Hits + stats.Misses; total > 0 {
+			resp.HitRatio = float64(stats.Hits) / float64(total)
+		}
+
+		api.WriteResponseWithCacheStats(w, logger, resp)
+		logger.Info("GetCacheStats: successfully returned cache stats", zap.Int64("hits", stats.Hits), zap.Int64("misses", stats.Misses))
+	}
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"astral/internal/api"
+	"astral/internal/caller"
+	"astral/internal/documents"
+	"astral/internal/storage/postgres_client"
+	"astral/internal/storage/redis_client"
+)
+
+// UpdateExpiry godoc
+// @Summary      Extend or clear the expiry of a document
+// @Description  Sets when a document expires, either absolute (expires_at, RFC 3339) or relative to now (ttl like "24h"). An empty body without both clears the expiry. Expired documents are gone for everybody and are removed by a background sweeper. Only the owner can change the expiry.
+// @Tags         docs
+// @Accept       json
+// @Produce      json
+// @Param        id      path      string             true   "Document id"
+// @Param        token   query     string             false  "User token (or Authorization: Bearer <token>)"
+// @Param        expiry  body      api.ExpiryRequest  true   "Example: {\"ttl\":\"72h\"}"
+// @Success      200   {object}  api.mainResponse  "Returns the document"
+// @Failure      400   {object}  api.mainResponse  "Invalid expiry"
+// @Failure      401   {object}  api.mainResponse  "Invalid token"
+// @Failure      403   {object}  api.mainResponse  "Access denied"
+// @Failure      404   {object}  api.mainResponse  "Document not found"
+// @Failure      409   {object}  api.mainResponse  "Document is under legal hold or retention"
+// @Failure      423   {object}  api.mainResponse  "Document is locked by another user"
+// @Failure      500   {object}  api.mainResponse  "Server error"
+// @Security     BearerAuth
+// @Router       /api/docs/{id}/expiry [put]
+func UpdateExpiry(pc postgresClient.PostgresClient, rc redisClient.RedisClient, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		login := caller.Login(ctx)
+
+		document, ok := getDocument(w, r, pc, true, logger)
+		if !ok {
+			return
+		}
+
+		if !checkLock(w, r, rc, document, logger) {
+			return
+		}
+
+		if document.Login != login {
+			api.WriteError(w, logger, http.StatusForbidden, "access denied")
+			logger.Warn("UpdateExpiry: only the owner can change the expiry", zap.String("id", document.Id), zap.String("login", login))
+			return
+		}
+
+		var req api.ExpiryRequest
+
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, sizeLimit)).Decode(&req)
+		if err != nil {
+			api.WriteError(w, logger, http.StatusBadRequest, "invalid request body")
+			logger.Warn("UpdateExpiry: invalid request body", zap.Error(err))
+			return
+		}
+
+		document.ExpiresAt, ok = resolveExpiry(w, req.ExpiresAt, req.TTL, logger)
+		if !ok {
+			return
+		}
+
+		err = pc.UpdateExpiry(ctx, document.Id, document.ExpiresAt)
+		if err != nil {
+			if errors.Is(err, postgresClient.ErrDocumentNotFound) {
+				api.WriteError(w, logger, http.StatusNotFound, "document not found")
+				logger.Warn("UpdateExpiry: document not found", zap.String("id", document.Id))
+				return
+			}
+
+			if writeHoldError(w, err, logger) {
+				return
+			}
+
+			api.WriteError(w, logger, http.StatusInternalServerError, "failed to update expiry")
+			logger.Error("UpdateExpiry: failed to update expiry", zap.Error(err))
+			return
+		}
+
+		refreshCache(ctx, rc, document, logger)
+
+		doc := toDoc(document)
+
+		api.WriteResponseWithDoc(w, logger, &doc)
+		logger.Info("UpdateExpiry: successfully updated expiry", zap.String("id", document.Id))
+	}
+}
+
+// resolveExpiry returns the expiry from an absolute time or a ttl. On failure the error response is already written.
+func resolveExpiry(w http.ResponseWriter, expiresAt *time.Time, ttl string, logger *zap.Logger) (*time.Time, bool) {
+	expires, err := documents.ResolveExpiry(time.Now(), expiresAt, ttl)
+	if err != nil {
+		api.WriteError(w, logger, http.StatusBadRequest, "invalid expiry")
+		logger.Warn("resolveExpiry: invalid expiry", zap.Error(err))
+		return nil, false
+	}
+
+	return expires, true
+}
@@ -0,0 +1,487 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"astral/internal/api"
+	"astral/internal/caller"
+	"astral/internal/documents"
+	"astral/internal/storage/postgres_client"
+	"astral/internal/storage/redis_client"
+)
+
+// CreateFolder godoc
+// @Summary      Create a folder
+// @Description  Creates a folder of the current user in the root or inside one of his folders. Names are unique per parent.
+// @Tags         folders
+// @Accept       json
+// @Produce      json
+// @Param        token   query     string             false  "User token (or Authorization: Bearer <token>)"
+// @Param        folder  body      api.FolderRequest  true   "Example: {\"name\":\"apollo\",\"parent\":\"<folder id>\"}"
+// @Success      200   {object}  api.mainResponse  "Returns the folder"
+// @Failure      400   {object}  api.mainResponse  "Invalid name or parent"
+// @Failure      401   {object}  api.mainResponse  "Invalid token"
+// @Failure      403   {object}  api.mainResponse  "Parent folder belongs to another user"
+// @Failure      404   {object}  api.mainResponse  "Parent folder not found"
+// @Failure      409   {object}  api.mainResponse  "Folder with this name already exists"
+// @Failure      500   {object}  api.mainResponse  "Server error"
+// @Security     BearerAuth
+// @Router       /api/folders [post]
+func CreateFolder(pc postgresClient.PostgresClient, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		login := caller.Login(ctx)
+
+		var req api.FolderRequest
+
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, sizeLimit)).Decode(&req)
+		if err != nil || req.Name == nil {
+			api.WriteError(w, logger, http.StatusBadRequest, "invalid request body")
+			logger.Warn("CreateFolder: invalid request body", zap.Error(err))
+			return
+		}
+
+		folder := &documents.Folder{
+			Id:        uuid.NewString(),
+			Login:     login,
+			Name:      *req.Name,
+			CreatedAt: time.Now(),
+		}
+
+		if req.Parent != nil {
+			folder.ParentId = *req.Parent
+		}
+
+		if err = documents.ValidateFolderName(folder.Name); err != nil {
+			api.WriteError(w, logger, http.StatusBadRequest, "invalid folder name")
+			logger.Warn("CreateFolder: invalid folder name", zap.Error(err))
+			return
+		}
+
+		if !checkFolder(w, r, pc, folder.ParentId, login, logger) {
+			return
+		}
+
+		err = pc.CreateFolder(ctx, folder)
+		if err != nil {
+			writeFolderError(w, err, logger)
+			return
+		}
+
+		api.WriteResponseWithFolders(w, logger, toFolder(folder), nil)
+		logger.Info("CreateFolder: successfully created folder", zap.String("id", folder.Id))
+	}
+}
+
+// ListFolders godoc
+// @Summary      Get a folder by path
+// @Description  Resolves a path like /projects/apollo among the folders of an owner and returns the folder with its subfolders. The root "/" can only be listed by its owner, other folders by everybody they are shared with. Documents of a folder are listed with GET /api/docs?path=...
+// @Tags         folders
+// @Produce      json
+// @Param        token  query     string  false  "User token (or Authorization: Bearer <token>)"
+// @Param        path   query     string  false  "Folder path, / by default"
+// @Param        login  query     string  false  "Owner of the folders, the current user by default"
+// @Success      200   {object}  api.mainResponse  "Folder and its subfolders"
+// @Failure      400   {object}  api.mainResponse  "Invalid path"
+// @Failure      401   {object}  api.mainResponse  "Invalid token"
+// @Failure      403   {object}  api.mainResponse  "Access denied"
+// @Failure      404   {object}  api.mainResponse  "Folder not found"
+// @Failure      500   {object}  api.mainResponse  "Server error"
+// @Security     BearerAuth
+// @Router       /api/folders [get]
+func ListFolders(pc postgresClient.PostgresClient, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		login := caller.Login(ctx)
+
+		owner := r.URL.Query().Get("login")
+		if owner == "" {
+			owner = login
+		}
+
+		folderId, ok := resolvePath(w, r, pc, owner, logger)
+		if !ok {
+			return
+		}
+
+		var resp *api.Folder
+
+		if folderId == "" {
+			if owner != login {
+				api.WriteError(w, logger, http.StatusForbidden, "access denied")
+				logger.Warn("ListFolders: access denied to root", zap.String("owner", owner), zap.String("login", login))
+				return
+			}
+		} else {
+			folder, ok := getFolder(w, r, pc, folderId, false, logger)
+			if !ok {
+				return
+			}
+
+			resp = toFolder(folder)
+		}
+
+		children, err := pc.ListFolders(ctx, owner, folderId)
+		if err != nil {
+			api.WriteError(w, logger, http.StatusInternalServerError, "failed to list folders")
+			logger.Error("ListFolders: failed to list folders", zap.Error(err))
+			return
+		}
+
+		folders := make([]api.Folder, 0, len(children))
+		for _, child := range children {
+			folders = append(folders, *toFolder(&child))
+		}
+
+		api.WriteResponseWithFolders(w, logger, resp, folders)
+		logger.Info("ListFolders: successfully listed folders", zap.String("folder", folderId), zap.Int("count", len(folders)))
+	}
+}
+
+// UpdateFolder godoc
+// @Summary      Rename or move a folder
+// @Description  Changes the name and/or the parent of a folder of the current user. An empty parent moves the folder to the root. A folder can not be moved into itself or its subfolders.
+// @Tags         folders
+// @Accept       json
+// @Produce      json
+// @Param        id      path      string             true   "Folder id"
+// @Param        token   query     string             false  "User token (or Authorization: Bearer <token>)"
+// @Param        folder  body      api.FolderRequest  true   "Example: {\"name\":\"apollo-2\",\"parent\":\"\"}"
+// @Success      200   {object}  api.mainResponse  "Returns the folder"
+// @Failure      400   {object}  api.mainResponse  "Invalid name or parent"
+// @Failure      401   {object}  api.mainResponse  "Invalid token"
+// @Failure      403   {object}  api.mainResponse  "Access denied"
+// @Failure      404   {object}  api.mainResponse  "Folder not found"
+// @Failure      409   {object}  api.mainResponse  "Name is taken or folder is moved into itself"
+// @Failure      500   {object}  api.mainResponse  "Server error"
+// @Security     BearerAuth
+// @Router       /api/folders/{id} [patch]
+func UpdateFolder(pc postgresClient.PostgresClient, rc redisClient.RedisClient, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		login := caller.Login(ctx)
+
+		folder, ok := getFolder(w, r, pc, chi.URLParam(r, "id"), true, logger)
+		if !ok {
+			return
+		}
+
+		var req api.FolderRequest
+
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, sizeLimit)).Decode(&req)
+		if err != nil {
+			api.WriteError(w, logger, http.StatusBadRequest, "invalid request body")
+			logger.Warn("UpdateFolder: invalid request body", zap.Error(err))
+			return
+		}
+
+		if req.Name != nil {
+			if err = documents.ValidateFolderName(*req.Name); err != nil {
+				api.WriteError(w, logger, http.StatusBadRequest, "invalid folder name")
+				logger.Warn("UpdateFolder: invalid folder name", zap.Error(err))
+				return
+			}
+
+			folder.Name = *req.Name
+		}
+
+		if req.Parent != nil {
+			if !checkFolder(w, r, pc, *req.Parent, login, logger) {
+				return
+			}
+
+			folder.ParentId = *req.Parent
+		}
+
+		previous := *folder
+
+		err = pc.UpdateFolder(ctx, folder)
+		if err != nil {
+			writeFolderError(w, err, logger)
+			return
+		}
+
+		if req.Parent != nil {
+			folder, err = pc.GetFolder(ctx, folder.Id)
+			if err != nil {
+				writeFolderError(w, err, logger)
+				return
+			}
+
+			// The folder inherits grants from its new parents and loses those of the old ones.
+			invalidateListings(ctx, rc, folderReaders(&previous, folder), false, logger)
+			invalidateDocuments(ctx, rc, logger)
+		}
+
+		api.WriteResponseWithFolders(w, logger, toFolder(folder), nil)
+		logger.Info("UpdateFolder: successfully updated folder", zap.String("id", folder.Id))
+	}
+}
+
+// ShareFolder godoc
+// @Summary      Share a folder
+// @Description  Replaces the logins a folder of the current user is shared with. They get access to all documents and subfolders inside it.
+// @Tags         folders
+// @Accept       json
+// @Produce      json
+// @Param        id     path      string            true   "Folder id"
+// @Param        token  query     string            false  "User token (or Authorization: Bearer <token>)"
+// @Param        grant  body      api.GrantRequest  true   "Example: {\"grant\":[\"user1\"]}"
+// @Success      200   {object}  api.mainResponse  "Returns the folder"
+// @Failure      400   {object}  api.mainResponse  "Invalid request body or unknown login"
+// @Failure      401   {object}  api.mainResponse  "Invalid token"
+// @Failure      403   {object}  api.mainResponse  "Access denied"
+// @Failure      404   {object}  api.mainResponse  "Folder not found"
+// @Failure      500   {object}  api.mainResponse  "Server error"
+// @Security     BearerAuth
+// @Router       /api/folders/{id}/grant [put]
+func ShareFolder(pc postgresClient.PostgresClient, rc redisClient.RedisClient, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		folder, ok := getFolder(w, r, pc, chi.URLParam(r, "id"), true, logger)
+		if !ok {
+			return
+		}
+
+		var req api.GrantRequest
+
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, sizeLimit)).Decode(&req)
+		if err != nil {
+			api.WriteError(w, logger, http.StatusBadRequest, "invalid request body")
+			logger.Warn("ShareFolder: invalid request body", zap.Error(err))
+			return
+		}
+
+		err = pc.SetFolderGrants(ctx, folder.Id, req.Grant)
+		if err != nil {
+			writeFolderError(w, err, logger)
+			return
+		}
+
+		shared, err := pc.GetFolder(ctx, folder.Id)
+		if err != nil {
+			writeFolderError(w, err, logger)
+			return
+		}
+
+		invalidateListings(ctx, rc, folderReaders(folder, shared), false, logger)
+		invalidateDocuments(ctx, rc, logger)
+
+		api.WriteResponseWithFolders(w, logger, toFolder(shared), nil)
+		logger.Info("ShareFolder: successfully shared folder", zap.String("id", folder.Id))
+	}
+}
+
+// MoveDoc godoc
+// @Summary      Move a document into a folder
+// @Description  Puts a document of the current user into one of his folders. An empty folder moves the document to the root.
+// @Tags         folders
+// @Accept       json
+// @Produce      json
+// @Param        id     path      string           true   "Document id"
+// @Param        token  query     string           false  "User token (or Authorization: Bearer <token>)"
+// @Param        move   body      api.MoveRequest  true   "Example: {\"folder\":\"<folder id>\"}"
+// @Success      200   {object}  api.mainResponse  "Returns the document"
+// @Failure      400   {object}  api.mainResponse  "Invalid request body"
+// @Failure      401   {object}  api.mainResponse  "Invalid token"
+// @Failure      403   {object}  api.mainResponse  "Access denied"
+// @Failure      404   {object}  api.mainResponse  "Document or folder not found"
+// @Failure      423   {object}  api.mainResponse  "Document is locked by another user"
+// @Failure      500   {object}  api.mainResponse  "Server error"
+// @Security     BearerAuth
+// @Router       /api/docs/{id}/move [post]
+func MoveDoc(pc postgresClient.PostgresClient, rc redisClient.RedisClient, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		login := caller.Login(ctx)
+
+		document, ok := getDocument(w, r, pc, true, logger)
+		if !ok {
+			return
+		}
+
+		if !checkLock(w, r, rc, document, logger) {
+			return
+		}
+
+		if document.Login != login {
+			api.WriteError(w, logger, http.StatusForbidden, "access denied")
+			logger.Warn("MoveDoc: only the owner can move a document", zap.String("id", document.Id), zap.String("login", login))
+			return
+		}
+
+		var req api.MoveRequest
+
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, sizeLimit)).Decode(&req)
+		if err != nil {
+			api.WriteError(w, logger, http.StatusBadRequest, "invalid request body")
+			logger.Warn("MoveDoc: invalid request body", zap.Error(err))
+			return
+		}
+
+		if !checkFolder(w, r, pc, req.Folder, document.Login, logger) {
+			return
+		}
+
+		err = pc.MoveDocument(ctx, document.Id, req.Folder)
+		if err != nil {
+			if errors.Is(err, postgresClient.ErrDocumentNotFound) {
+				api.WriteError(w, logger, http.StatusNotFound, "document not found")
+				logger.Warn("MoveDoc: document not found", zap.String("id", document.Id))
+				return
+			}
+
+			writeFolderError(w, err, logger)
+			return
+		}
+
+		moved, err := pc.GetDocument(ctx, document.Id)
+		if err != nil {
+			api.WriteError(w, logger, http.StatusInternalServerError, "failed to get document")
+			logger.Error("MoveDoc: failed to get moved document", zap.Error(err))
+			return
+		}
+
+		// Grants inherited from the old folder are gone, so its readers lose the document too.
+		invalidateListings(ctx, rc, listingReaders(document), document.Public, logger)
+		refreshCache(ctx, rc, moved, logger)
+
+		doc := toDoc(moved)
+
+		api.WriteResponseWithDoc(w, logger, &doc)
+		logger.Info("MoveDoc: successfully moved document", zap.String("id", document.Id), zap.String("folder", req.Folder))
+	}
+}
+
+// folderReaders returns the owner and everybody a folder was or is shared with.
+func folderReaders(before *documents.Folder, after *documents.Folder) []string {
+	readers := append([]string{before.Login}, before.Grant...)
+
+	return append(readers, after.Grant...)
+}
+
+// getFolder loads a folder and checks that the current user may read it, or own it when owner is set.
+// On failure the error response is already written.
+func getFolder(w http.ResponseWriter, r *http.Request, pc postgresClient.PostgresClient, id string, owner bool, logger *zap.Logger) (*documents.Folder, bool) {
+	login := caller.Login(r.Context())
+
+	if uuid.Validate(id) != nil {
+		api.WriteError(w, logger, http.StatusNotFound, "folder not found")
+		logger.Warn("getFolder: invalid folder id", zap.String("id", id))
+		return nil, false
+	}
+
+	folder, err := pc.GetFolder(r.Context(), id)
+	if err != nil {
+		writeFolderError(w, err, logger)
+		return nil, false
+	}
+
+	allowed := folder.CanRead(login)
+	if owner {
+		allowed = folder.Login == login
+	}
+
+	if !allowed {
+		api.WriteError(w, logger, http.StatusForbidden, "access denied")
+		logger.Warn("getFolder: access denied", zap.String("id", id), zap.String("login", login))
+		return nil, false
+	}
+
+	return folder, true
+}
+
+// checkFolder checks that documents and folders of owner can be put into the folder.
+// An empty id stands for the root. On failure the error response is already written.
+func checkFolder(w http.ResponseWriter, r *http.Request, pc postgresClient.PostgresClient, id string, owner string, logger *zap.Logger) bool {
+	if id == "" {
+		return true
+	}
+
+	if uuid.Validate(id) != nil {
+		api.WriteError(w, logger, http.StatusBadRequest, "invalid folder id")
+		logger.Warn("checkFolder: invalid folder id", zap.String("id", id))
+		return false
+	}
+
+	folder, err := pc.GetFolder(r.Context(), id)
+	if err != nil {
+		writeFolderError(w, err, logger)
+		return false
+	}
+
+	if folder.Login != owner {
+		api.WriteError(w, logger, http.StatusForbidden, "folder belongs to another user")
+		logger.Warn("checkFolder: folder belongs to another user", zap.String("id", id), zap.String("owner", owner))
+		return false
+	}
+
+	return true
+}
+
+// resolvePath turns the path url parameter into a folder id of owner, an empty id stands for the root.
+// On failure the error response is already written.
+func resolvePath(w http.ResponseWriter, r *http.Request, pc postgresClient.PostgresClient, owner string, logger *zap.Logger) (string, bool) {
+	path := r.URL.Query().Get("path")
+	if path == "" {
+		path = "/"
+	}
+
+	names, err := documents.SplitPath(path)
+	if err != nil {
+		api.WriteError(w, logger, http.StatusBadRequest, "invalid path")
+		logger.Warn("resolvePath: invalid path", zap.Error(err))
+		return "", false
+	}
+
+	id, err := pc.ResolveFolderPath(r.Context(), owner, names)
+	if err != nil {
+		writeFolderError(w, err, logger)
+		return "", false
+	}
+
+	return id, true
+}
+
+func writeFolderError(w http.ResponseWriter, err error, logger *zap.Logger) {
+	switch {
+	case errors.Is(err, postgresClient.ErrFolderNotFound):
+		api.WriteError(w, logger, http.StatusNotFound, "folder not found")
+		logger.Warn("writeFolderError: folder not found", zap.Error(err))
+
+	case errors.Is(err, postgresClient.ErrFolderExists):
+		api.WriteError(w, logger, http.StatusConflict, "folder with this name already exists")
+		logger.Warn("writeFolderError: folder already exists", zap.Error(err))
+
+	case errors.Is(err, postgresClient.ErrFolderCycle):
+		api.WriteError(w, logger, http.StatusConflict, "folder can not be moved into itself")
+		logger.Warn("writeFolderError: folder moved into itself", zap.Error(err))
+
+	case errors.Is(err, postgresClient.ErrUnknownGrantee):
+		api.WriteError(w, logger, http.StatusBadRequest, "unknown login in grant")
+		logger.Warn("writeFolderError: unknown login in grant", zap.Error(err))
+
+	default:
+		api.WriteError(w, logger, http.StatusInternalServerError, "failed to process folder")
+		logger.Error("writeFolderError: failed to process folder", zap.Error(err))
+	}
+}
+
+func toFolder(folder *documents.Folder) *api.Folder {
+	return &api.Folder{
+		Id:      folder.Id,
+		Owner:   folder.Login,
+		Parent:  folder.ParentId,
+		Name:    folder.Name,
+		Grant:   folder.Grant,
+		Created: folder.CreatedAt,
+	}
+}
@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"astral/internal/api"
+	"astral/internal/compressor"
+	"astral/internal/documents"
+	"astral/internal/storage/postgres_client"
+	"astral/internal/storage/redis_client"
+)
+
+// GetDoc godoc
+// @Summary      Download a document
+// @Description  Returns file content with its mime type or the JSON payload of the document. Compressed content is passed through as is when the client accepts its encoding. Small files (up to REDIS_CONTENT_CACHE_SIZE bytes) are served from the cache, X-Cache tells whether it was a HIT or a MISS; Cache-Control: no-cache skips the cache. Files uploaded with a mime that does not match their content under MIME_POLICY=flag carry the detected type in X-Detected-Mime.
+// @Tags         docs
+// @Produce      json
+// @Produce      octet-stream
+// @Param        id               path      string  true   "Document id"
+// @Param        token            query     string  false  "User token (or Authorization: Bearer <token>)"
+// @Param        Accept-Encoding  header    string  false  "Encodings the client accepts"
+// @Param        Cache-Control    header    string  false  "no-cache reads the content from the database"
+// @Success      200   {object}  api.mainResponse  "File content or document JSON"
+// @Failure      401   {object}  api.mainResponse  "Invalid token"
+// @Failure      403   {object}  api.mainResponse  "Access denied"
+// @Failure      404   {object}  api.mainResponse  "Document not found"
+// @Failure      500   {object}  api.mainResponse  "Server error"
+// @Security     BearerAuth
+// @Router       /api/docs/{id} [get]
+func GetDoc(pc postgresClient.PostgresClient, rc redisClient.RedisClient, cp compressor.ContentCompressor, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		bypass := noCache(r)
+
+		if !bypass {
+			if writeCachedFile(w, r, pc, rc, cp, logger) {
+				return
+			}
+		}
+
+		// Concurrent downloads of a document that is not cached share one database read.
+		load := func(ctx context.Context, id string) (*documents.Document, error) {
+			return rc.LoadDocument(ctx, id, pc.GetDocument)
+		}
+
+		if bypass {
+			load = pc.GetDocument
+		}
+
+		document, ok := loadDocument(w, r, load, false, logger)
+		if !ok {
+			return
+		}
+
+		if !bypass {
+			err := rc.CacheContent(ctx, document)
+			if err != nil {
+				logger.Warn("GetDoc: failed to cache content", zap.Error(err))
+			}
+
+			w.Header().Set("X-Cache", "MISS")
+		}
+
+		w.Header().Set("ETag", document.ETag())
+
+		writeDocument(w, r, cp, document, logger)
+	}
+}
+
+// writeCachedFile serves a file from the content cache. The cached metadata of the document is
+// still read to check access and that the cached version is the current one. It reports
+// whether a response, the file or an error, was written.
+func writeCachedFile(w http.ResponseWriter, r *http.Request, pc postgresClient.PostgresClient, rc redisClient.RedisClient,
+	cp compressor.ContentCompressor, logger *zap.Logger) bool {
+	ctx := r.Context()
+
+	version, content, err := rc.GetContent(ctx, chi.URLParam(r, "id"))
+	if err != nil {
+		if !errors.Is(err, redisClient.ErrCacheMiss) {
+			logger.Warn("writeCachedFile: failed to get cached content", zap.Error(err))
+		}
+
+		return false
+	}
+
+	info := func(ctx context.Context, id string) (*documents.Document, error) {
+		return rc.GetDocumentInfo(ctx, id, func(ctx context.Context, fresh bool) (*documents.Document, error) {
+			if fresh {
+				ctx = postgresClient.WithPrimary(ctx)
+			}
+
+			return pc.GetDocumentInfo(ctx, id)
+		})
+	}
+
+	document, ok := loadDocument(w, r, info, false, logger)
+	if !ok {
+		return true
+	}
+
+	if !document.File || document.Version != version {
+		return false
+	}
+
+	document.Content = content
+
+	w.Header().Set("X-Cache", "HIT")
+	w.Header().Set("ETag", document.ETag())
+
+	writeDocument(w, r, cp, document, logger)
+	return true
+}
+
+// noCache reports whether the request asks to skip the cache with Cache-Control: no-cache.
+func noCache(r *http.Request) bool {
+	for _, directive := range strings.Split(r.Header.Get("Cache-Control"), ",") {
+		if strings.EqualFold(strings.TrimSpace(directive), "no-cache") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writeDocument responds with the file content of document or with its JSON payload.
+func writeDocument(w http.ResponseWriter, r *http.Request, cp compressor.ContentCompressor, document *documents.Document, logger *zap.Logger) {
+	if !document.File {
+		api.WriteResponseWithData(w, logger, document.Id, decodeJSON(document.JSON), document.Name)
+		logger.Info("writeDocument: successfully returned document json", zap.String("id", document.Id))
+		return
+	}
+
+	err := writeFile(w, r, cp, document)
+	if err != nil {
+		api.WriteError(w, logger, http.StatusInternalServerError, "failed to read document content")
+		logger.Error("writeDocument: failed to read document content", zap.Error(err))
+		return
+	}
+
+	logger.Info("writeDocument: successfully returned document file", zap.String("id", document.Id))
+}
+
+func writeFile(w http.ResponseWriter, r *http.Request, cp compressor.ContentCompressor, document *documents.Document) error {
+	content := document.Content
+
+	if document.Codec != "" && document.Codec != compressor.CodecIdentity {
+		w.Header().Set("Vary", "Accept-Encoding")
+
+		if compressor.Accepts(r.Header.Get("Accept-Encoding"), document.Codec) {
+			w.Header().Set("Content-Encoding", document.Codec)
+		} else {
+			var err error
+
+			content, err = cp.Decompress(document.Codec, document.Content)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	contentType := document.Mime
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	if document.DetectedMime != "" {
+		w.Header().Set("X-Detected-Mime", document.DetectedMime)
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": document.Name}))
+	w.WriteHeader(http.StatusOK)
+
+	_, err := w.Write(content)
+
+	return err
+}
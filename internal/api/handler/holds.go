@@ -0,0 +1,315 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"astral/internal/api"
+	"astral/internal/documents"
+	"astral/internal/storage/postgres_client"
+	"astral/internal/storage/redis_client"
+)
+
+// CreateHold godoc
+// @Summary      Put a legal hold
+// @Description  Puts a legal hold on a single document (doc) or on all documents of a user (login). Held documents can not be deleted, purged or expired and keep all their versions; such requests fail with 409 and the reason. This endpoint is protected by an admin token middleware.
+// @Tags         holds
+// @Accept       json
+// @Produce      json
+// @Param        hold  body      api.HoldRequest  true  "Example: {\"login\":\"user1\",\"reason\":\"litigation 2024-17\"}"
+// @Success      200   {object}  api.mainResponse  "Returns the hold"
+// @Failure      400   {object}  api.mainResponse  "Invalid hold"
+// @Failure      401   {object}  api.mainResponse  "Invalid admin token"
+// @Failure      404   {object}  api.mainResponse  "Document or user not found"
+// @Failure      500   {object}  api.mainResponse  "Server error"
+// @Security     BearerAuth
+// @Router       /api/holds [post]
+func CreateHold(pc postgresClient.PostgresClient, rc redisClient.RedisClient, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		var req api.HoldRequest
+
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, sizeLimit)).Decode(&req)
+		if err != nil {
+			api.WriteError(w, logger, http.StatusBadRequest, "invalid request body")
+			logger.Warn("CreateHold: invalid request body", zap.Error(err))
+			return
+		}
+
+		hold := &documents.Hold{
+			Id:        uuid.NewString(),
+			DocId:     req.Doc,
+			Login:     req.Login,
+			Reason:    strings.TrimSpace(req.Reason),
+			CreatedAt: time.Now(),
+		}
+
+		err = documents.ValidateHold(hold)
+		if err == nil && hold.DocId != "" && uuid.Validate(hold.DocId) != nil {
+			err = documents.ErrInvalidHold
+		}
+		if err != nil {
+			api.WriteError(w, logger, http.StatusBadRequest, "invalid hold")
+			logger.Warn("CreateHold: invalid hold", zap.Error(err))
+			return
+		}
+
+		err = pc.CreateHold(ctx, hold)
+		if err != nil {
+			switch {
+			case errors.Is(err, postgresClient.ErrDocumentNotFound):
+				api.WriteError(w, logger, http.StatusNotFound, "document not found")
+				logger.Warn("CreateHold: document not found", zap.String("doc", hold.DocId))
+				return
+
+			case errors.Is(err, postgresClient.ErrUserNotFound):
+				api.WriteError(w, logger, http.StatusNotFound, "user not found")
+				logger.Warn("CreateHold: user not found", zap.String("login", hold.Login))
+				return
+
+			default:
+				api.WriteError(w, logger, http.StatusInternalServerError, "failed to create hold")
+				logger.Error("CreateHold: failed to create hold", zap.Error(err))
+				return
+			}
+		}
+
+		if hold.DocId != "" {
+			invalidateDocument(ctx, rc, hold.DocId, logger)
+		} else {
+			invalidateDocuments(ctx, rc, logger)
+		}
+
+		api.WriteResponseWithHolds(w, logger, toHold(hold), nil)
+		logger.Info("CreateHold: successfully created hold", zap.String("id", hold.Id))
+	}
+}
+
+// ListHolds godoc
+// @Summary      List legal holds
+// @Description  Lists all legal holds, oldest first. This endpoint is protected by an admin token middleware.
+// @Tags         holds
+// @Produce      json
+// @Success      200   {object}  api.mainResponse  "Legal holds"
+// @Failure      401   {object}  api.mainResponse  "Invalid admin token"
+// @Failure      500   {object}  api.mainResponse  "Server error"
+// @Security     BearerAuth
+// @Router       /api/holds [get]
+func ListHolds(pc postgresClient.PostgresClient, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		holds, err := pc.ListHolds(r.Context())
+		if err != nil {
+			api.WriteError(w, logger, http.StatusInternalServerError, "failed to list holds")
+			logger.Error("ListHolds: failed to list holds", zap.Error(err))
+			return
+		}
+
+		resp := make([]api.Hold, 0, len(holds))
+		for _, hold := range holds {
+			resp = append(resp, *toHold(&hold))
+		}
+
+		api.WriteResponseWithHolds(w, logger, nil, resp)
+		logger.Info("ListHolds: successfully listed holds", zap.Int("count", len(resp)))
+	}
+}
+
+// DeleteHold godoc
+// @Summary      Release a legal hold
+// @Description  Releases a legal hold. Retention rules still apply to the documents. This endpoint is protected by an admin token middleware.
+// @Tags         holds
+// @Produce      json
+// @Param        id    path      string  true  "Hold id"
+// @Success      200   {object}  api.mainResponse  "Returns the released hold id"
+// @Failure      401   {object}  api.mainResponse  "Invalid admin token"
+// @Failure      404   {object}  api.mainResponse  "Hold not found"
+// @Failure      500   {object}  api.mainResponse  "Server error"
+// @Security     BearerAuth
+// @Router       /api/holds/{id} [delete]
+func DeleteHold(pc postgresClient.PostgresClient, rc redisClient.RedisClient, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+
+		if uuid.Validate(id) != nil {
+			api.WriteError(w, logger, http.StatusNotFound, "hold not found")
+			logger.Warn("DeleteHold: invalid hold id", zap.String("id", id))
+			return
+		}
+
+		err := pc.DeleteHold(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, postgresClient.ErrHoldNotFound) {
+				api.WriteError(w, logger, http.StatusNotFound, "hold not found")
+				logger.Warn("DeleteHold: hold not found", zap.String("id", id))
+				return
+			}
+
+			api.WriteError(w, logger, http.StatusInternalServerError, "failed to delete hold")
+			logger.Error("DeleteHold: failed to delete hold", zap.Error(err))
+			return
+		}
+
+		// The hold is gone, so it is not known any more which documents it covered.
+		invalidateDocuments(r.Context(), rc, logger)
+
+		api.WriteResponseWithData(w, logger, id, nil, "")
+		logger.Info("DeleteHold: successfully released hold", zap.String("id", id))
+	}
+}
+
+// SaveRetentionRule godoc
+// @Summary      Create or replace a retention rule
+// @Description  Keeps documents with the tag and/or schema for keep_days after they were created, e.g. invoices for 7 years. A rule with both a tag and a schema only matches documents that have both. Deleting, purging or expiring a kept document fails with 409 and its versions are not pruned. This endpoint is protected by an admin token middleware.
+// @Tags         holds
+// @Accept       json
+// @Produce      json
+// @Param        name  path      string                    true  "Rule name"
+// @Param        rule  body      api.RetentionRuleRequest  true  "Example: {\"tag\":\"invoice\",\"keep_days\":2557}"
+// @Success      200   {object}  api.mainResponse  "Returns the rule"
+// @Failure      400   {object}  api.mainResponse  "Invalid rule"
+// @Failure      401   {object}  api.mainResponse  "Invalid admin token"
+// @Failure      500   {object}  api.mainResponse  "Server error"
+// @Security     BearerAuth
+// @Router       /api/retention/{name} [put]
+func SaveRetentionRule(pc postgresClient.PostgresClient, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req api.RetentionRuleRequest
+
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, sizeLimit)).Decode(&req)
+		if err != nil {
+			api.WriteError(w, logger, http.StatusBadRequest, "invalid request body")
+			logger.Warn("SaveRetentionRule: invalid request body", zap.Error(err))
+			return
+		}
+
+		rule := &documents.RetentionRule{
+			Name:      chi.URLParam(r, "name"),
+			Tag:       strings.TrimSpace(req.Tag),
+			Schema:    req.Schema,
+			KeepDays:  req.KeepDays,
+			CreatedAt: time.Now(),
+		}
+
+		err = documents.ValidateRetentionRule(rule)
+		if err != nil {
+			api.WriteError(w, logger, http.StatusBadRequest, "invalid retention rule")
+			logger.Warn("SaveRetentionRule: invalid retention rule", zap.Error(err))
+			return
+		}
+
+		err = pc.SaveRetentionRule(r.Context(), rule)
+		if err != nil {
+			api.WriteError(w, logger, http.StatusInternalServerError, "failed to save retention rule")
+			logger.Error("SaveRetentionRule: failed to save retention rule", zap.Error(err))
+			return
+		}
+
+		api.WriteResponseWithRetentionRules(w, logger, toRetentionRule(rule), nil)
+		logger.Info("SaveRetentionRule: successfully saved retention rule", zap.String("name", rule.Name))
+	}
+}
+
+// ListRetentionRules godoc
+// @Summary      List retention rules
+// @Description  Lists all retention rules by name. This endpoint is protected by an admin token middleware.
+// @Tags         holds
+// @Produce      json
+// @Success      200   {object}  api.mainResponse  "Retention rules"
+// @Failure      401   {object}  api.mainResponse  "Invalid admin token"
+// @Failure      500   {object}  api.mainResponse  "Server error"
+// @Security     BearerAuth
+// @Router       /api/retention [get]
+func ListRetentionRules(pc postgresClient.PostgresClient, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rules, err := pc.ListRetentionRules(r.Context())
+		if err != nil {
+			api.WriteError(w, logger, http.StatusInternalServerError, "failed to list retention rules")
+			logger.Error("ListRetentionRules: failed to list retention rules", zap.Error(err))
+			return
+		}
+
+		resp := make([]api.RetentionRule, 0, len(rules))
+		for _, rule := range rules {
+			resp = append(resp, *toRetentionRule(&rule))
+		}
+
+		api.WriteResponseWithRetentionRules(w, logger, nil, resp)
+		logger.Info("ListRetentionRules: successfully listed retention rules", zap.Int("count", len(resp)))
+	}
+}
+
+// DeleteRetentionRule godoc
+// @Summary      Delete a retention rule
+// @Description  Deletes a retention rule, the documents it kept can be deleted again unless another rule or a legal hold applies. This endpoint is protected by an admin token middleware.
+// @Tags         holds
+// @Produce      json
+// @Param        name  path      string  true  "Rule name"
+// @Success      200   {object}  api.mainResponse  "Returns the deleted rule name"
+// @Failure      401   {object}  api.mainResponse  "Invalid admin token"
+// @Failure      404   {object}  api.mainResponse  "Retention rule not found"
+// @Failure      500   {object}  api.mainResponse  "Server error"
+// @Security     BearerAuth
+// @Router       /api/retention/{name} [delete]
+func DeleteRetentionRule(pc postgresClient.PostgresClient, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "name")
+
+		err := pc.DeleteRetentionRule(r.Context(), name)
+		if err != nil {
+			if errors.Is(err, postgresClient.ErrRuleNotFound) {
+				api.WriteError(w, logger, http.StatusNotFound, "retention rule not found")
+				logger.Warn("DeleteRetentionRule: rule not found", zap.String("name", name))
+				return
+			}
+
+			api.WriteError(w, logger, http.StatusInternalServerError, "failed to delete retention rule")
+			logger.Error("DeleteRetentionRule: failed to delete rule", zap.Error(err))
+			return
+		}
+
+		api.WriteResponseWithData(w, logger, name, nil, "")
+		logger.Info("DeleteRetentionRule: successfully deleted rule", zap.String("name", name))
+	}
+}
+
+// writeHoldError writes 409 with the reason when a legal hold or a retention rule
+// blocked the change. It reports whether err was such an error.
+func writeHoldError(w http.ResponseWriter, err error, logger *zap.Logger) bool {
+	var holdErr *postgresClient.HoldError
+
+	if !errors.As(err, &holdErr) {
+		return false
+	}
+
+	api.WriteError(w, logger, http.StatusConflict, holdErr.Error())
+	logger.Warn("writeHoldError: document is held", zap.String("reason", holdErr.Reason))
+	return true
+}
+
+func toHold(hold *documents.Hold) *api.Hold {
+	return &api.Hold{
+		Id:      hold.Id,
+		Doc:     hold.DocId,
+		Login:   hold.Login,
+		Reason:  hold.Reason,
+		Created: hold.CreatedAt,
+	}
+}
+
+func toRetentionRule(rule *documents.RetentionRule) *api.RetentionRule {
+	return &api.RetentionRule{
+		Name:     rule.Name,
+		Tag:      rule.Tag,
+		Schema:   rule.Schema,
+		KeepDays: rule.KeepDays,
+		Created:  rule.CreatedAt,
+	}
+}
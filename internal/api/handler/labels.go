@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"astral/internal/api"
+	"astral/internal/documents"
+	"astral/internal/storage/postgres_client"
+	"astral/internal/storage/redis_client"
+)
+
+// UpdateLabels godoc
+// @Summary      Edit tags and metadata of a document
+// @Description  Replaces tags and/or key/value metadata of a document. A field that is left out stays unchanged. Labels are not versioned.
+// @Tags         docs
+// @Accept       json
+// @Produce      json
+// @Param        id      path      string      true   "Document id"
+// @Param        token   query     string      false  "User token (or Authorization: Bearer <token>)"
+// @Param        labels  body      api.Labels  true   "Example: {\"tags\":[\"invoice\"],\"metadata\":{\"project\":\"apollo\"}}"
+// @Success      200   {object}  api.mainResponse  "Returns the document"
+// @Failure      400   {object}  api.mainResponse  "Invalid tags or metadata"
+// @Failure      401   {object}  api.mainResponse  "Invalid token"
+// @Failure      403   {object}  api.mainResponse  "Access denied"
+// @Failure      404   {object}  api.mainResponse  "Document not found"
+// @Failure      423   {object}  api.mainResponse  "Document is locked by another user"
+// @Failure      500   {object}  api.mainResponse  "Server error"
+// @Security     BearerAuth
+// @Router       /api/docs/{id}/metadata [put]
+func UpdateLabels(pc postgresClient.PostgresClient, rc redisClient.RedisClient, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		document, ok := getDocument(w, r, pc, true, logger)
+		if !ok {
+			return
+		}
+
+		if !checkLock(w, r, rc, document, logger) {
+			return
+		}
+
+		var labels api.Labels
+
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, sizeLimit)).Decode(&labels)
+		if err != nil {
+			api.WriteError(w, logger, http.StatusBadRequest, "invalid request body")
+			logger.Warn("UpdateLabels: invalid request body", zap.Error(err))
+			return
+		}
+
+		if !applyLabels(w, labels.Tags, labels.Metadata, document, logger) {
+			return
+		}
+
+		err = pc.UpdateLabels(ctx, document.Id, document.Tags, document.Metadata)
+		if err != nil {
+			if errors.Is(err, postgresClient.ErrDocumentNotFound) {
+				api.WriteError(w, logger, http.StatusNotFound, "document not found")
+				logger.Warn("UpdateLabels: document not found", zap.String("id", document.Id))
+				return
+			}
+
+			api.WriteError(w, logger, http.StatusInternalServerError, "failed to update labels")
+			logger.Error("UpdateLabels: failed to update labels", zap.Error(err))
+			return
+		}
+
+		refreshCache(ctx, rc, document, logger)
+
+		doc := toDoc(document)
+
+		api.WriteResponseWithDoc(w, logger, &doc)
+		logger.Info("UpdateLabels: successfully updated labels", zap.String("id", document.Id))
+	}
+}
+
+// applyLabels validates tags and metadata and sets them on document. A nil value keeps
+// the current one. On failure the error response is already written.
+func applyLabels(w http.ResponseWriter, tags []string, metadata map[string]string, document *documents.Document, logger *zap.Logger) bool {
+	if tags != nil {
+		normalized, err := documents.NormalizeTags(tags)
+		if err != nil {
+			api.WriteError(w, logger, http.StatusBadRequest, "invalid tags")
+			logger.Warn("applyLabels: invalid tags", zap.Error(err))
+			return false
+		}
+
+		document.Tags = normalized
+	}
+
+	if metadata != nil {
+		err := documents.ValidateMetadata(metadata)
+		if err != nil {
+			api.WriteError(w, logger, http.StatusBadRequest, "invalid metadata")
+			logger.Warn("applyLabels: invalid metadata", zap.Error(err))
+			return false
+		}
+
+		document.Metadata = metadata
+	}
+
+	if document.Tags == nil {
+		document.Tags = []string{}
+	}
+
+	if document.Metadata == nil {
+		document.Metadata = map[string]string{}
+	}
+
+	return true
+}
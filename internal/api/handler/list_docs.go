@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"astral/internal/api"
+	"astral/internal/caller"
+	"astral/internal/documents"
+	"astral/internal/json_filter"
+	"astral/internal/storage/postgres_client"
+	"astral/internal/storage/redis_client"
+)
+
+const (
+	defaultListLimit = 100
+	maxListLimit     = 1000
+
+	metaParamPrefix = "meta."
+)
+
+// ListDocs godoc
+// @Summary      List documents
+// @Description  Lists documents the current user can read, newest first. Results are cached per user and query for REDIS_CACHE_TTL and dropped whenever a document visible to the user changes. JSON filters are combined with AND: "json" keeps documents whose JSON contains the given value (@>), every "jsonpath" is a predicate like "$.amount > 100", "$.lines[0].sku == \"A-1\"" or "exists($.customer.email)". Every "tag" must be set on the document and every "meta.<key>" parameter must match its metadata, e.g. "tag=invoice&meta.project=apollo". JSON filters are refused with 400 while encryption is enabled: sealed documents have no plain JSON to filter on.
+// @Tags         docs
+// @Produce      json
+// @Param        token     query     string    false  "User token (or Authorization: Bearer <token>)"
+// @Param        login     query     string    false  "Only documents of this owner"
+// @Param        path      query     string    false  "Only documents directly inside this folder of the owner (login or the current user), / for the root"
+// @Param        json      query     string    false  "JSON object or array the document JSON must contain"
+// @Param        jsonpath  query     []string  false  "JSONPath predicate, can be repeated"  collectionFormat(multi)
+// @Param        tag       query     []string  false  "Tag the document must have, can be repeated"  collectionFormat(multi)
+// @Param        limit     query     int       false  "Page size, 100 by default, at most 1000"
+// @Param        offset    query     int       false  "Number of documents to skip"
+// @Success      200   {object}  api.mainResponse  "Documents"
+// @Failure      400   {object}  api.mainResponse  "Invalid filter or paging parameters / JSON filter with encryption enabled"
+// @Failure      401   {object}  api.mainResponse  "Invalid token"
+// @Failure      404   {object}  api.mainResponse  "Folder not found"
+// @Failure      500   {object}  api.mainResponse  "Server error"
+// @Security     BearerAuth
+// @Router       /api/docs [get]
+func ListDocs(pc postgresClient.PostgresClient, rc redisClient.RedisClient, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		query, ok := parseListQuery(w, r, pc, logger)
+		if !ok {
+			return
+		}
+
+		docs, err := rc.GetDocs(ctx, query, func(ctx context.Context, fresh bool) ([]documents.Document, error) {
+			if fresh {
+				ctx = postgresClient.WithPrimary(ctx)
+			}
+
+			return pc.ListDocuments(ctx, query)
+		})
+		if errors.Is(err, postgresClient.ErrEncryptedQuery) {
+			api.WriteError(w, logger, http.StatusBadRequest, "json filters are not available with encryption enabled")
+			logger.Warn("ListDocs: json filter with encryption enabled")
+			return
+		}
+		if err != nil {
+			api.WriteError(w, logger, http.StatusInternalServerError, "failed to list documents")
+			logger.Error("ListDocs: failed to list documents", zap.Error(err))
+			return
+		}
+
+		resp := make([]api.Doc, 0, len(docs))
+		for _, document := range docs {
+			resp = append(resp, toDoc(&document))
+		}
+
+		api.WriteResponseWithDocs(w, logger, resp)
+		logger.Info("ListDocs: successfully listed documents", zap.Int("count", len(resp)))
+	}
+}
+
+// parseListQuery builds a listing query from the url parameters. On failure the error response is already written.
+func parseListQuery(w http.ResponseWriter, r *http.Request, pc postgresClient.PostgresClient, logger *zap.Logger) (*documents.ListQuery, bool) {
+	params := r.URL.Query()
+
+	query := &documents.ListQuery{
+		Login: caller.Login(r.Context()),
+		Owner: params.Get("login"),
+		Limit: defaultListLimit,
+	}
+
+	if raw := params.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 || limit > maxListLimit {
+			api.WriteError(w, logger, http.StatusBadRequest, "invalid limit")
+			logger.Warn("parseListQuery: invalid limit", zap.String("limit", raw))
+			return nil, false
+		}
+
+		query.Limit = limit
+	}
+
+	if raw := params.Get("offset"); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			api.WriteError(w, logger, http.StatusBadRequest, "invalid offset")
+			logger.Warn("parseListQuery: invalid offset", zap.String("offset", raw))
+			return nil, false
+		}
+
+		query.Offset = offset
+	}
+
+	if params.Has("path") {
+		owner := query.Owner
+		if owner == "" {
+			owner = query.Login
+		}
+
+		folderId, ok := resolvePath(w, r, pc, owner, logger)
+		if !ok {
+			return nil, false
+		}
+
+		query.Owner = owner
+		query.Folder = &folderId
+	}
+
+	if raw := params.Get("json"); raw != "" {
+		contains, err := jsonFilter.Containment(raw)
+		if err != nil {
+			api.WriteError(w, logger, http.StatusBadRequest, "invalid json filter")
+			logger.Warn("parseListQuery: invalid json filter", zap.Error(err))
+			return nil, false
+		}
+
+		query.JSONContains = contains
+	}
+
+	for _, raw := range params["jsonpath"] {
+		predicate, err := jsonFilter.Predicate(raw)
+		if err != nil {
+			api.WriteError(w, logger, http.StatusBadRequest, "invalid jsonpath filter")
+			logger.Warn("parseListQuery: invalid jsonpath filter", zap.Error(err))
+			return nil, false
+		}
+
+		query.JSONPath = append(query.JSONPath, predicate)
+	}
+
+	if tags, ok := params["tag"]; ok {
+		normalized, err := documents.NormalizeTags(tags)
+		if err != nil {
+			api.WriteError(w, logger, http.StatusBadRequest, "invalid tag filter")
+			logger.Warn("parseListQuery: invalid tag filter", zap.Error(err))
+			return nil, false
+		}
+
+		query.Tags = normalized
+	}
+
+	for name, values := range params {
+		key, ok := strings.CutPrefix(name, metaParamPrefix)
+		if !ok {
+			continue
+		}
+
+		if query.Metadata == nil {
+			query.Metadata = make(map[string]string)
+		}
+
+		query.Metadata[key] = values[0]
+	}
+
+	if err := documents.ValidateMetadata(query.Metadata); err != nil {
+		api.WriteError(w, logger, http.StatusBadRequest, "invalid metadata filter")
+		logger.Warn("parseListQuery: invalid metadata filter", zap.Error(err))
+		return nil, false
+	}
+
+	return query, true
+}
+
+func toDoc(document *documents.Document) api.Doc {
+	grant := document.Grant
+	if grant == nil {
+		grant = []string{}
+	}
+
+	tags := document.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	metadata := document.Metadata
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+
+	doc := api.Doc{
+		Id:       document.Id,
+		Owner:    document.Login,
+		Name:     document.Name,
+		Mime:     document.Mime,
+		Detected: document.DetectedMime,
+		File:     document.File,
+		Public:   document.Public,
+		Grant:    grant,
+		Size:     document.Size,
+		Version:  document.Version,
+		Schema:   document.Schema,
+		Folder:   document.FolderId,
+		Tags:     tags,
+		Metadata: metadata,
+		Created:  document.CreatedAt,
+		Updated:  document.UpdatedAt,
+		Expires:  document.ExpiresAt,
+	}
+
+	if !document.DeletedAt.IsZero() {
+		doc.Deleted = &document.DeletedAt
+	}
+
+	return doc
+}
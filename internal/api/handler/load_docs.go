@@ -1,10 +1,8 @@
 package handler
 
 import (
-	"encoding/json"
-	"io"
+	"errors"
 	"net/http"
-	"path/filepath"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,53 +10,41 @@ import (
 
 	"astral/internal/api"
 	"astral/internal/auth"
+	"astral/internal/compressor"
 	"astral/internal/documents"
+	"astral/internal/mime_sniffer"
+	"astral/internal/schema_validator"
 	"astral/internal/storage/postgres_client"
 	"astral/internal/storage/redis_client"
+	"astral/internal/text_extractor"
 )
 
-const maxLoadSize = 50 << 20
-
 // LoadDocs godoc
 // @Summary      Upload or create a document
 // @Description  Upload a document (file or JSON). The request is multipart/form-data.
 // @Tags         docs
 // @Accept       multipart/form-data
 // @Produce      json
-// @Param        meta  formData  string  true   "JSON string with metadata. Example: {\"name\":\"file.txt\",\"file\":true,\"public\":false,\"token\":\"...\",\"mime\":\"text/plain\",\"grant\":[\"user1\"]}"
+// @Param        meta  formData  string  true   "JSON string with metadata. Example: {\"name\":\"file.txt\",\"file\":true,\"public\":false,\"token\":\"...\",\"mime\":\"text/plain\",\"grant\":[\"user1\"],\"schema\":\"invoice\",\"tags\":[\"invoice\"],\"metadata\":{\"project\":\"apollo\"},\"folder\":\"<folder id>\",\"ttl\":\"24h\"}. Instead of ttl an absolute expires_at (RFC 3339) can be given, expired documents are gone for everybody"
 // @Param        file  formData  file    false  "File to upload (required if meta.file is true)"
 // @Param        json  formData  string  false  "Optional JSON payload (when not uploading a binary file)"
 // @Success      200   {object}  api.mainResponse  "Returns document JSON (if any) and file name"
-// @Failure      400   {object}  api.mainResponse  "Invalid form data / missing meta / missing file"
+// @Failure      400   {object}  api.mainResponse  "Invalid form data / missing meta / missing file / invalid json / json does not match schema / invalid tags or metadata / invalid expiry / unknown grantee"
 // @Failure      401   {object}  api.mainResponse  "Invalid token"
+// @Failure      403   {object}  api.mainResponse  "Folder belongs to another user"
+// @Failure      404   {object}  api.mainResponse  "Folder not found"
+// @Failure      413   {object}  api.mainResponse  "Document is larger than the storage quota"
+// @Failure      415   {object}  api.mainResponse  "Mime does not match file content or is not allowed"
 // @Failure      500   {object}  api.mainResponse  "Server error (DB/Redis/IO)"
+// @Failure      507   {object}  api.mainResponse  "Storage quota exceeded"
 // @Router       /api/docs [post]
-func LoadDocs(pc postgresClient.PostgresClient, rc redisClient.RedisClient, as auth.AuthService, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+func LoadDocs(pc postgresClient.PostgresClient, rc redisClient.RedisClient, as auth.AuthService, ms mimeSniffer.MimeSniffer,
+	cp compressor.ContentCompressor, sv schemaValidator.SchemaValidator, te textExtractor.TextExtractor, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 
-		r.Body = http.MaxBytesReader(w, r.Body, maxLoadSize)
-
-		err := r.ParseMultipartForm(maxLoadSize)
-		if err != nil {
-			api.WriteError(w, logger, http.StatusBadRequest, "invalid form data")
-			logger.Warn("LoadDocs: invalid form data", zap.Error(err))
-			return
-		}
-
-		metaStr := r.FormValue("meta")
-		if metaStr == "" {
-			api.WriteError(w, logger, http.StatusBadRequest, "meta required")
-			logger.Warn("LoadDocs: meta is missing")
-			return
-		}
-
-		var meta api.Meta
-
-		err = json.Unmarshal([]byte(metaStr), &meta)
-		if err != nil {
-			api.WriteError(w, logger, http.StatusBadRequest, "invalid meta json")
-			logger.Warn("LoadDocs: invalid meta json", zap.Error(err))
+		meta, ok := parseMeta(w, r, logger)
+		if !ok {
 			return
 		}
 
@@ -86,67 +72,71 @@ func LoadDocs(pc postgresClient.PostgresClient, rc redisClient.RedisClient, as a
 			Mime:      meta.Mime,
 			File:      meta.File,
 			Public:    meta.Public,
+			Grant:     meta.Grant,
 			CreatedAt: time.Now(),
+			Codec:     compressor.CodecIdentity,
+			Version:   1,
+			Schema:    meta.Schema,
+			FolderId:  meta.Folder,
 		}
 
-		if jsonStr := r.FormValue("json"); jsonStr != "" {
-			document.JSON = []byte(jsonStr)
+		if !applyLabels(w, meta.Tags, meta.Metadata, document, logger) {
+			return
+		}
+
+		document.ExpiresAt, ok = resolveExpiry(w, meta.ExpiresAt, meta.TTL, logger)
+		if !ok {
+			return
+		}
+
+		if !checkFolder(w, r, pc, document.FolderId, login, logger) {
+			return
 		}
 
 		if !meta.Public && len(meta.Grant) == 0 {
 			document.Grant = []string{login}
 		}
 
-		if meta.File {
-			file, header, err := r.FormFile("file")
-			if err != nil {
-				api.WriteError(w, logger, http.StatusBadRequest, "file is required")
-				logger.Warn("LoadDocs: file is required", zap.Error(err))
-				return
-			}
-			defer file.Close()
+		if !readBody(w, r, meta, document, ms, cp, te, logger) {
+			return
+		}
 
-			if document.Name == "" {
-				document.Name = filepath.Base(header.Filename)
-			}
+		if !checkSchema(w, r, pc, sv, document, logger) {
+			return
+		}
 
-			content, err := io.ReadAll(file)
-			if err != nil {
-				api.WriteError(w, logger, http.StatusInternalServerError, "failed to read file")
-				logger.Error("LoadDocs: failed read file", zap.Error(err))
+		err = pc.SaveDocument(ctx, document)
+		if err != nil {
+			if writeQuotaError(w, err, logger) {
 				return
 			}
 
-			document.Content = content
-		}
+			if errors.Is(err, postgresClient.ErrUnknownGrantee) {
+				api.WriteError(w, logger, http.StatusBadRequest, "unknown grantee")
+				logger.Warn("LoadDocs: unknown grantee", zap.Error(err))
+				return
+			}
 
-		err = pc.SaveDocument(ctx, document)
-		if err != nil {
 			api.WriteError(w, logger, http.StatusInternalServerError, "failed to save document")
 			logger.Error("LoadDocs: failed save document", zap.Error(err))
 			return
 		}
 
-		err = rc.CacheDocument(ctx, document)
-		if err != nil {
-			logger.Warn("LoadDocs: failed to cache document", zap.Error(err))
+		if document.FolderId != "" {
+			// Loaded again to pick up the grants the document inherits from its folders.
+			saved, err := pc.GetDocument(ctx, document.Id)
+			if err != nil {
+				logger.Warn("LoadDocs: failed to get saved document", zap.Error(err))
+			} else {
+				document.Grant = saved.Grant
+			}
 		}
 
-		err = rc.InvalidateDocs(ctx, document.Login)
-		if err != nil {
-			logger.Warn("LoadDocs: failed to invalidate doc cache", zap.Error(err))
-		}
+		refreshCache(ctx, rc, document, logger)
 
-		var jsonData interface{}
-		if len(document.JSON) > 0 {
-			if err := json.Unmarshal(document.JSON, &jsonData); err != nil {
-				jsonData = string(document.JSON)
-			}
-		} else {
-			jsonData = nil
-		}
+		w.Header().Set("ETag", document.ETag())
 
-		api.WriteResponseWithData(w, logger, jsonData, document.Name)
+		api.WriteResponseWithData(w, logger, document.Id, decodeJSON(document.JSON), document.Name)
 		logger.Info("LoadDocs: successfully loaded document", zap.String("id", id))
 	}
 }
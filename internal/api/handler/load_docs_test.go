@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"astral/internal/api"
+	"astral/internal/auth"
+	"astral/internal/caller"
+	"astral/internal/compressor"
+	"astral/internal/mime_sniffer"
+	"astral/internal/schema_validator"
+	"astral/internal/storage/memory_storage"
+	"astral/internal/storage/postgres_client"
+	"astral/internal/storage/redis_client"
+	"astral/internal/text_extractor"
+)
+
+const (
+	testLogin = "owner123"
+	testToken = "someToken"
+)
+
+var pdfContent = []byte("%PDF-1.7\n1 0 obj\n<< /Type /Catalog >>\nendobj\n")
+
+type testResponse struct {
+	Error *api.ErrorResponse `json:"error"`
+	Data  *api.Data          `json:"data"`
+}
+
+type testEnv struct {
+	store *memoryStorage.Store
+	cache *memoryStorage.Cache
+	as    *auth.Auth
+	ms    *mimeSniffer.Sniffer
+	cp    *compressor.Compressor
+}
+
+func newTestEnv(t *testing.T, policy string) *testEnv {
+	ctx := context.Background()
+
+	cp, err := compressor.New(&compressor.Config{}, zap.NewNop())
+	require.NoError(t, err)
+
+	env := &testEnv{
+		store: memoryStorage.NewStore(&postgresClient.Config{}, zap.NewNop()),
+		cache: memoryStorage.NewCache(&redisClient.Config{
+			TokenTTL:         time.Minute,
+			CacheTTL:         time.Minute,
+			ContentCacheSize: 1024,
+		}, zap.NewNop()),
+		as: auth.New(&auth.Config{}, zap.NewNop()),
+		ms: mimeSniffer.New(&mimeSniffer.Config{Policy: policy}, zap.NewNop()),
+		cp: cp,
+	}
+
+	require.NoError(t, env.store.SaveUser(ctx, testLogin, "hash"))
+	require.NoError(t, env.cache.SaveToken(ctx, testLogin, env.as.GenerateSha(testToken)))
+
+	return env
+}
+
+// upload posts a file with meta to LoadDocs.
+func (env *testEnv) upload(t *testing.T, meta map[string]any, content []byte) (*httptest.ResponseRecorder, testResponse) {
+	meta["token"] = testToken
+	meta["file"] = true
+
+	metaJSON, err := json.Marshal(meta)
+	require.NoError(t, err)
+
+	var body bytes.Buffer
+
+	mw := multipart.NewWriter(&body)
+	require.NoError(t, mw.WriteField("meta", string(metaJSON)))
+
+	fw, err := mw.CreateFormFile("file", "file.bin")
+	require.NoError(t, err)
+
+	_, err = fw.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+
+	r := httptest.NewRequest(http.MethodPost, "/api/docs", &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+
+	w := httptest.NewRecorder()
+
+	LoadDocs(env.store, env.cache, env.as, env.ms, env.cp,
+		schemaValidator.New(&schemaValidator.Config{}, zap.NewNop()),
+		textExtractor.New(&textExtractor.Config{}, zap.NewNop()),
+		zap.NewNop())(w, r)
+
+	return w, decodeResponse(t, w)
+}
+
+func (env *testEnv) list(t *testing.T) []api.Doc {
+	r := httptest.NewRequest(http.MethodGet, "/api/docs", nil)
+	r = r.WithContext(caller.WithLogin(r.Context(), testLogin))
+
+	w := httptest.NewRecorder()
+
+	ListDocs(env.store, env.cache, zap.NewNop())(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	return decodeResponse(t, w).Data.Docs
+}
+
+func (env *testEnv) download(t *testing.T, id string) *httptest.ResponseRecorder {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+
+	r := httptest.NewRequest(http.MethodGet, "/api/docs/"+id, nil)
+	r = r.WithContext(context.WithValue(caller.WithLogin(r.Context(), testLogin), chi.RouteCtxKey, rctx))
+
+	w := httptest.NewRecorder()
+
+	GetDoc(env.store, env.cache, env.cp, zap.NewNop())(w, r)
+
+	return w
+}
+
+func decodeResponse(t *testing.T, w *httptest.ResponseRecorder) testResponse {
+	var resp testResponse
+
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+	return resp
+}
+
+func TestLoadDocsMimeMismatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   string
+		mime     string
+		code     int
+		detected string
+	}{
+		{name: "matching mime", policy: mimeSniffer.PolicyFlag, mime: "application/pdf", code: http.StatusOK},
+		{name: "flagged mismatch", policy: mimeSniffer.PolicyFlag, mime: "image/png", code: http.StatusOK, detected: "application/pdf"},
+		{name: "rejected mismatch", policy: mimeSniffer.PolicyReject, mime: "image/png", code: http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, tt.policy)
+
+			w, resp := env.upload(t, map[string]any{"name": "report.pdf", "mime": tt.mime}, pdfContent)
+			require.Equal(t, tt.code, w.Code)
+
+			if tt.code != http.StatusOK {
+				assert.Empty(t, env.list(t))
+				return
+			}
+
+			docs := env.list(t)
+			require.Len(t, docs, 1)
+			assert.Equal(t, tt.mime, docs[0].Mime)
+			assert.Equal(t, tt.detected, docs[0].Detected)
+
+			w = env.download(t, resp.Data.Id)
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.mime, w.Header().Get("Content-Type"))
+			assert.Equal(t, tt.detected, w.Header().Get("X-Detected-Mime"))
+		})
+	}
+}
+
+func TestLoadDocsUnknownGrantee(t *testing.T) {
+	env := newTestEnv(t, mimeSniffer.PolicyFlag)
+
+	w, resp := env.upload(t, map[string]any{"name": "report.pdf", "grant": []string{"stranger"}}, pdfContent)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, "unknown grantee", resp.Error.Text)
+	assert.Empty(t, env.list(t))
+}
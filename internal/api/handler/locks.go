@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"astral/internal/api"
+	"astral/internal/caller"
+	"astral/internal/documents"
+	"astral/internal/storage/postgres_client"
+	"astral/internal/storage/redis_client"
+)
+
+// LockDoc godoc
+// @Summary      Check out a document
+// @Description  Locks a document for the current user. While it is locked, writes by other users are rejected with 423 Locked. The lock expires after ttl (REDIS_LOCK_TTL by default, at most REDIS_LOCK_MAX_TTL); locking again by the holder extends it. The body is optional.
+// @Tags         docs
+// @Accept       json
+// @Produce      json
+// @Param        id     path      string           true   "Document id"
+// @Param        token  query     string           false  "User token (or Authorization: Bearer <token>)"
+// @Param        lock   body      api.LockRequest  false  "Example: {\"ttl\":\"30m\"}"
+// @Success      200   {object}  api.mainResponse  "Returns the lock"
+// @Failure      400   {object}  api.mainResponse  "Invalid ttl"
+// @Failure      401   {object}  api.mainResponse  "Invalid token"
+// @Failure      403   {object}  api.mainResponse  "Access denied"
+// @Failure      404   {object}  api.mainResponse  "Document not found"
+// @Failure      423   {object}  api.mainResponse  "Document is locked by another user"
+// @Failure      500   {object}  api.mainResponse  "Server error"
+// @Security     BearerAuth
+// @Router       /api/docs/{id}/lock [post]
+func LockDoc(pc postgresClient.PostgresClient, rc redisClient.RedisClient, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		login := caller.Login(ctx)
+
+		document, ok := getDocument(w, r, pc, true, logger)
+		if !ok {
+			return
+		}
+
+		var req api.LockRequest
+
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, sizeLimit)).Decode(&req)
+		if err != nil && !errors.Is(err, io.EOF) {
+			api.WriteError(w, logger, http.StatusBadRequest, "invalid request body")
+			logger.Warn("LockDoc: invalid request body", zap.Error(err))
+			return
+		}
+
+		var ttl time.Duration
+
+		if req.TTL != "" {
+			ttl, err = time.ParseDuration(req.TTL)
+			if err != nil || ttl <= 0 {
+				api.WriteError(w, logger, http.StatusBadRequest, "invalid ttl")
+				logger.Warn("LockDoc: invalid ttl", zap.String("ttl", req.TTL))
+				return
+			}
+		}
+
+		lock, err := rc.LockDocument(ctx, document.Id, login, ttl)
+		if err != nil {
+			if errors.Is(err, redisClient.ErrDocumentLocked) {
+				writeLocked(w, lock, logger)
+				logger.Warn("LockDoc: document is locked", zap.String("id", document.Id))
+				return
+			}
+
+			api.WriteError(w, logger, http.StatusInternalServerError, "failed to lock document")
+			logger.Error("LockDoc: failed to lock document", zap.Error(err))
+			return
+		}
+
+		invalidateDocument(ctx, rc, document.Id, logger)
+
+		api.WriteResponseWithLock(w, logger, toLock(lock))
+		logger.Info("LockDoc: successfully locked document", zap.String("id", document.Id), zap.String("login", login))
+	}
+}
+
+// UnlockDoc godoc
+// @Summary      Release a document lock
+// @Description  Releases the lock the current user holds on a document.
+// @Tags         docs
+// @Produce      json
+// @Param        id     path      string  true   "Document id"
+// @Param        token  query     string  false  "User token (or Authorization: Bearer <token>)"
+// @Success      200   {object}  api.mainResponse  "Lock released"
+// @Failure      401   {object}  api.mainResponse  "Invalid token"
+// @Failure      403   {object}  api.mainResponse  "Access denied"
+// @Failure      404   {object}  api.mainResponse  "Document or lock not found"
+// @Failure      423   {object}  api.mainResponse  "Document is locked by another user"
+// @Failure      500   {object}  api.mainResponse  "Server error"
+// @Security     BearerAuth
+// @Router       /api/docs/{id}/lock [delete]
+func UnlockDoc(pc postgresClient.PostgresClient, rc redisClient.RedisClient, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		login := caller.Login(ctx)
+
+		document, ok := getDocument(w, r, pc, true, logger)
+		if !ok {
+			return
+		}
+
+		err := rc.UnlockDocument(ctx, document.Id, login)
+		if err != nil {
+			switch {
+			case errors.Is(err, redisClient.ErrLockNotFound):
+				api.WriteError(w, logger, http.StatusNotFound, "lock not found")
+				logger.Warn("UnlockDoc: lock not found", zap.String("id", document.Id))
+
+			case errors.Is(err, redisClient.ErrDocumentLocked):
+				api.WriteError(w, logger, http.StatusLocked, "document is locked by another user")
+				logger.Warn("UnlockDoc: lock is held by another user", zap.String("id", document.Id), zap.String("login", login))
+
+			default:
+				api.WriteError(w, logger, http.StatusInternalServerError, "failed to unlock document")
+				logger.Error("UnlockDoc: failed to unlock document", zap.Error(err))
+			}
+
+			return
+		}
+
+		invalidateDocument(ctx, rc, document.Id, logger)
+
+		api.WriteResponseWithData(w, logger, document.Id, nil, "")
+		logger.Info("UnlockDoc: successfully unlocked document", zap.String("id", document.Id), zap.String("login", login))
+	}
+}
+
+// BreakLock godoc
+// @Summary      Break a document lock
+// @Description  Removes the lock of a document whoever holds it. Requires the admin token.
+// @Tags         admin
+// @Produce      json
+// @Param        id     path      string  true   "Document id"
+// @Param        token  query     string  false  "Admin token (or Authorization: Bearer <token>)"
+// @Success      200   {object}  api.mainResponse  "Lock removed"
+// @Failure      401   {object}  api.mainResponse  "Invalid admin token"
+// @Failure      404   {object}  api.mainResponse  "Lock not found"
+// @Failure      500   {object}  api.mainResponse  "Server error"
+// @Security     BearerAuth
+// @Router       /api/locks/{id} [delete]
+func BreakLock(rc redisClient.RedisClient, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		id := chi.URLParam(r, "id")
+
+		err := rc.BreakDocumentLock(ctx, id)
+		if err != nil {
+			if errors.Is(err, redisClient.ErrLockNotFound) {
+				api.WriteError(w, logger, http.StatusNotFound, "lock not found")
+				logger.Warn("BreakLock: lock not found", zap.String("id", id))
+				return
+			}
+
+			api.WriteError(w, logger, http.StatusInternalServerError, "failed to break lock")
+			logger.Error("BreakLock: failed to break lock", zap.Error(err))
+			return
+		}
+
+		invalidateDocument(ctx, rc, id, logger)
+
+		api.WriteResponseWithData(w, logger, id, nil, "")
+		logger.Info("BreakLock: successfully broke lock", zap.String("id", id))
+	}
+}
+
+// checkLock rejects a write with 423 when the document is checked out by somebody
+// else. On failure the error response is already written.
+func checkLock(w http.ResponseWriter, r *http.Request, rc redisClient.RedisClient, document *documents.Document, logger *zap.Logger) bool {
+	login := caller.Login(r.Context())
+
+	lock, err := rc.GetDocumentLock(r.Context(), document.Id)
+	if err != nil {
+		api.WriteError(w, logger, http.StatusInternalServerError, "failed to check lock")
+		logger.Error("checkLock: failed to check lock", zap.Error(err))
+		return false
+	}
+
+	if lock != nil && !lock.HeldBy(login) {
+		writeLocked(w, lock, logger)
+		logger.Warn("checkLock: document is locked", zap.String("id", document.Id), zap.String("holder", lock.Login), zap.String("login", login))
+		return false
+	}
+
+	return true
+}
+
+func writeLocked(w http.ResponseWriter, lock *documents.Lock, logger *zap.Logger) {
+	if lock == nil {
+		api.WriteError(w, logger, http.StatusLocked, "document is locked")
+		return
+	}
+
+	api.WriteError(w, logger, http.StatusLocked, fmt.Sprintf("document is locked by %s until %s", lock.Login, lock.ExpiresAt.UTC().Format(time.RFC3339)))
+}
+
+func toLock(lock *documents.Lock) *api.Lock {
+	return &api.Lock{
+		Doc:     lock.DocId,
+		Owner:   lock.Login,
+		Expires: lock.ExpiresAt,
+	}
+}
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"astral/internal/api"
+	"astral/internal/compressor"
+	"astral/internal/patcher"
+	"astral/internal/schema_validator"
+	"astral/internal/storage/postgres_client"
+	"astral/internal/storage/redis_client"
+	"astral/internal/text_extractor"
+)
+
+// patchAttempts limits how many times a patch without If-Match is re-applied
+// when the document changes between reading and writing it.
+const patchAttempts = 3
+
+// PatchDoc godoc
+// @Summary      Patch the JSON payload of a document
+// @Description  Applies a JSON Patch (application/json-patch+json, RFC 6902) or a JSON Merge Patch (application/merge-patch+json, RFC 7396) to the document JSON. The change is stored as a new version. The patched JSON must match the schema the document is bound to.
+// @Tags         docs
+// @Accept       json
+// @Produce      json
+// @Param        id        path      string  true   "Document id"
+// @Param        token     query     string  false  "User token (or Authorization: Bearer <token>)"
+// @Param        If-Match  header    string  false  "ETag of the version being patched"
+// @Param        patch     body      string  true   "Patch document"
+// @Success      200   {object}  api.mainResponse  "Returns patched JSON"
+// @Failure      400   {object}  api.mainResponse  "Invalid patch document / patched json does not match schema"
+// @Failure      401   {object}  api.mainResponse  "Invalid token"
+// @Failure      403   {object}  api.mainResponse  "Access denied"
+// @Failure      404   {object}  api.mainResponse  "Document not found"
+// @Failure      409   {object}  api.mainResponse  "Patch test operation failed"
+// @Failure      412   {object}  api.mainResponse  "Document was changed since the given ETag"
+// @Failure      413   {object}  api.mainResponse  "Document is larger than the storage quota"
+// @Failure      415   {object}  api.mainResponse  "Unsupported patch content type"
+// @Failure      422   {object}  api.mainResponse  "Patch cannot be applied to the document"
+// @Failure      423   {object}  api.mainResponse  "Document is locked by another user"
+// @Failure      500   {object}  api.mainResponse  "Server error"
+// @Failure      507   {object}  api.mainResponse  "Storage quota exceeded"
+// @Security     BearerAuth
+// @Router       /api/docs/{id} [patch]
+func PatchDoc(pc postgresClient.PostgresClient, rc redisClient.RedisClient, cp compressor.ContentCompressor,
+	sv schemaValidator.SchemaValidator, te textExtractor.TextExtractor, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		expected, ok := expectedVersion(r)
+		if !ok {
+			api.WriteError(w, logger, http.StatusPreconditionFailed, "invalid If-Match header")
+			logger.Warn("PatchDoc: invalid If-Match header")
+			return
+		}
+
+		patch, err := io.ReadAll(http.MaxBytesReader(w, r.Body, sizeLimit))
+		if err != nil {
+			api.WriteError(w, logger, http.StatusBadRequest, "invalid request body")
+			logger.Warn("PatchDoc: invalid request body", zap.Error(err))
+			return
+		}
+
+		for attempt := 1; ; attempt++ {
+			current, ok := getDocument(w, r, pc, true, logger)
+			if !ok {
+				return
+			}
+
+			if !checkLock(w, r, rc, current, logger) {
+				return
+			}
+
+			if expected > 0 && current.Version != expected {
+				api.WriteError(w, logger, http.StatusPreconditionFailed, "document was changed")
+				logger.Warn("PatchDoc: version conflict", zap.String("id", current.Id))
+				return
+			}
+
+			patched, err := patcher.Apply(r.Header.Get("Content-Type"), current.JSON, patch)
+			if err != nil {
+				writePatchError(w, err, logger)
+				return
+			}
+
+			document := *current
+			document.JSON = patched
+			document.UpdatedAt = time.Now()
+
+			if !checkSchema(w, r, pc, sv, &document, logger) {
+				return
+			}
+
+			content, err := cp.Decompress(document.Codec, document.Content)
+			if err != nil {
+				api.WriteError(w, logger, http.StatusInternalServerError, "failed to read document")
+				logger.Error("PatchDoc: failed to decompress content", zap.Error(err))
+				return
+			}
+
+			document.Text = te.Extract(document.Mime, content, document.JSON)
+
+			err = pc.UpdateDocument(ctx, &document, current.Version)
+			if err != nil {
+				if errors.Is(err, postgresClient.ErrVersionConflict) && expected == 0 && attempt < patchAttempts {
+					logger.Info("PatchDoc: document changed concurrently, retrying", zap.Int("attempt", attempt))
+					continue
+				}
+
+				switch {
+				case errors.Is(err, postgresClient.ErrVersionConflict):
+					api.WriteError(w, logger, http.StatusPreconditionFailed, "document was changed")
+					logger.Warn("PatchDoc: version conflict", zap.String("id", document.Id))
+					return
+
+				case errors.Is(err, postgresClient.ErrDocumentNotFound):
+					api.WriteError(w, logger, http.StatusNotFound, "document not found")
+					logger.Warn("PatchDoc: document not found", zap.String("id", document.Id))
+					return
+
+				case writeQuotaError(w, err, logger):
+					return
+
+				default:
+					api.WriteError(w, logger, http.StatusInternalServerError, "failed to update document")
+					logger.Error("PatchDoc: failed to update document", zap.Error(err))
+					return
+				}
+			}
+
+			refreshCache(ctx, rc, &document, logger)
+
+			w.Header().Set("ETag", document.ETag())
+
+			api.WriteResponseWithData(w, logger, document.Id, decodeJSON(document.JSON), document.Name)
+			logger.Info("PatchDoc: successfully patched document",
+				zap.String("id", document.Id),
+				zap.Int("version", document.Version),
+			)
+			return
+		}
+	}
+}
+
+func writePatchError(w http.ResponseWriter, err error, logger *zap.Logger) {
+	switch {
+	case errors.Is(err, patcher.ErrUnsupportedType):
+		api.WriteError(w, logger, http.StatusUnsupportedMediaType, "unsupported patch content type")
+		logger.Warn("PatchDoc: unsupported patch content type", zap.Error(err))
+
+	case errors.Is(err, patcher.ErrInvalidPatch):
+		api.WriteError(w, logger, http.StatusBadRequest, "invalid patch document")
+		logger.Warn("PatchDoc: invalid patch document", zap.Error(err))
+
+	case errors.Is(err, patcher.ErrTestFailed):
+		api.WriteError(w, logger, http.StatusConflict, "patch test operation failed")
+		logger.Warn("PatchDoc: patch test operation failed", zap.Error(err))
+
+	default:
+		api.WriteError(w, logger, http.StatusUnprocessableEntity, "patch cannot be applied")
+		logger.Warn("PatchDoc: patch cannot be applied", zap.Error(err))
+	}
+}
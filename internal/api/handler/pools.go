@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"astral/internal/api"
+	"astral/internal/storage/postgres_client"
+)
+
+// GetPoolStats godoc
+// @Summary      Database pool statistics
+// @Description  Returns the connections of the primary and of every read replica, how many reads each one served, replica failures, and reads kept on the primary after a write or after a replica failed. Counters start with the process. The list is empty for storage backends without connection pools. Requires the admin token.
+// @Tags         admin
+// @Produce      json
+// @Param        token  query     string  false  "Admin token (or Authorization: Bearer <token>)"
+// @Success      200   {object}  api.mainResponse  "Pool statistics"
+// @Failure      401   {object}  api.mainResponse  "Invalid admin token"
+// @Security     BearerAuth
+// @Router       /api/db/stats [get]
+func GetPoolStats(pc postgresClient.PostgresClient, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stats := pc.PoolStats()
+
+		pools := make([]api.PoolStats, 0, len(stats))
+
+		for _, s := range stats {
+			pools = append(pools, api.PoolStats{
+				Name:              s.Name,
+				Role:              s.Role,
+				Down:              s.Down,
+				TotalConns:        s.TotalConns,
+				IdleConns:         s.IdleConns,
+				AcquiredConns:     s.AcquiredConns,
+				MaxConns:          s.MaxConns,
+				AcquireCount:      s.AcquireCount,
+				AcquireDurationMs: float64(s.AcquireDuration.Microseconds()) / 1000,
+				EmptyAcquires:     s.EmptyAcquires,
+				Reads:             s.Reads,
+				Failures:          s.Failures,
+				Pinned:            s.Pinned,
+				Fallbacks:         s.Fallbacks,
+			})
+		}
+
+		api.WriteResponseWithPoolStats(w, logger, pools)
+		logger.Info("GetPoolStats: successfully returned pool stats", zap.Int("pools", len(pools)))
+	}
+}
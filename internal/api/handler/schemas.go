@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"astral/internal/api"
+	"astral/internal/caller"
+	"astral/internal/documents"
+	"astral/internal/schema_validator"
+	"astral/internal/storage/postgres_client"
+)
+
+var schemaNameRe = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+type schemaRequest struct {
+	Name   string          `json:"name"`
+	Schema json.RawMessage `json:"schema"`
+}
+
+// SaveSchema godoc
+// @Summary      Register a JSON schema
+// @Description  Stores a named JSON Schema (draft 2020-12 unless $schema says otherwise) of the current user. Documents are bound to it with "schema" in meta. A schema with the same name is replaced.
+// @Tags         schemas
+// @Accept       json
+// @Produce      json
+// @Param        token   query     string  false  "User token (or Authorization: Bearer <token>)"
+// @Param        schema  body      string  true   "Example: {\"name\":\"invoice\",\"schema\":{\"type\":\"object\",\"required\":[\"number\"]}}"
+// @Success      200   {object}  api.mainResponse  "Returns the stored schema"
+// @Failure      400   {object}  api.mainResponse  "Invalid name or schema"
+// @Failure      401   {object}  api.mainResponse  "Invalid token"
+// @Failure      500   {object}  api.mainResponse  "Server error"
+// @Security     BearerAuth
+// @Router       /api/schemas [post]
+func SaveSchema(pc postgresClient.PostgresClient, sv schemaValidator.SchemaValidator, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		login := caller.Login(ctx)
+
+		var req schemaRequest
+
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, sizeLimit)).Decode(&req)
+		if err != nil {
+			api.WriteError(w, logger, http.StatusBadRequest, "invalid request body")
+			logger.Warn("SaveSchema: invalid request body", zap.Error(err))
+			return
+		}
+
+		if !schemaNameRe.MatchString(req.Name) {
+			api.WriteError(w, logger, http.StatusBadRequest, "invalid schema name")
+			logger.Warn("SaveSchema: invalid schema name", zap.String("name", req.Name))
+			return
+		}
+
+		if len(req.Schema) == 0 {
+			api.WriteError(w, logger, http.StatusBadRequest, "schema required")
+			logger.Warn("SaveSchema: schema is missing")
+			return
+		}
+
+		err = sv.Compile(req.Schema)
+		if err != nil {
+			api.WriteError(w, logger, http.StatusBadRequest, "invalid json schema")
+			logger.Warn("SaveSchema: invalid json schema", zap.Error(err))
+			return
+		}
+
+		err = pc.SaveSchema(ctx, login, req.Name, req.Schema)
+		if err != nil {
+			api.WriteError(w, logger, http.StatusInternalServerError, "failed to save schema")
+			logger.Error("SaveSchema: failed to save schema", zap.Error(err))
+			return
+		}
+
+		api.WriteResponseWithSchema(w, logger, &api.Schema{Name: req.Name, Schema: decodeJSON(req.Schema)})
+		logger.Info("SaveSchema: successfully saved schema", zap.String("login", login), zap.String("name", req.Name))
+	}
+}
+
+// GetSchema godoc
+// @Summary      Get a JSON schema
+// @Description  Returns a named JSON schema of the current user.
+// @Tags         schemas
+// @Produce      json
+// @Param        name   path      string  true   "Schema name"
+// @Param        token  query     string  false  "User token (or Authorization: Bearer <token>)"
+// @Success      200   {object}  api.mainResponse  "Returns the schema"
+// @Failure      401   {object}  api.mainResponse  "Invalid token"
+// @Failure      404   {object}  api.mainResponse  "Schema not found"
+// @Failure      500   {object}  api.mainResponse  "Server error"
+// @Security     BearerAuth
+// @Router       /api/schemas/{name} [get]
+func GetSchema(pc postgresClient.PostgresClient, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		login := caller.Login(ctx)
+		name := chi.URLParam(r, "name")
+
+		schema, err := pc.GetSchema(ctx, login, name)
+		if err != nil {
+			if errors.Is(err, postgresClient.ErrSchemaNotFound) {
+				api.WriteError(w, logger, http.StatusNotFound, "schema not found")
+				logger.Warn("GetSchema: schema not found", zap.String("name", name))
+				return
+			}
+
+			api.WriteError(w, logger, http.StatusInternalServerError, "failed to get schema")
+			logger.Error("GetSchema: failed to get schema", zap.Error(err))
+			return
+		}
+
+		api.WriteResponseWithSchema(w, logger, &api.Schema{Name: name, Schema: decodeJSON(schema)})
+		logger.Info("GetSchema: successfully got schema", zap.String("name", name))
+	}
+}
+
+// checkSchema validates the JSON payload of document against the schema it is bound to.
+// Schemas are looked up among the schemas of the document owner. On failure the error response is already written.
+func checkSchema(w http.ResponseWriter, r *http.Request, pc postgresClient.PostgresClient, sv schemaValidator.SchemaValidator,
+	document *documents.Document, logger *zap.Logger) bool {
+	if document.Schema == "" {
+		return true
+	}
+
+	schema, err := pc.GetSchema(r.Context(), document.Login, document.Schema)
+	if err != nil {
+		if errors.Is(err, postgresClient.ErrSchemaNotFound) {
+			api.WriteError(w, logger, http.StatusBadRequest, "unknown schema")
+			logger.Warn("checkSchema: unknown schema", zap.String("schema", document.Schema))
+			return false
+		}
+
+		api.WriteError(w, logger, http.StatusInternalServerError, "failed to get schema")
+		logger.Error("checkSchema: failed to get schema", zap.Error(err))
+		return false
+	}
+
+	if len(document.JSON) == 0 {
+		api.WriteError(w, logger, http.StatusBadRequest, "json required by schema")
+		logger.Warn("checkSchema: json is missing", zap.String("schema", document.Schema))
+		return false
+	}
+
+	violations, err := sv.Validate(schema, document.JSON)
+	if err != nil {
+		if errors.Is(err, schemaValidator.ErrInvalidDocument) {
+			api.WriteError(w, logger, http.StatusBadRequest, "invalid json")
+			logger.Warn("checkSchema: invalid json", zap.Error(err))
+			return false
+		}
+
+		api.WriteError(w, logger, http.StatusInternalServerError, "failed to validate json")
+		logger.Error("checkSchema: failed to validate json", zap.Error(err))
+		return false
+	}
+
+	if len(violations) > 0 {
+		details := make([]api.ErrorDetail, 0, len(violations))
+		for _, violation := range violations {
+			details = append(details, api.ErrorDetail{Path: violation.Path, Message: violation.Message})
+		}
+
+		api.WriteErrorWithDetails(w, logger, http.StatusBadRequest, "json does not match schema", details)
+		logger.Warn("checkSchema: json does not match schema",
+			zap.String("schema", document.Schema),
+			zap.Int("violations", len(violations)),
+		)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"astral/internal/api"
+	"astral/internal/storage/postgres_client"
+)
+
+// SearchDocs godoc
+// @Summary      Full-text search
+// @Description  Searches document names, JSON values and text of text-like files (text/*, JSON, CSV, Markdown) among the documents the current user can read. The query uses web search syntax: words, "quoted phrases", OR and -excluded words. Results are ranked and carry snippets with matches wrapped in <b></b>. Filters of the docs listing can be combined with the query. Search is refused with 400 while encryption is enabled: sealed documents have no extracted text to search.
+// @Tags         docs
+// @Produce      json
+// @Param        token     query     string    false  "User token (or Authorization: Bearer <token>)"
+// @Param        q         query     string    true   "Search query"
+// @Param        login     query     string    false  "Only documents of this owner"
+// @Param        path      query     string    false  "Only documents directly inside this folder of the owner (login or the current user), / for the root"
+// @Param        json      query     string    false  "JSON object or array the document JSON must contain"
+// @Param        jsonpath  query     []string  false  "JSONPath predicate, can be repeated"  collectionFormat(multi)
+// @Param        tag       query     []string  false  "Tag the document must have, can be repeated"  collectionFormat(multi)
+// @Param        limit     query     int       false  "Page size, 100 by default, at most 1000"
+// @Param        offset    query     int       false  "Number of documents to skip"
+// @Success      200   {object}  api.mainResponse  "Ranked documents with snippets"
+// @Failure      400   {object}  api.mainResponse  "Missing query / invalid filter or paging parameters / search with encryption enabled"
+// @Failure      401   {object}  api.mainResponse  "Invalid token"
+// @Failure      404   {object}  api.mainResponse  "Folder not found"
+// @Failure      500   {object}  api.mainResponse  "Server error"
+// @Security     BearerAuth
+// @Router       /api/docs/search [get]
+func SearchDocs(pc postgresClient.PostgresClient, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		search := strings.TrimSpace(r.URL.Query().Get("q"))
+		if search == "" {
+			api.WriteError(w, logger, http.StatusBadRequest, "query required")
+			logger.Warn("SearchDocs: query is missing")
+			return
+		}
+
+		query, ok := parseListQuery(w, r, pc, logger)
+		if !ok {
+			return
+		}
+
+		query.Search = search
+
+		results, err := pc.SearchDocuments(ctx, query)
+		if errors.Is(err, postgresClient.ErrEncryptedQuery) {
+			api.WriteError(w, logger, http.StatusBadRequest, "search is not available with encryption enabled")
+			logger.Warn("SearchDocs: search with encryption enabled")
+			return
+		}
+		if err != nil {
+			api.WriteError(w, logger, http.StatusInternalServerError, "failed to search documents")
+			logger.Error("SearchDocs: failed to search documents", zap.Error(err))
+			return
+		}
+
+		resp := make([]api.Doc, 0, len(results))
+		for _, result := range results {
+			doc := toDoc(&result.Document)
+			doc.Rank = result.Rank
+			doc.Snippet = result.Snippet
+
+			resp = append(resp, doc)
+		}
+
+		api.WriteResponseWithDocs(w, logger, resp)
+		logger.Info("SearchDocs: successfully searched documents", zap.Int("count", len(resp)))
+	}
+}
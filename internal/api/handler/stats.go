@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"astral/internal/api"
+	"astral/internal/caller"
+	"astral/internal/storage/postgres_client"
+)
+
+// GetStats godoc
+// @Summary      Storage statistics of the current user
+// @Description  Returns number of documents, original and stored content size and the compression ratio.
+// @Tags         docs
+// @Produce      json
+// @Param        token  query     string  false  "User token (or Authorization: Bearer <token>)"
+// @Success      200    {object}  api.mainResponse  "Storage statistics"
+// @Failure      401    {object}  api.mainResponse  "Invalid token"
+// @Failure      500    {object}  api.mainResponse  "Server error"
+// @Security     BearerAuth
+// @Router       /api/docs/stats [get]
+func GetStats(pc postgresClient.PostgresClient, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		login := caller.Login(ctx)
+
+		stats, err := pc.GetStats(ctx, login)
+		if err != nil {
+			api.WriteError(w, logger, http.StatusInternalServerError, "failed to get stats")
+			logger.Error("GetStats: failed to get stats", zap.Error(err))
+			return
+		}
+
+		api.WriteResponseWithStats(w, logger, &api.Stats{
+			Documents:  stats.Documents,
+			Size:       stats.Size,
+			StoredSize: stats.StoredSize,
+			Ratio:      stats.Ratio(),
+		})
+		logger.Info("GetStats: successfully returned stats")
+	}
+}
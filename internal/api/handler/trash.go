@@ -0,0 +1,223 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"astral/internal/api"
+	"astral/internal/caller"
+	"astral/internal/documents"
+	"astral/internal/storage/postgres_client"
+	"astral/internal/storage/redis_client"
+)
+
+// DeleteDoc godoc
+// @Summary      Move a document to the trash
+// @Description  Moves a document of the current user to the trash. Trashed documents are hidden from reads and listings, can be restored and are purged for good after the retention period (TRASH_RETENTION). Documents under a legal hold or a retention rule can not be deleted.
+// @Tags         trash
+// @Produce      json
+// @Param        id     path      string  true   "Document id"
+// @Param        token  query     string  false  "User token (or Authorization: Bearer <token>)"
+// @Success      200   {object}  api.mainResponse  "Returns the trashed document"
+// @Failure      401   {object}  api.mainResponse  "Invalid token"
+// @Failure      403   {object}  api.mainResponse  "Access denied"
+// @Failure      404   {object}  api.mainResponse  "Document not found"
+// @Failure      409   {object}  api.mainResponse  "Document is under legal hold or retention"
+// @Failure      423   {object}  api.mainResponse  "Document is locked by another user"
+// @Failure      500   {object}  api.mainResponse  "Server error"
+// @Security     BearerAuth
+// @Router       /api/docs/{id} [delete]
+func DeleteDoc(pc postgresClient.PostgresClient, rc redisClient.RedisClient, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		login := caller.Login(ctx)
+
+		document, ok := getDocument(w, r, pc, true, logger)
+		if !ok {
+			return
+		}
+
+		if !checkLock(w, r, rc, document, logger) {
+			return
+		}
+
+		if document.Login != login {
+			api.WriteError(w, logger, http.StatusForbidden, "access denied")
+			logger.Warn("DeleteDoc: only the owner can delete a document", zap.String("id", document.Id), zap.String("login", login))
+			return
+		}
+
+		err := pc.DeleteDocument(ctx, document.Id)
+		if err != nil {
+			if errors.Is(err, postgresClient.ErrDocumentNotFound) {
+				api.WriteError(w, logger, http.StatusNotFound, "document not found")
+				logger.Warn("DeleteDoc: document not found", zap.String("id", document.Id))
+				return
+			}
+
+			if writeHoldError(w, err, logger) {
+				return
+			}
+
+			api.WriteError(w, logger, http.StatusInternalServerError, "failed to delete document")
+			logger.Error("DeleteDoc: failed to delete document", zap.Error(err))
+			return
+		}
+
+		dropCache(ctx, rc, document, logger)
+
+		document.DeletedAt = time.Now()
+		doc := toDoc(document)
+
+		api.WriteResponseWithDoc(w, logger, &doc)
+		logger.Info("DeleteDoc: successfully moved document to trash", zap.String("id", document.Id))
+	}
+}
+
+// ListTrash godoc
+// @Summary      List the trash
+// @Description  Lists trashed documents of the current user, most recently deleted first.
+// @Tags         trash
+// @Produce      json
+// @Param        token  query     string  false  "User token (or Authorization: Bearer <token>)"
+// @Success      200   {object}  api.mainResponse  "Trashed documents"
+// @Failure      401   {object}  api.mainResponse  "Invalid token"
+// @Failure      500   {object}  api.mainResponse  "Server error"
+// @Security     BearerAuth
+// @Router       /api/trash [get]
+func ListTrash(pc postgresClient.PostgresClient, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		login := caller.Login(ctx)
+
+		docs, err := pc.ListTrash(ctx, login)
+		if err != nil {
+			api.WriteError(w, logger, http.StatusInternalServerError, "failed to list trash")
+			logger.Error("ListTrash: failed to list trash", zap.Error(err))
+			return
+		}
+
+		resp := make([]api.Doc, 0, len(docs))
+		for _, document := range docs {
+			resp = append(resp, toDoc(&document))
+		}
+
+		api.WriteResponseWithDocs(w, logger, resp)
+		logger.Info("ListTrash: successfully listed trash", zap.Int("count", len(resp)))
+	}
+}
+
+// RestoreTrash godoc
+// @Summary      Restore a document from the trash
+// @Description  Takes a trashed document of the current user out of the trash.
+// @Tags         trash
+// @Produce      json
+// @Param        id     path      string  true   "Document id"
+// @Param        token  query     string  false  "User token (or Authorization: Bearer <token>)"
+// @Success      200   {object}  api.mainResponse  "Returns the restored document"
+// @Failure      401   {object}  api.mainResponse  "Invalid token"
+// @Failure      404   {object}  api.mainResponse  "Document not found in trash"
+// @Failure      500   {object}  api.mainResponse  "Server error"
+// @Security     BearerAuth
+// @Router       /api/trash/{id}/restore [post]
+func RestoreTrash(pc postgresClient.PostgresClient, rc redisClient.RedisClient, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		login := caller.Login(ctx)
+
+		id, ok := trashId(w, r, logger)
+		if !ok {
+			return
+		}
+
+		err := pc.RestoreDocument(ctx, id, login)
+		if err != nil {
+			writeTrashError(w, err, id, logger)
+			return
+		}
+
+		document, err := pc.GetDocument(ctx, id)
+		if err != nil {
+			api.WriteError(w, logger, http.StatusInternalServerError, "failed to get document")
+			logger.Error("RestoreTrash: failed to get restored document", zap.Error(err))
+			return
+		}
+
+		refreshCache(ctx, rc, document, logger)
+
+		doc := toDoc(document)
+
+		api.WriteResponseWithDoc(w, logger, &doc)
+		logger.Info("RestoreTrash: successfully restored document", zap.String("id", id))
+	}
+}
+
+// PurgeTrash godoc
+// @Summary      Purge a document from the trash
+// @Description  Removes a trashed document of the current user for good, together with its content and versions. This can not be undone.
+// @Tags         trash
+// @Produce      json
+// @Param        id     path      string  true   "Document id"
+// @Param        token  query     string  false  "User token (or Authorization: Bearer <token>)"
+// @Success      200   {object}  api.mainResponse  "Returns the purged document id"
+// @Failure      401   {object}  api.mainResponse  "Invalid token"
+// @Failure      404   {object}  api.mainResponse  "Document not found in trash"
+// @Failure      409   {object}  api.mainResponse  "Document is under legal hold or retention"
+// @Failure      500   {object}  api.mainResponse  "Server error"
+// @Security     BearerAuth
+// @Router       /api/trash/{id} [delete]
+func PurgeTrash(pc postgresClient.PostgresClient, rc redisClient.RedisClient, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		login := caller.Login(ctx)
+
+		id, ok := trashId(w, r, logger)
+		if !ok {
+			return
+		}
+
+		err := pc.PurgeDocument(ctx, id, login)
+		if err != nil {
+			writeTrashError(w, err, id, logger)
+			return
+		}
+
+		dropCache(ctx, rc, &documents.Document{Id: id, Login: login}, logger)
+
+		api.WriteResponseWithData(w, logger, id, nil, "")
+		logger.Info("PurgeTrash: successfully purged document", zap.String("id", id))
+	}
+}
+
+// trashId returns the {id} url parameter. On failure the error response is already written.
+func trashId(w http.ResponseWriter, r *http.Request, logger *zap.Logger) (string, bool) {
+	id := chi.URLParam(r, "id")
+
+	if uuid.Validate(id) != nil {
+		api.WriteError(w, logger, http.StatusNotFound, "document not found in trash")
+		logger.Warn("trashId: invalid document id", zap.String("id", id))
+		return "", false
+	}
+
+	return id, true
+}
+
+func writeTrashError(w http.ResponseWriter, err error, id string, logger *zap.Logger) {
+	if errors.Is(err, postgresClient.ErrDocumentNotFound) {
+		api.WriteError(w, logger, http.StatusNotFound, "document not found in trash")
+		logger.Warn("writeTrashError: document not found in trash", zap.String("id", id))
+		return
+	}
+
+	if writeHoldError(w, err, logger) {
+		return
+	}
+
+	api.WriteError(w, logger, http.StatusInternalServerError, "failed to update trash")
+	logger.Error("writeTrashError: failed to update trash", zap.Error(err))
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"astral/internal/api"
+	"astral/internal/compressor"
+	"astral/internal/documents"
+	"astral/internal/mime_sniffer"
+	"astral/internal/schema_validator"
+	"astral/internal/storage/postgres_client"
+	"astral/internal/storage/redis_client"
+	"astral/internal/text_extractor"
+)
+
+// UpdateDoc godoc
+// @Summary      Upload a new version of a document
+// @Description  Replaces the content of a document, the previous content is kept as a version. Sharing settings of the document stay unchanged, tags and metadata are only replaced when given in meta. The JSON payload is validated against the schema from meta or, without one, the schema the document is bound to.
+// @Tags         versions
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id        path      string  true   "Document id"
+// @Param        token     query     string  false  "User token (or Authorization: Bearer <token>)"
+// @Param        If-Match  header    string  false  "ETag of the version being replaced"
+// @Param        meta      formData  string  true   "JSON string with metadata. Example: {\"name\":\"file.txt\",\"file\":true,\"mime\":\"text/plain\",\"schema\":\"invoice\"}"
+// @Param        file      formData  file    false  "File to upload (required if meta.file is true)"
+// @Param        json      formData  string  false  "Optional JSON payload"
+// @Success      200   {object}  api.mainResponse  "Returns document id, JSON (if any) and file name"
+// @Failure      400   {object}  api.mainResponse  "Invalid form data / missing meta / missing file / invalid json / json does not match schema / invalid tags or metadata"
+// @Failure      401   {object}  api.mainResponse  "Invalid token"
+// @Failure      403   {object}  api.mainResponse  "Access denied"
+// @Failure      404   {object}  api.mainResponse  "Document not found"
+// @Failure      412   {object}  api.mainResponse  "Document was changed since the given ETag"
+// @Failure      413   {object}  api.mainResponse  "Document is larger than the storage quota"
+// @Failure      415   {object}  api.mainResponse  "Mime does not match file content or is not allowed"
+// @Failure      423   {object}  api.mainResponse  "Document is locked by another user"
+// @Failure      500   {object}  api.mainResponse  "Server error"
+// @Failure      507   {object}  api.mainResponse  "Storage quota exceeded"
+// @Security     BearerAuth
+// @Router       /api/docs/{id} [put]
+func UpdateDoc(pc postgresClient.PostgresClient, rc redisClient.RedisClient, ms mimeSniffer.MimeSniffer,
+	cp compressor.ContentCompressor, sv schemaValidator.SchemaValidator, te textExtractor.TextExtractor, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		expected, ok := expectedVersion(r)
+		if !ok {
+			api.WriteError(w, logger, http.StatusPreconditionFailed, "invalid If-Match header")
+			logger.Warn("UpdateDoc: invalid If-Match header")
+			return
+		}
+
+		current, ok := getDocument(w, r, pc, true, logger)
+		if !ok {
+			return
+		}
+
+		if !checkLock(w, r, rc, current, logger) {
+			return
+		}
+
+		meta, ok := parseMeta(w, r, logger)
+		if !ok {
+			return
+		}
+
+		document := &documents.Document{
+			Id:        current.Id,
+			Login:     current.Login,
+			Name:      meta.Name,
+			Mime:      meta.Mime,
+			File:      meta.File,
+			Public:    current.Public,
+			Grant:     current.Grant,
+			CreatedAt: current.CreatedAt,
+			UpdatedAt: time.Now(),
+			Codec:     compressor.CodecIdentity,
+			Schema:    current.Schema,
+			Tags:      current.Tags,
+			Metadata:  current.Metadata,
+		}
+
+		if meta.Schema != "" {
+			document.Schema = meta.Schema
+		}
+
+		if !applyLabels(w, meta.Tags, meta.Metadata, document, logger) {
+			return
+		}
+
+		if !readBody(w, r, meta, document, ms, cp, te, logger) {
+			return
+		}
+
+		if document.Name == "" {
+			document.Name = current.Name
+		}
+
+		if !checkSchema(w, r, pc, sv, document, logger) {
+			return
+		}
+
+		err := pc.UpdateDocument(ctx, document, expected)
+		if err != nil {
+			switch {
+			case errors.Is(err, postgresClient.ErrVersionConflict):
+				api.WriteError(w, logger, http.StatusPreconditionFailed, "document was changed")
+				logger.Warn("UpdateDoc: version conflict", zap.String("id", document.Id))
+				return
+
+			case errors.Is(err, postgresClient.ErrDocumentNotFound):
+				api.WriteError(w, logger, http.StatusNotFound, "document not found")
+				logger.Warn("UpdateDoc: document not found", zap.String("id", document.Id))
+				return
+
+			case writeQuotaError(w, err, logger):
+				return
+
+			default:
+				api.WriteError(w, logger, http.StatusInternalServerError, "failed to update document")
+				logger.Error("UpdateDoc: failed to update document", zap.Error(err))
+				return
+			}
+		}
+
+		refreshCache(ctx, rc, document, logger)
+
+		w.Header().Set("ETag", document.ETag())
+
+		api.WriteResponseWithData(w, logger, document.Id, decodeJSON(document.JSON), document.Name)
+		logger.Info("UpdateDoc: successfully updated document",
+			zap.String("id", document.Id),
+			zap.Int("version", document.Version),
+		)
+	}
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+
+	"go.uber.org/zap"
+
+	"astral/internal/api"
+	"astral/internal/compressor"
+	"astral/internal/documents"
+	"astral/internal/mime_sniffer"
+	"astral/internal/text_extractor"
+)
+
+const maxLoadSize = 50 << 20
+
+// parseMeta reads the multipart form of an upload and decodes its meta field.
+// On failure the error response is already written.
+func parseMeta(w http.ResponseWriter, r *http.Request, logger *zap.Logger) (*api.Meta, bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxLoadSize)
+
+	err := r.ParseMultipartForm(maxLoadSize)
+	if err != nil {
+		api.WriteError(w, logger, http.StatusBadRequest, "invalid form data")
+		logger.Warn("parseMeta: invalid form data", zap.Error(err))
+		return nil, false
+	}
+
+	metaStr := r.FormValue("meta")
+	if metaStr == "" {
+		api.WriteError(w, logger, http.StatusBadRequest, "meta required")
+		logger.Warn("parseMeta: meta is missing")
+		return nil, false
+	}
+
+	var meta api.Meta
+
+	err = json.Unmarshal([]byte(metaStr), &meta)
+	if err != nil {
+		api.WriteError(w, logger, http.StatusBadRequest, "invalid meta json")
+		logger.Warn("parseMeta: invalid meta json", zap.Error(err))
+		return nil, false
+	}
+
+	return &meta, true
+}
+
+// readBody fills document with the JSON payload and the uploaded file of the request.
+// The JSON payload must be well-formed. File content is checked against its mime, its searchable
+// text is extracted and the content is compressed. On failure the error response is already written.
+func readBody(w http.ResponseWriter, r *http.Request, meta *api.Meta, document *documents.Document,
+	ms mimeSniffer.MimeSniffer, cp compressor.ContentCompressor, te textExtractor.TextExtractor, logger *zap.Logger) bool {
+	if jsonStr := r.FormValue("json"); jsonStr != "" {
+		if !json.Valid([]byte(jsonStr)) {
+			api.WriteError(w, logger, http.StatusBadRequest, "invalid json")
+			logger.Warn("readBody: invalid json")
+			return false
+		}
+
+		document.JSON = []byte(jsonStr)
+	}
+
+	if !meta.File {
+		document.Text = te.Extract("", nil, document.JSON)
+		return true
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		api.WriteError(w, logger, http.StatusBadRequest, "file is required")
+		logger.Warn("readBody: file is required", zap.Error(err))
+		return false
+	}
+	defer file.Close()
+
+	if document.Name == "" {
+		document.Name = filepath.Base(header.Filename)
+	}
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		api.WriteError(w, logger, http.StatusInternalServerError, "failed to read file")
+		logger.Error("readBody: failed read file", zap.Error(err))
+		return false
+	}
+
+	document.Mime, document.DetectedMime, err = ms.Check(document.Mime, content)
+	if err != nil {
+		switch {
+		case errors.Is(err, mimeSniffer.ErrMimeMismatch):
+			api.WriteError(w, logger, http.StatusUnsupportedMediaType, "mime does not match file content")
+			logger.Warn("readBody: mime mismatch", zap.Error(err))
+			return false
+
+		case errors.Is(err, mimeSniffer.ErrMimeDenied):
+			api.WriteError(w, logger, http.StatusUnsupportedMediaType, "mime type is not allowed")
+			logger.Warn("readBody: mime denied", zap.Error(err))
+			return false
+
+		default:
+			api.WriteError(w, logger, http.StatusInternalServerError, "failed to check mime")
+			logger.Error("readBody: failed to check mime", zap.Error(err))
+			return false
+		}
+	}
+
+	document.Size = int64(len(content))
+	document.Text = te.Extract(document.Mime, content, document.JSON)
+
+	document.Codec, document.Content, err = cp.Compress(document.Mime, content)
+	if err != nil {
+		api.WriteError(w, logger, http.StatusInternalServerError, "failed to compress file")
+		logger.Error("readBody: failed to compress file", zap.Error(err))
+		return false
+	}
+
+	return true
+}
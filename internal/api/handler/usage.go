@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"astral/internal/api"
+	"astral/internal/caller"
+	"astral/internal/documents"
+	"astral/internal/storage/postgres_client"
+)
+
+// GetUsage godoc
+// @Summary      Storage usage of the current user
+// @Description  Returns how many documents and bytes the current user stores and the quota. Bytes are the original content size plus the JSON of every document, trashed documents count until they are purged, versions do not count. A zero limit means unlimited.
+// @Tags         users
+// @Produce      json
+// @Param        token  query     string  false  "User token (or Authorization: Bearer <token>)"
+// @Success      200    {object}  api.mainResponse  "Usage and quota"
+// @Failure      401    {object}  api.mainResponse  "Invalid token"
+// @Failure      500    {object}  api.mainResponse  "Server error"
+// @Security     BearerAuth
+// @Router       /api/users/me/usage [get]
+func GetUsage(pc postgresClient.PostgresClient, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		login := caller.Login(ctx)
+
+		usage, err := pc.GetUsage(ctx, login)
+		if err != nil {
+			api.WriteError(w, logger, http.StatusInternalServerError, "failed to get usage")
+			logger.Error("GetUsage: failed to get usage", zap.Error(err))
+			return
+		}
+
+		api.WriteResponseWithUsage(w, logger, toUsage(usage))
+		logger.Info("GetUsage: successfully returned usage")
+	}
+}
+
+// SetQuota godoc
+// @Summary      Set the quota of a user
+// @Description  Overrides QUOTA_DEFAULT_DOCUMENTS and QUOTA_DEFAULT_BYTES for one user. A null limit falls back to the default, zero means unlimited. This endpoint is protected by an admin token middleware.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        login  path      string            true  "User login"
+// @Param        quota  body      api.QuotaRequest  true  "Example: {\"documents\":1000,\"bytes\":1073741824}"
+// @Success      200    {object}  api.mainResponse  "Usage and the new quota"
+// @Failure      400    {object}  api.mainResponse  "Invalid request body"
+// @Failure      401    {object}  api.mainResponse  "Invalid admin token"
+// @Failure      404    {object}  api.mainResponse  "User not found"
+// @Failure      500    {object}  api.mainResponse  "Server error"
+// @Security     BearerAuth
+// @Router       /api/users/{login}/quota [put]
+func SetQuota(pc postgresClient.PostgresClient, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		login := chi.URLParam(r, "login")
+
+		var req api.QuotaRequest
+
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, sizeLimit)).Decode(&req)
+		if err != nil || (req.Documents != nil && *req.Documents < 0) || (req.Bytes != nil && *req.Bytes < 0) {
+			api.WriteError(w, logger, http.StatusBadRequest, "invalid request body")
+			logger.Warn("SetQuota: invalid request body", zap.Error(err))
+			return
+		}
+
+		err = pc.SetQuota(ctx, login, req.Documents, req.Bytes)
+		if err != nil {
+			if errors.Is(err, postgresClient.ErrUserNotFound) {
+				api.WriteError(w, logger, http.StatusNotFound, "user not found")
+				logger.Warn("SetQuota: user not found", zap.String("login", login))
+				return
+			}
+
+			api.WriteError(w, logger, http.StatusInternalServerError, "failed to set quota")
+			logger.Error("SetQuota: failed to set quota", zap.Error(err))
+			return
+		}
+
+		usage, err := pc.GetUsage(ctx, login)
+		if err != nil {
+			api.WriteError(w, logger, http.StatusInternalServerError, "failed to get usage")
+			logger.Error("SetQuota: failed to get usage", zap.Error(err))
+			return
+		}
+
+		api.WriteResponseWithUsage(w, logger, toUsage(usage))
+		logger.Info("SetQuota: successfully set quota", zap.String("login", login))
+	}
+}
+
+func toUsage(usage *documents.Usage) *api.Usage {
+	return &api.Usage{
+		Documents:    usage.Documents,
+		Bytes:        usage.Bytes,
+		MaxDocuments: usage.MaxDocuments,
+		MaxBytes:     usage.MaxBytes,
+	}
+}
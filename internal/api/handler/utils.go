@@ -1,10 +1,20 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"slices"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
 
 	"astral/internal/api"
+	"astral/internal/caller"
+	"astral/internal/documents"
+	"astral/internal/storage/postgres_client"
+	"astral/internal/storage/redis_client"
 )
 
 func decodeBody(w http.ResponseWriter, r *http.Request, user *api.User) error {
@@ -18,3 +28,159 @@ func decodeBody(w http.ResponseWriter, r *http.Request, user *api.User) error {
 
 	return nil
 }
+
+// decodeJSON turns a stored JSON payload into a value for the response,
+// falling back to the raw string when it cannot be parsed.
+func decodeJSON(raw []byte) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+
+	var jsonData interface{}
+
+	if err := json.Unmarshal(raw, &jsonData); err != nil {
+		return string(raw)
+	}
+
+	return jsonData
+}
+
+// getDocument loads the document from the {id} url parameter and checks that the
+// current user may read it, or write it when write is set. On failure the error response is already written.
+// Checks for a write read the primary, so that they never rely on a lagging replica.
+func getDocument(w http.ResponseWriter, r *http.Request, pc postgresClient.PostgresClient, write bool, logger *zap.Logger) (*documents.Document, bool) {
+	if write {
+		return loadDocument(w, r, pc.GetDocumentForWrite, write, logger)
+	}
+
+	return loadDocument(w, r, pc.GetDocument, write, logger)
+}
+
+// loadDocument is getDocument with the loader of the document, e.g. one that leaves out the content.
+func loadDocument(w http.ResponseWriter, r *http.Request, load func(ctx context.Context, id string) (*documents.Document, error),
+	write bool, logger *zap.Logger) (*documents.Document, bool) {
+	ctx := r.Context()
+
+	id := chi.URLParam(r, "id")
+	login := caller.Login(ctx)
+
+	document, err := load(ctx, id)
+	if err != nil {
+		if errors.Is(err, postgresClient.ErrDocumentNotFound) {
+			api.WriteError(w, logger, http.StatusNotFound, "document not found")
+			logger.Warn("loadDocument: document not found", zap.String("id", id))
+			return nil, false
+		}
+
+		api.WriteError(w, logger, http.StatusInternalServerError, "failed to get document")
+		logger.Error("loadDocument: failed to get document", zap.Error(err))
+		return nil, false
+	}
+
+	allowed := document.CanRead(login)
+	if write {
+		allowed = document.CanWrite(login)
+	}
+
+	if !allowed {
+		api.WriteError(w, logger, http.StatusForbidden, "access denied")
+		logger.Warn("loadDocument: access denied", zap.String("id", id), zap.String("login", login))
+		return nil, false
+	}
+
+	return document, true
+}
+
+// expectedVersion returns the version required by the If-Match header, 0 when any version is fine.
+func expectedVersion(r *http.Request) (int, bool) {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" || ifMatch == "*" {
+		return 0, true
+	}
+
+	return documents.ParseETag(ifMatch)
+}
+
+// refreshCache puts the changed document into the cache and drops cached listings of everybody who can see it.
+func refreshCache(ctx context.Context, rc redisClient.RedisClient, document *documents.Document, logger *zap.Logger) {
+	err := rc.CacheDocument(ctx, document)
+	if err != nil {
+		logger.Warn("refreshCache: failed to cache document", zap.Error(err))
+	}
+
+	invalidateListings(ctx, rc, listingReaders(document), document.Public, logger)
+}
+
+// writeQuotaError writes 413 when the document alone is larger than the quota and 507 when
+// the quota is used up. It reports whether err was a quota error.
+func writeQuotaError(w http.ResponseWriter, err error, logger *zap.Logger) bool {
+	switch {
+	case errors.Is(err, documents.ErrDocumentTooLarge):
+		api.WriteError(w, logger, http.StatusRequestEntityTooLarge, "document is larger than the storage quota")
+		logger.Warn("writeQuotaError: document is larger than the storage quota", zap.Error(err))
+		return true
+
+	case errors.Is(err, documents.ErrQuotaExceeded):
+		api.WriteError(w, logger, http.StatusInsufficientStorage, "storage quota exceeded, see /api/users/me/usage")
+		logger.Warn("writeQuotaError: storage quota exceeded", zap.Error(err))
+		return true
+
+	default:
+		return false
+	}
+}
+
+// dropCache removes a document that is no longer readable from the cache and drops cached listings of everybody who could see it.
+func dropCache(ctx context.Context, rc redisClient.RedisClient, document *documents.Document, logger *zap.Logger) {
+	err := rc.InvalidateDocument(ctx, document.Id)
+	if err != nil {
+		logger.Warn("dropCache: failed to invalidate document", zap.Error(err))
+	}
+
+	invalidateListings(ctx, rc, listingReaders(document), document.Public, logger)
+}
+
+// invalidateDocument drops the cached metadata of a document whose lock or hold changed.
+func invalidateDocument(ctx context.Context, rc redisClient.RedisClient, id string, logger *zap.Logger) {
+	err := rc.InvalidateDocument(ctx, id)
+	if err != nil {
+		logger.Warn("invalidateDocument: failed to invalidate document", zap.Error(err), zap.String("id", id))
+	}
+}
+
+// invalidateDocuments drops the cached metadata of all documents, for changes such as folder grants
+// that reach documents not known by id.
+func invalidateDocuments(ctx context.Context, rc redisClient.RedisClient, logger *zap.Logger) {
+	err := rc.InvalidateAllDocuments(ctx)
+	if err != nil {
+		logger.Warn("invalidateDocuments: failed to invalidate documents", zap.Error(err))
+	}
+}
+
+// invalidateListings drops cached listings of logins, or of all users when public is set:
+// a public document shows up in the listings of everybody.
+func invalidateListings(ctx context.Context, rc redisClient.RedisClient, logins []string, public bool, logger *zap.Logger) {
+	if public {
+		err := rc.InvalidateAllDocs(ctx)
+		if err != nil {
+			logger.Warn("invalidateListings: failed to invalidate doc cache", zap.Error(err))
+		}
+
+		return
+	}
+
+	slices.Sort(logins)
+
+	for _, login := range slices.Compact(logins) {
+		err := rc.InvalidateDocs(ctx, login)
+		if err != nil {
+			logger.Warn("invalidateListings: failed to invalidate doc cache", zap.Error(err), zap.String("login", login))
+		}
+	}
+}
+
+// listingReaders returns the logins whose listings can contain document: the owner and
+// the grantees, including the grants of its folders when the document was loaded with them.
+func listingReaders(document *documents.Document) []string {
+	return append([]string{document.Login}, document.Grant...)
+}
@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"astral/internal/api"
+	"astral/internal/compressor"
+	"astral/internal/storage/postgres_client"
+	"astral/internal/storage/redis_client"
+)
+
+// ListVersions godoc
+// @Summary      List versions of a document
+// @Description  Returns archived versions of a document, newest first. The current version is not included.
+// @Tags         versions
+// @Produce      json
+// @Param        id     path      string  true   "Document id"
+// @Param        token  query     string  false  "User token (or Authorization: Bearer <token>)"
+// @Success      200    {object}  api.mainResponse  "Archived versions"
+// @Failure      401    {object}  api.mainResponse  "Invalid token"
+// @Failure      403    {object}  api.mainResponse  "Access denied"
+// @Failure      404    {object}  api.mainResponse  "Document not found"
+// @Failure      500    {object}  api.mainResponse  "Server error"
+// @Security     BearerAuth
+// @Router       /api/docs/{id}/versions [get]
+func ListVersions(pc postgresClient.PostgresClient, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		document, ok := getDocument(w, r, pc, false, logger)
+		if !ok {
+			return
+		}
+
+		versions, err := pc.ListVersions(ctx, document.Id)
+		if err != nil {
+			api.WriteError(w, logger, http.StatusInternalServerError, "failed to list versions")
+			logger.Error("ListVersions: failed to list versions", zap.Error(err))
+			return
+		}
+
+		resp := make([]api.Version, 0, len(versions))
+		for _, version := range versions {
+			resp = append(resp, api.Version{
+				Version: version.Version,
+				Name:    version.Name,
+				Mime:    version.Mime,
+				File:    version.File,
+				Size:    version.Size,
+				Created: version.CreatedAt,
+			})
+		}
+
+		w.Header().Set("ETag", document.ETag())
+
+		api.WriteResponseWithVersions(w, logger, document.Id, resp)
+		logger.Info("ListVersions: successfully listed versions", zap.String("id", document.Id))
+	}
+}
+
+// GetVersion godoc
+// @Summary      Download a version of a document
+// @Description  Returns file content or JSON payload of an archived version.
+// @Tags         versions
+// @Produce      json
+// @Produce      octet-stream
+// @Param        id       path      string  true   "Document id"
+// @Param        version  path      int     true   "Version number"
+// @Param        token    query     string  false  "User token (or Authorization: Bearer <token>)"
+// @Success      200      {object}  api.mainResponse  "File content or document JSON"
+// @Failure      400      {object}  api.mainResponse  "Invalid version"
+// @Failure      401      {object}  api.mainResponse  "Invalid token"
+// @Failure      403      {object}  api.mainResponse  "Access denied"
+// @Failure      404      {object}  api.mainResponse  "Document or version not found"
+// @Failure      500      {object}  api.mainResponse  "Server error"
+// @Security     BearerAuth
+// @Router       /api/docs/{id}/versions/{version} [get]
+func GetVersion(pc postgresClient.PostgresClient, cp compressor.ContentCompressor, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		number, err := strconv.Atoi(chi.URLParam(r, "version"))
+		if err != nil || number <= 0 {
+			api.WriteError(w, logger, http.StatusBadRequest, "invalid version")
+			logger.Warn("GetVersion: invalid version", zap.String("version", chi.URLParam(r, "version")))
+			return
+		}
+
+		document, ok := getDocument(w, r, pc, false, logger)
+		if !ok {
+			return
+		}
+
+		if number == document.Version {
+			w.Header().Set("ETag", document.ETag())
+			writeDocument(w, r, cp, document, logger)
+			return
+		}
+
+		version, err := pc.GetVersion(ctx, document.Id, number)
+		if err != nil {
+			if errors.Is(err, postgresClient.ErrVersionNotFound) {
+				api.WriteError(w, logger, http.StatusNotFound, "version not found")
+				logger.Warn("GetVersion: version not found", zap.Int("version", number))
+				return
+			}
+
+			api.WriteError(w, logger, http.StatusInternalServerError, "failed to get version")
+			logger.Error("GetVersion: failed to get version", zap.Error(err))
+			return
+		}
+
+		w.Header().Set("ETag", version.ETag())
+		writeDocument(w, r, cp, version, logger)
+	}
+}
+
+// RestoreVersion godoc
+// @Summary      Restore a version of a document
+// @Description  Makes an archived version current again. The replaced content is kept as a new version.
+// @Tags         versions
+// @Produce      json
+// @Param        id       path      string  true   "Document id"
+// @Param        version  path      int     true   "Version number"
+// @Param        token    query     string  false  "User token (or Authorization: Bearer <token>)"
+// @Success      200      {object}  api.mainResponse  "Returns restored document id, JSON (if any) and file name"
+// @Failure      400      {object}  api.mainResponse  "Invalid version"
+// @Failure      401      {object}  api.mainResponse  "Invalid token"
+// @Failure      403      {object}  api.mainResponse  "Access denied"
+// @Failure      404      {object}  api.mainResponse  "Document or version not found"
+// @Failure      413      {object}  api.mainResponse  "Version is larger than the storage quota"
+// @Failure      423      {object}  api.mainResponse  "Document is locked by another user"
+// @Failure      500      {object}  api.mainResponse  "Server error"
+// @Failure      507      {object}  api.mainResponse  "Storage quota exceeded"
+// @Security     BearerAuth
+// @Router       /api/docs/{id}/versions/{version}/restore [post]
+func RestoreVersion(pc postgresClient.PostgresClient, rc redisClient.RedisClient, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		number, err := strconv.Atoi(chi.URLParam(r, "version"))
+		if err != nil || number <= 0 {
+			api.WriteError(w, logger, http.StatusBadRequest, "invalid version")
+			logger.Warn("RestoreVersion: invalid version", zap.String("version", chi.URLParam(r, "version")))
+			return
+		}
+
+		document, ok := getDocument(w, r, pc, true, logger)
+		if !ok {
+			return
+		}
+
+		if !checkLock(w, r, rc, document, logger) {
+			return
+		}
+
+		_, err = pc.RestoreVersion(ctx, document.Id, number)
+		if err != nil {
+			if errors.Is(err, postgresClient.ErrVersionNotFound) {
+				api.WriteError(w, logger, http.StatusNotFound, "version not found")
+				logger.Warn("RestoreVersion: version not found", zap.Int("version", number))
+				return
+			}
+
+			if writeQuotaError(w, err, logger) {
+				return
+			}
+
+			api.WriteError(w, logger, http.StatusInternalServerError, "failed to restore version")
+			logger.Error("RestoreVersion: failed to restore version", zap.Error(err))
+			return
+		}
+
+		restored, err := pc.GetDocument(ctx, document.Id)
+		if err != nil {
+			api.WriteError(w, logger, http.StatusInternalServerError, "failed to get document")
+			logger.Error("RestoreVersion: failed to get restored document", zap.Error(err))
+			return
+		}
+
+		refreshCache(ctx, rc, restored, logger)
+
+		w.Header().Set("ETag", restored.ETag())
+
+		api.WriteResponseWithData(w, logger, restored.Id, decodeJSON(restored.JSON), restored.Name)
+		logger.Info("RestoreVersion: successfully restored version",
+			zap.String("id", restored.Id),
+			zap.Int("restored", number),
+			zap.Int("version", restored.Version),
+		)
+	}
+}
@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -9,6 +11,7 @@ import (
 	"go.uber.org/zap"
 
 	"astral/internal/auth"
+	"astral/internal/caller"
 )
 
 func TestRequireAdminToken(t *testing.T) {
@@ -74,3 +77,85 @@ func TestRequireAdminToken(t *testing.T) {
 		})
 	}
 }
+
+type fakeTokenStore map[string]string
+
+func (f fakeTokenStore) SaveToken(_ context.Context, login string, token string) error {
+	f[token] = login
+	return nil
+}
+
+func (f fakeTokenStore) GetLoginByToken(_ context.Context, token string) (string, error) {
+	login, ok := f[token]
+	if !ok {
+		return "", errors.New("token not found")
+	}
+
+	return login, nil
+}
+
+func TestRequireToken(t *testing.T) {
+	as := auth.New(&auth.Config{}, zap.NewNop())
+
+	ts := fakeTokenStore{as.GenerateSha("userToken"): "someLogin"}
+
+	tests := []struct {
+		name       string
+		query      string
+		header     string
+		statusCode int
+		response   string
+	}{
+		{
+			name:       "token in query",
+			query:      "?token=userToken",
+			statusCode: http.StatusOK,
+			response:   "someLogin",
+		},
+		{
+			name:       "token in header",
+			header:     bearerPrefix + "userToken",
+			statusCode: http.StatusOK,
+			response:   "someLogin",
+		},
+		{
+			name:       "unknown token",
+			query:      "?token=wrongToken",
+			statusCode: http.StatusUnauthorized,
+			response:   "{\"error\":{\"code\":401,\"text\":\"Invalid token\"}}\n",
+		},
+		{
+			name:       "invalid header format",
+			header:     "userToken",
+			statusCode: http.StatusUnauthorized,
+			response:   "{\"error\":{\"code\":401,\"text\":\"Invalid authorization header format\"}}\n",
+		},
+		{
+			name:       "no token",
+			statusCode: http.StatusUnauthorized,
+			response:   "{\"error\":{\"code\":401,\"text\":\"No token found\"}}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(caller.Login(r.Context())))
+			})
+
+			handlerToTest := RequireToken(ts, as, zap.NewNop())(nextHandler)
+
+			r := httptest.NewRequest("GET", "/api/docs/stats"+tt.query, nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			handlerToTest.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.statusCode, w.Code)
+			assert.Equal(t, tt.response, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"astral/internal/api"
+	"astral/internal/auth"
+	"astral/internal/caller"
+	"astral/internal/storage/redis_client"
+)
+
+// RequireToken resolves the user token passed in the "token" query parameter
+// or in the Authorization header and puts the owner's login into the request context.
+func RequireToken(ts redisClient.TokenStore, as auth.AuthService, logger *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			token := r.URL.Query().Get("token")
+
+			if token == "" {
+				header, err := getAuthorizationHeader(r)
+				if err != nil {
+					api.WriteError(w, logger, http.StatusUnauthorized, "No token found")
+					logger.Warn("RequireToken:", zap.Error(err))
+					return
+				}
+
+				token, err = extractToken(header)
+				if err != nil {
+					api.WriteError(w, logger, http.StatusUnauthorized, "Invalid authorization header format")
+					logger.Warn("RequireToken:", zap.Error(err))
+					return
+				}
+			}
+
+			login, err := ts.GetLoginByToken(r.Context(), as.GenerateSha(token))
+			if err != nil {
+				api.WriteError(w, logger, http.StatusUnauthorized, "Invalid token")
+				logger.Warn("RequireToken: invalid token", zap.Error(err))
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(caller.WithLogin(r.Context(), login)))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
@@ -1,5 +1,7 @@
 package api
 
+import "time"
+
 type HttpServer struct {
 	Host string `env:"HTTP_HOST" env-required:"true"`
 	Port int    `env:"HTTP_PORT" env-required:"true"`
@@ -17,4 +19,65 @@ type Meta struct {
 	Token  string   `json:"token"`
 	Mime   string   `json:"mime"`
 	Grant  []string `json:"grant"`
+	Schema string   `json:"schema"`
+	Folder string   `json:"folder"`
+
+	Tags     []string          `json:"tags"`
+	Metadata map[string]string `json:"metadata"`
+
+	ExpiresAt *time.Time `json:"expires_at"`
+	TTL       string     `json:"ttl"`
+}
+
+type Labels struct {
+	Tags     []string          `json:"tags"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+// FolderRequest creates or changes a folder. On change a nil field stays as is,
+// an empty parent stands for the root.
+type FolderRequest struct {
+	Name   *string `json:"name"`
+	Parent *string `json:"parent"`
+}
+
+type GrantRequest struct {
+	Grant []string `json:"grant"`
+}
+
+type MoveRequest struct {
+	Folder string `json:"folder"`
+}
+
+// QuotaRequest overrides the default quota of a user. A nil limit falls back
+// to the default, zero means unlimited.
+type QuotaRequest struct {
+	Documents *int64 `json:"documents"`
+	Bytes     *int64 `json:"bytes"`
+}
+
+// ExpiryRequest sets when a document expires: an absolute expires_at or a ttl
+// like "24h" from now. Without both the document never expires.
+type ExpiryRequest struct {
+	ExpiresAt *time.Time `json:"expires_at"`
+	TTL       string     `json:"ttl"`
+}
+
+// HoldRequest puts a legal hold on a single document (doc) or on all documents of a login.
+type HoldRequest struct {
+	Doc    string `json:"doc"`
+	Login  string `json:"login"`
+	Reason string `json:"reason"`
+}
+
+// RetentionRuleRequest keeps documents with the tag and/or schema for keep_days after they were created.
+type RetentionRuleRequest struct {
+	Tag      string `json:"tag"`
+	Schema   string `json:"schema"`
+	KeepDays int    `json:"keep_days"`
+}
+
+// LockRequest checks a document out for ttl like "30m", REDIS_LOCK_TTL when it is empty.
+type LockRequest struct {
+	TTL string `json:"ttl"`
 }
@@ -3,6 +3,7 @@ package api
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -14,8 +15,16 @@ type mainResponse struct {
 }
 
 type ErrorResponse struct {
-	Code int    `json:"code,omitempty"`
-	Text string `json:"text,omitempty"`
+	Code    int           `json:"code,omitempty"`
+	Text    string        `json:"text,omitempty"`
+	Details []ErrorDetail `json:"details,omitempty"`
+}
+
+// ErrorDetail points at the part of the request that caused the error.
+// Path is a JSON pointer, empty for the whole document.
+type ErrorDetail struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
 }
 
 func WriteError(w http.ResponseWriter, logger *zap.Logger, code int, text string) {
@@ -35,6 +44,24 @@ func WriteError(w http.ResponseWriter, logger *zap.Logger, code int, text string
 	}
 }
 
+func WriteErrorWithDetails(w http.ResponseWriter, logger *zap.Logger, code int, text string, details []ErrorDetail) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	resp := mainResponse{
+		ErrorResponse: &ErrorResponse{
+			Code:    code,
+			Text:    text,
+			Details: details,
+		},
+	}
+
+	err := json.NewEncoder(w).Encode(resp)
+	if err != nil {
+		logger.Error("WriteErrorWithDetails: failed to encode response", zap.Error(err))
+	}
+}
+
 type Response struct {
 	Login string `json:"login,omitempty"`
 	Token string `json:"token,omitempty"`
@@ -73,16 +100,33 @@ func WriteResponseWithToken(w http.ResponseWriter, logger *zap.Logger, token str
 }
 
 type Data struct {
-	JSON interface{} `json:"json,omitempty"`
-	File string      `json:"file,omitempty"`
+	Id       string          `json:"id,omitempty"`
+	JSON     interface{}     `json:"json,omitempty"`
+	File     string          `json:"file,omitempty"`
+	Stats    *Stats          `json:"stats,omitempty"`
+	Versions []Version       `json:"versions,omitempty"`
+	Schema   *Schema         `json:"schema,omitempty"`
+	Docs     []Doc           `json:"docs,omitempty"`
+	Doc      *Doc            `json:"doc,omitempty"`
+	Folder   *Folder         `json:"folder,omitempty"`
+	Folders  []Folder        `json:"folders,omitempty"`
+	Usage    *Usage          `json:"usage,omitempty"`
+	Hold     *Hold           `json:"hold,omitempty"`
+	Holds    []Hold          `json:"holds,omitempty"`
+	Rule     *RetentionRule  `json:"rule,omitempty"`
+	Rules    []RetentionRule `json:"rules,omitempty"`
+	Lock     *Lock           `json:"lock,omitempty"`
+	Cache    *CacheStats     `json:"cache,omitempty"`
+	Pools    []PoolStats     `json:"pools,omitempty"`
 }
 
-func WriteResponseWithData(w http.ResponseWriter, logger *zap.Logger, jsonData interface{}, fileName string) {
+func WriteResponseWithData(w http.ResponseWriter, logger *zap.Logger, id string, jsonData interface{}, fileName string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 
 	resp := mainResponse{
 		Data: &Data{
+			Id:   id,
 			JSON: jsonData,
 			File: fileName,
 		},
@@ -93,3 +137,308 @@ func WriteResponseWithData(w http.ResponseWriter, logger *zap.Logger, jsonData i
 		logger.Error("WriteResponseWithData: failed to encode response", zap.Error(err))
 	}
 }
+
+type Stats struct {
+	Documents  int64   `json:"documents"`
+	Size       int64   `json:"size"`
+	StoredSize int64   `json:"stored_size"`
+	Ratio      float64 `json:"ratio"`
+}
+
+func WriteResponseWithStats(w http.ResponseWriter, logger *zap.Logger, stats *Stats) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	resp := mainResponse{
+		Data: &Data{
+			Stats: stats,
+		},
+	}
+
+	err := json.NewEncoder(w).Encode(resp)
+	if err != nil {
+		logger.Error("WriteResponseWithStats: failed to encode response", zap.Error(err))
+	}
+}
+
+type Version struct {
+	Version int       `json:"version"`
+	Name    string    `json:"name"`
+	Mime    string    `json:"mime"`
+	File    bool      `json:"file"`
+	Size    int64     `json:"size"`
+	Created time.Time `json:"created"`
+}
+
+func WriteResponseWithVersions(w http.ResponseWriter, logger *zap.Logger, id string, versions []Version) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	resp := mainResponse{
+		Data: &Data{
+			Id:       id,
+			Versions: versions,
+		},
+	}
+
+	err := json.NewEncoder(w).Encode(resp)
+	if err != nil {
+		logger.Error("WriteResponseWithVersions: failed to encode response", zap.Error(err))
+	}
+}
+
+type Schema struct {
+	Name   string      `json:"name"`
+	Schema interface{} `json:"schema"`
+}
+
+func WriteResponseWithSchema(w http.ResponseWriter, logger *zap.Logger, schema *Schema) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	resp := mainResponse{
+		Data: &Data{
+			Schema: schema,
+		},
+	}
+
+	err := json.NewEncoder(w).Encode(resp)
+	if err != nil {
+		logger.Error("WriteResponseWithSchema: failed to encode response", zap.Error(err))
+	}
+}
+
+type Doc struct {
+	Id       string            `json:"id"`
+	Owner    string            `json:"owner"`
+	Name     string            `json:"name"`
+	Mime     string            `json:"mime"`
+	Detected string            `json:"detected_mime,omitempty"`
+	File     bool              `json:"file"`
+	Public   bool              `json:"public"`
+	Grant    []string          `json:"grant"`
+	Size     int64             `json:"size"`
+	Version  int               `json:"version"`
+	Schema   string            `json:"schema,omitempty"`
+	Folder   string            `json:"folder,omitempty"`
+	Tags     []string          `json:"tags"`
+	Metadata map[string]string `json:"metadata"`
+	Created  time.Time         `json:"created"`
+	Updated  time.Time         `json:"updated"`
+	Deleted  *time.Time        `json:"deleted,omitempty"`
+	Expires  *time.Time        `json:"expires,omitempty"`
+	Rank     float32           `json:"rank,omitempty"`
+	Snippet  string            `json:"snippet,omitempty"`
+}
+
+func WriteResponseWithDocs(w http.ResponseWriter, logger *zap.Logger, docs []Doc) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	resp := mainResponse{
+		Data: &Data{
+			Docs: docs,
+		},
+	}
+
+	err := json.NewEncoder(w).Encode(resp)
+	if err != nil {
+		logger.Error("WriteResponseWithDocs: failed to encode response", zap.Error(err))
+	}
+}
+
+func WriteResponseWithDoc(w http.ResponseWriter, logger *zap.Logger, doc *Doc) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	resp := mainResponse{
+		Data: &Data{
+			Doc: doc,
+		},
+	}
+
+	err := json.NewEncoder(w).Encode(resp)
+	if err != nil {
+		logger.Error("WriteResponseWithDoc: failed to encode response", zap.Error(err))
+	}
+}
+
+type Folder struct {
+	Id      string    `json:"id"`
+	Owner   string    `json:"owner"`
+	Parent  string    `json:"parent,omitempty"`
+	Name    string    `json:"name"`
+	Grant   []string  `json:"grant,omitempty"`
+	Created time.Time `json:"created"`
+}
+
+func WriteResponseWithFolders(w http.ResponseWriter, logger *zap.Logger, folder *Folder, folders []Folder) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	resp := mainResponse{
+		Data: &Data{
+			Folder:  folder,
+			Folders: folders,
+		},
+	}
+
+	err := json.NewEncoder(w).Encode(resp)
+	if err != nil {
+		logger.Error("WriteResponseWithFolders: failed to encode response", zap.Error(err))
+	}
+}
+
+// Usage is the storage a user uses and may use, a zero limit means unlimited.
+type Usage struct {
+	Documents    int64 `json:"documents"`
+	Bytes        int64 `json:"bytes"`
+	MaxDocuments int64 `json:"max_documents"`
+	MaxBytes     int64 `json:"max_bytes"`
+}
+
+func WriteResponseWithUsage(w http.ResponseWriter, logger *zap.Logger, usage *Usage) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	resp := mainResponse{
+		Data: &Data{
+			Usage: usage,
+		},
+	}
+
+	err := json.NewEncoder(w).Encode(resp)
+	if err != nil {
+		logger.Error("WriteResponseWithUsage: failed to encode response", zap.Error(err))
+	}
+}
+
+type Hold struct {
+	Id      string    `json:"id"`
+	Doc     string    `json:"doc,omitempty"`
+	Login   string    `json:"login,omitempty"`
+	Reason  string    `json:"reason"`
+	Created time.Time `json:"created"`
+}
+
+func WriteResponseWithHolds(w http.ResponseWriter, logger *zap.Logger, hold *Hold, holds []Hold) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	resp := mainResponse{
+		Data: &Data{
+			Hold:  hold,
+			Holds: holds,
+		},
+	}
+
+	err := json.NewEncoder(w).Encode(resp)
+	if err != nil {
+		logger.Error("WriteResponseWithHolds: failed to encode response", zap.Error(err))
+	}
+}
+
+type RetentionRule struct {
+	Name     string    `json:"name"`
+	Tag      string    `json:"tag,omitempty"`
+	Schema   string    `json:"schema,omitempty"`
+	KeepDays int       `json:"keep_days"`
+	Created  time.Time `json:"created"`
+}
+
+func WriteResponseWithRetentionRules(w http.ResponseWriter, logger *zap.Logger, rule *RetentionRule, rules []RetentionRule) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	resp := mainResponse{
+		Data: &Data{
+			Rule:  rule,
+			Rules: rules,
+		},
+	}
+
+	err := json.NewEncoder(w).Encode(resp)
+	if err != nil {
+		logger.Error("WriteResponseWithRetentionRules: failed to encode response", zap.Error(err))
+	}
+}
+
+type Lock struct {
+	Doc     string    `json:"doc"`
+	Owner   string    `json:"owner"`
+	Expires time.Time `json:"expires"`
+}
+
+func WriteResponseWithLock(w http.ResponseWriter, logger *zap.Logger, lock *Lock) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	resp := mainResponse{
+		Data: &Data{
+			Lock: lock,
+		},
+	}
+
+	err := json.NewEncoder(w).Encode(resp)
+	if err != nil {
+		logger.Error("WriteResponseWithLock: failed to encode response", zap.Error(err))
+	}
+}
+
+// CacheStats counts downloads served from the content cache (hits) and from the database (misses).
+type CacheStats struct {
+	ContentHits   int64   `json:"content_hits"`
+	ContentMisses int64   `json:"content_misses"`
+	HitRatio      float64 `json:"hit_ratio"`
+}
+
+func WriteResponseWithCacheStats(w http.ResponseWriter, logger *zap.Logger, stats *CacheStats) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	resp := mainResponse{
+		Data: &Data{
+			Cache: stats,
+		},
+	}
+
+	err := json.NewEncoder(w).Encode(resp)
+	if err != nil {
+		logger.Error("WriteResponseWithCacheStats: failed to encode response", zap.Error(err))
+	}
+}
+
+// PoolStats describes a database connection pool. Reads count the reads routed to the pool,
+// on the primary Pinned of them followed a write of the same user and Fallbacks replaced a replica.
+type PoolStats struct {
+	Name              string  `json:"name"`
+	Role              string  `json:"role"`
+	Down              bool    `json:"down"`
+	TotalConns        int32   `json:"total_conns"`
+	IdleConns         int32   `json:"idle_conns"`
+	AcquiredConns     int32   `json:"acquired_conns"`
+	MaxConns          int32   `json:"max_conns"`
+	AcquireCount      int64   `json:"acquire_count"`
+	AcquireDurationMs float64 `json:"acquire_duration_ms"`
+	EmptyAcquires     int64   `json:"empty_acquires"`
+	Reads             int64   `json:"reads"`
+	Failures          int64   `json:"failures"`
+	Pinned            int64   `json:"pinned,omitempty"`
+	Fallbacks         int64   `json:"fallbacks,omitempty"`
+}
+
+func WriteResponseWithPoolStats(w http.ResponseWriter, logger *zap.Logger, pools []PoolStats) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	resp := mainResponse{
+		Data: &Data{
+			Pools: pools,
+		},
+	}
+
+	err := json.NewEncoder(w).Encode(resp)
+	if err != nil {
+		logger.Error("WriteResponseWithPoolStats: failed to encode response", zap.Error(err))
+	}
+}
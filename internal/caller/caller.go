@@ -0,0 +1,18 @@
+// Package caller carries the login of the authenticated user through a request context.
+// It has no dependencies, so that handlers and storage can both read the login.
+package caller
+
+import "context"
+
+type loginKey struct{}
+
+func WithLogin(ctx context.Context, login string) context.Context {
+	return context.WithValue(ctx, loginKey{}, login)
+}
+
+// Login returns the login of ctx, empty when the request is not authenticated.
+func Login(ctx context.Context) string {
+	login, _ := ctx.Value(loginKey{}).(string)
+
+	return login
+}
@@ -0,0 +1,159 @@
+package compressor
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"go.uber.org/zap"
+
+	"astral/internal/mime_sniffer"
+)
+
+func New(config *Config, logger *zap.Logger) (*Compressor, error) {
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		return nil, fmt.Errorf("New: failed to create zstd encoder: %w", err)
+	}
+
+	decoder, err := zstd.NewReader(nil)
+	if err != nil {
+		return nil, fmt.Errorf("New: failed to create zstd decoder: %w", err)
+	}
+
+	return &Compressor{
+		config:  config,
+		logger:  logger,
+		encoder: encoder,
+		decoder: decoder,
+	}, nil
+}
+
+// Compress picks a codec for mime and returns it together with the encoded content.
+// Content is kept as is when compression is disabled, the type is not configured
+// for compression or the encoded form would not be smaller.
+func (c *Compressor) Compress(mime string, content []byte) (string, []byte, error) {
+	if !c.config.Enabled || len(content) < c.config.MinSize {
+		return CodecIdentity, content, nil
+	}
+
+	var (
+		codec string
+		data  []byte
+	)
+
+	switch {
+	case mimeSniffer.MatchAny(c.config.GzipTypes, mime):
+		var buf bytes.Buffer
+
+		zw := gzip.NewWriter(&buf)
+
+		_, err := zw.Write(content)
+		if err != nil {
+			c.logger.Error("Compress: failed to gzip content", zap.Error(err))
+			return "", nil, fmt.Errorf("Compress: failed to gzip content: %w", err)
+		}
+
+		err = zw.Close()
+		if err != nil {
+			c.logger.Error("Compress: failed to gzip content", zap.Error(err))
+			return "", nil, fmt.Errorf("Compress: failed to gzip content: %w", err)
+		}
+
+		codec, data = CodecGzip, buf.Bytes()
+
+	case mimeSniffer.MatchAny(c.config.ZstdTypes, mime):
+		codec, data = CodecZstd, c.encoder.EncodeAll(content, nil)
+
+	default:
+		return CodecIdentity, content, nil
+	}
+
+	if len(data) >= len(content) {
+		c.logger.Debug("Compress: compression does not pay off", zap.String("codec", codec))
+		return CodecIdentity, content, nil
+	}
+
+	return codec, data, nil
+}
+
+func (c *Compressor) Decompress(codec string, data []byte) ([]byte, error) {
+	switch codec {
+	case CodecIdentity, "":
+		return data, nil
+
+	case CodecGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			c.logger.Error("Decompress: failed to open gzip content", zap.Error(err))
+			return nil, fmt.Errorf("Decompress: failed to open gzip content: %w", err)
+		}
+		defer zr.Close()
+
+		content, err := io.ReadAll(zr)
+		if err != nil {
+			c.logger.Error("Decompress: failed to read gzip content", zap.Error(err))
+			return nil, fmt.Errorf("Decompress: failed to read gzip content: %w", err)
+		}
+
+		return content, nil
+
+	case CodecZstd:
+		content, err := c.decoder.DecodeAll(data, nil)
+		if err != nil {
+			c.logger.Error("Decompress: failed to decode zstd content", zap.Error(err))
+			return nil, fmt.Errorf("Decompress: failed to decode zstd content: %w", err)
+		}
+
+		return content, nil
+
+	default:
+		c.logger.Error("Decompress: unknown codec", zap.String("codec", codec))
+		return nil, fmt.Errorf("Decompress: %w: %s", ErrUnknownCodec, codec)
+	}
+}
+
+// Accepts reports whether an Accept-Encoding header value allows codec.
+func Accepts(header string, codec string) bool {
+	if codec == CodecIdentity || codec == "" {
+		return false
+	}
+
+	wildcard := false
+
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		switch name {
+		case codec:
+			return qValue(params) > 0
+		case "*":
+			wildcard = qValue(params) > 0
+		}
+	}
+
+	return wildcard
+}
+
+func qValue(params string) float64 {
+	for _, param := range strings.Split(params, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok || strings.TrimSpace(key) != "q" {
+			continue
+		}
+
+		q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return 0
+		}
+
+		return q
+	}
+
+	return 1
+}
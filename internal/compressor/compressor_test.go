@@ -0,0 +1,152 @@
+package compressor
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newCompressor(t *testing.T) *Compressor {
+	c, err := New(&Config{
+		Enabled:   true,
+		MinSize:   16,
+		GzipTypes: []string{"text/html"},
+		ZstdTypes: []string{"text/*", "application/json"},
+	}, zap.NewNop())
+	require.NoError(t, err)
+
+	return c
+}
+
+func TestCompress(t *testing.T) {
+	text := bytes.Repeat([]byte("id,name,amount\n1,apollo,100\n"), 100)
+
+	tests := []struct {
+		name    string
+		mime    string
+		content []byte
+		codec   string
+	}{
+		{
+			name:    "gzip for html",
+			mime:    "text/html; charset=utf-8",
+			content: text,
+			codec:   CodecGzip,
+		},
+		{
+			name:    "zstd for csv",
+			mime:    "text/csv",
+			content: text,
+			codec:   CodecZstd,
+		},
+		{
+			name:    "not configured type",
+			mime:    "image/png",
+			content: text,
+			codec:   CodecIdentity,
+		},
+		{
+			name:    "too small",
+			mime:    "text/plain",
+			content: []byte("tiny"),
+			codec:   CodecIdentity,
+		},
+		{
+			name:    "not compressible",
+			mime:    "application/json",
+			content: []byte(`{"k":"a8Zq1xV0pL"}`),
+			codec:   CodecIdentity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newCompressor(t)
+
+			codec, data, err := c.Compress(tt.mime, tt.content)
+			require.NoError(t, err)
+			require.Equal(t, tt.codec, codec)
+
+			if codec != CodecIdentity {
+				require.Less(t, len(data), len(tt.content))
+			}
+
+			content, err := c.Decompress(codec, data)
+			require.NoError(t, err)
+			require.Equal(t, tt.content, content)
+		})
+	}
+}
+
+func TestCompressDisabled(t *testing.T) {
+	c := newCompressor(t)
+	c.config.Enabled = false
+
+	content := bytes.Repeat([]byte("a"), 1024)
+
+	codec, data, err := c.Compress("text/plain", content)
+	require.NoError(t, err)
+	require.Equal(t, CodecIdentity, codec)
+	require.Equal(t, content, data)
+}
+
+func TestDecompressUnknownCodec(t *testing.T) {
+	c := newCompressor(t)
+
+	_, err := c.Decompress("br", []byte("data"))
+	require.ErrorIs(t, err, ErrUnknownCodec)
+}
+
+func TestAccepts(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		codec  string
+		want   bool
+	}{
+		{
+			name:   "listed",
+			header: "gzip, deflate, br",
+			codec:  CodecGzip,
+			want:   true,
+		},
+		{
+			name:   "not listed",
+			header: "gzip, deflate",
+			codec:  CodecZstd,
+			want:   false,
+		},
+		{
+			name:   "explicitly refused",
+			header: "zstd;q=0, *",
+			codec:  CodecZstd,
+			want:   false,
+		},
+		{
+			name:   "wildcard",
+			header: "*;q=0.5",
+			codec:  CodecZstd,
+			want:   true,
+		},
+		{
+			name:   "identity is never passed through",
+			header: "*",
+			codec:  CodecIdentity,
+			want:   false,
+		},
+		{
+			name:   "empty header",
+			header: "",
+			codec:  CodecGzip,
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, Accepts(tt.header, tt.codec))
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package compressor
+
+import (
+	"errors"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+const (
+	CodecIdentity = "identity"
+	CodecGzip     = "gzip"
+	CodecZstd     = "zstd"
+)
+
+var (
+	ErrUnknownCodec = errors.New("unknown codec")
+)
+
+type Config struct {
+	Enabled   bool     `env:"COMPRESSION_ENABLED" env-default:"true"`
+	MinSize   int      `env:"COMPRESSION_MIN_SIZE" env-default:"512"`
+	GzipTypes []string `env:"COMPRESSION_GZIP_TYPES" env-separator:"," env-default:"text/html,text/css,text/javascript,application/javascript"`
+	ZstdTypes []string `env:"COMPRESSION_ZSTD_TYPES" env-separator:"," env-default:"text/*,application/json,application/xml,application/x-ndjson"`
+}
+
+type Compressor struct {
+	config  *Config
+	logger  *zap.Logger
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+}
+
+type ContentCompressor interface {
+	Compress(mime string, content []byte) (string, []byte, error)
+	Decompress(codec string, data []byte) ([]byte, error)
+}
+
+type MockContentCompressor struct {
+	mock.Mock
+}
@@ -7,17 +7,37 @@ import (
 
 	"astral/internal/api"
 	"astral/internal/auth"
+	"astral/internal/compressor"
+	"astral/internal/expiry"
+	"astral/internal/keyring"
+	"astral/internal/local_cache"
 	"astral/internal/logger"
+	"astral/internal/mime_sniffer"
+	"astral/internal/schema_validator"
+	"astral/internal/storage"
 	"astral/internal/storage/postgres_client"
 	"astral/internal/storage/redis_client"
+	"astral/internal/storage/sqlite_client"
+	"astral/internal/text_extractor"
+	"astral/internal/trash"
 )
 
 type Config struct {
-	HttpServer api.HttpServer        `env-required:"true"`
-	Auth       auth.Config           `env-required:"true"`
-	Redis      redisClient.Config    `env-required:"true"`
-	Postgres   postgresClient.Config `env-required:"true"`
-	Logger     logger.Config         `env-required:"true"`
+	HttpServer  api.HttpServer        `env-required:"true"`
+	Auth        auth.Config           `env-required:"true"`
+	Redis       redisClient.Config    `env-required:"true"`
+	Postgres    postgresClient.Config `env-required:"true"`
+	Logger      logger.Config         `env-required:"true"`
+	Mime        mimeSniffer.Config
+	Compression compressor.Config
+	Encryption  keyring.Config
+	Schema      schemaValidator.Config
+	Search      textExtractor.Config
+	Trash       trash.Config
+	Expiry      expiry.Config
+	LocalCache  localCache.Config
+	Storage     storage.Config
+	SQLite      sqliteClient.Config
 }
 
 func New(path string) (*Config, error) {
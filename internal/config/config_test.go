@@ -9,11 +9,8 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func TestNew(t *testing.T) {
-	tempDir := t.TempDir()
-	tempFile := tempDir + "/config.env"
-
-	content := `
+// requiredSettings are the settings a config can not be loaded without.
+const requiredSettings = `
 	HTTP_HOST=localhost
 	HTTP_PORT=4848
 
@@ -25,6 +22,8 @@ func TestNew(t *testing.T) {
 	REDIS_TOKEN_DB=0
 	REDIS_CACHE_DB=1
 	REDIS_TIMEOUT=33s
+	REDIS_TOKEN_TTL=15m
+	REDIS_CACHE_TTL=20m
 	REDIS_PASSWORD=redisPassword
 
 	POSTGRES_HOST=localhost
@@ -37,38 +36,188 @@ func TestNew(t *testing.T) {
 	POSTGRES_MIN_CONNECTIONS=500
 
 	LOGGER=dev
-	`
-
-	err := os.WriteFile(tempFile, []byte(content), 0644)
-	require.NoError(t, err)
-
-	cfg, err := New(tempFile)
-	require.NoError(t, err)
-
-	assert.Equal(t, "localhost", cfg.HttpServer.Host)
-	assert.Equal(t, 4848, cfg.HttpServer.Port)
+`
 
-	assert.Equal(t, "someAdminToken", cfg.Auth.AdminToken)
-	assert.Equal(t, 111, cfg.Auth.LengthToken)
-
-	assert.Equal(t, "localhost", cfg.Redis.Host)
-	assert.Equal(t, 6754321, cfg.Redis.Port)
-	assert.Equal(t, 0, cfg.Redis.TokenDB)
-	assert.Equal(t, 1, cfg.Redis.CacheDB)
-	assert.Equal(t, 33*time.Second, cfg.Redis.Timeout)
-	assert.Equal(t, "redisPassword", cfg.Redis.Password)
-
-	assert.Equal(t, "localhost", cfg.Postgres.Host)
-	assert.Equal(t, "6754321", cfg.Postgres.Port)
-	assert.Equal(t, "root", cfg.Postgres.User)
-	assert.Equal(t, "postgresPassword", cfg.Postgres.Password)
-	assert.Equal(t, "postgres", cfg.Postgres.Database)
-	assert.Equal(t, 33*time.Second, cfg.Postgres.Timeout)
-	assert.Equal(t, 1000, cfg.Postgres.MaxConns)
-	assert.Equal(t, 500, cfg.Postgres.MinConns)
-
-	assert.Equal(t, "dev", cfg.Logger.Env)
-
-	_, err = New("wrongPath")
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		check   func(t *testing.T, cfg *Config)
+	}{
+		{
+			name: "required settings",
+			check: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, "localhost", cfg.HttpServer.Host)
+				assert.Equal(t, 4848, cfg.HttpServer.Port)
+
+				assert.Equal(t, "someAdminToken", cfg.Auth.AdminToken)
+				assert.Equal(t, 111, cfg.Auth.LengthToken)
+
+				assert.Equal(t, "localhost", cfg.Redis.Host)
+				assert.Equal(t, 6754321, cfg.Redis.Port)
+				assert.Equal(t, 0, cfg.Redis.TokenDB)
+				assert.Equal(t, 1, cfg.Redis.CacheDB)
+				assert.Equal(t, 33*time.Second, cfg.Redis.Timeout)
+				assert.Equal(t, 15*time.Minute, cfg.Redis.TokenTTL)
+				assert.Equal(t, 20*time.Minute, cfg.Redis.CacheTTL)
+				assert.Equal(t, "redisPassword", cfg.Redis.Password)
+
+				assert.Equal(t, "localhost", cfg.Postgres.Host)
+				assert.Equal(t, "6754321", cfg.Postgres.Port)
+				assert.Equal(t, "root", cfg.Postgres.User)
+				assert.Equal(t, "postgresPassword", cfg.Postgres.Password)
+				assert.Equal(t, "postgres", cfg.Postgres.Database)
+				assert.Equal(t, 33*time.Second, cfg.Postgres.Timeout)
+				assert.Equal(t, 1000, cfg.Postgres.MaxConns)
+				assert.Equal(t, 500, cfg.Postgres.MinConns)
+
+				assert.Equal(t, "dev", cfg.Logger.Env)
+			},
+		},
+		{
+			name: "defaults",
+			check: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, "flag", cfg.Mime.Policy)
+				assert.Empty(t, cfg.Mime.Allow)
+				assert.Empty(t, cfg.Mime.Deny)
+
+				assert.Equal(t, 10, cfg.Postgres.VersionsKeepLast)
+				assert.Equal(t, 0, cfg.Postgres.VersionsKeepDays)
+				assert.Equal(t, int64(0), cfg.Postgres.QuotaDocuments)
+				assert.Equal(t, int64(0), cfg.Postgres.QuotaBytes)
+				assert.True(t, cfg.Postgres.AutoMigrate)
+				assert.Equal(t, "disable", cfg.Postgres.SSLMode)
+				assert.Equal(t, "schema_astral", cfg.Postgres.Schema)
+				assert.Equal(t, time.Duration(0), cfg.Postgres.StatementTimeout)
+				assert.Empty(t, cfg.Postgres.ReplicaURLs)
+				assert.Equal(t, 10*time.Second, cfg.Postgres.ReplicaCooldown)
+
+				assert.Equal(t, 256, cfg.Schema.CacheSize)
+				assert.Equal(t, 262144, cfg.Search.MaxSize)
+
+				assert.Equal(t, 720*time.Hour, cfg.Trash.Retention)
+				assert.Equal(t, time.Hour, cfg.Trash.PurgeInterval)
+				assert.Equal(t, 500, cfg.Trash.PurgeBatch)
+
+				assert.Equal(t, time.Minute, cfg.Expiry.SweepInterval)
+				assert.Equal(t, 500, cfg.Expiry.SweepBatch)
+
+				assert.Equal(t, "standalone", cfg.Redis.Mode)
+				assert.Empty(t, cfg.Redis.Addrs)
+				assert.Empty(t, cfg.Redis.TokenPrefix)
+				assert.Empty(t, cfg.Redis.CachePrefix)
+				assert.Equal(t, 15*time.Minute, cfg.Redis.LockTTL)
+				assert.Equal(t, 24*time.Hour, cfg.Redis.LockMaxTTL)
+				assert.Equal(t, 65536, cfg.Redis.ContentCacheSize)
+				assert.Equal(t, time.Minute, cfg.Redis.CacheStaleTTL)
+				assert.Equal(t, 1.0, cfg.Redis.CacheEarlyBeta)
+
+				assert.Equal(t, 0, cfg.LocalCache.Entries)
+				assert.Equal(t, 67108864, cfg.LocalCache.Bytes)
+				assert.Equal(t, 5*time.Second, cfg.LocalCache.TTL)
+
+				assert.Equal(t, "postgres", cfg.Storage.Backend)
+
+				assert.True(t, cfg.Compression.Enabled)
+				assert.Equal(t, 512, cfg.Compression.MinSize)
+				assert.Equal(t, []string{"text/html", "text/css", "text/javascript", "application/javascript"}, cfg.Compression.GzipTypes)
+				assert.Equal(t, []string{"text/*", "application/json", "application/xml", "application/x-ndjson"}, cfg.Compression.ZstdTypes)
+
+				assert.Empty(t, cfg.Encryption.Keys)
+				assert.Empty(t, cfg.Encryption.KeyFile)
+				assert.Empty(t, cfg.Encryption.ActiveKey)
+
+				assert.Equal(t, "./data/astral.db", cfg.SQLite.Path)
+				assert.Equal(t, 5*time.Second, cfg.SQLite.Timeout)
+			},
+		},
+		{
+			name: "mime policy",
+			content: `
+	MIME_POLICY=reject
+	MIME_ALLOW=text/*,application/pdf
+	`,
+			check: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, "reject", cfg.Mime.Policy)
+				assert.Equal(t, []string{"text/*", "application/pdf"}, cfg.Mime.Allow)
+				assert.Empty(t, cfg.Mime.Deny)
+			},
+		},
+		{
+			name: "postgres connection",
+			content: `
+	POSTGRES_AUTO_MIGRATE=false
+	POSTGRES_SSLMODE=verify-full
+	POSTGRES_SSLROOTCERT=/etc/ssl/root.crt
+	POSTGRES_SCHEMA=astral_test
+	POSTGRES_STATEMENT_TIMEOUT=15s
+	POSTGRES_REPLICA_URLS=postgres://u:p@replica1/astral,postgres://u:p@replica2/astral
+	POSTGRES_PIN_PRIMARY=3s
+	`,
+			check: func(t *testing.T, cfg *Config) {
+				assert.False(t, cfg.Postgres.AutoMigrate)
+				assert.Equal(t, "verify-full", cfg.Postgres.SSLMode)
+				assert.Equal(t, "/etc/ssl/root.crt", cfg.Postgres.SSLRootCert)
+				assert.Equal(t, "astral_test", cfg.Postgres.Schema)
+				assert.Equal(t, 15*time.Second, cfg.Postgres.StatementTimeout)
+				assert.Equal(t, []string{"postgres://u:p@replica1/astral", "postgres://u:p@replica2/astral"}, cfg.Postgres.ReplicaURLs)
+				assert.Equal(t, 3*time.Second, cfg.Postgres.PinPrimary)
+			},
+		},
+		{
+			name: "compression",
+			content: `
+	COMPRESSION_ENABLED=false
+	COMPRESSION_MIN_SIZE=1024
+	COMPRESSION_GZIP_TYPES=text/html
+	COMPRESSION_ZSTD_TYPES=application/json,text/*
+	`,
+			check: func(t *testing.T, cfg *Config) {
+				assert.False(t, cfg.Compression.Enabled)
+				assert.Equal(t, 1024, cfg.Compression.MinSize)
+				assert.Equal(t, []string{"text/html"}, cfg.Compression.GzipTypes)
+				assert.Equal(t, []string{"application/json", "text/*"}, cfg.Compression.ZstdTypes)
+			},
+		},
+		{
+			name: "encryption",
+			content: `
+	ENCRYPTION_KEYS=k1:MTExMTExMTExMTExMTExMTExMTExMTExMTExMTExMTE=,k2:MjIyMjIyMjIyMjIyMjIyMjIyMjIyMjIyMjIyMjIyMjI=
+	ENCRYPTION_KEY_FILE=/run/secrets/astral_keys
+	ENCRYPTION_ACTIVE_KEY=k2
+	`,
+			check: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, []string{
+					"k1:MTExMTExMTExMTExMTExMTExMTExMTExMTExMTExMTE=",
+					"k2:MjIyMjIyMjIyMjIyMjIyMjIyMjIyMjIyMjIyMjIyMjI=",
+				}, cfg.Encryption.Keys)
+				assert.Equal(t, "/run/secrets/astral_keys", cfg.Encryption.KeyFile)
+				assert.Equal(t, "k2", cfg.Encryption.ActiveKey)
+			},
+		},
+		{
+			name:    "storage backend",
+			content: "STORAGE=memory",
+			check: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, "memory", cfg.Storage.Backend)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempFile := t.TempDir() + "/config.env"
+
+			err := os.WriteFile(tempFile, []byte(requiredSettings+tt.content), 0644)
+			require.NoError(t, err)
+
+			cfg, err := New(tempFile)
+			require.NoError(t, err)
+
+			tt.check(t, cfg)
+		})
+	}
+
+	_, err := New("wrongPath")
 	assert.Contains(t, err.Error(), "failed to read config")
 }
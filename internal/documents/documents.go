@@ -1,6 +1,11 @@
 package documents
 
-import "time"
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
 
 type Document struct {
 	Id        string
@@ -13,4 +18,108 @@ type Document struct {
 	Content   []byte
 	JSON      []byte
 	CreatedAt time.Time
+	Codec     string
+	Size      int64
+	Version   int
+	UpdatedAt time.Time
+	Schema    string
+	Tags      []string
+	Metadata  map[string]string
+	FolderId  string
+	DeletedAt time.Time
+	ExpiresAt *time.Time
+	// DetectedMime is the mime found in the content when it did not match Mime
+	// and the mismatch was only flagged. Empty when they match.
+	DetectedMime string
+	// Sealed is set for documents stored encrypted.
+	Sealed bool
+	// Text is the searchable text extracted from the content and JSON on save.
+	Text string
+}
+
+// CanRead reports whether login may read the document: the owner,
+// anybody for public documents and the logins from Grant.
+func (d *Document) CanRead(login string) bool {
+	return d.Login == login || d.Public || slices.Contains(d.Grant, login)
+}
+
+// CanWrite reports whether login may change the document: the owner and the logins from Grant.
+func (d *Document) CanWrite(login string) bool {
+	return d.Login == login || slices.Contains(d.Grant, login)
+}
+
+// ETag identifies the current version of the document for conditional requests.
+func (d *Document) ETag() string {
+	return strconv.Quote(strconv.Itoa(d.Version))
+}
+
+// ParseETag returns the version from an ETag produced by Document.ETag.
+func ParseETag(etag string) (int, bool) {
+	etag = strings.TrimPrefix(strings.TrimSpace(etag), "W/")
+
+	unquoted, err := strconv.Unquote(etag)
+	if err != nil {
+		return 0, false
+	}
+
+	version, err := strconv.Atoi(unquoted)
+	if err != nil || version <= 0 {
+		return 0, false
+	}
+
+	return version, true
+}
+
+type Stats struct {
+	Documents  int64
+	Size       int64
+	StoredSize int64
+}
+
+// Ratio returns how many times the stored content is smaller than the original one.
+func (s *Stats) Ratio() float64 {
+	if s.StoredSize == 0 {
+		return 1
+	}
+
+	return float64(s.Size) / float64(s.StoredSize)
+}
+
+// ListQuery describes which documents to list. Only documents Login can read are returned.
+type ListQuery struct {
+	Login string
+	Owner string
+
+	// JSONContains is a JSON value the document JSON must contain (@>).
+	JSONContains []byte
+	// JSONPath holds compiled jsonpath predicates the document JSON must satisfy (@@).
+	JSONPath []string
+
+	// Folder limits the listing to one folder, an empty id stands for the root.
+	Folder *string
+
+	// Tags must all be set on the document.
+	Tags []string
+	// Metadata entries must all be present on the document with the same values.
+	Metadata map[string]string
+
+	// Search is a web search style full-text query, documents are ranked by it when set.
+	Search string
+
+	Limit  int
+	Offset int
+}
+
+// ReadsContent reports whether the query looks into the document JSON or text,
+// which is not possible for sealed documents.
+func (q *ListQuery) ReadsContent() bool {
+	return len(q.JSONContains) > 0 || len(q.JSONPath) > 0 || q.Search != ""
+}
+
+// SearchResult is a document found by a full-text query. Snippet holds fragments
+// of the matched text with the terms wrapped in <b></b>.
+type SearchResult struct {
+	Document
+	Rank    float32
+	Snippet string
 }
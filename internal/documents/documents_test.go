@@ -0,0 +1,461 @@
+package documents
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccess(t *testing.T) {
+	document := &Document{
+		Login: "owner123",
+		Grant: []string{"reader12"},
+	}
+
+	require.True(t, document.CanRead("owner123"))
+	require.True(t, document.CanRead("reader12"))
+	require.False(t, document.CanRead("stranger"))
+
+	require.True(t, document.CanWrite("owner123"))
+	require.True(t, document.CanWrite("reader12"))
+	require.False(t, document.CanWrite("stranger"))
+
+	document.Public = true
+
+	require.True(t, document.CanRead("stranger"))
+	require.False(t, document.CanWrite("stranger"))
+}
+
+func TestETag(t *testing.T) {
+	document := &Document{Version: 7}
+
+	require.Equal(t, `"7"`, document.ETag())
+
+	tests := []struct {
+		name    string
+		etag    string
+		version int
+		ok      bool
+	}{
+		{
+			name:    "strong",
+			etag:    document.ETag(),
+			version: 7,
+			ok:      true,
+		},
+		{
+			name:    "weak",
+			etag:    `W/"3"`,
+			version: 3,
+			ok:      true,
+		},
+		{
+			name: "unquoted",
+			etag: "3",
+		},
+		{
+			name: "not a version",
+			etag: `"abc"`,
+		},
+		{
+			name: "zero",
+			etag: `"0"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, ok := ParseETag(tt.etag)
+			require.Equal(t, tt.ok, ok)
+			require.Equal(t, tt.version, version)
+		})
+	}
+}
+
+func TestRatio(t *testing.T) {
+	require.Equal(t, 1.0, (&Stats{}).Ratio())
+	require.Equal(t, 4.0, (&Stats{Size: 400, StoredSize: 100}).Ratio())
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		tags    []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "trimmed, sorted and deduplicated",
+			tags: []string{" invoice", "2024", "invoice "},
+			want: []string{"2024", "invoice"},
+		},
+		{
+			name: "no tags",
+			tags: nil,
+			want: []string{},
+		},
+		{
+			name:    "empty tag",
+			tags:    []string{"invoice", " "},
+			wantErr: true,
+		},
+		{
+			name:    "too long",
+			tags:    []string{strings.Repeat("t", MaxTagLength+1)},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeTags(tt.tags)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidTag)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidateMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata map[string]string
+		wantErr  bool
+	}{
+		{
+			name:     "valid",
+			metadata: map[string]string{"project": "apollo", "cost.center": "42"},
+		},
+		{
+			name:     "invalid key",
+			metadata: map[string]string{"project name": "apollo"},
+			wantErr:  true,
+		},
+		{
+			name:     "value too long",
+			metadata: map[string]string{"note": strings.Repeat("n", MaxMetadataValue+1)},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateMetadata(tt.metadata)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidMetadata)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestSplitPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "root",
+			path: "/",
+			want: nil,
+		},
+		{
+			name: "nested",
+			path: "/projects/apollo/",
+			want: []string{"projects", "apollo"},
+		},
+		{
+			name: "repeated slashes",
+			path: "//projects//apollo",
+			want: []string{"projects", "apollo"},
+		},
+		{
+			name:    "relative",
+			path:    "projects",
+			wantErr: true,
+		},
+		{
+			name:    "parent reference",
+			path:    "/projects/../secret",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SplitPath(tt.path)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidPath)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFolderAccess(t *testing.T) {
+	folder := &Folder{
+		Login: "owner123",
+		Grant: []string{"reader12"},
+	}
+
+	require.True(t, folder.CanRead("owner123"))
+	require.True(t, folder.CanRead("reader12"))
+	require.False(t, folder.CanRead("stranger"))
+}
+
+func TestUsageCheck(t *testing.T) {
+	usage := &Usage{Documents: 9, Bytes: 900, MaxDocuments: 10, MaxBytes: 1000}
+
+	tests := []struct {
+		name      string
+		usage     *Usage
+		bytes     int64
+		documents int64
+		err       error
+	}{
+		{
+			name:      "fits",
+			usage:     usage,
+			bytes:     100,
+			documents: 1,
+		},
+		{
+			name:  "too many bytes",
+			usage: usage,
+			bytes: 101,
+			err:   ErrQuotaExceeded,
+		},
+		{
+			name:  "larger than the quota",
+			usage: usage,
+			bytes: 1001,
+			err:   ErrDocumentTooLarge,
+		},
+		{
+			name:      "too many documents",
+			usage:     &Usage{Documents: 10, MaxDocuments: 10},
+			documents: 1,
+			err:       ErrQuotaExceeded,
+		},
+		{
+			name:      "release always fits",
+			usage:     &Usage{Documents: 20, Bytes: 2000, MaxDocuments: 10, MaxBytes: 1000},
+			bytes:     -100,
+			documents: -1,
+		},
+		{
+			name:      "unlimited",
+			usage:     &Usage{Documents: 1 << 20, Bytes: 1 << 40},
+			bytes:     1 << 30,
+			documents: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.usage.Check(tt.bytes, tt.documents)
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+
+	document := &Document{Size: 10, JSON: []byte(`{"a":1}`)}
+	require.Equal(t, int64(17), document.UsageBytes())
+}
+
+func TestResolveExpiry(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	future := now.Add(time.Hour)
+	past := now.Add(-time.Hour)
+
+	tests := []struct {
+		name      string
+		expiresAt *time.Time
+		ttl       string
+		want      *time.Time
+		err       bool
+	}{
+		{
+			name: "never",
+		},
+		{
+			name: "ttl",
+			ttl:  "24h",
+			want: func() *time.Time { t := now.Add(24 * time.Hour); return &t }(),
+		},
+		{
+			name:      "expires at",
+			expiresAt: &future,
+			want:      &future,
+		},
+		{
+			name:      "both",
+			expiresAt: &future,
+			ttl:       "1h",
+			err:       true,
+		},
+		{
+			name: "invalid ttl",
+			ttl:  "tomorrow",
+			err:  true,
+		},
+		{
+			name: "negative ttl",
+			ttl:  "-1h",
+			err:  true,
+		},
+		{
+			name:      "in the past",
+			expiresAt: &past,
+			err:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveExpiry(now, tt.expiresAt, tt.ttl)
+			if tt.err {
+				require.ErrorIs(t, err, ErrInvalidExpiry)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+
+	document := &Document{ExpiresAt: &past}
+	require.True(t, document.Expired(now))
+
+	document.ExpiresAt = &future
+	require.False(t, document.Expired(now))
+
+	document.ExpiresAt = nil
+	require.False(t, document.Expired(now))
+}
+
+func TestValidateHold(t *testing.T) {
+	tests := []struct {
+		name string
+		hold *Hold
+		ok   bool
+	}{
+		{
+			name: "document",
+			hold: &Hold{DocId: "5f0c", Reason: "litigation 2024-17"},
+			ok:   true,
+		},
+		{
+			name: "login",
+			hold: &Hold{Login: "owner123", Reason: "audit"},
+			ok:   true,
+		},
+		{
+			name: "no target",
+			hold: &Hold{Reason: "audit"},
+		},
+		{
+			name: "both targets",
+			hold: &Hold{DocId: "5f0c", Login: "owner123", Reason: "audit"},
+		},
+		{
+			name: "no reason",
+			hold: &Hold{Login: "owner123"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateHold(tt.hold)
+			if !tt.ok {
+				require.ErrorIs(t, err, ErrInvalidHold)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestValidateRetentionRule(t *testing.T) {
+	tests := []struct {
+		name string
+		rule *RetentionRule
+		ok   bool
+	}{
+		{
+			name: "by tag",
+			rule: &RetentionRule{Name: "invoices", Tag: "invoice", KeepDays: 7 * 365},
+			ok:   true,
+		},
+		{
+			name: "by schema",
+			rule: &RetentionRule{Name: "contracts", Schema: "contract", KeepDays: 30},
+			ok:   true,
+		},
+		{
+			name: "invalid name",
+			rule: &RetentionRule{Name: "keep invoices", Tag: "invoice", KeepDays: 30},
+		},
+		{
+			name: "matches everything",
+			rule: &RetentionRule{Name: "all", KeepDays: 30},
+		},
+		{
+			name: "no period",
+			rule: &RetentionRule{Name: "invoices", Tag: "invoice"},
+		},
+		{
+			name: "too long",
+			rule: &RetentionRule{Name: "invoices", Tag: "invoice", KeepDays: 1 << 20},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRetentionRule(tt.rule)
+			if !tt.ok {
+				require.ErrorIs(t, err, ErrInvalidRetentionRule)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestReadsContent(t *testing.T) {
+	tests := []struct {
+		name  string
+		query ListQuery
+		want  bool
+	}{
+		{name: "labels only", query: ListQuery{Tags: []string{"invoice"}, Metadata: map[string]string{"project": "apollo"}}},
+		{name: "json containment", query: ListQuery{JSONContains: []byte(`{"a":1}`)}, want: true},
+		{name: "jsonpath", query: ListQuery{JSONPath: []string{"$.a > 1"}}, want: true},
+		{name: "search", query: ListQuery{Search: "invoice"}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.query.ReadsContent())
+		})
+	}
+}
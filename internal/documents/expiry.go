@@ -0,0 +1,42 @@
+package documents
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrInvalidExpiry = errors.New("invalid expiry")
+
+// Expired reports whether the document has an expiry that is not after now.
+func (d *Document) Expired(now time.Time) bool {
+	return d.ExpiresAt != nil && !d.ExpiresAt.After(now)
+}
+
+// ResolveExpiry turns an absolute expiresAt or a relative ttl like "24h" into the
+// expiry time. At most one of them may be set, nil means the document never expires.
+func ResolveExpiry(now time.Time, expiresAt *time.Time, ttl string) (*time.Time, error) {
+	switch {
+	case expiresAt != nil && ttl != "":
+		return nil, fmt.Errorf("ResolveExpiry: %w: expires_at and ttl are mutually exclusive", ErrInvalidExpiry)
+
+	case ttl != "":
+		duration, err := time.ParseDuration(ttl)
+		if err != nil || duration <= 0 {
+			return nil, fmt.Errorf("ResolveExpiry: %w: ttl %q", ErrInvalidExpiry, ttl)
+		}
+
+		expires := now.Add(duration)
+		return &expires, nil
+
+	case expiresAt != nil:
+		if !expiresAt.After(now) {
+			return nil, fmt.Errorf("ResolveExpiry: %w: expires_at is in the past", ErrInvalidExpiry)
+		}
+
+		return expiresAt, nil
+
+	default:
+		return nil, nil
+	}
+}
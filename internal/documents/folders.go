@@ -0,0 +1,60 @@
+package documents
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+var ErrInvalidPath = errors.New("invalid folder path")
+
+// Folder groups documents of one owner. Grant holds the logins the folder is shared with,
+// directly or through one of its parents; they get access to everything inside.
+type Folder struct {
+	Id        string
+	Login     string
+	ParentId  string
+	Name      string
+	Grant     []string
+	CreatedAt time.Time
+}
+
+// CanRead reports whether login may see the folder and its content.
+func (f *Folder) CanRead(login string) bool {
+	return f.Login == login || slices.Contains(f.Grant, login)
+}
+
+// ValidateFolderName checks that name can be used as a path segment.
+func ValidateFolderName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.Contains(name, "/") || len(name) > 255 {
+		return fmt.Errorf("ValidateFolderName: %w: %q", ErrInvalidPath, name)
+	}
+
+	return nil
+}
+
+// SplitPath splits a folder path like "/projects/apollo" into its names.
+// The root path "/" gives no names.
+func SplitPath(path string) ([]string, error) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("SplitPath: %w: path must start with /", ErrInvalidPath)
+	}
+
+	var names []string
+
+	for _, name := range strings.Split(strings.Trim(path, "/"), "/") {
+		if name == "" {
+			continue
+		}
+
+		if err := ValidateFolderName(name); err != nil {
+			return nil, err
+		}
+
+		names = append(names, name)
+	}
+
+	return names, nil
+}
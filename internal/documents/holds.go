@@ -0,0 +1,67 @@
+package documents
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	MaxHoldReason     = 1024
+	MaxRetentionYears = 100
+)
+
+var (
+	ErrInvalidHold          = errors.New("invalid legal hold")
+	ErrInvalidRetentionRule = errors.New("invalid retention rule")
+)
+
+// Hold is a legal hold on a single document (DocId) or on all documents of a login.
+// Held documents can not be deleted, purged, expired or lose their versions.
+type Hold struct {
+	Id        string
+	DocId     string
+	Login     string
+	Reason    string
+	CreatedAt time.Time
+}
+
+// RetentionRule keeps documents with Tag and/or Schema for KeepDays after they were created.
+type RetentionRule struct {
+	Name      string
+	Tag       string
+	Schema    string
+	KeepDays  int
+	CreatedAt time.Time
+}
+
+// ValidateHold checks that the hold targets exactly one document or login and has a reason.
+func ValidateHold(hold *Hold) error {
+	if (hold.DocId == "") == (hold.Login == "") {
+		return fmt.Errorf("ValidateHold: %w: either a document or a login is required", ErrInvalidHold)
+	}
+
+	if hold.Reason == "" || utf8.RuneCountInString(hold.Reason) > MaxHoldReason {
+		return fmt.Errorf("ValidateHold: %w: reason must be 1 to %d characters long", ErrInvalidHold, MaxHoldReason)
+	}
+
+	return nil
+}
+
+// ValidateRetentionRule checks the rule name, that it matches by tag or schema and its period.
+func ValidateRetentionRule(rule *RetentionRule) error {
+	if !metadataKeyRe.MatchString(rule.Name) {
+		return fmt.Errorf("ValidateRetentionRule: %w: name %q", ErrInvalidRetentionRule, rule.Name)
+	}
+
+	if rule.Tag == "" && rule.Schema == "" {
+		return fmt.Errorf("ValidateRetentionRule: %w: a tag or a schema is required", ErrInvalidRetentionRule)
+	}
+
+	if rule.KeepDays <= 0 || rule.KeepDays > MaxRetentionYears*366 {
+		return fmt.Errorf("ValidateRetentionRule: %w: keep_days must be between 1 and %d", ErrInvalidRetentionRule, MaxRetentionYears*366)
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package documents
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	MaxTags          = 50
+	MaxTagLength     = 64
+	MaxMetadataKeys  = 50
+	MaxMetadataValue = 1024
+)
+
+var (
+	ErrInvalidTag      = errors.New("invalid tag")
+	ErrInvalidMetadata = errors.New("invalid metadata")
+)
+
+var metadataKeyRe = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// NormalizeTags trims tags, drops duplicates and sorts them.
+// Tags must be non-empty and at most MaxTagLength characters long.
+func NormalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+
+		if tag == "" || utf8.RuneCountInString(tag) > MaxTagLength {
+			return nil, fmt.Errorf("NormalizeTags: %w: %q", ErrInvalidTag, tag)
+		}
+
+		normalized = append(normalized, tag)
+	}
+
+	slices.Sort(normalized)
+	normalized = slices.Compact(normalized)
+
+	if len(normalized) > MaxTags {
+		return nil, fmt.Errorf("NormalizeTags: %w: more than %d tags", ErrInvalidTag, MaxTags)
+	}
+
+	return normalized, nil
+}
+
+// ValidateMetadata checks metadata keys and values. Keys consist of letters,
+// digits, '_', '.' and '-', values are at most MaxMetadataValue bytes long.
+func ValidateMetadata(metadata map[string]string) error {
+	if len(metadata) > MaxMetadataKeys {
+		return fmt.Errorf("ValidateMetadata: %w: more than %d keys", ErrInvalidMetadata, MaxMetadataKeys)
+	}
+
+	for key, value := range metadata {
+		if !metadataKeyRe.MatchString(key) {
+			return fmt.Errorf("ValidateMetadata: %w: key %q", ErrInvalidMetadata, key)
+		}
+
+		if len(value) > MaxMetadataValue {
+			return fmt.Errorf("ValidateMetadata: %w: value of %q is too long", ErrInvalidMetadata, key)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,15 @@
+package documents
+
+import "time"
+
+// Lock is a check-out of a document: while it lasts only Login may change the document.
+type Lock struct {
+	DocId     string
+	Login     string
+	ExpiresAt time.Time
+}
+
+// HeldBy reports whether login holds the lock.
+func (l *Lock) HeldBy(login string) bool {
+	return l.Login == login
+}
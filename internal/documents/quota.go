@@ -0,0 +1,46 @@
+package documents
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrQuotaExceeded    = errors.New("storage quota exceeded")
+	ErrDocumentTooLarge = errors.New("document is larger than the storage quota")
+)
+
+// Usage is the storage a login uses and may use. Trashed documents count until
+// they are purged, archived versions until they are pruned. A zero limit means unlimited.
+type Usage struct {
+	Documents    int64
+	Bytes        int64
+	MaxDocuments int64
+	MaxBytes     int64
+}
+
+// UsageBytes returns how many bytes the document counts against the quota of its owner:
+// the original content size and the JSON.
+func (d *Document) UsageBytes() int64 {
+	return d.Size + int64(len(d.JSON))
+}
+
+// Check reports whether bytes and documents more still fit into the quota.
+// Negative values release storage and always fit.
+func (u *Usage) Check(bytes int64, documents int64) error {
+	if u.MaxBytes > 0 && bytes > 0 {
+		if bytes > u.MaxBytes {
+			return fmt.Errorf("Check: %w: %d bytes, quota is %d bytes", ErrDocumentTooLarge, bytes, u.MaxBytes)
+		}
+
+		if u.Bytes+bytes > u.MaxBytes {
+			return fmt.Errorf("Check: %w: %d of %d bytes used, %d more requested", ErrQuotaExceeded, u.Bytes, u.MaxBytes, bytes)
+		}
+	}
+
+	if u.MaxDocuments > 0 && documents > 0 && u.Documents+documents > u.MaxDocuments {
+		return fmt.Errorf("Check: %w: %d of %d documents used", ErrQuotaExceeded, u.Documents, u.MaxDocuments)
+	}
+
+	return nil
+}
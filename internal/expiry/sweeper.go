@@ -0,0 +1,46 @@
+package expiry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"astral/internal/documents"
+	"astral/internal/purge"
+)
+
+func New(config *Config, store Store, cache purge.Cache, logger *zap.Logger) *Sweeper {
+	return &Sweeper{
+		config: config,
+		store:  store,
+		cache:  cache,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// Run removes expired documents every SweepInterval until ctx is done.
+func (s *Sweeper) Run(ctx context.Context) {
+	purge.Every(ctx, s.config.SweepInterval, s.SweepOnce, s.logger)
+}
+
+// SweepOnce removes every expired document batch by batch and drops its cache
+// entries. It returns the number of removed documents.
+func (s *Sweeper) SweepOnce(ctx context.Context) (int, error) {
+	now := s.now()
+
+	total, err := purge.Batches(ctx, func(ctx context.Context, limit int) ([]documents.Document, error) {
+		return s.store.PurgeExpired(ctx, now, limit)
+	}, s.config.SweepBatch, s.cache, s.logger)
+	if err != nil {
+		return total, fmt.Errorf("SweepOnce: failed to purge expired documents: %w", err)
+	}
+
+	if total > 0 {
+		s.logger.Info("SweepOnce: removed expired documents", zap.Int("count", total))
+	}
+
+	return total, nil
+}
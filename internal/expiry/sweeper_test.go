@@ -0,0 +1,46 @@
+package expiry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"astral/internal/documents"
+)
+
+type fakeStore struct {
+	now time.Time
+	err error
+}
+
+func (s *fakeStore) PurgeExpired(_ context.Context, now time.Time, _ int) ([]documents.Document, error) {
+	s.now = now
+	return nil, s.err
+}
+
+type nopCache struct{}
+
+func (nopCache) InvalidateDocument(context.Context, string) error { return nil }
+func (nopCache) InvalidateDocs(context.Context, string) error     { return nil }
+
+func TestSweepOnce(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	store := &fakeStore{}
+
+	s := New(&Config{SweepBatch: 2}, store, nopCache{}, zap.NewNop())
+	s.now = func() time.Time { return now }
+
+	count, err := s.SweepOnce(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, count)
+	require.Equal(t, now, store.now, "documents that expired by now are removed")
+
+	store.err = errors.New("connection refused")
+
+	_, err = s.SweepOnce(context.Background())
+	require.ErrorIs(t, err, store.err)
+}
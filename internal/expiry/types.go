@@ -0,0 +1,39 @@
+package expiry
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+
+	"astral/internal/documents"
+	"astral/internal/purge"
+)
+
+type Config struct {
+	SweepInterval time.Duration `env:"EXPIRY_SWEEP_INTERVAL" env-default:"1m"`
+	SweepBatch    int           `env:"EXPIRY_SWEEP_BATCH" env-default:"500"`
+}
+
+// Store removes expired documents for good.
+type Store interface {
+	PurgeExpired(ctx context.Context, now time.Time, limit int) ([]documents.Document, error)
+}
+
+type Sweeper struct {
+	config *Config
+	store  Store
+	cache  purge.Cache
+	logger *zap.Logger
+	now    func() time.Time
+}
+
+type ExpirySweeper interface {
+	Run(ctx context.Context)
+	SweepOnce(ctx context.Context) (int, error)
+}
+
+type MockExpirySweeper struct {
+	mock.Mock
+}
@@ -0,0 +1,210 @@
+package jsonFilter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	ErrInvalidContainment = errors.New("invalid containment filter")
+	ErrInvalidPredicate   = errors.New("invalid jsonpath predicate")
+)
+
+var comparisons = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+// Containment checks that raw is a JSON object or array usable with the @> operator
+// and returns it in compact form.
+func Containment(raw string) ([]byte, error) {
+	raw = strings.TrimSpace(raw)
+
+	if !strings.HasPrefix(raw, "{") && !strings.HasPrefix(raw, "[") {
+		return nil, fmt.Errorf("Containment: %w: object or array expected", ErrInvalidContainment)
+	}
+
+	var buf bytes.Buffer
+
+	err := json.Compact(&buf, []byte(raw))
+	if err != nil {
+		return nil, fmt.Errorf("Containment: %w: %v", ErrInvalidContainment, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Predicate compiles a simple JSONPath predicate into a Postgres jsonpath expression
+// for the @@ operator. Supported forms are
+//
+//	$.path <op> literal   where op is one of == != < <= > >=
+//	exists($.path)
+//
+// A path consists of $ followed by .key, ."quoted key", [index] and [*] steps.
+// Literals are JSON strings, numbers, true, false and null. The result is rebuilt
+// from the parsed parts, so user input never reaches the query unescaped.
+func Predicate(expr string) (string, error) {
+	expr = strings.TrimSpace(expr)
+
+	if inner, ok := strings.CutPrefix(expr, "exists("); ok {
+		inner, ok = strings.CutSuffix(inner, ")")
+		if !ok {
+			return "", fmt.Errorf("Predicate: %w: unclosed exists", ErrInvalidPredicate)
+		}
+
+		path, rest, err := parsePath(strings.TrimSpace(inner))
+		if err != nil {
+			return "", err
+		}
+
+		if strings.TrimSpace(rest) != "" {
+			return "", fmt.Errorf("Predicate: %w: unexpected %q", ErrInvalidPredicate, rest)
+		}
+
+		return "exists(" + path + ")", nil
+	}
+
+	path, rest, err := parsePath(expr)
+	if err != nil {
+		return "", err
+	}
+
+	rest = strings.TrimSpace(rest)
+
+	var op string
+	for _, candidate := range comparisons {
+		if strings.HasPrefix(rest, candidate) {
+			op = candidate
+			break
+		}
+	}
+
+	if op == "" {
+		return "", fmt.Errorf("Predicate: %w: comparison operator expected", ErrInvalidPredicate)
+	}
+
+	literal, err := parseLiteral(strings.TrimSpace(rest[len(op):]))
+	if err != nil {
+		return "", err
+	}
+
+	return path + " " + op + " " + literal, nil
+}
+
+// parsePath reads a path from the start of s and returns it in canonical form with the unparsed remainder.
+func parsePath(s string) (string, string, error) {
+	if !strings.HasPrefix(s, "$") {
+		return "", "", fmt.Errorf("parsePath: %w: path must start with $", ErrInvalidPredicate)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("$")
+
+	s = s[1:]
+
+	for len(s) > 0 {
+		switch s[0] {
+		case '.':
+			s = s[1:]
+
+			var key string
+
+			if strings.HasPrefix(s, `"`) {
+				end, err := stringEnd(s)
+				if err != nil {
+					return "", "", err
+				}
+
+				err = json.Unmarshal([]byte(s[:end]), &key)
+				if err != nil {
+					return "", "", fmt.Errorf("parsePath: %w: %v", ErrInvalidPredicate, err)
+				}
+
+				s = s[end:]
+			} else {
+				end := strings.IndexFunc(s, func(r rune) bool {
+					return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+				})
+				if end == -1 {
+					end = len(s)
+				}
+
+				key, s = s[:end], s[end:]
+			}
+
+			if key == "" {
+				return "", "", fmt.Errorf("parsePath: %w: empty key", ErrInvalidPredicate)
+			}
+
+			quoted, _ := json.Marshal(key)
+			sb.WriteString(".")
+			sb.Write(quoted)
+
+		case '[':
+			end := strings.IndexByte(s, ']')
+			if end == -1 {
+				return "", "", fmt.Errorf("parsePath: %w: unclosed [", ErrInvalidPredicate)
+			}
+
+			index := strings.TrimSpace(s[1:end])
+			if index != "*" {
+				n, err := strconv.Atoi(index)
+				if err != nil || n < 0 {
+					return "", "", fmt.Errorf("parsePath: %w: invalid index %q", ErrInvalidPredicate, index)
+				}
+
+				index = strconv.Itoa(n)
+			}
+
+			sb.WriteString("[" + index + "]")
+			s = s[end+1:]
+
+		default:
+			return sb.String(), s, nil
+		}
+	}
+
+	return sb.String(), s, nil
+}
+
+// parseLiteral checks that s is a single JSON scalar and returns it as a jsonpath literal.
+func parseLiteral(s string) (string, error) {
+	if s == "" {
+		return "", fmt.Errorf("parseLiteral: %w: value expected", ErrInvalidPredicate)
+	}
+
+	var value interface{}
+
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+
+	err := dec.Decode(&value)
+	if err != nil || dec.More() {
+		return "", fmt.Errorf("parseLiteral: %w: invalid value %q", ErrInvalidPredicate, s)
+	}
+
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return "", fmt.Errorf("parseLiteral: %w: only scalar values can be compared", ErrInvalidPredicate)
+	}
+
+	literal, _ := json.Marshal(value)
+
+	return string(literal), nil
+}
+
+// stringEnd returns the index right after the JSON string that s starts with.
+func stringEnd(s string) (int, error) {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1, nil
+		}
+	}
+
+	return 0, fmt.Errorf("stringEnd: %w: unclosed string", ErrInvalidPredicate)
+}
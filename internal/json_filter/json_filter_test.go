@@ -0,0 +1,128 @@
+package jsonFilter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainment(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "object",
+			raw:  `{ "status": "paid", "tags": ["a"] }`,
+			want: `{"status":"paid","tags":["a"]}`,
+		},
+		{
+			name: "array",
+			raw:  `[1, 2]`,
+			want: `[1,2]`,
+		},
+		{
+			name:    "scalar",
+			raw:     `"paid"`,
+			wantErr: true,
+		},
+		{
+			name:    "broken json",
+			raw:     `{"status":`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Containment(tt.raw)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidContainment)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestPredicate(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "number comparison",
+			expr: `$.amount > 100`,
+			want: `$."amount" > 100`,
+		},
+		{
+			name: "nested path with index",
+			expr: `$.lines[0].sku=="A-1"`,
+			want: `$."lines"[0]."sku" == "A-1"`,
+		},
+		{
+			name: "quoted key and wildcard",
+			expr: `$."due date"[*] != null`,
+			want: `$."due date"[*] != null`,
+		},
+		{
+			name: "exists",
+			expr: `exists($.customer.email)`,
+			want: `exists($."customer"."email")`,
+		},
+		{
+			name: "string is re-escaped",
+			expr: `$.name == "a\" || true"`,
+			want: `$."name" == "a\" || true"`,
+		},
+		{
+			name:    "missing root",
+			expr:    `amount > 1`,
+			wantErr: true,
+		},
+		{
+			name:    "missing operator",
+			expr:    `$.amount`,
+			wantErr: true,
+		},
+		{
+			name:    "trailing expression",
+			expr:    `$.amount > 1 || $.b == 2`,
+			wantErr: true,
+		},
+		{
+			name:    "object literal",
+			expr:    `$.a == {"b": 1}`,
+			wantErr: true,
+		},
+		{
+			name:    "negative index",
+			expr:    `$.a[-1] == 1`,
+			wantErr: true,
+		},
+		{
+			name:    "filter expression",
+			expr:    `$.a ? (@ > 1)`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Predicate(tt.expr)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidPredicate)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package keyring
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+func New(config *Config, logger *zap.Logger) (*Keyring, error) {
+	pairs := config.Keys
+
+	if config.KeyFile != "" {
+		filePairs, err := readKeyFile(config.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		pairs = append(pairs, filePairs...)
+	}
+
+	keys := make(map[string][]byte, len(pairs))
+
+	for _, pair := range pairs {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		id, encoded, ok := strings.Cut(pair, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("New: %w: expected id:base64key", ErrInvalidKey)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != dataKeySize {
+			return nil, fmt.Errorf("New: %w: key %s must be %d base64 encoded bytes", ErrInvalidKey, id, dataKeySize)
+		}
+
+		keys[id] = key
+	}
+
+	if config.ActiveKey != "" {
+		if _, ok := keys[config.ActiveKey]; !ok {
+			return nil, fmt.Errorf("New: %w: %s", ErrUnknownKey, config.ActiveKey)
+		}
+	}
+
+	return &Keyring{
+		keys:   keys,
+		active: config.ActiveKey,
+		logger: logger,
+	}, nil
+}
+
+// Enabled reports whether new content has to be encrypted.
+func (k *Keyring) Enabled() bool {
+	return k != nil && k.active != ""
+}
+
+func (k *Keyring) ActiveKeyID() string {
+	return k.active
+}
+
+// GenerateDataKey returns a fresh data key together with its copy wrapped by the active master key.
+func (k *Keyring) GenerateDataKey(aad string) ([]byte, []byte, string, error) {
+	if !k.Enabled() {
+		return nil, nil, "", fmt.Errorf("GenerateDataKey: %w", ErrNoActiveKey)
+	}
+
+	dataKey := make([]byte, dataKeySize)
+
+	_, err := rand.Read(dataKey)
+	if err != nil {
+		k.logger.Error("GenerateDataKey: failed to generate data key", zap.Error(err))
+		return nil, nil, "", fmt.Errorf("GenerateDataKey: failed to generate data key: %w", err)
+	}
+
+	wrapped, err := seal(k.keys[k.active], dataKey, aad)
+	if err != nil {
+		k.logger.Error("GenerateDataKey: failed to wrap data key", zap.Error(err))
+		return nil, nil, "", fmt.Errorf("GenerateDataKey: failed to wrap data key: %w", err)
+	}
+
+	return dataKey, wrapped, k.active, nil
+}
+
+func (k *Keyring) UnwrapDataKey(keyID string, wrapped []byte, aad string) ([]byte, error) {
+	masterKey, ok := k.keys[keyID]
+	if !ok {
+		k.logger.Error("UnwrapDataKey: unknown master key", zap.String("key_id", keyID))
+		return nil, fmt.Errorf("UnwrapDataKey: %w: %s", ErrUnknownKey, keyID)
+	}
+
+	dataKey, err := open(masterKey, wrapped, aad)
+	if err != nil {
+		k.logger.Error("UnwrapDataKey: failed to unwrap data key", zap.Error(err))
+		return nil, fmt.Errorf("UnwrapDataKey: %w", err)
+	}
+
+	return dataKey, nil
+}
+
+// RewrapDataKey re-encrypts a wrapped data key under the active master key.
+// The content encrypted with the data key stays untouched.
+func (k *Keyring) RewrapDataKey(keyID string, wrapped []byte, aad string) ([]byte, string, error) {
+	dataKey, err := k.UnwrapDataKey(keyID, wrapped, aad)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !k.Enabled() {
+		return nil, "", fmt.Errorf("RewrapDataKey: %w", ErrNoActiveKey)
+	}
+
+	rewrapped, err := seal(k.keys[k.active], dataKey, aad)
+	if err != nil {
+		k.logger.Error("RewrapDataKey: failed to wrap data key", zap.Error(err))
+		return nil, "", fmt.Errorf("RewrapDataKey: failed to wrap data key: %w", err)
+	}
+
+	return rewrapped, k.active, nil
+}
+
+func (k *Keyring) Encrypt(dataKey []byte, plaintext []byte, aad string) ([]byte, error) {
+	ciphertext, err := seal(dataKey, plaintext, aad)
+	if err != nil {
+		k.logger.Error("Encrypt: failed to encrypt", zap.Error(err))
+		return nil, fmt.Errorf("Encrypt: failed to encrypt: %w", err)
+	}
+
+	return ciphertext, nil
+}
+
+func (k *Keyring) Decrypt(dataKey []byte, ciphertext []byte, aad string) ([]byte, error) {
+	plaintext, err := open(dataKey, ciphertext, aad)
+	if err != nil {
+		k.logger.Error("Decrypt: failed to decrypt", zap.Error(err))
+		return nil, fmt.Errorf("Decrypt: %w", err)
+	}
+
+	return plaintext, nil
+}
+
+// seal encrypts plaintext with AES-GCM and prepends the random nonce to the result.
+func seal(key []byte, plaintext []byte, aad string) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, []byte(aad)), nil
+}
+
+func open(key []byte, ciphertext []byte, aad string) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, ErrDecrypt
+	}
+
+	nonce, data := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+
+	plaintext, err := gcm.Open(nil, nonce, data, []byte(aad))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func readKeyFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("readKeyFile: failed to open key file: %w", err)
+	}
+	defer file.Close()
+
+	var pairs []string
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		pairs = append(pairs, line)
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("readKeyFile: failed to read key file: %w", err)
+	}
+
+	return pairs, nil
+}
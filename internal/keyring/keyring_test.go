@@ -0,0 +1,131 @@
+package keyring
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var (
+	firstKey  = "k1:" + base64.StdEncoding.EncodeToString([]byte(strings.Repeat("a", dataKeySize)))
+	secondKey = "k2:" + base64.StdEncoding.EncodeToString([]byte(strings.Repeat("b", dataKeySize)))
+)
+
+func TestNew(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "keys")
+	err := os.WriteFile(keyFile, []byte("# master keys\n"+secondKey+"\n"), 0600)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		config  Config
+		enabled bool
+		wantErr error
+	}{
+		{
+			name:    "disabled",
+			config:  Config{Keys: []string{firstKey}},
+			enabled: false,
+		},
+		{
+			name:    "inline key",
+			config:  Config{Keys: []string{firstKey}, ActiveKey: "k1"},
+			enabled: true,
+		},
+		{
+			name:    "key from file",
+			config:  Config{Keys: []string{firstKey}, KeyFile: keyFile, ActiveKey: "k2"},
+			enabled: true,
+		},
+		{
+			name:    "unknown active key",
+			config:  Config{Keys: []string{firstKey}, ActiveKey: "k3"},
+			wantErr: ErrUnknownKey,
+		},
+		{
+			name:    "short key",
+			config:  Config{Keys: []string{"k1:" + base64.StdEncoding.EncodeToString([]byte("short"))}},
+			wantErr: ErrInvalidKey,
+		},
+		{
+			name:    "missing id",
+			config:  Config{Keys: []string{"somekey"}},
+			wantErr: ErrInvalidKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := New(&tt.config, zap.NewNop())
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.enabled, k.Enabled())
+		})
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	k, err := New(&Config{Keys: []string{firstKey}, ActiveKey: "k1"}, zap.NewNop())
+	require.NoError(t, err)
+
+	dataKey, wrapped, keyID, err := k.GenerateDataKey("doc1")
+	require.NoError(t, err)
+	require.Equal(t, "k1", keyID)
+
+	ciphertext, err := k.Encrypt(dataKey, []byte("secret content"), "doc1:content")
+	require.NoError(t, err)
+	require.NotContains(t, string(ciphertext), "secret content")
+
+	unwrapped, err := k.UnwrapDataKey(keyID, wrapped, "doc1")
+	require.NoError(t, err)
+
+	plaintext, err := k.Decrypt(unwrapped, ciphertext, "doc1:content")
+	require.NoError(t, err)
+	require.Equal(t, "secret content", string(plaintext))
+
+	_, err = k.Decrypt(unwrapped, ciphertext, "doc2:content")
+	require.ErrorIs(t, err, ErrDecrypt)
+
+	_, err = k.UnwrapDataKey(keyID, wrapped, "doc2")
+	require.ErrorIs(t, err, ErrDecrypt)
+
+	_, err = k.UnwrapDataKey("k2", wrapped, "doc1")
+	require.ErrorIs(t, err, ErrUnknownKey)
+}
+
+func TestRewrapDataKey(t *testing.T) {
+	oldRing, err := New(&Config{Keys: []string{firstKey}, ActiveKey: "k1"}, zap.NewNop())
+	require.NoError(t, err)
+
+	dataKey, wrapped, keyID, err := oldRing.GenerateDataKey("doc1")
+	require.NoError(t, err)
+
+	ciphertext, err := oldRing.Encrypt(dataKey, []byte("secret content"), "doc1:content")
+	require.NoError(t, err)
+
+	newRing, err := New(&Config{Keys: []string{firstKey, secondKey}, ActiveKey: "k2"}, zap.NewNop())
+	require.NoError(t, err)
+
+	rewrapped, newKeyID, err := newRing.RewrapDataKey(keyID, wrapped, "doc1")
+	require.NoError(t, err)
+	require.Equal(t, "k2", newKeyID)
+
+	onlyNewRing, err := New(&Config{Keys: []string{secondKey}, ActiveKey: "k2"}, zap.NewNop())
+	require.NoError(t, err)
+
+	unwrapped, err := onlyNewRing.UnwrapDataKey(newKeyID, rewrapped, "doc1")
+	require.NoError(t, err)
+
+	plaintext, err := onlyNewRing.Decrypt(unwrapped, ciphertext, "doc1:content")
+	require.NoError(t, err)
+	require.Equal(t, "secret content", string(plaintext))
+}
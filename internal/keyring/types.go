@@ -0,0 +1,30 @@
+package keyring
+
+import (
+	"errors"
+
+	"go.uber.org/zap"
+)
+
+const dataKeySize = 32
+
+var (
+	ErrUnknownKey  = errors.New("unknown master key")
+	ErrInvalidKey  = errors.New("invalid master key")
+	ErrNoActiveKey = errors.New("active master key is not configured")
+	ErrDecrypt     = errors.New("failed to decrypt")
+)
+
+// Config describes master keys as "id:base64key" pairs. Keys can be set inline
+// or read from a file with one pair per line. Encryption is enabled when ActiveKey is set.
+type Config struct {
+	Keys      []string `env:"ENCRYPTION_KEYS" env-separator:","`
+	KeyFile   string   `env:"ENCRYPTION_KEY_FILE"`
+	ActiveKey string   `env:"ENCRYPTION_ACTIVE_KEY"`
+}
+
+type Keyring struct {
+	keys   map[string][]byte
+	active string
+	logger *zap.Logger
+}
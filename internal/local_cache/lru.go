@@ -0,0 +1,167 @@
+package localCache
+
+import (
+	"container/list"
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// maxInvalidations is how many DeletePrefix calls SetSince can look back on.
+// A value loaded before older ones is not cached.
+const maxInvalidations = 128
+
+func New(config *Config, logger *zap.Logger) *Cache {
+	return &Cache{
+		config: config,
+		logger: logger,
+		now:    time.Now,
+		order:  list.New(),
+		items:  make(map[string]*list.Element),
+	}
+}
+
+// Enabled reports whether LOCAL_CACHE_ENTRIES allows anything to be cached.
+func (c *Cache) Enabled() bool {
+	return c.config.Entries > 0
+}
+
+// Get returns the value of key and marks it as recently used. Expired values are dropped.
+func (c *Cache) Get(key string) (any, bool) {
+	if !c.Enabled() {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	e := element.Value.(*entry)
+	if !c.now().Before(e.expiresAt) {
+		c.remove(element)
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+
+	return e.value, true
+}
+
+// Set caches value for ttl, at most LOCAL_CACHE_TTL. size is what the value counts
+// against LOCAL_CACHE_BYTES; least recently used values are evicted to make room.
+func (c *Cache) Set(key string, value any, size int, ttl time.Duration) {
+	c.set(key, value, size, ttl, nil)
+}
+
+// Epoch returns the current invalidation epoch, to be taken before a value is loaded for SetSince.
+func (c *Cache) Epoch() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.epoch
+}
+
+// SetSince caches value like Set unless a DeletePrefix matching key came after epoch:
+// the value was loaded before that invalidation and may be outdated.
+func (c *Cache) SetSince(key string, value any, size int, ttl time.Duration, epoch uint64) {
+	c.set(key, value, size, ttl, &epoch)
+}
+
+func (c *Cache) set(key string, value any, size int, ttl time.Duration, since *uint64) {
+	if !c.Enabled() || size > c.config.Bytes {
+		return
+	}
+
+	ttl = min(ttl, c.config.TTL)
+	if ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if since != nil && c.invalidatedSince(key, *since) {
+		c.logger.Debug("SetSince: dropped value loaded before an invalidation", zap.String("key", key))
+		return
+	}
+
+	if element, ok := c.items[key]; ok {
+		c.remove(element)
+	}
+
+	element := c.order.PushFront(&entry{
+		key:       key,
+		value:     value,
+		size:      size,
+		expiresAt: c.now().Add(ttl),
+	})
+
+	c.items[key] = element
+	c.size += size
+
+	for c.order.Len() > c.config.Entries || c.size > c.config.Bytes {
+		c.remove(c.order.Back())
+	}
+}
+
+// DeletePrefix drops every value whose key starts with prefix and returns how many were dropped.
+func (c *Cache) DeletePrefix(prefix string) int {
+	if !c.Enabled() {
+		return 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.epoch++
+
+	c.invalidations = append(c.invalidations, invalidation{prefix: prefix, epoch: c.epoch})
+	if len(c.invalidations) > maxInvalidations {
+		c.invalidations = slices.Delete(c.invalidations, 0, len(c.invalidations)-maxInvalidations)
+	}
+
+	var deleted int
+
+	for key, element := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			c.remove(element)
+			deleted++
+		}
+	}
+
+	c.logger.Debug("DeletePrefix: dropped local cache entries", zap.String("prefix", prefix), zap.Int("count", deleted))
+	return deleted
+}
+
+// invalidatedSince reports whether key was invalidated after epoch. When the invalidations
+// since epoch are no longer all kept, the answer is yes.
+func (c *Cache) invalidatedSince(key string, epoch uint64) bool {
+	if epoch >= c.epoch {
+		return false
+	}
+
+	if c.invalidations[0].epoch > epoch+1 {
+		return true
+	}
+
+	for _, inv := range c.invalidations {
+		if inv.epoch > epoch && strings.HasPrefix(key, inv.prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Cache) remove(element *list.Element) {
+	e := c.order.Remove(element).(*entry)
+
+	delete(c.items, e.key)
+	c.size -= e.size
+}
@@ -0,0 +1,166 @@
+package localCache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newCache(entries int, bytes int) (*Cache, *time.Time) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	c := New(&Config{Entries: entries, Bytes: bytes, TTL: 5 * time.Second}, zap.NewNop())
+	c.now = func() time.Time { return now }
+
+	return c, &now
+}
+
+func TestCache(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries int
+		bytes   int
+		run     func(c *Cache, now *time.Time)
+		present []string
+		missing []string
+	}{
+		{
+			name:    "disabled",
+			entries: 0,
+			bytes:   100,
+			run: func(c *Cache, _ *time.Time) {
+				c.Set("a", 1, 1, time.Minute)
+			},
+			missing: []string{"a"},
+		},
+		{
+			name:    "evicts least recently used entry",
+			entries: 2,
+			bytes:   100,
+			run: func(c *Cache, _ *time.Time) {
+				c.Set("a", 1, 1, time.Minute)
+				c.Set("b", 2, 1, time.Minute)
+				c.Get("a")
+				c.Set("c", 3, 1, time.Minute)
+			},
+			present: []string{"a", "c"},
+			missing: []string{"b"},
+		},
+		{
+			name:    "evicts by size",
+			entries: 10,
+			bytes:   10,
+			run: func(c *Cache, _ *time.Time) {
+				c.Set("a", 1, 4, time.Minute)
+				c.Set("b", 2, 4, time.Minute)
+				c.Set("c", 3, 4, time.Minute)
+			},
+			present: []string{"b", "c"},
+			missing: []string{"a"},
+		},
+		{
+			name:    "skips values larger than the cache",
+			entries: 10,
+			bytes:   10,
+			run: func(c *Cache, _ *time.Time) {
+				c.Set("a", 1, 4, time.Minute)
+				c.Set("b", 2, 11, time.Minute)
+			},
+			present: []string{"a"},
+			missing: []string{"b"},
+		},
+		{
+			name:    "expires after the shorter ttl",
+			entries: 10,
+			bytes:   100,
+			run: func(c *Cache, now *time.Time) {
+				c.Set("a", 1, 1, time.Minute)
+				c.Set("b", 2, 1, time.Second)
+				*now = now.Add(2 * time.Second)
+				c.Set("c", 3, 1, time.Minute)
+				*now = now.Add(4 * time.Second)
+			},
+			present: []string{"c"},
+			missing: []string{"a", "b"},
+		},
+		{
+			name:    "deletes by prefix",
+			entries: 10,
+			bytes:   100,
+			run: func(c *Cache, _ *time.Time) {
+				c.Set("docs:alice:1", 1, 1, time.Minute)
+				c.Set("docs:alice:2", 2, 1, time.Minute)
+				c.Set("docs:bob:1", 3, 1, time.Minute)
+				c.DeletePrefix("docs:alice:")
+			},
+			present: []string{"docs:bob:1"},
+			missing: []string{"docs:alice:1", "docs:alice:2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, now := newCache(tt.entries, tt.bytes)
+
+			tt.run(c, now)
+
+			for _, key := range tt.present {
+				_, ok := c.Get(key)
+				assert.True(t, ok, key)
+			}
+
+			for _, key := range tt.missing {
+				_, ok := c.Get(key)
+				assert.False(t, ok, key)
+			}
+		})
+	}
+}
+
+func TestCacheSize(t *testing.T) {
+	c, _ := newCache(10, 100)
+
+	c.Set("a", 1, 30, time.Minute)
+	c.Set("a", 2, 20, time.Minute)
+	c.Set("b", 3, 10, time.Minute)
+
+	assert.Equal(t, 30, c.size)
+
+	value, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 2, value)
+
+	assert.Equal(t, 1, c.DeletePrefix("b"))
+	assert.Equal(t, 20, c.size)
+}
+
+func TestCacheSetSince(t *testing.T) {
+	c, _ := newCache(10, 100)
+
+	epoch := c.Epoch()
+	c.DeletePrefix("docs:alice:")
+
+	c.SetSince("docs:alice:1", 1, 1, time.Minute, epoch)
+	c.SetSince("docs:bob:1", 2, 1, time.Minute, epoch)
+	c.SetSince("docs:alice:2", 3, 1, time.Minute, c.Epoch())
+
+	_, ok := c.Get("docs:alice:1")
+	assert.False(t, ok, "loaded before the invalidation")
+
+	_, ok = c.Get("docs:bob:1")
+	assert.True(t, ok, "another prefix was invalidated")
+
+	_, ok = c.Get("docs:alice:2")
+	assert.True(t, ok, "loaded after the invalidation")
+
+	for range maxInvalidations {
+		c.DeletePrefix("content:")
+	}
+
+	c.SetSince("docs:bob:2", 4, 1, time.Minute, epoch)
+
+	_, ok = c.Get("docs:bob:2")
+	assert.False(t, ok, "the invalidations since the load are no longer all kept")
+}
@@ -0,0 +1,59 @@
+package localCache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type Config struct {
+	// Entries bounds the number of cached values, 0 disables the local cache.
+	Entries int           `env:"LOCAL_CACHE_ENTRIES" env-default:"0"`
+	Bytes   int           `env:"LOCAL_CACHE_BYTES" env-default:"67108864"`
+	TTL     time.Duration `env:"LOCAL_CACHE_TTL" env-default:"5s"`
+}
+
+type entry struct {
+	key       string
+	value     any
+	size      int
+	expiresAt time.Time
+}
+
+// invalidation is a DeletePrefix call, kept for SetSince.
+type invalidation struct {
+	prefix string
+	epoch  uint64
+}
+
+// Cache is an in-process LRU cache bounded by the number of entries and their total size.
+type Cache struct {
+	config *Config
+	logger *zap.Logger
+	now    func() time.Time
+
+	mu    sync.Mutex
+	order *list.List
+	items map[string]*list.Element
+	size  int
+
+	// epoch counts DeletePrefix calls, invalidations holds the latest of them.
+	epoch         uint64
+	invalidations []invalidation
+}
+
+type LocalCache interface {
+	Enabled() bool
+	Get(key string) (any, bool)
+	Set(key string, value any, size int, ttl time.Duration)
+	Epoch() uint64
+	SetSince(key string, value any, size int, ttl time.Duration, epoch uint64)
+	DeletePrefix(prefix string) int
+}
+
+type MockLocalCache struct {
+	mock.Mock
+}
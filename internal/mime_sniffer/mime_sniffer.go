@@ -0,0 +1,125 @@
+package mimeSniffer
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+	"go.uber.org/zap"
+)
+
+func New(config *Config, logger *zap.Logger) *Sniffer {
+	return &Sniffer{
+		config: config,
+		logger: logger,
+	}
+}
+
+// Check detects the real type of content and returns the mime that should be stored.
+// An empty declared mime is filled in with the detected one. A mismatch is rejected
+// or, under the flag policy, let through with the detected mime as the second result.
+func (s *Sniffer) Check(declared string, content []byte) (string, string, error) {
+	detected := mimetype.Detect(content)
+	detectedMime := normalize(detected.String())
+
+	result := normalize(declared)
+	mismatch := ""
+
+	switch {
+	case result == "":
+		result = detectedMime
+
+	case !matches(detected, result):
+		if s.config.Policy == PolicyReject {
+			s.logger.Warn("Check: mime mismatch",
+				zap.String("declared", result),
+				zap.String("detected", detectedMime),
+			)
+			return "", "", fmt.Errorf("Check: %w: declared %s, detected %s", ErrMimeMismatch, result, detectedMime)
+		}
+
+		s.logger.Warn("Check: mime mismatch flagged",
+			zap.String("declared", result),
+			zap.String("detected", detectedMime),
+		)
+
+		mismatch = detectedMime
+	}
+
+	for _, m := range []string{detectedMime, result} {
+		if !s.isAllowed(m) {
+			s.logger.Warn("Check: mime denied", zap.String("mime", m))
+			return "", "", fmt.Errorf("Check: %w: %s", ErrMimeDenied, m)
+		}
+	}
+
+	return result, mismatch, nil
+}
+
+func (s *Sniffer) isAllowed(m string) bool {
+	if MatchAny(s.config.Deny, m) {
+		return false
+	}
+
+	if len(s.config.Allow) == 0 {
+		return true
+	}
+
+	return MatchAny(s.config.Allow, m)
+}
+
+// MatchAny reports whether m matches one of patterns. A pattern is either
+// an exact type, a "type/*" wildcard or "*/*".
+func MatchAny(patterns []string, m string) bool {
+	m = normalize(m)
+
+	for _, pattern := range patterns {
+		if matchPattern(pattern, m) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matches reports whether declared describes detected or one of its parents,
+// so that e.g. JSON content declared as text/plain is accepted.
+func matches(detected *mimetype.MIME, declared string) bool {
+	for m := detected; m != nil; m = m.Parent() {
+		if m.Is(declared) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchPattern(pattern string, m string) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+
+	switch {
+	case pattern == "":
+		return false
+	case pattern == "*" || pattern == "*/*":
+		return true
+	case strings.HasSuffix(pattern, "/*"):
+		return strings.HasPrefix(m, strings.TrimSuffix(pattern, "*"))
+	default:
+		return pattern == m
+	}
+}
+
+func normalize(m string) string {
+	m = strings.TrimSpace(m)
+	if m == "" {
+		return ""
+	}
+
+	mediaType, _, err := mime.ParseMediaType(m)
+	if err != nil {
+		return strings.ToLower(m)
+	}
+
+	return mediaType
+}
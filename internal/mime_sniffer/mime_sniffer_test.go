@@ -0,0 +1,137 @@
+package mimeSniffer
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var pdfContent = []byte("%PDF-1.7\n1 0 obj\n<< /Type /Catalog >>\nendobj\n")
+
+func docxContent(t *testing.T) []byte {
+	var buf bytes.Buffer
+
+	zw := zip.NewWriter(&buf)
+
+	for _, name := range []string{"[Content_Types].xml", "word/document.xml"} {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+
+		_, err = w.Write([]byte("<xml/>"))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, zw.Close())
+
+	return buf.Bytes()
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		declared string
+		content  []byte
+		want     string
+		mismatch string
+		wantErr  error
+	}{
+		{
+			name:     "fill missing mime",
+			config:   Config{Policy: PolicyReject},
+			declared: "",
+			content:  pdfContent,
+			want:     "application/pdf",
+		},
+		{
+			name:     "matching mime with params",
+			config:   Config{Policy: PolicyReject},
+			declared: "Application/PDF; charset=binary",
+			content:  pdfContent,
+			want:     "application/pdf",
+		},
+		{
+			name:     "parent mime accepted",
+			config:   Config{Policy: PolicyReject},
+			declared: "text/plain",
+			content:  []byte(`{"a": 1}`),
+			want:     "text/plain",
+		},
+		{
+			name:     "office format detected",
+			config:   Config{Policy: PolicyReject},
+			declared: "",
+			content:  docxContent(t),
+			want:     "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		},
+		{
+			name:     "office format declared as zip",
+			config:   Config{Policy: PolicyReject},
+			declared: "application/zip",
+			content:  docxContent(t),
+			want:     "application/zip",
+		},
+		{
+			name:     "mismatch rejected",
+			config:   Config{Policy: PolicyReject},
+			declared: "application/json",
+			content:  pdfContent,
+			wantErr:  ErrMimeMismatch,
+		},
+		{
+			name:     "mismatch flagged",
+			config:   Config{Policy: PolicyFlag},
+			declared: "application/json",
+			content:  pdfContent,
+			want:     "application/json",
+			mismatch: "application/pdf",
+		},
+		{
+			name:     "denied mime",
+			config:   Config{Policy: PolicyFlag, Deny: []string{"application/pdf"}},
+			declared: "",
+			content:  pdfContent,
+			wantErr:  ErrMimeDenied,
+		},
+		{
+			name:     "denied detected mime behind declared one",
+			config:   Config{Policy: PolicyFlag, Deny: []string{"application/pdf"}},
+			declared: "text/plain",
+			content:  pdfContent,
+			wantErr:  ErrMimeDenied,
+		},
+		{
+			name:     "allow list with wildcard",
+			config:   Config{Policy: PolicyFlag, Allow: []string{"text/*"}},
+			declared: "",
+			content:  []byte("hello"),
+			want:     "text/plain",
+		},
+		{
+			name:     "not in allow list",
+			config:   Config{Policy: PolicyFlag, Allow: []string{"image/*"}},
+			declared: "",
+			content:  []byte("hello"),
+			wantErr:  ErrMimeDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(&tt.config, zap.NewNop())
+
+			got, mismatch, err := s.Check(tt.declared, tt.content)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+			require.Equal(t, tt.mismatch, mismatch)
+		})
+	}
+}
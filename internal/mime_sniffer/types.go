@@ -0,0 +1,37 @@
+package mimeSniffer
+
+import (
+	"errors"
+
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+const (
+	PolicyReject = "reject"
+	PolicyFlag   = "flag"
+)
+
+var (
+	ErrMimeMismatch = errors.New("declared mime does not match content")
+	ErrMimeDenied   = errors.New("mime type is not allowed")
+)
+
+type Config struct {
+	Policy string   `env:"MIME_POLICY" env-default:"flag"`
+	Allow  []string `env:"MIME_ALLOW" env-separator:","`
+	Deny   []string `env:"MIME_DENY" env-separator:","`
+}
+
+type Sniffer struct {
+	config *Config
+	logger *zap.Logger
+}
+
+type MimeSniffer interface {
+	Check(declared string, content []byte) (string, string, error)
+}
+
+type MockMimeSniffer struct {
+	mock.Mock
+}
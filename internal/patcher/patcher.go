@@ -0,0 +1,69 @@
+package patcher
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+const (
+	ContentTypeJSONPatch  = "application/json-patch+json"
+	ContentTypeMergePatch = "application/merge-patch+json"
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported patch content type")
+	ErrInvalidPatch    = errors.New("invalid patch document")
+	ErrTestFailed      = errors.New("patch test operation failed")
+	ErrNotApplicable   = errors.New("patch cannot be applied")
+)
+
+// Apply applies a JSON Patch (RFC 6902) or a JSON Merge Patch (RFC 7396) to doc,
+// depending on contentType. An empty doc is treated as an empty object.
+func Apply(contentType string, doc []byte, patch []byte) ([]byte, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("Apply: %w: %s", ErrUnsupportedType, contentType)
+	}
+
+	if len(doc) == 0 {
+		doc = []byte("{}")
+	}
+
+	switch mediaType {
+	case ContentTypeJSONPatch:
+		decoded, err := jsonpatch.DecodePatch(patch)
+		if err != nil {
+			return nil, fmt.Errorf("Apply: %w: %v", ErrInvalidPatch, err)
+		}
+
+		result, err := decoded.Apply(doc)
+		if err != nil {
+			if errors.Is(err, jsonpatch.ErrTestFailed) {
+				return nil, fmt.Errorf("Apply: %w: %v", ErrTestFailed, err)
+			}
+
+			return nil, fmt.Errorf("Apply: %w: %v", ErrNotApplicable, err)
+		}
+
+		return result, nil
+
+	case ContentTypeMergePatch:
+		if !json.Valid(patch) {
+			return nil, fmt.Errorf("Apply: %w: patch is not valid json", ErrInvalidPatch)
+		}
+
+		result, err := jsonpatch.MergePatch(doc, patch)
+		if err != nil {
+			return nil, fmt.Errorf("Apply: %w: %v", ErrNotApplicable, err)
+		}
+
+		return result, nil
+
+	default:
+		return nil, fmt.Errorf("Apply: %w: %s", ErrUnsupportedType, mediaType)
+	}
+}
@@ -0,0 +1,97 @@
+package patcher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApply(t *testing.T) {
+	doc := []byte(`{"title":"invoice","total":100,"items":["a","b"],"draft":true}`)
+
+	tests := []struct {
+		name        string
+		contentType string
+		doc         []byte
+		patch       string
+		want        string
+		wantErr     error
+	}{
+		{
+			name:        "json patch",
+			contentType: ContentTypeJSONPatch,
+			doc:         doc,
+			patch:       `[{"op":"replace","path":"/total","value":150},{"op":"add","path":"/items/-","value":"c"},{"op":"remove","path":"/draft"}]`,
+			want:        `{"title":"invoice","total":150,"items":["a","b","c"]}`,
+		},
+		{
+			name:        "json patch with charset",
+			contentType: ContentTypeJSONPatch + "; charset=utf-8",
+			doc:         doc,
+			patch:       `[{"op":"test","path":"/total","value":100},{"op":"replace","path":"/title","value":"bill"}]`,
+			want:        `{"title":"bill","total":100,"items":["a","b"],"draft":true}`,
+		},
+		{
+			name:        "json patch on empty document",
+			contentType: ContentTypeJSONPatch,
+			doc:         nil,
+			patch:       `[{"op":"add","path":"/a","value":1}]`,
+			want:        `{"a":1}`,
+		},
+		{
+			name:        "failed test operation",
+			contentType: ContentTypeJSONPatch,
+			doc:         doc,
+			patch:       `[{"op":"test","path":"/total","value":1}]`,
+			wantErr:     ErrTestFailed,
+		},
+		{
+			name:        "missing path",
+			contentType: ContentTypeJSONPatch,
+			doc:         doc,
+			patch:       `[{"op":"replace","path":"/missing/field","value":1}]`,
+			wantErr:     ErrNotApplicable,
+		},
+		{
+			name:        "invalid json patch",
+			contentType: ContentTypeJSONPatch,
+			doc:         doc,
+			patch:       `{"op":"add"}`,
+			wantErr:     ErrInvalidPatch,
+		},
+		{
+			name:        "merge patch",
+			contentType: ContentTypeMergePatch,
+			doc:         doc,
+			patch:       `{"total":200,"draft":null,"meta":{"by":"apollo"}}`,
+			want:        `{"title":"invoice","total":200,"items":["a","b"],"meta":{"by":"apollo"}}`,
+		},
+		{
+			name:        "invalid merge patch",
+			contentType: ContentTypeMergePatch,
+			doc:         doc,
+			patch:       `{"total":`,
+			wantErr:     ErrInvalidPatch,
+		},
+		{
+			name:        "unsupported content type",
+			contentType: "application/json",
+			doc:         doc,
+			patch:       `{}`,
+			wantErr:     ErrUnsupportedType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply(tt.contentType, tt.doc, []byte(tt.patch))
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
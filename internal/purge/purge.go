@@ -0,0 +1,84 @@
+// Package purge runs the batch loops that remove documents for good: the trash purger
+// and the expiry sweeper differ only in which documents they ask the store for.
+package purge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"astral/internal/documents"
+)
+
+// ErrInvalidBatch is returned for a batch size below 1, which would never end a run of Batches.
+var ErrInvalidBatch = errors.New("batch size must be positive")
+
+// Cache drops cached copies of purged documents.
+type Cache interface {
+	InvalidateDocument(ctx context.Context, id string) error
+	InvalidateDocs(ctx context.Context, login string) error
+}
+
+// Func removes up to limit documents for good and returns their ids and owners.
+type Func func(ctx context.Context, limit int) ([]documents.Document, error)
+
+// Every calls once every interval until ctx is done, the first time right away.
+func Every(ctx context.Context, interval time.Duration, once func(ctx context.Context) (int, error), logger *zap.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := once(ctx); err != nil {
+			logger.Error("Every: failed to purge documents", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Batches calls purge with batch until a call returns fewer documents and drops the cache
+// entries of every purged document and the listings of their owners. It returns the number
+// of purged documents.
+func Batches(ctx context.Context, purge Func, batch int, cache Cache, logger *zap.Logger) (int, error) {
+	if batch <= 0 {
+		return 0, fmt.Errorf("%w: %d", ErrInvalidBatch, batch)
+	}
+
+	total := 0
+
+	for {
+		purged, err := purge(ctx, batch)
+		if err != nil {
+			return total, err
+		}
+
+		owners := make(map[string]struct{})
+
+		for _, document := range purged {
+			if err = cache.InvalidateDocument(ctx, document.Id); err != nil {
+				logger.Warn("Batches: failed to invalidate document", zap.String("id", document.Id), zap.Error(err))
+			}
+
+			owners[document.Login] = struct{}{}
+		}
+
+		for login := range owners {
+			if err = cache.InvalidateDocs(ctx, login); err != nil {
+				logger.Warn("Batches: failed to invalidate doc cache", zap.String("login", login), zap.Error(err))
+			}
+		}
+
+		total += len(purged)
+
+		if len(purged) < batch {
+			return total, nil
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package purge
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"astral/internal/documents"
+)
+
+type fakeCache struct {
+	docs   []string
+	logins []string
+}
+
+func (c *fakeCache) InvalidateDocument(_ context.Context, id string) error {
+	c.docs = append(c.docs, id)
+	return nil
+}
+
+func (c *fakeCache) InvalidateDocs(_ context.Context, login string) error {
+	c.logins = append(c.logins, login)
+	return nil
+}
+
+var errConnection = errors.New("connection refused")
+
+func TestBatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		pending  []documents.Document
+		batch    int
+		storeErr error
+		err      error
+		count    int
+		calls    int
+		docs     []string
+		logins   []string
+	}{
+		{
+			name:  "nothing to purge",
+			batch: 2,
+			calls: 1,
+		},
+		{
+			name: "several batches",
+			pending: []documents.Document{
+				{Id: "a", Login: "alice"},
+				{Id: "b", Login: "alice"},
+				{Id: "c", Login: "bob"},
+			},
+			batch:  2,
+			count:  3,
+			calls:  2,
+			docs:   []string{"a", "b", "c"},
+			logins: []string{"alice", "bob"},
+		},
+		{
+			name:  "zero batch",
+			batch: 0,
+			err:   ErrInvalidBatch,
+		},
+		{
+			name:  "negative batch",
+			batch: -1,
+			err:   ErrInvalidBatch,
+		},
+		{
+			name:     "store error",
+			batch:    2,
+			storeErr: errConnection,
+			calls:    1,
+			err:      errConnection,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pending := tt.pending
+			cache := &fakeCache{}
+			calls := 0
+
+			purge := func(_ context.Context, limit int) ([]documents.Document, error) {
+				calls++
+
+				if tt.storeErr != nil {
+					return nil, tt.storeErr
+				}
+
+				n := min(limit, len(pending))
+				purged := pending[:n]
+				pending = pending[n:]
+
+				return purged, nil
+			}
+
+			count, err := Batches(context.Background(), purge, tt.batch, cache, zap.NewNop())
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+				require.Equal(t, tt.calls, calls)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.count, count)
+			require.Equal(t, tt.calls, calls)
+			require.Equal(t, tt.docs, cache.docs)
+			require.Equal(t, tt.logins, cache.logins)
+			require.Empty(t, pending)
+		})
+	}
+}
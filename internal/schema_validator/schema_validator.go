@@ -0,0 +1,123 @@
+package schemaValidator
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"go.uber.org/zap"
+)
+
+const schemaURL = "astral://schema.json"
+
+func New(config *Config, logger *zap.Logger) *Validator {
+	return &Validator{
+		config: config,
+		logger: logger,
+		cache:  make(map[string]*jsonschema.Schema),
+	}
+}
+
+// Compile checks that schema is a valid JSON Schema. Schemas without
+// $schema are treated as draft 2020-12.
+func (v *Validator) Compile(schema []byte) error {
+	_, err := v.compile(schema)
+	return err
+}
+
+// Validate checks document against schema and returns every violation found.
+// A nil result means the document is valid.
+func (v *Validator) Validate(schema []byte, document []byte) ([]Violation, error) {
+	compiled, err := v.compile(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(document))
+	if err != nil {
+		return nil, fmt.Errorf("Validate: %w: %v", ErrInvalidDocument, err)
+	}
+
+	err = compiled.Validate(instance)
+	if err == nil {
+		return nil, nil
+	}
+
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		v.logger.Error("Validate: failed to validate document", zap.Error(err))
+		return nil, fmt.Errorf("Validate: failed to validate document: %w", err)
+	}
+
+	var violations []Violation
+
+	for _, unit := range validationErr.BasicOutput().Errors {
+		if unit.Error == nil {
+			continue
+		}
+
+		violations = append(violations, Violation{
+			Path:    unit.InstanceLocation,
+			Message: unit.Error.String(),
+		})
+	}
+
+	if len(violations) == 0 {
+		violations = append(violations, Violation{Message: validationErr.Error()})
+	}
+
+	return violations, nil
+}
+
+// compile returns the compiled form of schema, reusing earlier compilations of the same schema.
+func (v *Validator) compile(schema []byte) (*jsonschema.Schema, error) {
+	sum := sha256.Sum256(schema)
+	key := hex.EncodeToString(sum[:])
+
+	v.mu.Lock()
+	compiled, ok := v.cache[key]
+	v.mu.Unlock()
+
+	if ok {
+		return compiled, nil
+	}
+
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(schema))
+	if err != nil {
+		return nil, fmt.Errorf("compile: %w: %v", ErrInvalidSchema, err)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.DefaultDraft(jsonschema.Draft2020)
+	compiler.UseLoader(noLoader{})
+
+	err = compiler.AddResource(schemaURL, doc)
+	if err != nil {
+		return nil, fmt.Errorf("compile: %w: %v", ErrInvalidSchema, err)
+	}
+
+	compiled, err = compiler.Compile(schemaURL)
+	if err != nil {
+		return nil, fmt.Errorf("compile: %w: %v", ErrInvalidSchema, err)
+	}
+
+	v.mu.Lock()
+	if len(v.cache) >= v.config.CacheSize {
+		clear(v.cache)
+	}
+	v.cache[key] = compiled
+	v.mu.Unlock()
+
+	return compiled, nil
+}
+
+// noLoader refuses to resolve external references, so that user schemas
+// can not make the server read local files or fetch urls.
+type noLoader struct{}
+
+func (noLoader) Load(url string) (any, error) {
+	return nil, fmt.Errorf("external reference %s is not allowed", url)
+}
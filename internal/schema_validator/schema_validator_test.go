@@ -0,0 +1,121 @@
+package schemaValidator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const invoiceSchema = `{
+	"type": "object",
+	"required": ["number", "amount"],
+	"properties": {
+		"number": {"type": "string"},
+		"amount": {"type": "number", "minimum": 0},
+		"lines": {
+			"type": "array",
+			"items": {"type": "object", "required": ["sku"]}
+		}
+	}
+}`
+
+func newValidator() *Validator {
+	return New(&Config{CacheSize: 2}, zap.NewNop())
+}
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		wantErr bool
+	}{
+		{
+			name:   "valid schema",
+			schema: invoiceSchema,
+		},
+		{
+			name:   "older draft",
+			schema: `{"$schema": "http://json-schema.org/draft-07/schema#", "type": "string"}`,
+		},
+		{
+			name:    "not json",
+			schema:  `{"type":`,
+			wantErr: true,
+		},
+		{
+			name:    "wrong keyword type",
+			schema:  `{"type": 42}`,
+			wantErr: true,
+		},
+		{
+			name:    "external reference",
+			schema:  `{"$ref": "file:///etc/passwd"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newValidator().Compile([]byte(tt.schema))
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidSchema)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		paths    []string
+	}{
+		{
+			name:     "valid document",
+			document: `{"number": "A-1", "amount": 10, "lines": [{"sku": "x"}]}`,
+		},
+		{
+			name:     "missing property",
+			document: `{"number": "A-1"}`,
+			paths:    []string{""},
+		},
+		{
+			name:     "nested violations",
+			document: `{"number": 1, "amount": -5, "lines": [{"sku": "x"}, {}]}`,
+			paths:    []string{"/number", "/amount", "/lines/1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations, err := newValidator().Validate([]byte(invoiceSchema), []byte(tt.document))
+			require.NoError(t, err)
+
+			var paths []string
+			for _, violation := range violations {
+				require.NotEmpty(t, violation.Message)
+				paths = append(paths, violation.Path)
+			}
+
+			require.ElementsMatch(t, tt.paths, paths)
+		})
+	}
+}
+
+func TestValidateInvalidDocument(t *testing.T) {
+	_, err := newValidator().Validate([]byte(invoiceSchema), []byte(`{"number":`))
+	require.ErrorIs(t, err, ErrInvalidDocument)
+}
+
+func TestCompileCache(t *testing.T) {
+	v := newValidator()
+
+	for _, schema := range []string{`{"type": "string"}`, `{"type": "number"}`, `{"type": "object"}`} {
+		require.NoError(t, v.Compile([]byte(schema)))
+		require.LessOrEqual(t, len(v.cache), v.config.CacheSize)
+	}
+}
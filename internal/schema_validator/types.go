@@ -0,0 +1,43 @@
+package schemaValidator
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrInvalidSchema   = errors.New("invalid json schema")
+	ErrInvalidDocument = errors.New("invalid json document")
+)
+
+type Config struct {
+	CacheSize int `env:"SCHEMA_CACHE_SIZE" env-default:"256"`
+}
+
+// Violation is a single place where a document does not match its schema.
+// Path is a JSON pointer into the document, empty for the document root.
+type Violation struct {
+	Path    string
+	Message string
+}
+
+type Validator struct {
+	config *Config
+	logger *zap.Logger
+
+	mu    sync.Mutex
+	cache map[string]*jsonschema.Schema
+}
+
+type SchemaValidator interface {
+	Compile(schema []byte) error
+	Validate(schema []byte, document []byte) ([]Violation, error)
+}
+
+type MockSchemaValidator struct {
+	mock.Mock
+}
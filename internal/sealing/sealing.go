@@ -0,0 +1,107 @@
+// Package sealing turns document content and JSON into the form the storage backends keep
+// and back, encrypting them with the keyring when encryption is enabled.
+package sealing
+
+import (
+	"fmt"
+
+	"astral/internal/documents"
+	"astral/internal/keyring"
+)
+
+// Content is the form in which document content and JSON are stored.
+// When encryption is enabled, JSON moves into SealedJSON and the json column stays empty.
+// Search text is only kept for documents that are not encrypted, so that plain text
+// of encrypted documents never reaches the database.
+type Content struct {
+	Content    []byte
+	JSON       []byte
+	SealedJSON []byte
+	DataKey    []byte
+	KeyID      *string
+	Text       *string
+}
+
+// Seal returns the stored form of the content and JSON of document.
+func Seal(kr *keyring.Keyring, document *documents.Document) (*Content, error) {
+	if !kr.Enabled() {
+		sealed := &Content{
+			Content: document.Content,
+			JSON:    document.JSON,
+		}
+
+		if document.Text != "" {
+			sealed.Text = &document.Text
+		}
+
+		return sealed, nil
+	}
+
+	dataKey, wrapped, keyID, err := kr.GenerateDataKey(document.Id)
+	if err != nil {
+		return nil, fmt.Errorf("Seal: %w", err)
+	}
+
+	sealed := &Content{
+		DataKey: wrapped,
+		KeyID:   &keyID,
+	}
+
+	if document.Content != nil {
+		sealed.Content, err = kr.Encrypt(dataKey, document.Content, contentAAD(document.Id))
+		if err != nil {
+			return nil, fmt.Errorf("Seal: %w", err)
+		}
+	}
+
+	if document.JSON != nil {
+		sealed.SealedJSON, err = kr.Encrypt(dataKey, document.JSON, jsonAAD(document.Id))
+		if err != nil {
+			return nil, fmt.Errorf("Seal: %w", err)
+		}
+	}
+
+	return sealed, nil
+}
+
+// Open fills document content and JSON from their stored form.
+func Open(kr *keyring.Keyring, document *documents.Document, sealed *Content) error {
+	document.Sealed = sealed.KeyID != nil
+
+	if sealed.KeyID == nil {
+		document.Content = sealed.Content
+		document.JSON = sealed.JSON
+		return nil
+	}
+
+	dataKey, err := kr.UnwrapDataKey(*sealed.KeyID, sealed.DataKey, document.Id)
+	if err != nil {
+		return fmt.Errorf("Open: %w", err)
+	}
+
+	document.Content, document.JSON = nil, nil
+
+	if sealed.Content != nil {
+		document.Content, err = kr.Decrypt(dataKey, sealed.Content, contentAAD(document.Id))
+		if err != nil {
+			return fmt.Errorf("Open: %w", err)
+		}
+	}
+
+	if sealed.SealedJSON != nil {
+		document.JSON, err = kr.Decrypt(dataKey, sealed.SealedJSON, jsonAAD(document.Id))
+		if err != nil {
+			return fmt.Errorf("Open: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func contentAAD(id string) string {
+	return id + ":content"
+}
+
+func jsonAAD(id string) string {
+	return id + ":json"
+}
@@ -0,0 +1,67 @@
+package sealing
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"astral/internal/documents"
+	"astral/internal/keyring"
+)
+
+func TestSealOpen(t *testing.T) {
+	key := "k1:" + base64.StdEncoding.EncodeToString([]byte(strings.Repeat("a", 32)))
+
+	kr, err := keyring.New(&keyring.Config{Keys: []string{key}, ActiveKey: "k1"}, zap.NewNop())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		kr     *keyring.Keyring
+		sealed bool
+	}{
+		{name: "encryption disabled", kr: nil},
+		{name: "encryption enabled", kr: kr, sealed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document := &documents.Document{
+				Id:      "doc",
+				Content: []byte("hello"),
+				JSON:    []byte(`{"a": 1}`),
+				Text:    "hello",
+			}
+
+			stored, err := Seal(tt.kr, document)
+			require.NoError(t, err)
+
+			if tt.sealed {
+				assert.NotEqual(t, document.Content, stored.Content)
+				assert.Nil(t, stored.JSON, "encrypted JSON is only kept sealed")
+				assert.Nil(t, stored.Text, "plain text of encrypted documents is not kept")
+			} else {
+				assert.Equal(t, document.Content, stored.Content)
+				assert.Equal(t, document.JSON, stored.JSON)
+				require.NotNil(t, stored.Text)
+				assert.Equal(t, "hello", *stored.Text)
+			}
+
+			opened := &documents.Document{Id: document.Id}
+
+			require.NoError(t, Open(tt.kr, opened, stored))
+			assert.Equal(t, tt.sealed, opened.Sealed)
+			assert.Equal(t, document.Content, opened.Content)
+			assert.Equal(t, document.JSON, opened.JSON)
+
+			other := &documents.Document{Id: "other"}
+			if tt.sealed {
+				assert.Error(t, Open(tt.kr, other, stored), "sealed content is bound to its document")
+			}
+		})
+	}
+}
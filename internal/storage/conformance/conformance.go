@@ -0,0 +1,758 @@
+// Package conformance checks that a storage backend keeps the contract of
+// postgresClient.PostgresClient: the same results and the same errors as Postgres.
+// Backends run it from their own tests with Run.
+package conformance
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"astral/internal/documents"
+	"astral/internal/json_filter"
+	"astral/internal/storage/postgres_client"
+)
+
+// Run runs the suite, newClient returns a client with default settings for one test.
+// Tests may share a database: they only create uniquely named users, documents and
+// rules, and never assume a listing that is not limited to their own users is empty.
+func Run(t *testing.T, newClient func(t *testing.T) postgresClient.PostgresClient) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, c postgresClient.PostgresClient)
+	}{
+		{name: "users", run: testUsers},
+		{name: "documents", run: testDocuments},
+		{name: "listing", run: testListing},
+		{name: "search", run: testSearch},
+		{name: "versions", run: testVersions},
+		{name: "labels", run: testLabels},
+		{name: "trash", run: testTrash},
+		{name: "expiry", run: testExpiry},
+		{name: "folders", run: testFolders},
+		{name: "holds", run: testHolds},
+		{name: "retention rules", run: testRetentionRules},
+		{name: "quotas", run: testQuotas},
+		{name: "schemas", run: testSchemas},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newClient(t)
+			t.Cleanup(c.Close)
+
+			tt.run(t, c)
+		})
+	}
+}
+
+// newUser saves a user with a unique login.
+func newUser(t *testing.T, c postgresClient.PostgresClient) string {
+	login := "user-" + uuid.NewString()[:8]
+
+	require.NoError(t, c.SaveUser(context.Background(), login, "hash-"+login))
+
+	return login
+}
+
+// newDocument returns a file document of login created age ago. Times are kept
+// to microseconds, the precision every backend stores.
+func newDocument(login string, content string, age time.Duration) *documents.Document {
+	return &documents.Document{
+		Id:        uuid.NewString(),
+		Login:     login,
+		Name:      "file.txt",
+		Mime:      "text/plain",
+		File:      true,
+		Content:   []byte(content),
+		Size:      int64(len(content)),
+		CreatedAt: time.Now().Add(-age).UTC().Truncate(time.Microsecond),
+		Tags:      []string{},
+		Metadata:  map[string]string{},
+	}
+}
+
+func save(t *testing.T, c postgresClient.PostgresClient, document *documents.Document) *documents.Document {
+	require.NoError(t, c.SaveDocument(context.Background(), document))
+
+	return document
+}
+
+func ids[T any](items []T, id func(item T) string) []string {
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		result = append(result, id(item))
+	}
+
+	return result
+}
+
+func documentId(document documents.Document) string {
+	return document.Id
+}
+
+func testUsers(t *testing.T, c postgresClient.PostgresClient) {
+	ctx := context.Background()
+	login := newUser(t, c)
+
+	hash, err := c.GetPasswordHash(ctx, login)
+	require.NoError(t, err)
+	assert.Equal(t, "hash-"+login, hash)
+
+	assert.ErrorIs(t, c.SaveUser(ctx, login, "other"), postgresClient.ErrDuplicateLogin)
+
+	_, err = c.GetPasswordHash(ctx, "unknown-"+uuid.NewString())
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func testDocuments(t *testing.T, c postgresClient.PostgresClient) {
+	ctx := context.Background()
+	alice, bob := newUser(t, c), newUser(t, c)
+
+	file := newDocument(alice, "hello", time.Minute)
+	file.Grant = []string{bob}
+	file.Tags = []string{"a", "b"}
+	file.Metadata = map[string]string{"k": "v"}
+	save(t, c, file)
+
+	got, err := c.GetDocument(ctx, file.Id)
+	require.NoError(t, err)
+	assert.Equal(t, alice, got.Login)
+	assert.Equal(t, "file.txt", got.Name)
+	assert.Equal(t, "text/plain", got.Mime)
+	assert.True(t, got.File)
+	assert.Equal(t, []byte("hello"), got.Content)
+	assert.Equal(t, 1, got.Version)
+	assert.True(t, file.CreatedAt.Equal(got.CreatedAt))
+	assert.True(t, file.CreatedAt.Equal(got.UpdatedAt))
+	assert.Equal(t, []string{bob}, got.Grant)
+	assert.Equal(t, []string{"a", "b"}, got.Tags)
+	assert.Equal(t, map[string]string{"k": "v"}, got.Metadata)
+	assert.Nil(t, got.ExpiresAt)
+
+	info, err := c.GetDocumentInfo(ctx, file.Id)
+	require.NoError(t, err)
+	assert.Empty(t, info.Content)
+	assert.Equal(t, []string{bob}, info.Grant)
+
+	forWrite, err := c.GetDocumentForWrite(ctx, file.Id)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("hello"), forWrite.Content)
+	assert.Equal(t, []string{bob}, forWrite.Grant)
+
+	note := newDocument(alice, "", 0)
+	note.File = false
+	note.Content = nil
+	note.Size = 0
+	note.JSON = []byte(`{"kind": "note", "lines": [1, 2]}`)
+	save(t, c, note)
+
+	got, err = c.GetDocument(ctx, note.Id)
+	require.NoError(t, err)
+	assert.False(t, got.File)
+	assert.JSONEq(t, `{"kind": "note", "lines": [1, 2]}`, string(got.JSON))
+
+	stats, err := c.GetStats(ctx, alice)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), stats.Documents)
+	assert.Equal(t, int64(5), stats.Size)
+
+	_, err = c.GetDocument(ctx, uuid.NewString())
+	assert.ErrorIs(t, err, postgresClient.ErrDocumentNotFound)
+
+	_, err = c.GetDocumentInfo(ctx, uuid.NewString())
+	assert.ErrorIs(t, err, postgresClient.ErrDocumentNotFound)
+
+	_, err = c.GetDocumentForWrite(ctx, uuid.NewString())
+	assert.ErrorIs(t, err, postgresClient.ErrDocumentNotFound)
+
+	granted := newDocument(alice, "x", 0)
+	granted.Grant = []string{"unknown-" + uuid.NewString()}
+	assert.ErrorIs(t, c.SaveDocument(ctx, granted), postgresClient.ErrUnknownGrantee)
+
+	misplaced := newDocument(alice, "x", 0)
+	misplaced.FolderId = uuid.NewString()
+	assert.ErrorIs(t, c.SaveDocument(ctx, misplaced), postgresClient.ErrFolderNotFound)
+}
+
+func testListing(t *testing.T, c postgresClient.PostgresClient) {
+	ctx := context.Background()
+	alice, bob, carol := newUser(t, c), newUser(t, c), newUser(t, c)
+
+	invoice := newDocument(alice, "", 3*time.Minute)
+	invoice.File = false
+	invoice.Content = nil
+	invoice.JSON = []byte(`{"kind": "invoice", "total": 250, "lines": [{"sku": "A-1"}]}`)
+	invoice.Tags = []string{"finance", "2024"}
+	invoice.Metadata = map[string]string{"team": "sales"}
+	save(t, c, invoice)
+
+	note := newDocument(alice, "", 2*time.Minute)
+	note.File = false
+	note.Content = nil
+	note.JSON = []byte(`{"kind": "note", "total": 5}`)
+	note.Tags = []string{"finance"}
+	note.Metadata = map[string]string{"team": "support"}
+	save(t, c, note)
+
+	file := newDocument(alice, "plain", time.Minute)
+	file.Grant = []string{bob}
+	save(t, c, file)
+
+	public := newDocument(carol, "public", time.Minute)
+	public.Public = true
+	save(t, c, public)
+
+	private := newDocument(carol, "private", time.Minute)
+	save(t, c, private)
+
+	deleted := newDocument(alice, "deleted", 0)
+	save(t, c, deleted)
+	require.NoError(t, c.DeleteDocument(ctx, deleted.Id))
+
+	predicate, err := jsonFilter.Predicate(`$.total > 100`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		query documents.ListQuery
+		want  []string
+	}{
+		{
+			name:  "own documents newest first",
+			query: documents.ListQuery{Login: alice, Owner: alice, Limit: 10},
+			want:  []string{file.Id, note.Id, invoice.Id},
+		},
+		{
+			name:  "page",
+			query: documents.ListQuery{Login: alice, Owner: alice, Limit: 1, Offset: 1},
+			want:  []string{note.Id},
+		},
+		{
+			name:  "granted",
+			query: documents.ListQuery{Login: bob, Owner: alice, Limit: 10},
+			want:  []string{file.Id},
+		},
+		{
+			name:  "public",
+			query: documents.ListQuery{Login: alice, Owner: carol, Limit: 10},
+			want:  []string{public.Id},
+		},
+		{
+			name:  "tags",
+			query: documents.ListQuery{Login: alice, Owner: alice, Tags: []string{"finance", "2024"}, Limit: 10},
+			want:  []string{invoice.Id},
+		},
+		{
+			name:  "metadata",
+			query: documents.ListQuery{Login: alice, Owner: alice, Metadata: map[string]string{"team": "support"}, Limit: 10},
+			want:  []string{note.Id},
+		},
+		{
+			name:  "json containment",
+			query: documents.ListQuery{Login: alice, Owner: alice, JSONContains: []byte(`{"lines": [{"sku": "A-1"}]}`), Limit: 10},
+			want:  []string{invoice.Id},
+		},
+		{
+			name:  "json predicate",
+			query: documents.ListQuery{Login: alice, Owner: alice, JSONPath: []string{predicate}, Limit: 10},
+			want:  []string{invoice.Id},
+		},
+		{
+			name:  "json filter page",
+			query: documents.ListQuery{Login: alice, Owner: alice, JSONContains: []byte(`{}`), Limit: 1, Offset: 1},
+			want:  []string{invoice.Id},
+		},
+		{
+			name:  "root folder",
+			query: documents.ListQuery{Login: alice, Owner: alice, Folder: new(string), Limit: 10},
+			want:  []string{file.Id, note.Id, invoice.Id},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docs, err := c.ListDocuments(ctx, &tt.query)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ids(docs, documentId))
+
+			for _, document := range docs {
+				assert.Empty(t, document.Content)
+				assert.Empty(t, document.JSON)
+			}
+		})
+	}
+
+	docs, err := c.ListDocuments(ctx, &documents.ListQuery{Login: bob, Owner: alice, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, []string{bob}, docs[0].Grant)
+	assert.Equal(t, "file.txt", docs[0].Name)
+	assert.Equal(t, 1, docs[0].Version)
+}
+
+func testSearch(t *testing.T, c postgresClient.PostgresClient) {
+	ctx := context.Background()
+	alice := newUser(t, c)
+
+	report := newDocument(alice, "", 2*time.Minute)
+	report.Name = "report.txt"
+	report.Text = "quarterly numbers of the orchard, apples sold well"
+	save(t, c, report)
+
+	apples := newDocument(alice, "", time.Minute)
+	apples.Name = "apples.txt"
+	apples.Text = "apples everywhere"
+	save(t, c, apples)
+
+	other := newDocument(alice, "", 0)
+	other.Text = "bananas only"
+	save(t, c, other)
+
+	results, err := c.SearchDocuments(ctx, &documents.ListQuery{Login: alice, Owner: alice, Search: "apples", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, apples.Id, results[0].Id, "a match in the name ranks higher")
+	assert.Equal(t, report.Id, results[1].Id)
+	assert.Greater(t, results[0].Rank, results[1].Rank)
+	assert.Contains(t, results[1].Snippet, "<b>apples</b>")
+
+	results, err = c.SearchDocuments(ctx, &documents.ListQuery{Login: alice, Owner: alice, Search: "apples -orchard", Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{apples.Id}, ids(results, func(result documents.SearchResult) string { return result.Id }))
+
+	results, err = c.SearchDocuments(ctx, &documents.ListQuery{Login: alice, Owner: alice, Search: "cherries", Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func testVersions(t *testing.T, c postgresClient.PostgresClient) {
+	ctx := context.Background()
+	alice := newUser(t, c)
+
+	document := newDocument(alice, "first", time.Minute)
+	document.DetectedMime = "application/pdf"
+	save(t, c, document)
+
+	update := newDocument(alice, "second", 0)
+	update.Id = document.Id
+	update.UpdatedAt = update.CreatedAt
+	require.NoError(t, c.UpdateDocument(ctx, update, 1))
+	assert.Equal(t, 2, update.Version)
+
+	got, err := c.GetDocumentInfo(ctx, document.Id)
+	require.NoError(t, err)
+	assert.Empty(t, got.DetectedMime)
+
+	assert.ErrorIs(t, c.UpdateDocument(ctx, update, 1), postgresClient.ErrVersionConflict)
+
+	missing := newDocument(alice, "x", 0)
+	assert.ErrorIs(t, c.UpdateDocument(ctx, missing, 0), postgresClient.ErrDocumentNotFound)
+
+	versions, err := c.ListVersions(ctx, document.Id)
+	require.NoError(t, err)
+	require.Len(t, versions, 1)
+	assert.Equal(t, 1, versions[0].Version)
+
+	version, err := c.GetVersion(ctx, document.Id, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("first"), version.Content)
+
+	_, err = c.GetVersion(ctx, document.Id, 7)
+	assert.ErrorIs(t, err, postgresClient.ErrVersionNotFound)
+
+	restored, err := c.RestoreVersion(ctx, document.Id, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 3, restored)
+
+	got, err = c.GetDocument(ctx, document.Id)
+	require.NoError(t, err)
+	assert.Equal(t, 3, got.Version)
+	assert.Equal(t, []byte("first"), got.Content)
+	assert.Equal(t, "application/pdf", got.DetectedMime)
+
+	listed, err := c.ListDocuments(ctx, &documents.ListQuery{Login: alice, Owner: alice, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.Equal(t, "application/pdf", listed[0].DetectedMime)
+
+	versions, err = c.ListVersions(ctx, document.Id)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 1}, versionNumbers(versions))
+
+	_, err = c.RestoreVersion(ctx, document.Id, 7)
+	assert.ErrorIs(t, err, postgresClient.ErrVersionNotFound)
+}
+
+func versionNumbers(versions []documents.Document) []int {
+	result := make([]int, 0, len(versions))
+	for _, version := range versions {
+		result = append(result, version.Version)
+	}
+
+	return result
+}
+
+func testLabels(t *testing.T, c postgresClient.PostgresClient) {
+	ctx := context.Background()
+	alice := newUser(t, c)
+
+	document := save(t, c, newDocument(alice, "content", 0))
+
+	require.NoError(t, c.UpdateLabels(ctx, document.Id, []string{"x"}, map[string]string{"k": "v"}))
+
+	got, err := c.GetDocumentInfo(ctx, document.Id)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"x"}, got.Tags)
+	assert.Equal(t, map[string]string{"k": "v"}, got.Metadata)
+	assert.Equal(t, 1, got.Version)
+
+	assert.ErrorIs(t, c.UpdateLabels(ctx, uuid.NewString(), nil, nil), postgresClient.ErrDocumentNotFound)
+}
+
+func testTrash(t *testing.T, c postgresClient.PostgresClient) {
+	ctx := context.Background()
+	alice, bob := newUser(t, c), newUser(t, c)
+
+	document := save(t, c, newDocument(alice, "content", 0))
+
+	require.NoError(t, c.DeleteDocument(ctx, document.Id))
+	assert.ErrorIs(t, c.DeleteDocument(ctx, document.Id), postgresClient.ErrDocumentNotFound)
+
+	stats, err := c.GetStats(ctx, alice)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), stats.Documents, "trashed documents are not counted")
+
+	_, err = c.GetDocument(ctx, document.Id)
+	assert.ErrorIs(t, err, postgresClient.ErrDocumentNotFound)
+
+	trash, err := c.ListTrash(ctx, alice)
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	assert.Equal(t, document.Id, trash[0].Id)
+	assert.False(t, trash[0].DeletedAt.IsZero())
+
+	assert.ErrorIs(t, c.RestoreDocument(ctx, document.Id, bob), postgresClient.ErrDocumentNotFound)
+	require.NoError(t, c.RestoreDocument(ctx, document.Id, alice))
+
+	_, err = c.GetDocument(ctx, document.Id)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, c.PurgeDocument(ctx, document.Id, alice), postgresClient.ErrDocumentNotFound, "only trashed documents are purged")
+
+	require.NoError(t, c.DeleteDocument(ctx, document.Id))
+	require.NoError(t, c.PurgeDocument(ctx, document.Id, alice))
+
+	trash, err = c.ListTrash(ctx, alice)
+	require.NoError(t, err)
+	assert.Empty(t, trash)
+
+	usage, err := c.GetUsage(ctx, alice)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), usage.Documents)
+	assert.Equal(t, int64(0), usage.Bytes)
+
+	old := save(t, c, newDocument(alice, "old", 0))
+	require.NoError(t, c.DeleteDocument(ctx, old.Id))
+
+	purged, err := c.PurgeDocuments(ctx, time.Now().Add(time.Minute), 100000)
+	require.NoError(t, err)
+	assert.Contains(t, purged, documents.Document{Id: old.Id, Login: alice})
+}
+
+func testExpiry(t *testing.T, c postgresClient.PostgresClient) {
+	ctx := context.Background()
+	alice := newUser(t, c)
+
+	document := save(t, c, newDocument(alice, "content", 0))
+
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Microsecond)
+	require.NoError(t, c.UpdateExpiry(ctx, document.Id, &expiresAt))
+
+	got, err := c.GetDocumentInfo(ctx, document.Id)
+	require.NoError(t, err)
+	require.NotNil(t, got.ExpiresAt)
+	assert.True(t, expiresAt.Equal(*got.ExpiresAt))
+
+	assert.ErrorIs(t, c.UpdateExpiry(ctx, uuid.NewString(), nil), postgresClient.ErrDocumentNotFound)
+
+	purged, err := c.PurgeExpired(ctx, time.Now(), 100000)
+	require.NoError(t, err)
+	assert.NotContains(t, ids(purged, documentId), document.Id)
+
+	expired := save(t, c, newDocument(alice, "expired", 0))
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, c.UpdateExpiry(ctx, expired.Id, &past))
+
+	stats, err := c.GetStats(ctx, alice)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats.Documents, "expired documents are not counted")
+
+	purged, err = c.PurgeExpired(ctx, expiresAt.Add(time.Second), 100000)
+	require.NoError(t, err)
+	assert.Contains(t, purged, documents.Document{Id: document.Id, Login: alice})
+
+	_, err = c.GetDocumentInfo(ctx, document.Id)
+	assert.ErrorIs(t, err, postgresClient.ErrDocumentNotFound)
+}
+
+func testFolders(t *testing.T, c postgresClient.PostgresClient) {
+	ctx := context.Background()
+	alice, bob := newUser(t, c), newUser(t, c)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	root := &documents.Folder{Id: uuid.NewString(), Login: alice, Name: "docs", CreatedAt: now}
+	require.NoError(t, c.CreateFolder(ctx, root))
+
+	child := &documents.Folder{Id: uuid.NewString(), Login: alice, ParentId: root.Id, Name: "2024", CreatedAt: now}
+	require.NoError(t, c.CreateFolder(ctx, child))
+
+	duplicate := &documents.Folder{Id: uuid.NewString(), Login: alice, Name: "docs", CreatedAt: now}
+	assert.ErrorIs(t, c.CreateFolder(ctx, duplicate), postgresClient.ErrFolderExists)
+
+	other := &documents.Folder{Id: uuid.NewString(), Login: bob, Name: "docs", CreatedAt: now}
+	require.NoError(t, c.CreateFolder(ctx, other), "names are unique per owner")
+
+	orphan := &documents.Folder{Id: uuid.NewString(), Login: alice, ParentId: uuid.NewString(), Name: "x", CreatedAt: now}
+	assert.ErrorIs(t, c.CreateFolder(ctx, orphan), postgresClient.ErrFolderNotFound)
+
+	id, err := c.ResolveFolderPath(ctx, alice, []string{"docs", "2024"})
+	require.NoError(t, err)
+	assert.Equal(t, child.Id, id)
+
+	id, err = c.ResolveFolderPath(ctx, alice, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "", id)
+
+	_, err = c.ResolveFolderPath(ctx, alice, []string{"docs", "2025"})
+	assert.ErrorIs(t, err, postgresClient.ErrFolderNotFound)
+
+	folders, err := c.ListFolders(ctx, alice, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{root.Id}, ids(folders, func(folder documents.Folder) string { return folder.Id }))
+	assert.True(t, now.Equal(folders[0].CreatedAt))
+
+	require.NoError(t, c.SetFolderGrants(ctx, root.Id, []string{bob}))
+	assert.ErrorIs(t, c.SetFolderGrants(ctx, root.Id, []string{"unknown-" + uuid.NewString()}), postgresClient.ErrUnknownGrantee)
+
+	folder, err := c.GetFolder(ctx, child.Id)
+	require.NoError(t, err)
+	assert.Equal(t, alice, folder.Login)
+	assert.Equal(t, root.Id, folder.ParentId)
+	assert.Equal(t, []string{bob}, folder.Grant, "grants are inherited from parents")
+
+	_, err = c.GetFolder(ctx, uuid.NewString())
+	assert.ErrorIs(t, err, postgresClient.ErrFolderNotFound)
+
+	document := save(t, c, newDocument(alice, "content", 0))
+	require.NoError(t, c.MoveDocument(ctx, document.Id, child.Id))
+	assert.ErrorIs(t, c.MoveDocument(ctx, document.Id, uuid.NewString()), postgresClient.ErrFolderNotFound)
+	assert.ErrorIs(t, c.MoveDocument(ctx, uuid.NewString(), ""), postgresClient.ErrDocumentNotFound)
+
+	got, err := c.GetDocumentInfo(ctx, document.Id)
+	require.NoError(t, err)
+	assert.Equal(t, child.Id, got.FolderId)
+	assert.Equal(t, []string{bob}, got.Grant)
+
+	docs, err := c.ListDocuments(ctx, &documents.ListQuery{Login: bob, Owner: alice, Folder: &child.Id, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{document.Id}, ids(docs, documentId), "shared folders are readable")
+
+	cycle := *folder
+	cycle.Id, cycle.ParentId, cycle.Name = root.Id, child.Id, "docs"
+	assert.ErrorIs(t, c.UpdateFolder(ctx, &cycle), postgresClient.ErrFolderCycle)
+
+	folder.ParentId, folder.Name = "", "docs"
+	assert.ErrorIs(t, c.UpdateFolder(ctx, folder), postgresClient.ErrFolderExists)
+
+	folder.Name = "archive"
+	require.NoError(t, c.UpdateFolder(ctx, folder))
+
+	folders, err = c.ListFolders(ctx, alice, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"archive", "docs"}, ids(folders, func(folder documents.Folder) string { return folder.Name }))
+
+	missing := &documents.Folder{Id: uuid.NewString(), Login: alice, Name: "missing"}
+	assert.ErrorIs(t, c.UpdateFolder(ctx, missing), postgresClient.ErrFolderNotFound)
+}
+
+func testHolds(t *testing.T, c postgresClient.PostgresClient) {
+	ctx := context.Background()
+	alice := newUser(t, c)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	document := save(t, c, newDocument(alice, "content", 0))
+
+	hold := &documents.Hold{Id: uuid.NewString(), DocId: document.Id, Reason: "case 42", CreatedAt: now}
+	require.NoError(t, c.CreateHold(ctx, hold))
+
+	err := c.DeleteDocument(ctx, document.Id)
+	require.ErrorIs(t, err, postgresClient.ErrDocumentHeld)
+
+	var holdErr *postgresClient.HoldError
+	require.True(t, errors.As(err, &holdErr))
+	assert.Equal(t, "legal hold: case 42", holdErr.Reason)
+
+	past := time.Now().Add(-time.Hour)
+	assert.ErrorIs(t, c.UpdateExpiry(ctx, document.Id, &past), postgresClient.ErrDocumentHeld)
+	assert.ErrorIs(t, c.PurgeDocument(ctx, document.Id, alice), postgresClient.ErrDocumentNotFound, "a held document outside the trash is not found")
+
+	holds, err := c.ListHolds(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, ids(holds, func(hold documents.Hold) string { return hold.Id }), hold.Id)
+
+	require.NoError(t, c.DeleteHold(ctx, hold.Id))
+	assert.ErrorIs(t, c.DeleteHold(ctx, hold.Id), postgresClient.ErrHoldNotFound)
+
+	userHold := &documents.Hold{Id: uuid.NewString(), Login: alice, Reason: "audit", CreatedAt: now}
+	require.NoError(t, c.CreateHold(ctx, userHold))
+	assert.ErrorIs(t, c.DeleteDocument(ctx, document.Id), postgresClient.ErrDocumentHeld, "a hold on the owner covers the document")
+
+	require.NoError(t, c.DeleteHold(ctx, userHold.Id))
+	require.NoError(t, c.DeleteDocument(ctx, document.Id))
+
+	trashHold := &documents.Hold{Id: uuid.NewString(), DocId: document.Id, Reason: "case 43", CreatedAt: now}
+	require.NoError(t, c.CreateHold(ctx, trashHold))
+	assert.ErrorIs(t, c.PurgeDocument(ctx, document.Id, alice), postgresClient.ErrDocumentHeld)
+
+	err = c.PurgeDocument(ctx, document.Id, newUser(t, c))
+	assert.ErrorIs(t, err, postgresClient.ErrDocumentNotFound, "the hold of another user's document does not leak")
+	assert.False(t, errors.As(err, &holdErr))
+
+	require.NoError(t, c.DeleteHold(ctx, trashHold.Id))
+	require.NoError(t, c.PurgeDocument(ctx, document.Id, alice))
+
+	missing := &documents.Hold{Id: uuid.NewString(), DocId: uuid.NewString(), Reason: "x", CreatedAt: now}
+	assert.ErrorIs(t, c.CreateHold(ctx, missing), postgresClient.ErrDocumentNotFound)
+
+	missing = &documents.Hold{Id: uuid.NewString(), Login: "unknown-" + uuid.NewString(), Reason: "x", CreatedAt: now}
+	assert.ErrorIs(t, c.CreateHold(ctx, missing), postgresClient.ErrUserNotFound)
+}
+
+func testRetentionRules(t *testing.T, c postgresClient.PostgresClient) {
+	ctx := context.Background()
+	alice := newUser(t, c)
+	tag := "keep-" + uuid.NewString()[:8]
+
+	rule := &documents.RetentionRule{Name: "rule-" + tag, Tag: tag, KeepDays: 30, CreatedAt: time.Now().UTC().Truncate(time.Microsecond)}
+	require.NoError(t, c.SaveRetentionRule(ctx, rule))
+
+	kept := newDocument(alice, "kept", 0)
+	kept.Tags = []string{tag}
+	save(t, c, kept)
+
+	free := save(t, c, newDocument(alice, "free", 0))
+
+	err := c.DeleteDocument(ctx, kept.Id)
+	require.ErrorIs(t, err, postgresClient.ErrDocumentHeld)
+
+	var holdErr *postgresClient.HoldError
+	require.True(t, errors.As(err, &holdErr))
+
+	until := kept.CreatedAt.AddDate(0, 0, 30).Format("2006-01-02")
+	assert.Equal(t, "retention rule "+rule.Name+" keeps the document until "+until, holdErr.Reason)
+
+	require.NoError(t, c.DeleteDocument(ctx, free.Id))
+
+	rules, err := c.ListRetentionRules(ctx)
+	require.NoError(t, err)
+
+	index := slices.IndexFunc(rules, func(r documents.RetentionRule) bool { return r.Name == rule.Name })
+	require.GreaterOrEqual(t, index, 0)
+	assert.Equal(t, tag, rules[index].Tag)
+	assert.Equal(t, 30, rules[index].KeepDays)
+
+	rule.Tag = "other-" + tag
+	require.NoError(t, c.SaveRetentionRule(ctx, rule))
+	require.NoError(t, c.DeleteDocument(ctx, kept.Id), "a replaced rule no longer keeps the document")
+	require.NoError(t, c.RestoreDocument(ctx, kept.Id, alice))
+
+	require.NoError(t, c.DeleteRetentionRule(ctx, rule.Name))
+	assert.ErrorIs(t, c.DeleteRetentionRule(ctx, rule.Name), postgresClient.ErrRuleNotFound)
+}
+
+func testQuotas(t *testing.T, c postgresClient.PostgresClient) {
+	ctx := context.Background()
+	alice := newUser(t, c)
+
+	maxDocuments, maxBytes := int64(2), int64(10)
+	require.NoError(t, c.SetQuota(ctx, alice, &maxDocuments, &maxBytes))
+
+	document := save(t, c, newDocument(alice, "12345", 0))
+
+	assert.ErrorIs(t, c.SaveDocument(ctx, newDocument(alice, "123456", 0)), documents.ErrQuotaExceeded)
+	assert.ErrorIs(t, c.SaveDocument(ctx, newDocument(alice, "12345678901", 0)), documents.ErrDocumentTooLarge)
+
+	usage, err := c.GetUsage(ctx, alice)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), usage.Documents)
+	assert.Equal(t, int64(5), usage.Bytes)
+	assert.Equal(t, maxDocuments, usage.MaxDocuments)
+	assert.Equal(t, maxBytes, usage.MaxBytes)
+
+	update := newDocument(alice, "123", 0)
+	update.Id = document.Id
+	require.NoError(t, c.UpdateDocument(ctx, update, 0))
+
+	usage, err = c.GetUsage(ctx, alice)
+	require.NoError(t, err)
+	assert.Equal(t, int64(8), usage.Bytes, "the archived version stays charged")
+
+	update = newDocument(alice, "123", 0)
+	update.Id = document.Id
+	assert.ErrorIs(t, c.UpdateDocument(ctx, update, 0), documents.ErrQuotaExceeded)
+
+	_, err = c.RestoreVersion(ctx, document.Id, 1)
+	assert.ErrorIs(t, err, documents.ErrQuotaExceeded, "the restored copy is charged on top of the kept version")
+
+	got, err := c.GetDocumentInfo(ctx, document.Id)
+	require.NoError(t, err)
+	assert.Equal(t, 2, got.Version)
+
+	usage, err = c.GetUsage(ctx, alice)
+	require.NoError(t, err)
+	assert.Equal(t, int64(8), usage.Bytes)
+
+	require.NoError(t, c.DeleteDocument(ctx, document.Id))
+	require.NoError(t, c.PurgeDocument(ctx, document.Id, alice))
+
+	usage, err = c.GetUsage(ctx, alice)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), usage.Documents)
+	assert.Equal(t, int64(0), usage.Bytes, "purging gives back the versions too")
+
+	require.NoError(t, c.SetQuota(ctx, alice, nil, nil))
+
+	usage, err = c.GetUsage(ctx, alice)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), usage.MaxDocuments)
+	assert.Equal(t, int64(0), usage.MaxBytes)
+
+	unknown := "unknown-" + uuid.NewString()
+
+	_, err = c.GetUsage(ctx, unknown)
+	assert.ErrorIs(t, err, postgresClient.ErrUserNotFound)
+	assert.ErrorIs(t, c.SetQuota(ctx, unknown, nil, nil), postgresClient.ErrUserNotFound)
+}
+
+func testSchemas(t *testing.T, c postgresClient.PostgresClient) {
+	ctx := context.Background()
+	alice := newUser(t, c)
+
+	require.NoError(t, c.SaveSchema(ctx, alice, "invoice", []byte(`{"type": "object"}`)))
+	require.NoError(t, c.SaveSchema(ctx, alice, "invoice", []byte(`{"type": "array"}`)))
+
+	schema, err := c.GetSchema(ctx, alice, "invoice")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type": "array"}`, string(schema))
+
+	_, err = c.GetSchema(ctx, alice, "order")
+	assert.ErrorIs(t, err, postgresClient.ErrSchemaNotFound)
+}
@@ -0,0 +1,154 @@
+package matcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+)
+
+// NewJSONFilter compiles a containment filter and jsonpath predicates in the canonical form
+// produced by jsonFilter. Both may be empty.
+func NewJSONFilter(contains []byte, predicates []string) (*JSONFilter, error) {
+	var filter JSONFilter
+
+	if len(contains) > 0 {
+		err := decodeJSON(contains, &filter.want)
+		if err != nil {
+			return nil, fmt.Errorf("NewJSONFilter: %w: %v", ErrInvalidContainment, err)
+		}
+	}
+
+	for _, raw := range predicates {
+		p, err := parsePredicate(raw)
+		if err != nil {
+			return nil, fmt.Errorf("NewJSONFilter: %w", err)
+		}
+
+		filter.predicates = append(filter.predicates, p)
+	}
+
+	return &filter, nil
+}
+
+// Empty reports whether the filter matches any document.
+func (f *JSONFilter) Empty() bool {
+	return f.want == nil && len(f.predicates) == 0
+}
+
+// Match reports whether raw contains the containment filter and satisfies all predicates.
+// Documents without JSON only match an empty filter.
+func (f *JSONFilter) Match(raw []byte) bool {
+	if f.Empty() {
+		return true
+	}
+
+	var value any
+
+	if raw == nil || decodeJSON(raw, &value) != nil {
+		return false
+	}
+
+	if f.want != nil && !jsonContains(value, f.want) {
+		return false
+	}
+
+	for _, p := range f.predicates {
+		if !p.match(value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// decodeJSON decodes raw keeping numbers exact.
+func decodeJSON(raw []byte, value *any) error {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	return dec.Decode(value)
+}
+
+// jsonContains reports whether have contains want like the jsonb @> operator: objects contain
+// the keys of want with contained values, arrays contain every element of want in some element.
+func jsonContains(have any, want any) bool {
+	switch want := want.(type) {
+	case map[string]any:
+		object, ok := have.(map[string]any)
+		if !ok {
+			return false
+		}
+
+		for key, value := range want {
+			got, ok := object[key]
+			if !ok || !jsonContains(got, value) {
+				return false
+			}
+		}
+
+		return true
+
+	case []any:
+		array, ok := have.([]any)
+		if !ok {
+			return false
+		}
+
+		for _, value := range want {
+			if !slices.ContainsFunc(array, func(got any) bool { return jsonContains(got, value) }) {
+				return false
+			}
+		}
+
+		return true
+
+	default:
+		c, ok := compareScalars(have, want)
+		return ok && c == 0
+	}
+}
+
+// compareScalars orders two JSON scalars of the same type. Numbers compare by value,
+// strings bytewise, booleans and nulls only by equality; ok is false when they can not be compared.
+func compareScalars(a any, b any) (int, bool) {
+	switch a := a.(type) {
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return 0, false
+		}
+
+		x, okX := new(big.Rat).SetString(a.String())
+		y, okY := new(big.Rat).SetString(b.String())
+		if !okX || !okY {
+			return 0, false
+		}
+
+		return x.Cmp(y), true
+
+	case string:
+		b, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+
+		return strings.Compare(a, b), true
+
+	case bool:
+		b, ok := b.(bool)
+		if !ok || a != b {
+			return 1, ok
+		}
+
+		return 0, true
+
+	case nil:
+		return 0, b == nil
+
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,237 @@
+package matcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// predicate is a jsonpath predicate in the canonical form produced by jsonFilter.Predicate:
+// exists(path) or path <op> literal, where a path consists of ."key", [index] and [*] steps.
+type predicate struct {
+	steps   []pathStep
+	exists  bool
+	op      string
+	literal any
+}
+
+type pathStep struct {
+	key      string
+	index    int
+	isKey    bool
+	wildcard bool
+}
+
+var operators = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+func parsePredicate(raw string) (*predicate, error) {
+	if inner, ok := strings.CutPrefix(raw, "exists("); ok {
+		inner, ok = strings.CutSuffix(inner, ")")
+		if !ok {
+			return nil, fmt.Errorf("parsePredicate: %w: %q", ErrInvalidPredicate, raw)
+		}
+
+		steps, rest, err := parseSteps(inner)
+		if err != nil || rest != "" {
+			return nil, fmt.Errorf("parsePredicate: %w: %q", ErrInvalidPredicate, raw)
+		}
+
+		return &predicate{steps: steps, exists: true}, nil
+	}
+
+	steps, rest, err := parseSteps(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parsePredicate: %w: %q", ErrInvalidPredicate, raw)
+	}
+
+	rest = strings.TrimSpace(rest)
+
+	p := &predicate{steps: steps}
+
+	for _, op := range operators {
+		if literal, ok := strings.CutPrefix(rest, op); ok {
+			p.op = op
+
+			err = decodeJSON([]byte(literal), &p.literal)
+			if err != nil {
+				return nil, fmt.Errorf("parsePredicate: %w: %q", ErrInvalidPredicate, raw)
+			}
+
+			return p, nil
+		}
+	}
+
+	return nil, fmt.Errorf("parsePredicate: %w: %q", ErrInvalidPredicate, raw)
+}
+
+// parseSteps reads the path at the start of s and returns its steps with the unparsed remainder.
+func parseSteps(s string) ([]pathStep, string, error) {
+	s, ok := strings.CutPrefix(s, "$")
+	if !ok {
+		return nil, "", ErrInvalidPredicate
+	}
+
+	var steps []pathStep
+
+	for {
+		switch {
+		case strings.HasPrefix(s, `."`):
+			dec := json.NewDecoder(strings.NewReader(s[1:]))
+
+			var key string
+
+			err := dec.Decode(&key)
+			if err != nil {
+				return nil, "", ErrInvalidPredicate
+			}
+
+			steps = append(steps, pathStep{key: key, isKey: true})
+			s = s[1+int(dec.InputOffset()):]
+
+		case strings.HasPrefix(s, "["):
+			end := strings.IndexByte(s, ']')
+			if end == -1 {
+				return nil, "", ErrInvalidPredicate
+			}
+
+			if s[1:end] == "*" {
+				steps = append(steps, pathStep{wildcard: true})
+			} else {
+				index, err := strconv.Atoi(s[1:end])
+				if err != nil {
+					return nil, "", ErrInvalidPredicate
+				}
+
+				steps = append(steps, pathStep{index: index})
+			}
+
+			s = s[end+1:]
+
+		default:
+			return steps, s, nil
+		}
+	}
+}
+
+// match evaluates the predicate in lax mode like the @@ operator: arrays are unwrapped
+// where a key is expected and a comparison holds when any item on the path satisfies it.
+func (p *predicate) match(value any) bool {
+	items := []any{value}
+
+	for _, step := range p.steps {
+		var next []any
+
+		for _, item := range items {
+			next = append(next, step.apply(item)...)
+		}
+
+		items = next
+	}
+
+	if p.exists {
+		return len(items) > 0
+	}
+
+	for _, item := range items {
+		if array, ok := item.([]any); ok {
+			for _, element := range array {
+				if p.compare(element) {
+					return true
+				}
+			}
+
+			continue
+		}
+
+		if p.compare(item) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (step pathStep) apply(item any) []any {
+	array, isArray := item.([]any)
+
+	switch {
+	case step.isKey && isArray:
+		var values []any
+
+		for _, element := range array {
+			values = append(values, step.apply(element)...)
+		}
+
+		return values
+
+	case step.isKey:
+		object, ok := item.(map[string]any)
+		if !ok {
+			return nil
+		}
+
+		value, ok := object[step.key]
+		if !ok {
+			return nil
+		}
+
+		return []any{value}
+
+	case step.wildcard && isArray:
+		return array
+
+	case step.wildcard:
+		return []any{item}
+
+	case isArray:
+		if step.index < len(array) {
+			return []any{array[step.index]}
+		}
+
+		return nil
+
+	case step.index == 0:
+		return []any{item}
+
+	default:
+		return nil
+	}
+}
+
+// compare applies the operator to item and the literal. Values of different types never
+// compare, except null that is only equal to null.
+func (p *predicate) compare(item any) bool {
+	if (item == nil) != (p.literal == nil) {
+		return p.op == "!="
+	}
+
+	c, ok := compareScalars(item, p.literal)
+	if !ok {
+		return false
+	}
+
+	switch p.op {
+	case "==":
+		return c == 0
+	case "!=":
+		return c != 0
+	}
+
+	switch item.(type) {
+	case json.Number, string:
+	default:
+		return false
+	}
+
+	switch p.op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	default:
+		return c >= 0
+	}
+}
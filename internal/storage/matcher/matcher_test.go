@@ -0,0 +1,172 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"astral/internal/json_filter"
+)
+
+func TestJSONFilter(t *testing.T) {
+	const document = `{"status":"paid","total":10.50,"note":null,"items":[{"sku":"a","qty":2},{"sku":"b","qty":5}]}`
+
+	tests := []struct {
+		name       string
+		contains   string
+		predicates []string
+		want       bool
+	}{
+		{
+			name: "empty filter",
+			want: true,
+		},
+		{
+			name:     "contained object",
+			contains: `{"status":"paid","items":[{"sku":"b"}]}`,
+			want:     true,
+		},
+		{
+			name:     "number compares by value",
+			contains: `{"total":10.5}`,
+			want:     true,
+		},
+		{
+			name:     "missing element",
+			contains: `{"items":[{"sku":"c"}]}`,
+			want:     false,
+		},
+		{
+			name:       "comparison through array",
+			predicates: []string{`$.items[*].qty > 4`},
+			want:       true,
+		},
+		{
+			name:       "lax mode unwraps arrays",
+			predicates: []string{`$.items.sku == "a"`},
+			want:       true,
+		},
+		{
+			name:       "strings and numbers do not compare",
+			predicates: []string{`$.status > 1`},
+			want:       false,
+		},
+		{
+			name:       "null only equals null",
+			predicates: []string{`$.note == null`, `$.status != null`},
+			want:       true,
+		},
+		{
+			name:       "exists",
+			predicates: []string{`exists($.items[1])`},
+			want:       true,
+		},
+		{
+			name:       "missing key",
+			predicates: []string{`exists($.customer)`},
+			want:       false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var contains []byte
+			if tt.contains != "" {
+				contains = []byte(tt.contains)
+			}
+
+			predicates := make([]string, 0, len(tt.predicates))
+			for _, expr := range tt.predicates {
+				predicate, err := jsonFilter.Predicate(expr)
+				require.NoError(t, err)
+
+				predicates = append(predicates, predicate)
+			}
+
+			filter, err := NewJSONFilter(contains, predicates)
+			require.NoError(t, err)
+
+			require.Equal(t, tt.want, filter.Match([]byte(document)))
+		})
+	}
+}
+
+func TestJSONFilterWithoutJSON(t *testing.T) {
+	filter, err := NewJSONFilter([]byte(`{}`), nil)
+	require.NoError(t, err)
+	require.False(t, filter.Match(nil))
+
+	_, err = NewJSONFilter(nil, []string{"$.a ~ 1"})
+	require.ErrorIs(t, err, ErrInvalidPredicate)
+}
+
+func TestSearch(t *testing.T) {
+	const (
+		name = "Quarterly report"
+		text = "The board approved the quarterly budget. Travel costs went down."
+	)
+
+	tests := []struct {
+		name     string
+		query    string
+		match    bool
+		headline string
+	}{
+		{
+			name:     "word",
+			query:    "budget",
+			match:    true,
+			headline: "The board approved the quarterly <b>budget.</b> Travel costs went down.",
+		},
+		{
+			name:  "phrase",
+			query: `"quarterly budget"`,
+			match: true,
+		},
+		{
+			name:  "phrase in wrong order",
+			query: `"budget quarterly"`,
+			match: false,
+		},
+		{
+			name:  "alternative",
+			query: "salary or travel",
+			match: true,
+		},
+		{
+			name:  "negation",
+			query: "report -travel",
+			match: false,
+		},
+		{
+			name:  "empty query",
+			query: "  ",
+			match: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			search := ParseSearch(tt.query)
+
+			_, ok := search.Rank(name, text)
+			require.Equal(t, tt.match, ok)
+
+			if tt.headline != "" {
+				require.Equal(t, tt.headline, search.Headline(name+"\n"+text))
+			}
+		})
+	}
+}
+
+func TestSearchRanksNameHigher(t *testing.T) {
+	search := ParseSearch("report")
+
+	inName, ok := search.Rank("report", "")
+	require.True(t, ok)
+
+	inText, ok := search.Rank("notes", "report")
+	require.True(t, ok)
+
+	require.Greater(t, inName, inText)
+}
@@ -0,0 +1,197 @@
+package matcher
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+const (
+	headlineMaxWords     = 20
+	headlineMinWords     = 5
+	headlineMaxFragments = 2
+	headlineDelimiter    = " ... "
+
+	// Weights of matches in the name and in the text, like the A and B weights of ts_rank_cd.
+	nameWeight = 1.0
+	textWeight = 0.4
+)
+
+// searchTerm is a word or a quoted phrase of a search query.
+type searchTerm struct {
+	words  []string
+	negate bool
+}
+
+// ParseSearch parses a query like websearch_to_tsquery with the simple configuration:
+// words, "quoted phrases", or between alternatives and - before excluded terms.
+func ParseSearch(raw string) *Search {
+	var (
+		query  Search
+		or     bool
+		negate bool
+	)
+
+	add := func(words []string) {
+		if len(words) == 0 {
+			return
+		}
+
+		term := searchTerm{words: words, negate: negate}
+		negate = false
+
+		if or && len(query.groups) > 0 {
+			last := len(query.groups) - 1
+			query.groups[last] = append(query.groups[last], term)
+		} else {
+			query.groups = append(query.groups, []searchTerm{term})
+		}
+
+		or = false
+	}
+
+	for raw != "" {
+		raw = strings.TrimLeftFunc(raw, unicode.IsSpace)
+
+		switch {
+		case raw == "":
+
+		case strings.HasPrefix(raw, "-"):
+			negate = true
+			raw = raw[1:]
+
+		case strings.HasPrefix(raw, `"`):
+			end := strings.IndexByte(raw[1:], '"')
+			if end == -1 {
+				end = len(raw) - 1
+			}
+
+			add(lexemes(raw[1 : end+1]))
+			raw = raw[min(end+2, len(raw)):]
+
+		default:
+			end := strings.IndexFunc(raw, unicode.IsSpace)
+			if end == -1 {
+				end = len(raw)
+			}
+
+			word := raw[:end]
+			raw = raw[end:]
+
+			if strings.EqualFold(word, "or") {
+				or = true
+				continue
+			}
+
+			add(lexemes(word))
+		}
+	}
+
+	return &query
+}
+
+// Rank reports whether the query matches a document with name and text and how well.
+func (q *Search) Rank(name string, text string) (float32, bool) {
+	if len(q.groups) == 0 {
+		return 0, false
+	}
+
+	nameWords, textWords := lexemes(name), lexemes(text)
+
+	var rank float64
+
+	for _, group := range q.groups {
+		matched := false
+
+		for _, term := range group {
+			inName, inText := countPhrase(nameWords, term.words), countPhrase(textWords, term.words)
+
+			if term.negate {
+				matched = matched || inName+inText == 0
+				continue
+			}
+
+			if inName+inText > 0 {
+				matched = true
+				rank += nameWeight*float64(inName) + textWeight*float64(inText)
+			}
+		}
+
+		if !matched {
+			return 0, false
+		}
+	}
+
+	return float32(rank / float64(1+len(textWords)/100)), true
+}
+
+// Headline returns up to two fragments of text around the matched words with them wrapped in <b></b>.
+func (q *Search) Headline(text string) string {
+	words := strings.Fields(text)
+
+	var wanted []string
+
+	for _, group := range q.groups {
+		for _, term := range group {
+			if !term.negate {
+				wanted = append(wanted, term.words...)
+			}
+		}
+	}
+
+	matches := func(word string) bool {
+		return slices.ContainsFunc(lexemes(word), func(lexeme string) bool {
+			return slices.Contains(wanted, lexeme)
+		})
+	}
+
+	var fragments []string
+
+	for i := 0; i < len(words) && len(fragments) < headlineMaxFragments; i++ {
+		if !matches(words[i]) {
+			continue
+		}
+
+		start := max(i-headlineMinWords, 0)
+		end := min(start+headlineMaxWords, len(words))
+
+		fragment := make([]string, 0, end-start)
+
+		for _, word := range words[start:end] {
+			if matches(word) {
+				word = "<b>" + word + "</b>"
+			}
+
+			fragment = append(fragment, word)
+		}
+
+		fragments = append(fragments, strings.Join(fragment, " "))
+		i = end - 1
+	}
+
+	if len(fragments) == 0 {
+		return strings.Join(words[:min(headlineMaxWords, len(words))], " ")
+	}
+
+	return strings.Join(fragments, headlineDelimiter)
+}
+
+// lexemes splits s into lower case words of letters and digits.
+func lexemes(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// countPhrase counts where phrase occurs in words.
+func countPhrase(words []string, phrase []string) int {
+	count := 0
+
+	for i := 0; i+len(phrase) <= len(words); i++ {
+		if slices.Equal(words[i:i+len(phrase)], phrase) {
+			count++
+		}
+	}
+
+	return count
+}
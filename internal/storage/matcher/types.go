@@ -0,0 +1,21 @@
+package matcher
+
+import "errors"
+
+var (
+	ErrInvalidContainment = errors.New("invalid containment filter")
+	ErrInvalidPredicate   = errors.New("invalid jsonpath predicate")
+)
+
+// JSONFilter matches document JSON against the containment filter and the jsonpath
+// predicates of a listing, the way the jsonb @> and @@ operators of Postgres do.
+type JSONFilter struct {
+	want       any
+	predicates []*predicate
+}
+
+// Search is a parsed web search style query: every group has to match,
+// a group matches when one of its terms does.
+type Search struct {
+	groups [][]searchTerm
+}
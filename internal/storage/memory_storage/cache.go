@@ -0,0 +1,416 @@
+package memoryStorage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"astral/internal/documents"
+	"astral/internal/storage/redis_client"
+)
+
+func NewCache(config *redisClient.Config, logger *zap.Logger) *Cache {
+	return &Cache{
+		logger:           logger,
+		now:              time.Now,
+		tokenTTL:         config.TokenTTL,
+		cacheTTL:         config.CacheTTL,
+		lockTTL:          config.LockTTL,
+		lockMaxTTL:       config.LockMaxTTL,
+		contentCacheSize: config.ContentCacheSize,
+		tokens:           make(map[string]expiring[string]),
+		locks:            make(map[string]documents.Lock),
+		listings:         make(map[string]expiring[[]byte]),
+		infos:            make(map[string]expiring[[]byte]),
+		contents:         make(map[string]expiring[cachedContent]),
+	}
+}
+
+// SaveToken keeps token for REDIS_TOKEN_TTL. Expired tokens are dropped on the way.
+func (c *Cache) SaveToken(_ context.Context, login string, token string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+
+	for key, saved := range c.tokens {
+		if !saved.alive(now) {
+			delete(c.tokens, key)
+		}
+	}
+
+	c.tokens[token] = expiring[string]{value: login, expiresAt: now.Add(c.tokenTTL)}
+
+	c.logger.Info("SaveToken: successfully saved token")
+	return nil
+}
+
+// GetLoginByToken returns an error wrapping redis.Nil for unknown and expired tokens, like the Redis client.
+func (c *Cache) GetLoginByToken(_ context.Context, token string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	saved, ok := c.tokens[token]
+	if !ok || !saved.alive(c.now()) {
+		delete(c.tokens, token)
+
+		c.logger.Error("GetLoginByToken: failed to get token", zap.Error(redis.Nil))
+		return "", fmt.Errorf("GetLoginByToken: failed to get token: %w", redis.Nil)
+	}
+
+	return saved.value, nil
+}
+
+// CacheDocument drops the cached metadata and content of a changed document.
+func (c *Cache) CacheDocument(_ context.Context, document *documents.Document) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.infos, document.Id)
+	delete(c.contents, document.Id)
+
+	return nil
+}
+
+// GetDocs returns the listing for query.Login from the cache, calling load on a miss. Listings are
+// cached for REDIS_CACHE_TTL but never past the expiry of a listed document. Concurrent misses share one load.
+// There are no replicas to lag behind, so listings are never loaded fresh.
+func (c *Cache) GetDocs(ctx context.Context, query *documents.ListQuery, load func(ctx context.Context, fresh bool) ([]documents.Document, error)) ([]documents.Document, error) {
+	key, err := listingKey(query)
+	if err != nil {
+		return nil, fmt.Errorf("GetDocs: %w", err)
+	}
+
+	c.mu.Lock()
+	cached, ok := c.listings[key]
+	c.mu.Unlock()
+
+	docsBytes := cached.value
+
+	if !ok || !cached.alive(c.now()) {
+		value, err, _ := c.group.Do(key, func() (any, error) {
+			return c.loadListing(context.WithoutCancel(ctx), key, load)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("GetDocs: %w", err)
+		}
+
+		docsBytes = value.([]byte)
+	}
+
+	// Listings are kept marshaled, so that every caller gets its own copy like from Redis.
+	var docs []documents.Document
+
+	err = json.Unmarshal(docsBytes, &docs)
+	if err != nil {
+		c.logger.Warn("GetDocs: failed to unmarshal cached docs", zap.Error(err))
+		return nil, fmt.Errorf("GetDocs: failed to unmarshal cached docs: %w", err)
+	}
+
+	return docs, nil
+}
+
+func (c *Cache) loadListing(ctx context.Context, key string, load func(ctx context.Context, fresh bool) ([]documents.Document, error)) ([]byte, error) {
+	docs, err := load(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	docsBytes, err := json.Marshal(docs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal docs for cache: %w", err)
+	}
+
+	expiresAt := c.now().Add(c.cacheTTL)
+
+	for _, document := range docs {
+		if document.ExpiresAt != nil && document.ExpiresAt.Before(expiresAt) {
+			expiresAt = *document.ExpiresAt
+		}
+	}
+
+	c.mu.Lock()
+	c.listings[key] = expiring[[]byte]{value: docsBytes, expiresAt: expiresAt}
+	c.mu.Unlock()
+
+	return docsBytes, nil
+}
+
+// GetDocumentInfo returns the metadata of a document, without content, JSON and text, from the cache,
+// calling load on a miss. It is cached for REDIS_CACHE_TTL but never past the expiry of the document.
+// Concurrent misses share one load, which is never asked for fresh metadata.
+func (c *Cache) GetDocumentInfo(ctx context.Context, id string, load func(ctx context.Context, fresh bool) (*documents.Document, error)) (*documents.Document, error) {
+	c.mu.Lock()
+	cached, ok := c.infos[id]
+	c.mu.Unlock()
+
+	docBytes := cached.value
+
+	if !ok || !cached.alive(c.now()) {
+		value, err, _ := c.group.Do("doc:"+id, func() (any, error) {
+			return c.loadInfo(context.WithoutCancel(ctx), id, load)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("GetDocumentInfo: %w", err)
+		}
+
+		docBytes = value.([]byte)
+	}
+
+	var document documents.Document
+
+	err := json.Unmarshal(docBytes, &document)
+	if err != nil {
+		c.logger.Warn("GetDocumentInfo: failed to unmarshal cached document", zap.Error(err))
+		return nil, fmt.Errorf("GetDocumentInfo: failed to unmarshal cached document: %w", err)
+	}
+
+	return &document, nil
+}
+
+func (c *Cache) loadInfo(ctx context.Context, id string, load func(ctx context.Context, fresh bool) (*documents.Document, error)) ([]byte, error) {
+	document, err := load(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	info := *document
+	info.Content = nil
+	info.JSON = nil
+	info.Text = ""
+
+	docBytes, err := json.Marshal(&info)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal document for cache: %w", err)
+	}
+
+	expiresAt := c.now().Add(c.cacheTTL)
+	if info.ExpiresAt != nil && info.ExpiresAt.Before(expiresAt) {
+		expiresAt = *info.ExpiresAt
+	}
+
+	c.mu.Lock()
+	c.infos[id] = expiring[[]byte]{value: docBytes, expiresAt: expiresAt}
+	c.mu.Unlock()
+
+	return docBytes, nil
+}
+
+// LoadDocument calls load for a document, concurrent calls for the same id share one load.
+func (c *Cache) LoadDocument(ctx context.Context, id string, load func(ctx context.Context, id string) (*documents.Document, error)) (*documents.Document, error) {
+	value, err, _ := c.group.Do("load:"+id, func() (any, error) {
+		return load(context.WithoutCancel(ctx), id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Every caller gets its own copy, the content is shared and must not be changed.
+	document := *value.(*documents.Document)
+
+	return &document, nil
+}
+
+// InvalidateDocs drops all cached listings of login.
+func (c *Cache) InvalidateDocs(_ context.Context, login string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.listings {
+		if strings.HasPrefix(key, "docs:"+login+":") {
+			delete(c.listings, key)
+		}
+	}
+
+	return nil
+}
+
+// InvalidateAllDocs drops cached listings of every user.
+func (c *Cache) InvalidateAllDocs(_ context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	clear(c.listings)
+
+	return nil
+}
+
+// InvalidateDocument drops the cached metadata and content of a single document.
+func (c *Cache) InvalidateDocument(_ context.Context, id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.infos, id)
+	delete(c.contents, id)
+
+	return nil
+}
+
+// InvalidateAllDocuments drops the cached metadata of every document.
+func (c *Cache) InvalidateAllDocuments(_ context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	clear(c.infos)
+
+	return nil
+}
+
+// GetContent returns the version and the stored content of a cached file, ErrCacheMiss
+// when it is not cached. The caller compares the version with the current one.
+func (c *Cache) GetContent(_ context.Context, id string) (int, []byte, error) {
+	if c.contentCacheSize <= 0 {
+		return 0, nil, redisClient.ErrCacheMiss
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.contents[id]
+	if !ok || !cached.alive(c.now()) {
+		delete(c.contents, id)
+
+		c.contentMisses.Add(1)
+		return 0, nil, redisClient.ErrCacheMiss
+	}
+
+	c.contentHits.Add(1)
+	return cached.value.version, cached.value.content, nil
+}
+
+// CacheContent caches the stored content of a file document that is at most REDIS_CONTENT_CACHE_SIZE bytes long.
+func (c *Cache) CacheContent(_ context.Context, document *documents.Document) error {
+	if !document.File || document.Sealed || c.contentCacheSize <= 0 || len(document.Content) > c.contentCacheSize {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.contents[document.Id] = expiring[cachedContent]{
+		value:     cachedContent{version: document.Version, content: document.Content},
+		expiresAt: c.now().Add(c.cacheTTL),
+	}
+
+	return nil
+}
+
+// ContentCacheStats returns the hits and misses of GetContent.
+func (c *Cache) ContentCacheStats() redisClient.CacheStats {
+	return redisClient.CacheStats{
+		Hits:   c.contentHits.Load(),
+		Misses: c.contentMisses.Load(),
+	}
+}
+
+// LockDocument checks the document out to login for ttl, REDIS_LOCK_TTL when ttl is zero
+// and at most REDIS_LOCK_MAX_TTL. Locking again by the holder extends the lock.
+// Returns ErrDocumentLocked together with the current lock when somebody else holds it.
+func (c *Cache) LockDocument(_ context.Context, id string, login string, ttl time.Duration) (*documents.Lock, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if ttl <= 0 {
+		ttl = c.lockTTL
+	}
+
+	ttl = min(ttl, c.lockMaxTTL)
+
+	if lock, ok := c.liveLock(id); ok && lock.Login != login {
+		c.logger.Warn("LockDocument: document is locked", zap.String("doc", id), zap.String("holder", lock.Login))
+		return &lock, redisClient.ErrDocumentLocked
+	}
+
+	lock := documents.Lock{DocId: id, Login: login, ExpiresAt: c.now().Add(ttl)}
+	c.locks[id] = lock
+
+	c.logger.Info("LockDocument: successfully locked document", zap.String("doc", id), zap.Duration("ttl", ttl))
+	return &lock, nil
+}
+
+// GetDocumentLock returns the current lock of a document, nil when it is not locked.
+func (c *Cache) GetDocumentLock(_ context.Context, id string) (*documents.Lock, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	lock, ok := c.liveLock(id)
+	if !ok {
+		return nil, nil
+	}
+
+	return &lock, nil
+}
+
+// UnlockDocument releases the lock of login. Returns ErrLockNotFound when the document
+// is not locked and ErrDocumentLocked when somebody else holds the lock.
+func (c *Cache) UnlockDocument(_ context.Context, id string, login string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	lock, ok := c.liveLock(id)
+	if !ok {
+		c.logger.Warn("UnlockDocument: lock not found", zap.String("doc", id))
+		return redisClient.ErrLockNotFound
+	}
+
+	if lock.Login != login {
+		c.logger.Warn("UnlockDocument: lock is held by another user", zap.String("doc", id), zap.String("holder", lock.Login))
+		return redisClient.ErrDocumentLocked
+	}
+
+	delete(c.locks, id)
+
+	c.logger.Info("UnlockDocument: successfully unlocked document", zap.String("doc", id))
+	return nil
+}
+
+// BreakDocumentLock removes the lock of a document whoever holds it.
+func (c *Cache) BreakDocumentLock(_ context.Context, id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.liveLock(id); !ok {
+		c.logger.Warn("BreakDocumentLock: lock not found", zap.String("doc", id))
+		return redisClient.ErrLockNotFound
+	}
+
+	delete(c.locks, id)
+
+	c.logger.Info("BreakDocumentLock: successfully broke lock", zap.String("doc", id))
+	return nil
+}
+
+func (c *Cache) Close() {}
+
+// liveLock returns the lock of a document unless it expired. The caller holds the lock.
+func (c *Cache) liveLock(id string) (documents.Lock, bool) {
+	lock, ok := c.locks[id]
+	if !ok {
+		return documents.Lock{}, false
+	}
+
+	if !c.now().Before(lock.ExpiresAt) {
+		delete(c.locks, id)
+		return documents.Lock{}, false
+	}
+
+	return lock, true
+}
+
+// listingKey returns the key of a listing: docs:<login>:<the rest of the query>.
+func listingKey(query *documents.ListQuery) (string, error) {
+	normalized := *query
+	normalized.Login = ""
+
+	queryBytes, err := json.Marshal(normalized)
+	if err != nil {
+		return "", fmt.Errorf("listingKey: failed to marshal query: %w", err)
+	}
+
+	return "docs:" + query.Login + ":" + string(queryBytes), nil
+}
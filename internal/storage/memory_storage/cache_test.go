@@ -0,0 +1,209 @@
+package memoryStorage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"astral/internal/documents"
+	"astral/internal/storage/redis_client"
+)
+
+func newMemoryCache() (*Cache, *time.Time) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	c := NewCache(&redisClient.Config{
+		TokenTTL:         15 * time.Minute,
+		CacheTTL:         time.Minute,
+		LockTTL:          time.Minute,
+		LockMaxTTL:       time.Hour,
+		ContentCacheSize: 16,
+	}, zap.NewNop())
+	c.now = func() time.Time { return now }
+
+	return c, &now
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		run  func(c *Cache, now *time.Time) error
+		err  error
+	}{
+		{
+			name: "token",
+			run: func(c *Cache, _ *time.Time) error {
+				if err := c.SaveToken(ctx, "alice", "t"); err != nil {
+					return err
+				}
+
+				_, err := c.GetLoginByToken(ctx, "t")
+				return err
+			},
+		},
+		{
+			name: "token expires",
+			run: func(c *Cache, now *time.Time) error {
+				if err := c.SaveToken(ctx, "alice", "t"); err != nil {
+					return err
+				}
+
+				*now = now.Add(15 * time.Minute)
+
+				_, err := c.GetLoginByToken(ctx, "t")
+				return err
+			},
+			err: redis.Nil,
+		},
+		{
+			name: "locked by another user",
+			run: func(c *Cache, _ *time.Time) error {
+				if _, err := c.LockDocument(ctx, "a", "alice", 0); err != nil {
+					return err
+				}
+
+				_, err := c.LockDocument(ctx, "a", "bob", 0)
+				return err
+			},
+			err: redisClient.ErrDocumentLocked,
+		},
+		{
+			name: "lock expires",
+			run: func(c *Cache, now *time.Time) error {
+				if _, err := c.LockDocument(ctx, "a", "alice", 0); err != nil {
+					return err
+				}
+
+				*now = now.Add(time.Minute)
+
+				_, err := c.LockDocument(ctx, "a", "bob", 0)
+				return err
+			},
+		},
+		{
+			name: "unlock by another user",
+			run: func(c *Cache, _ *time.Time) error {
+				if _, err := c.LockDocument(ctx, "a", "alice", 0); err != nil {
+					return err
+				}
+
+				return c.UnlockDocument(ctx, "a", "bob")
+			},
+			err: redisClient.ErrDocumentLocked,
+		},
+		{
+			name: "break missing lock",
+			run: func(c *Cache, _ *time.Time) error {
+				return c.BreakDocumentLock(ctx, "a")
+			},
+			err: redisClient.ErrLockNotFound,
+		},
+		{
+			name: "large content is not cached",
+			run: func(c *Cache, _ *time.Time) error {
+				document := &documents.Document{Id: "a", File: true, Version: 1, Content: []byte("more than sixteen bytes")}
+				if err := c.CacheContent(ctx, document); err != nil {
+					return err
+				}
+
+				_, _, err := c.GetContent(ctx, "a")
+				return err
+			},
+			err: redisClient.ErrCacheMiss,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, now := newMemoryCache()
+
+			err := tt.run(c, now)
+			if tt.err == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestCacheGetDocs(t *testing.T) {
+	ctx := context.Background()
+	c, now := newMemoryCache()
+
+	loads := 0
+	load := func(context.Context, bool) ([]documents.Document, error) {
+		loads++
+		return []documents.Document{{Id: "a", Login: "alice"}}, nil
+	}
+
+	query := &documents.ListQuery{Login: "alice", Limit: 10}
+
+	for range 2 {
+		docs, err := c.GetDocs(ctx, query, load)
+		require.NoError(t, err)
+		assert.Equal(t, "a", docs[0].Id)
+	}
+
+	assert.Equal(t, 1, loads)
+
+	require.NoError(t, c.InvalidateDocs(ctx, "alice"))
+
+	_, err := c.GetDocs(ctx, query, load)
+	require.NoError(t, err)
+	assert.Equal(t, 2, loads)
+
+	*now = now.Add(time.Minute)
+
+	_, err = c.GetDocs(ctx, query, load)
+	require.NoError(t, err)
+	assert.Equal(t, 3, loads)
+}
+
+func TestCacheGetDocumentInfo(t *testing.T) {
+	ctx := context.Background()
+	c, now := newMemoryCache()
+
+	expiresAt := now.Add(2 * time.Minute)
+
+	loads := 0
+	load := func(context.Context, bool) (*documents.Document, error) {
+		loads++
+		return &documents.Document{Id: "a", Login: "alice", Content: []byte("content"), ExpiresAt: &expiresAt}, nil
+	}
+
+	for range 2 {
+		document, err := c.GetDocumentInfo(ctx, "a", load)
+		require.NoError(t, err)
+		assert.Equal(t, "alice", document.Login)
+		assert.Nil(t, document.Content)
+	}
+
+	assert.Equal(t, 1, loads)
+
+	require.NoError(t, c.InvalidateDocument(ctx, "a"))
+
+	_, err := c.GetDocumentInfo(ctx, "a", load)
+	require.NoError(t, err)
+	assert.Equal(t, 2, loads)
+
+	require.NoError(t, c.InvalidateAllDocuments(ctx))
+
+	_, err = c.GetDocumentInfo(ctx, "a", load)
+	require.NoError(t, err)
+	assert.Equal(t, 3, loads)
+
+	*now = now.Add(time.Minute)
+
+	_, err = c.GetDocumentInfo(ctx, "a", load)
+	require.NoError(t, err)
+	assert.Equal(t, 4, loads)
+}
@@ -0,0 +1,16 @@
+package memoryStorage
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+
+	"astral/internal/storage/conformance"
+	"astral/internal/storage/postgres_client"
+)
+
+func TestConformance(t *testing.T) {
+	conformance.Run(t, func(t *testing.T) postgresClient.PostgresClient {
+		return NewStore(&postgresClient.Config{}, zap.NewNop())
+	})
+}
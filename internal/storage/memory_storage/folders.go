@@ -0,0 +1,251 @@
+package memoryStorage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"astral/internal/documents"
+	"astral/internal/storage/postgres_client"
+)
+
+// MoveDocument puts a document into a folder, an empty folderId moves it to the root.
+func (s *Store) MoveDocument(_ context.Context, id string, folderId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.docs[id]
+	if !ok || !stored.DeletedAt.IsZero() {
+		s.logger.Warn("MoveDocument: document not found", zap.String("id", id))
+		return postgresClient.ErrDocumentNotFound
+	}
+
+	if folderId != "" {
+		if _, ok = s.folders[folderId]; !ok {
+			s.logger.Warn("MoveDocument: folder not found", zap.String("folder", folderId))
+			return postgresClient.ErrFolderNotFound
+		}
+	}
+
+	stored.FolderId = folderId
+
+	s.logger.Info("MoveDocument: successfully move document", zap.String("id", id), zap.String("folder", folderId))
+	return nil
+}
+
+func (s *Store) CreateFolder(_ context.Context, folder *documents.Folder) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.folders[folder.Id]; ok {
+		s.logger.Error("CreateFolder: folder id is taken", zap.String("id", folder.Id))
+		return fmt.Errorf("CreateFolder: folder %s already exists", folder.Id)
+	}
+
+	if _, ok := s.users[folder.Login]; !ok {
+		s.logger.Error("CreateFolder: user not found", zap.String("login", folder.Login))
+		return fmt.Errorf("CreateFolder: failed to create folder: %w", postgresClient.ErrUserNotFound)
+	}
+
+	if s.nameTaken(folder.Login, folder.ParentId, folder.Name, "") {
+		s.logger.Warn("CreateFolder: folder already exists", zap.String("name", folder.Name))
+		return postgresClient.ErrFolderExists
+	}
+
+	if folder.ParentId != "" {
+		if _, ok := s.folders[folder.ParentId]; !ok {
+			s.logger.Warn("CreateFolder: parent folder not found", zap.String("parent", folder.ParentId))
+			return postgresClient.ErrFolderNotFound
+		}
+	}
+
+	stored := &storedFolder{Folder: *folder}
+	stored.Grant = nil
+
+	s.folders[folder.Id] = stored
+
+	s.logger.Info("CreateFolder: successfully create folder", zap.String("id", folder.Id))
+	return nil
+}
+
+// GetFolder returns a folder with the grants it has directly or through its parents.
+func (s *Store) GetFolder(_ context.Context, id string) (*documents.Folder, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stored, ok := s.folders[id]
+	if !ok {
+		s.logger.Warn("GetFolder: folder not found", zap.String("id", id))
+		return nil, postgresClient.ErrFolderNotFound
+	}
+
+	folder := stored.Folder
+	folder.Grant = []string{}
+
+	for _, parent := range s.folderChain(id) {
+		folder.Grant = append(folder.Grant, parent.Grant...)
+	}
+
+	slices.Sort(folder.Grant)
+	folder.Grant = slices.Compact(folder.Grant)
+
+	return &folder, nil
+}
+
+// ResolveFolderPath returns the id of the folder of login found by following names
+// from the root. No names resolve to the root itself, which has an empty id.
+func (s *Store) ResolveFolderPath(_ context.Context, login string, names []string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	id := ""
+
+	for _, name := range names {
+		child, ok := s.findFolder(login, id, name)
+		if !ok {
+			s.logger.Warn("ResolveFolderPath: folder not found", zap.Strings("path", names))
+			return "", postgresClient.ErrFolderNotFound
+		}
+
+		id = child.Id
+	}
+
+	return id, nil
+}
+
+// ListFolders returns the folders of login directly inside parentId, or in the root for an empty parentId.
+func (s *Store) ListFolders(_ context.Context, login string, parentId string) ([]documents.Folder, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	folders := make([]documents.Folder, 0)
+
+	for _, stored := range s.folders {
+		if stored.Login == login && stored.ParentId == parentId {
+			folder := stored.Folder
+			folder.Grant = nil
+
+			folders = append(folders, folder)
+		}
+	}
+
+	slices.SortFunc(folders, func(a, b documents.Folder) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return folders, nil
+}
+
+// UpdateFolder renames a folder and moves it under folder.ParentId. A folder can not
+// be moved into itself or into one of its subfolders.
+func (s *Store) UpdateFolder(_ context.Context, folder *documents.Folder) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if folder.ParentId != "" {
+		for _, parent := range s.folderChain(folder.ParentId) {
+			if parent.Id == folder.Id {
+				s.logger.Warn("UpdateFolder: folder moved into itself", zap.String("id", folder.Id))
+				return postgresClient.ErrFolderCycle
+			}
+		}
+	}
+
+	stored, ok := s.folders[folder.Id]
+	if !ok {
+		s.logger.Warn("UpdateFolder: folder not found", zap.String("id", folder.Id))
+		return postgresClient.ErrFolderNotFound
+	}
+
+	if s.nameTaken(stored.Login, folder.ParentId, folder.Name, folder.Id) {
+		s.logger.Warn("UpdateFolder: folder already exists", zap.String("name", folder.Name))
+		return postgresClient.ErrFolderExists
+	}
+
+	if folder.ParentId != "" {
+		if _, ok = s.folders[folder.ParentId]; !ok {
+			s.logger.Warn("UpdateFolder: parent folder not found", zap.String("parent", folder.ParentId))
+			return postgresClient.ErrFolderNotFound
+		}
+	}
+
+	stored.ParentId = folder.ParentId
+	stored.Name = folder.Name
+
+	s.logger.Info("UpdateFolder: successfully update folder", zap.String("id", folder.Id))
+	return nil
+}
+
+// SetFolderGrants replaces the logins a folder is shared with.
+func (s *Store) SetFolderGrants(_ context.Context, id string, grant []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.folders[id]
+	if !ok {
+		s.logger.Warn("SetFolderGrants: folder not found", zap.String("id", id))
+		return postgresClient.ErrFolderNotFound
+	}
+
+	for _, grantee := range grant {
+		if _, ok = s.users[grantee]; !ok {
+			s.logger.Warn("SetFolderGrants: unknown grantee", zap.String("grantee", grantee))
+			return postgresClient.ErrUnknownGrantee
+		}
+	}
+
+	stored.Grant = slices.Clone(grant)
+	slices.Sort(stored.Grant)
+	stored.Grant = slices.Compact(stored.Grant)
+
+	s.logger.Info("SetFolderGrants: successfully set folder grants", zap.String("id", id), zap.Int("count", len(grant)))
+	return nil
+}
+
+// folderChain returns the folder id and all its parents, nearest first. The caller holds the lock.
+func (s *Store) folderChain(id string) []*storedFolder {
+	var chain []*storedFolder
+
+	for id != "" {
+		folder, ok := s.folders[id]
+		if !ok || slices.Contains(chain, folder) {
+			break
+		}
+
+		chain = append(chain, folder)
+		id = folder.ParentId
+	}
+
+	return chain
+}
+
+// sharedWith reports whether folder id or one of its parents is shared with login.
+func (s *Store) sharedWith(id string, login string) bool {
+	for _, folder := range s.folderChain(id) {
+		if slices.Contains(folder.Grant, login) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Store) findFolder(login string, parentId string, name string) (*storedFolder, bool) {
+	for _, folder := range s.folders {
+		if folder.Login == login && folder.ParentId == parentId && folder.Name == name {
+			return folder, true
+		}
+	}
+
+	return nil, false
+}
+
+// nameTaken reports whether login already has a folder other than except named name in parentId.
+func (s *Store) nameTaken(login string, parentId string, name string, except string) bool {
+	folder, ok := s.findFolder(login, parentId, name)
+
+	return ok && folder.Id != except
+}
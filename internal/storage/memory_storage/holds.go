@@ -0,0 +1,193 @@
+package memoryStorage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"astral/internal/documents"
+	"astral/internal/storage/postgres_client"
+)
+
+func (s *Store) CreateHold(_ context.Context, hold *documents.Hold) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if hold.DocId != "" {
+		if _, ok := s.docs[hold.DocId]; !ok {
+			s.logger.Warn("CreateHold: document not found", zap.String("doc", hold.DocId))
+			return postgresClient.ErrDocumentNotFound
+		}
+	}
+
+	if hold.Login != "" {
+		if _, ok := s.users[hold.Login]; !ok {
+			s.logger.Warn("CreateHold: user not found", zap.String("login", hold.Login))
+			return postgresClient.ErrUserNotFound
+		}
+	}
+
+	if _, ok := s.holds[hold.Id]; ok {
+		s.logger.Error("CreateHold: hold id is taken", zap.String("id", hold.Id))
+		return fmt.Errorf("CreateHold: hold %s already exists", hold.Id)
+	}
+
+	s.holds[hold.Id] = *hold
+
+	s.logger.Info("CreateHold: successfully create hold", zap.String("id", hold.Id))
+	return nil
+}
+
+func (s *Store) ListHolds(_ context.Context) ([]documents.Hold, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.sortedHolds(), nil
+}
+
+// DeleteHold releases a legal hold.
+func (s *Store) DeleteHold(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.holds[id]; !ok {
+		s.logger.Warn("DeleteHold: hold not found", zap.String("id", id))
+		return postgresClient.ErrHoldNotFound
+	}
+
+	delete(s.holds, id)
+
+	s.logger.Info("DeleteHold: successfully release hold", zap.String("id", id))
+	return nil
+}
+
+// SaveRetentionRule creates a retention rule or replaces the one with the same name.
+func (s *Store) SaveRetentionRule(_ context.Context, rule *documents.RetentionRule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved := *rule
+	if existing, ok := s.rules[rule.Name]; ok {
+		saved.CreatedAt = existing.CreatedAt
+	}
+
+	s.rules[rule.Name] = saved
+
+	s.logger.Info("SaveRetentionRule: successfully save rule", zap.String("name", rule.Name))
+	return nil
+}
+
+func (s *Store) ListRetentionRules(_ context.Context) ([]documents.RetentionRule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rules := make([]documents.RetentionRule, 0, len(s.rules))
+	for _, rule := range s.rules {
+		rules = append(rules, rule)
+	}
+
+	slices.SortFunc(rules, func(a, b documents.RetentionRule) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return rules, nil
+}
+
+func (s *Store) DeleteRetentionRule(_ context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.rules[name]; !ok {
+		s.logger.Warn("DeleteRetentionRule: rule not found", zap.String("name", name))
+		return postgresClient.ErrRuleNotFound
+	}
+
+	delete(s.rules, name)
+
+	s.logger.Info("DeleteRetentionRule: successfully delete rule", zap.String("name", name))
+	return nil
+}
+
+// held reports whether a document is under a legal hold or kept by a retention rule.
+func (s *Store) held(stored *storedDocument) bool {
+	_, ok := s.holdReason(stored)
+	return ok
+}
+
+// holdReason explains why a document is held, legal holds go first.
+func (s *Store) holdReason(stored *storedDocument) (string, bool) {
+	for _, hold := range s.sortedHolds() {
+		if hold.DocId == stored.Id || hold.Login == stored.Login {
+			return "legal hold: " + hold.Reason, true
+		}
+	}
+
+	var (
+		reason string
+		oldest *documents.RetentionRule
+	)
+
+	for _, rule := range s.rules {
+		if rule.Tag != "" && !slices.Contains(stored.Tags, rule.Tag) {
+			continue
+		}
+
+		if rule.Schema != "" && rule.Schema != stored.Schema {
+			continue
+		}
+
+		keepUntil := stored.CreatedAt.AddDate(0, 0, rule.KeepDays)
+		if !keepUntil.After(s.now()) {
+			continue
+		}
+
+		if oldest == nil || rule.CreatedAt.Before(oldest.CreatedAt) {
+			oldest = &rule
+			reason = "retention rule " + rule.Name + " keeps the document until " + keepUntil.Format("2006-01-02")
+		}
+	}
+
+	return reason, oldest != nil
+}
+
+// sortedHolds returns the legal holds oldest first. The caller holds the lock.
+func (s *Store) sortedHolds() []documents.Hold {
+	holds := make([]documents.Hold, 0, len(s.holds))
+	for _, hold := range s.holds {
+		holds = append(holds, hold)
+	}
+
+	slices.SortFunc(holds, func(a, b documents.Hold) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.Id, b.Id)
+	})
+
+	return holds
+}
+
+// notChanged explains why a guarded change of caller did not touch document id:
+// a *HoldError when the document is held, ErrDocumentNotFound otherwise. Only a document
+// of login, any owner when it is empty, that is in the trash exactly when trashed is set
+// reports its hold.
+func (s *Store) notChanged(caller string, id string, login string, trashed bool) error {
+	stored, ok := s.docs[id]
+	if !ok || (login != "" && stored.Login != login) || stored.DeletedAt.IsZero() == trashed {
+		s.logger.Warn(caller+": document not found", zap.String("id", id))
+		return postgresClient.ErrDocumentNotFound
+	}
+
+	reason, held := s.holdReason(stored)
+	if !held {
+		s.logger.Warn(caller+": document not found", zap.String("id", id))
+		return postgresClient.ErrDocumentNotFound
+	}
+
+	s.logger.Warn(caller+": document is held", zap.String("id", id), zap.String("reason", reason))
+	return &postgresClient.HoldError{Reason: reason}
+}
@@ -0,0 +1,171 @@
+package memoryStorage
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"astral/internal/documents"
+	"astral/internal/storage/matcher"
+)
+
+// ListDocuments returns documents matching query without their content, newest first.
+func (s *Store) ListDocuments(_ context.Context, query *documents.ListQuery) ([]documents.Document, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matched, err := s.filter(query)
+	if err != nil {
+		s.logger.Error("ListDocuments: failed to list documents", zap.Error(err))
+		return nil, fmt.Errorf("ListDocuments: failed to list documents: %w", err)
+	}
+
+	slices.SortFunc(matched, newestFirst)
+
+	docs := make([]documents.Document, 0)
+
+	for _, stored := range page(matched, query) {
+		docs = append(docs, listed(stored))
+	}
+
+	return docs, nil
+}
+
+// SearchDocuments runs the full-text query.Search over names and extracted text
+// and returns the matching documents ranked, with highlighted snippets.
+func (s *Store) SearchDocuments(_ context.Context, query *documents.ListQuery) ([]documents.SearchResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	search := matcher.ParseSearch(query.Search)
+
+	matched, err := s.filter(query)
+	if err != nil {
+		s.logger.Error("SearchDocuments: failed to search documents", zap.Error(err))
+		return nil, fmt.Errorf("SearchDocuments: failed to search documents: %w", err)
+	}
+
+	var results []documents.SearchResult
+
+	for _, stored := range matched {
+		rank, ok := search.Rank(stored.Name, stored.Text)
+		if !ok {
+			continue
+		}
+
+		results = append(results, documents.SearchResult{
+			Document: listed(stored),
+			Rank:     rank,
+			Snippet:  search.Headline(stored.Name + "\n" + stored.Text),
+		})
+	}
+
+	slices.SortFunc(results, func(a, b documents.SearchResult) int {
+		if c := cmp.Compare(b.Rank, a.Rank); c != 0 {
+			return c
+		}
+
+		return newestFirst(&storedDocument{Document: a.Document}, &storedDocument{Document: b.Document})
+	})
+
+	return append([]documents.SearchResult{}, page(results, query)...), nil
+}
+
+// filter returns the documents query.Login can read that match the filters shared by listing and search.
+// The caller holds the lock.
+func (s *Store) filter(query *documents.ListQuery) ([]*storedDocument, error) {
+	now := s.now()
+
+	jsonFilter, err := matcher.NewJSONFilter(query.JSONContains, query.JSONPath)
+	if err != nil {
+		return nil, fmt.Errorf("filter: %w", err)
+	}
+
+	var matched []*storedDocument
+
+	for _, stored := range s.docs {
+		if !stored.DeletedAt.IsZero() || stored.Expired(now) || !s.readable(stored, query.Login) {
+			continue
+		}
+
+		if query.Owner != "" && stored.Login != query.Owner {
+			continue
+		}
+
+		if query.Folder != nil && stored.FolderId != *query.Folder {
+			continue
+		}
+
+		if !containsAll(stored.Tags, query.Tags) || !containsEntries(stored.Metadata, query.Metadata) {
+			continue
+		}
+
+		if !jsonFilter.Match(stored.JSON) {
+			continue
+		}
+
+		matched = append(matched, stored)
+	}
+
+	return matched, nil
+}
+
+// readable reports whether login may read the document: the owner, anybody for public documents,
+// the logins it is granted to and the logins one of its folders is shared with.
+func (s *Store) readable(stored *storedDocument, login string) bool {
+	if stored.Login == login || stored.Public || slices.Contains(stored.Grant, login) {
+		return true
+	}
+
+	return stored.FolderId != "" && s.sharedWith(stored.FolderId, login)
+}
+
+// listed returns a document as listings show it: without content and with its direct grants only.
+func listed(stored *storedDocument) documents.Document {
+	document := cloneInfo(&stored.Document)
+
+	document.Grant = cloneTags(stored.Grant)
+	slices.Sort(document.Grant)
+
+	return document
+}
+
+func newestFirst(a, b *storedDocument) int {
+	if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+		return c
+	}
+
+	return strings.Compare(a.Id, b.Id)
+}
+
+// page applies the limit and offset of query.
+func page[T any](items []T, query *documents.ListQuery) []T {
+	start := min(max(query.Offset, 0), len(items))
+	end := min(start+max(query.Limit, 0), len(items))
+
+	return items[start:end]
+}
+
+func containsAll(have []string, want []string) bool {
+	for _, value := range want {
+		if !slices.Contains(have, value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func containsEntries(have map[string]string, want map[string]string) bool {
+	for key, value := range want {
+		if got, ok := have[key]; !ok || got != value {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,125 @@
+package memoryStorage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"go.uber.org/zap"
+
+	"astral/internal/documents"
+	"astral/internal/storage/postgres_client"
+)
+
+// GetUsage returns the storage login uses together with its effective quota.
+func (s *Store) GetUsage(_ context.Context, login string) (*documents.Usage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.users[login]
+	if !ok {
+		s.logger.Warn("GetUsage: user not found", zap.String("login", login))
+		return nil, postgresClient.ErrUserNotFound
+	}
+
+	usage := s.applyQuota(u)
+
+	return &usage, nil
+}
+
+// SetQuota overrides the default quota of login. A nil limit falls back to the default, zero means unlimited.
+func (s *Store) SetQuota(_ context.Context, login string, maxDocuments *int64, maxBytes *int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[login]
+	if !ok {
+		s.logger.Warn("SetQuota: user not found", zap.String("login", login))
+		return postgresClient.ErrUserNotFound
+	}
+
+	u.maxDocuments = cloneLimit(maxDocuments)
+	u.maxBytes = cloneLimit(maxBytes)
+
+	s.logger.Info("SetQuota: successfully set quota", zap.String("login", login))
+	return nil
+}
+
+// SaveSchema registers a named JSON schema of a user, replacing the schema with the same name.
+func (s *Store) SaveSchema(_ context.Context, login string, name string, schema []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[login]; !ok {
+		s.logger.Error("SaveSchema: user not found", zap.String("login", login))
+		return fmt.Errorf("SaveSchema: failed to save schema: %w", postgresClient.ErrUserNotFound)
+	}
+
+	s.schemas[schemaKey{login: login, name: name}] = slices.Clone(schema)
+
+	s.logger.Info("SaveSchema: successfully save schema", zap.String("login", login), zap.String("name", name))
+	return nil
+}
+
+func (s *Store) GetSchema(_ context.Context, login string, name string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	schema, ok := s.schemas[schemaKey{login: login, name: name}]
+	if !ok {
+		s.logger.Warn("GetSchema: schema not found", zap.String("login", login), zap.String("name", name))
+		return nil, postgresClient.ErrSchemaNotFound
+	}
+
+	return slices.Clone(schema), nil
+}
+
+// chargeUsage adds bytes and documents to the usage of login, or returns
+// documents.ErrQuotaExceeded or documents.ErrDocumentTooLarge when they do not fit.
+// The caller holds the lock.
+func (s *Store) chargeUsage(login string, bytes int64, docs int64) error {
+	u, ok := s.users[login]
+	if !ok {
+		s.logger.Error("chargeUsage: user not found", zap.String("login", login))
+		return fmt.Errorf("chargeUsage: %w", postgresClient.ErrUserNotFound)
+	}
+
+	usage := s.applyQuota(u)
+
+	err := usage.Check(bytes, docs)
+	if err != nil {
+		s.logger.Warn("chargeUsage: quota exceeded", zap.String("login", login), zap.Error(err))
+		return err
+	}
+
+	u.usage.Bytes += bytes
+	u.usage.Documents += docs
+
+	return nil
+}
+
+// applyQuota returns the usage of u with the limits from its overrides or the configured defaults.
+func (s *Store) applyQuota(u *user) documents.Usage {
+	usage := u.usage
+
+	usage.MaxDocuments = s.quotaDocuments
+	if u.maxDocuments != nil {
+		usage.MaxDocuments = *u.maxDocuments
+	}
+
+	usage.MaxBytes = s.quotaBytes
+	if u.maxBytes != nil {
+		usage.MaxBytes = *u.maxBytes
+	}
+
+	return usage
+}
+
+func cloneLimit(limit *int64) *int64 {
+	if limit == nil {
+		return nil
+	}
+
+	clone := *limit
+	return &clone
+}
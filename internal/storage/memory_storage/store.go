@@ -0,0 +1,712 @@
+package memoryStorage
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	"astral/internal/documents"
+	"astral/internal/storage/postgres_client"
+)
+
+func NewStore(config *postgresClient.Config, logger *zap.Logger) *Store {
+	return &Store{
+		logger:           logger,
+		now:              time.Now,
+		versionsKeepLast: config.VersionsKeepLast,
+		versionsKeepDays: config.VersionsKeepDays,
+		quotaDocuments:   config.QuotaDocuments,
+		quotaBytes:       config.QuotaBytes,
+		users:            make(map[string]*user),
+		docs:             make(map[string]*storedDocument),
+		folders:          make(map[string]*storedFolder),
+		holds:            make(map[string]documents.Hold),
+		rules:            make(map[string]documents.RetentionRule),
+		schemas:          make(map[schemaKey][]byte),
+	}
+}
+
+func (s *Store) SaveUser(_ context.Context, login string, passwordHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[login]; ok {
+		s.logger.Warn("SaveUser: duplicate login")
+		return postgresClient.ErrDuplicateLogin
+	}
+
+	s.users[login] = &user{passwordHash: passwordHash}
+
+	s.logger.Info("SaveUser: successfully save user")
+	return nil
+}
+
+// GetPasswordHash returns pgx.ErrNoRows for an unknown login, like the Postgres client.
+func (s *Store) GetPasswordHash(_ context.Context, login string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.users[login]
+	if !ok {
+		s.logger.Warn("GetPasswordHash: no password hash found")
+		return "", pgx.ErrNoRows
+	}
+
+	return u.passwordHash, nil
+}
+
+func (s *Store) SaveDocument(_ context.Context, document *documents.Document) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.docs[document.Id]; ok {
+		s.logger.Error("SaveDocument: document already exists", zap.String("id", document.Id))
+		return fmt.Errorf("SaveDocument: document %s already exists", document.Id)
+	}
+
+	if document.FolderId != "" {
+		if _, ok := s.folders[document.FolderId]; !ok {
+			s.logger.Error("SaveDocument: folder not found", zap.String("folder", document.FolderId))
+			return fmt.Errorf("SaveDocument: failed to save document: %w", postgresClient.ErrFolderNotFound)
+		}
+	}
+
+	for _, grantee := range document.Grant {
+		if _, ok := s.users[grantee]; !ok {
+			return fmt.Errorf("SaveDocument: failed to save grant: %w", postgresClient.ErrUnknownGrantee)
+		}
+	}
+
+	err := s.chargeUsage(document.Login, document.UsageBytes(), 1)
+	if err != nil {
+		return fmt.Errorf("SaveDocument: %w", err)
+	}
+
+	stored := &storedDocument{
+		Document:   cloneDocument(document),
+		usageBytes: document.UsageBytes(),
+	}
+	stored.Version = 1
+	stored.UpdatedAt = document.CreatedAt
+	stored.DeletedAt = time.Time{}
+	stored.Sealed = false
+
+	s.docs[document.Id] = stored
+
+	s.logger.Info("SaveDocument: successfully save document")
+	return nil
+}
+
+func (s *Store) GetDocument(_ context.Context, id string) (*documents.Document, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stored, err := s.liveDocument("GetDocument", id)
+	if err != nil {
+		return nil, err
+	}
+
+	document := cloneDocument(&stored.Document)
+	document.Grant = s.documentGrants(stored)
+
+	s.logger.Info("GetDocument: successfully get document", zap.String("id", id))
+	return &document, nil
+}
+
+// GetDocumentForWrite is GetDocument, the store has no replicas to lag behind.
+func (s *Store) GetDocumentForWrite(ctx context.Context, id string) (*documents.Document, error) {
+	return s.GetDocument(ctx, id)
+}
+
+// GetDocumentInfo returns a document with its grants like GetDocument, but without content and JSON.
+func (s *Store) GetDocumentInfo(_ context.Context, id string) (*documents.Document, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stored, err := s.liveDocument("GetDocumentInfo", id)
+	if err != nil {
+		return nil, err
+	}
+
+	document := cloneInfo(&stored.Document)
+	document.Grant = s.documentGrants(stored)
+
+	return &document, nil
+}
+
+// GetStats counts the documents of login that are neither trashed nor expired.
+func (s *Store) GetStats(_ context.Context, login string) (*documents.Stats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var stats documents.Stats
+
+	now := s.now()
+
+	for _, stored := range s.docs {
+		if stored.Login != login || !stored.DeletedAt.IsZero() || stored.Expired(now) {
+			continue
+		}
+
+		stats.Documents++
+		stats.Size += stored.Size
+		stats.StoredSize += int64(len(stored.Content))
+	}
+
+	return &stats, nil
+}
+
+// UpdateDocument replaces the content of a document and keeps the previous one as a version.
+// When expectedVersion is positive the update only succeeds if it is still the current version.
+// On success document.Version holds the new version.
+// The new content is charged to the owner once pruned versions are given back; when it does not
+// fit the quota the update fails with documents.ErrQuotaExceeded and nothing changes.
+func (s *Store) UpdateDocument(_ context.Context, document *documents.Document, expectedVersion int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, err := s.lockDocument(document.Id)
+	if err != nil {
+		return fmt.Errorf("UpdateDocument: %w", err)
+	}
+
+	if expectedVersion > 0 && current.Version != expectedVersion {
+		s.logger.Warn("UpdateDocument: version conflict",
+			zap.Int("expected", expectedVersion),
+			zap.Int("current", current.Version),
+		)
+		return postgresClient.ErrVersionConflict
+	}
+
+	// The replaced content stays charged as a version, the new one comes on top.
+	err = s.chargeUsage(current.Login, document.UsageBytes()-s.prunableBytes(current, current.Version+1), 0)
+	if err != nil {
+		return fmt.Errorf("UpdateDocument: %w", err)
+	}
+
+	s.archive(current)
+
+	updated := cloneDocument(document)
+
+	current.Name = updated.Name
+	current.Mime = updated.Mime
+	current.DetectedMime = updated.DetectedMime
+	current.File = updated.File
+	current.Content = updated.Content
+	current.JSON = updated.JSON
+	current.Codec = updated.Codec
+	current.Size = updated.Size
+	current.UpdatedAt = updated.UpdatedAt
+	current.Schema = updated.Schema
+	current.Text = updated.Text
+	current.Tags = updated.Tags
+	current.Metadata = updated.Metadata
+	current.Version++
+	current.usageBytes = document.UsageBytes()
+
+	document.Version = current.Version
+
+	s.pruneVersions(current)
+
+	s.logger.Info("UpdateDocument: successfully update document",
+		zap.String("id", document.Id),
+		zap.Int("version", document.Version),
+	)
+	return nil
+}
+
+// UpdateLabels replaces tags and metadata of a document. Labels are not versioned,
+// so the document version stays the same.
+func (s *Store) UpdateLabels(_ context.Context, id string, tags []string, metadata map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.docs[id]
+	if !ok || !stored.DeletedAt.IsZero() {
+		s.logger.Warn("UpdateLabels: document not found", zap.String("id", id))
+		return postgresClient.ErrDocumentNotFound
+	}
+
+	stored.Tags = cloneTags(tags)
+	stored.Metadata = cloneMetadata(metadata)
+
+	s.logger.Info("UpdateLabels: successfully update labels", zap.String("id", id))
+	return nil
+}
+
+// ListVersions returns the archived versions of a document without content, newest first.
+func (s *Store) ListVersions(_ context.Context, id string) ([]documents.Document, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stored, ok := s.docs[id]
+	if !ok {
+		return []documents.Document{}, nil
+	}
+
+	versions := make([]documents.Document, 0, len(stored.versions))
+
+	for i := len(stored.versions) - 1; i >= 0; i-- {
+		v := stored.versions[i]
+
+		versions = append(versions, documents.Document{
+			Id:        id,
+			Version:   v.Version,
+			Name:      v.Name,
+			Mime:      v.Mime,
+			File:      v.File,
+			Codec:     v.Codec,
+			Size:      v.Size,
+			CreatedAt: v.CreatedAt,
+		})
+	}
+
+	return versions, nil
+}
+
+// GetVersion returns an archived version of a document with its content.
+// Ownership and grants are not part of a version and stay empty.
+func (s *Store) GetVersion(_ context.Context, id string, version int) (*documents.Document, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	v, ok := s.findVersion(id, version)
+	if !ok {
+		s.logger.Warn("GetVersion: version not found", zap.String("id", id), zap.Int("version", version))
+		return nil, postgresClient.ErrVersionNotFound
+	}
+
+	return &documents.Document{
+		Id:        id,
+		Version:   version,
+		Name:      v.Name,
+		Mime:      v.Mime,
+		File:      v.File,
+		Content:   slices.Clone(v.Content),
+		JSON:      slices.Clone(v.JSON),
+		Codec:     v.Codec,
+		Size:      v.Size,
+		CreatedAt: v.CreatedAt,
+	}, nil
+}
+
+// RestoreVersion makes an archived version current again. The replaced content
+// is archived like on any other update. Returns the new version number.
+// The restored copy is charged to the owner like new content, documents.ErrQuotaExceeded
+// is returned when it does not fit.
+func (s *Store) RestoreVersion(_ context.Context, id string, version int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, err := s.lockDocument(id)
+	if err != nil {
+		return 0, fmt.Errorf("RestoreVersion: %w", err)
+	}
+
+	v, ok := s.findVersion(id, version)
+	if !ok {
+		s.logger.Warn("RestoreVersion: version not found", zap.String("id", id), zap.Int("version", version))
+		return 0, postgresClient.ErrVersionNotFound
+	}
+
+	// The restored content is a copy of the version, which is kept, so it is charged in full.
+	err = s.chargeUsage(current.Login, v.usageBytes-s.prunableBytes(current, current.Version+1), 0)
+	if err != nil {
+		return 0, fmt.Errorf("RestoreVersion: %w", err)
+	}
+
+	restored := cloneDocument(&v.Document)
+
+	s.archive(current)
+
+	current.Name = restored.Name
+	current.Mime = restored.Mime
+	current.DetectedMime = restored.DetectedMime
+	current.File = restored.File
+	current.Content = restored.Content
+	current.JSON = restored.JSON
+	current.Codec = restored.Codec
+	current.Size = restored.Size
+	current.Schema = restored.Schema
+	current.Text = restored.Text
+	current.UpdatedAt = s.now()
+	current.Version++
+	current.usageBytes = v.usageBytes
+
+	s.pruneVersions(current)
+
+	s.logger.Info("RestoreVersion: successfully restore version",
+		zap.String("id", id),
+		zap.Int("restored", version),
+		zap.Int("version", current.Version),
+	)
+	return current.Version, nil
+}
+
+// RewrapDataKeys has nothing to do: documents in memory are never stored encrypted.
+func (s *Store) RewrapDataKeys(_ context.Context) (int, error) {
+	return 0, nil
+}
+
+// DeleteDocument moves a document to the trash of its owner. A trashed document is
+// hidden from reads and listings until it is restored or purged. Held documents can not be deleted.
+func (s *Store) DeleteDocument(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.docs[id]
+	if !ok || !stored.DeletedAt.IsZero() || s.held(stored) {
+		return s.notChanged("DeleteDocument", id, "", false)
+	}
+
+	stored.DeletedAt = s.now()
+
+	s.logger.Info("DeleteDocument: successfully move document to trash", zap.String("id", id))
+	return nil
+}
+
+// ListTrash returns the trashed documents of login without content, most recently deleted first.
+func (s *Store) ListTrash(_ context.Context, login string) ([]documents.Document, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	trashed := make([]documents.Document, 0)
+
+	for _, stored := range s.docs {
+		if stored.Login != login || stored.DeletedAt.IsZero() {
+			continue
+		}
+
+		document := cloneInfo(&stored.Document)
+		document.Grant = nil
+		document.ExpiresAt = nil
+
+		trashed = append(trashed, document)
+	}
+
+	slices.SortFunc(trashed, func(a, b documents.Document) int {
+		if c := b.DeletedAt.Compare(a.DeletedAt); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.Id, b.Id)
+	})
+
+	return trashed, nil
+}
+
+// RestoreDocument takes a document of login out of the trash.
+func (s *Store) RestoreDocument(_ context.Context, id string, login string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.docs[id]
+	if !ok || stored.Login != login || stored.DeletedAt.IsZero() {
+		s.logger.Warn("RestoreDocument: document not found in trash", zap.String("id", id))
+		return postgresClient.ErrDocumentNotFound
+	}
+
+	stored.DeletedAt = time.Time{}
+
+	s.logger.Info("RestoreDocument: successfully restore document", zap.String("id", id))
+	return nil
+}
+
+// PurgeDocument removes a trashed document of login for good, together with its versions and grants.
+// Held documents can not be purged.
+func (s *Store) PurgeDocument(_ context.Context, id string, login string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.docs[id]
+	if !ok || stored.Login != login || stored.DeletedAt.IsZero() || s.held(stored) {
+		return s.notChanged("PurgeDocument", id, login, true)
+	}
+
+	s.purge(stored)
+
+	s.logger.Info("PurgeDocument: successfully purge document", zap.String("id", id))
+	return nil
+}
+
+// PurgeDocuments removes up to limit documents that were trashed before the given time
+// and returns their ids and owners. Held documents stay in the trash.
+func (s *Store) PurgeDocuments(_ context.Context, before time.Time, limit int) ([]documents.Document, error) {
+	purged := s.purgeWhere(limit, func(stored *storedDocument) bool {
+		return !stored.DeletedAt.IsZero() && stored.DeletedAt.Before(before)
+	})
+
+	if len(purged) > 0 {
+		s.logger.Info("PurgeDocuments: purged documents", zap.Int("count", len(purged)))
+	}
+
+	return purged, nil
+}
+
+// UpdateExpiry sets when a document expires, nil keeps it forever. Held documents can not get an expiry.
+func (s *Store) UpdateExpiry(_ context.Context, id string, expiresAt *time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.docs[id]
+	if !ok || !stored.DeletedAt.IsZero() || (expiresAt != nil && s.held(stored)) {
+		return s.notChanged("UpdateExpiry", id, "", false)
+	}
+
+	stored.ExpiresAt = cloneTime(expiresAt)
+
+	s.logger.Info("UpdateExpiry: successfully update expiry", zap.String("id", id))
+	return nil
+}
+
+// PurgeExpired removes up to limit documents that expired by now, trashed or not,
+// and returns their ids and owners. Held documents are kept.
+func (s *Store) PurgeExpired(_ context.Context, now time.Time, limit int) ([]documents.Document, error) {
+	purged := s.purgeWhere(limit, func(stored *storedDocument) bool {
+		return stored.Expired(now)
+	})
+
+	if len(purged) > 0 {
+		s.logger.Info("PurgeExpired: purged documents", zap.Int("count", len(purged)))
+	}
+
+	return purged, nil
+}
+
+// PoolStats returns nothing, the store has no connection pools.
+func (s *Store) PoolStats() []postgresClient.PoolStats {
+	return nil
+}
+
+func (s *Store) Close() {}
+
+// liveDocument returns a document that is neither trashed nor expired. The caller holds the lock.
+func (s *Store) liveDocument(caller string, id string) (*storedDocument, error) {
+	stored, ok := s.docs[id]
+	if !ok || !stored.DeletedAt.IsZero() {
+		s.logger.Warn(caller+": document not found", zap.String("id", id))
+		return nil, postgresClient.ErrDocumentNotFound
+	}
+
+	if stored.Expired(s.now()) {
+		s.logger.Warn(caller+": document expired", zap.String("id", id))
+		return nil, postgresClient.ErrDocumentNotFound
+	}
+
+	return stored, nil
+}
+
+// lockDocument returns a document that is not trashed for an update. The caller holds the lock.
+func (s *Store) lockDocument(id string) (*storedDocument, error) {
+	stored, ok := s.docs[id]
+	if !ok || !stored.DeletedAt.IsZero() {
+		s.logger.Warn("lockDocument: document not found", zap.String("id", id))
+		return nil, postgresClient.ErrDocumentNotFound
+	}
+
+	return stored, nil
+}
+
+// documentGrants returns the logins a document is shared with directly or through its folders.
+func (s *Store) documentGrants(stored *storedDocument) []string {
+	grant := slices.Clone(stored.Grant)
+
+	for _, folder := range s.folderChain(stored.FolderId) {
+		grant = append(grant, folder.Grant...)
+	}
+
+	slices.Sort(grant)
+
+	return slices.Compact(grant)
+}
+
+// archive keeps the current content of a document as a version.
+func (s *Store) archive(stored *storedDocument) {
+	version := cloneDocument(&stored.Document)
+	version.CreatedAt = stored.UpdatedAt
+
+	stored.versions = append(stored.versions, storedVersion{
+		Document:   version,
+		usageBytes: stored.usageBytes,
+		archivedAt: s.now(),
+	})
+}
+
+// pruneVersions removes archived versions that fall outside the configured retention.
+// A held document keeps them all, how many were kept is logged.
+func (s *Store) pruneVersions(stored *storedDocument) {
+	if s.held(stored) {
+		kept := 0
+		for _, v := range stored.versions {
+			if s.expired(v, stored.Version) {
+				kept++
+			}
+		}
+
+		if kept > 0 {
+			s.logger.Info("pruneVersions: kept expired versions of a held document", zap.String("id", stored.Id), zap.Int("count", kept))
+		}
+
+		return
+	}
+
+	before := len(stored.versions)
+
+	stored.versions = slices.DeleteFunc(stored.versions, func(v storedVersion) bool {
+		return s.expired(v, stored.Version)
+	})
+
+	if pruned := before - len(stored.versions); pruned > 0 {
+		s.logger.Info("pruneVersions: pruned versions", zap.String("id", stored.Id), zap.Int("count", pruned))
+	}
+}
+
+// prunableBytes returns the bytes of the versions pruneVersions removes once the document is at version current.
+// They are given back to the owner in the same charge that takes the new content.
+func (s *Store) prunableBytes(stored *storedDocument, current int) int64 {
+	if s.held(stored) {
+		return 0
+	}
+
+	var freed int64
+
+	for _, v := range stored.versions {
+		if s.expired(v, current) {
+			freed += v.usageBytes
+		}
+	}
+
+	return freed
+}
+
+// expired reports whether a version falls outside the configured retention of a document at version current.
+func (s *Store) expired(v storedVersion, current int) bool {
+	if s.versionsKeepLast > 0 && v.Version < current-s.versionsKeepLast {
+		return true
+	}
+
+	return s.versionsKeepDays > 0 && v.archivedAt.Before(s.now().AddDate(0, 0, -s.versionsKeepDays))
+}
+
+func (s *Store) findVersion(id string, version int) (*storedVersion, bool) {
+	stored, ok := s.docs[id]
+	if !ok {
+		return nil, false
+	}
+
+	for i := range stored.versions {
+		if stored.versions[i].Version == version {
+			return &stored.versions[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// purgeWhere removes up to limit documents matching cond that are not held, oldest first.
+func (s *Store) purgeWhere(limit int, cond func(stored *storedDocument) bool) []documents.Document {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var candidates []*storedDocument
+
+	for _, stored := range s.docs {
+		if cond(stored) && !s.held(stored) {
+			candidates = append(candidates, stored)
+		}
+	}
+
+	slices.SortFunc(candidates, func(a, b *storedDocument) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.Id, b.Id)
+	})
+
+	purged := make([]documents.Document, 0, min(limit, len(candidates)))
+
+	for _, stored := range candidates[:min(limit, len(candidates))] {
+		s.purge(stored)
+		purged = append(purged, documents.Document{Id: stored.Id, Login: stored.Login})
+	}
+
+	return purged
+}
+
+// purge removes a document with its versions and legal holds and gives its storage back to the owner.
+func (s *Store) purge(stored *storedDocument) {
+	delete(s.docs, stored.Id)
+
+	for id, hold := range s.holds {
+		if hold.DocId == stored.Id {
+			delete(s.holds, id)
+		}
+	}
+
+	if u, ok := s.users[stored.Login]; ok {
+		u.usage.Documents--
+		u.usage.Bytes -= stored.usageBytes
+
+		for _, v := range stored.versions {
+			u.usage.Bytes -= v.usageBytes
+		}
+	}
+}
+
+func cloneDocument(document *documents.Document) documents.Document {
+	clone := *document
+	clone.Grant = slices.Clone(document.Grant)
+	clone.Content = slices.Clone(document.Content)
+	clone.JSON = slices.Clone(document.JSON)
+	clone.Tags = cloneTags(document.Tags)
+	clone.Metadata = cloneMetadata(document.Metadata)
+	clone.ExpiresAt = cloneTime(document.ExpiresAt)
+
+	return clone
+}
+
+// cloneInfo copies a document without its content and JSON.
+func cloneInfo(document *documents.Document) documents.Document {
+	info := *document
+	info.Content, info.JSON, info.Text = nil, nil, ""
+	info.Grant = slices.Clone(document.Grant)
+	info.Tags = cloneTags(document.Tags)
+	info.Metadata = cloneMetadata(document.Metadata)
+	info.ExpiresAt = cloneTime(document.ExpiresAt)
+
+	return info
+}
+
+// cloneTags copies tags, a missing value becomes empty like the column default.
+func cloneTags(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+
+	return slices.Clone(tags)
+}
+
+// cloneMetadata copies metadata, a missing value becomes empty like the column default.
+func cloneMetadata(metadata map[string]string) map[string]string {
+	if metadata == nil {
+		return map[string]string{}
+	}
+
+	return maps.Clone(metadata)
+}
+
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	clone := *t
+	return &clone
+}
@@ -0,0 +1,359 @@
+package memoryStorage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"astral/internal/documents"
+	"astral/internal/storage/postgres_client"
+)
+
+func newStore(t *testing.T, config *postgresClient.Config) (*Store, *time.Time) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	s := NewStore(config, zap.NewNop())
+	s.now = func() time.Time { return now }
+
+	for _, login := range []string{"alice", "bob", "carol"} {
+		require.NoError(t, s.SaveUser(context.Background(), login, "hash"))
+	}
+
+	return s, &now
+}
+
+func newDocument(id string, login string, created time.Time) *documents.Document {
+	return &documents.Document{
+		Id:        id,
+		Login:     login,
+		Name:      id + ".txt",
+		File:      true,
+		Content:   []byte("content of " + id),
+		Size:      int64(len("content of " + id)),
+		CreatedAt: created,
+	}
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		config postgresClient.Config
+		run    func(s *Store, now *time.Time) error
+		err    error
+	}{
+		{
+			name: "duplicate login",
+			run: func(s *Store, _ *time.Time) error {
+				return s.SaveUser(ctx, "alice", "hash")
+			},
+			err: postgresClient.ErrDuplicateLogin,
+		},
+		{
+			name: "grant to unknown user",
+			run: func(s *Store, now *time.Time) error {
+				document := newDocument("a", "alice", *now)
+				document.Grant = []string{"mallory"}
+
+				return s.SaveDocument(ctx, document)
+			},
+			err: postgresClient.ErrUnknownGrantee,
+		},
+		{
+			name:   "quota exceeded",
+			config: postgresClient.Config{QuotaDocuments: 1},
+			run: func(s *Store, now *time.Time) error {
+				if err := s.SaveDocument(ctx, newDocument("a", "alice", *now)); err != nil {
+					return err
+				}
+
+				return s.SaveDocument(ctx, newDocument("b", "alice", *now))
+			},
+			err: documents.ErrQuotaExceeded,
+		},
+		{
+			name: "version conflict",
+			run: func(s *Store, now *time.Time) error {
+				if err := s.SaveDocument(ctx, newDocument("a", "alice", *now)); err != nil {
+					return err
+				}
+
+				if err := s.UpdateDocument(ctx, newDocument("a", "alice", *now), 1); err != nil {
+					return err
+				}
+
+				return s.UpdateDocument(ctx, newDocument("a", "alice", *now), 1)
+			},
+			err: postgresClient.ErrVersionConflict,
+		},
+		{
+			name: "held document can not be deleted",
+			run: func(s *Store, now *time.Time) error {
+				if err := s.SaveDocument(ctx, newDocument("a", "alice", *now)); err != nil {
+					return err
+				}
+
+				if err := s.CreateHold(ctx, &documents.Hold{Id: "h", Login: "alice", Reason: "audit", CreatedAt: *now}); err != nil {
+					return err
+				}
+
+				return s.DeleteDocument(ctx, "a")
+			},
+			err: postgresClient.ErrDocumentHeld,
+		},
+		{
+			name: "trashed document is not found",
+			run: func(s *Store, now *time.Time) error {
+				if err := s.SaveDocument(ctx, newDocument("a", "alice", *now)); err != nil {
+					return err
+				}
+
+				if err := s.DeleteDocument(ctx, "a"); err != nil {
+					return err
+				}
+
+				_, err := s.GetDocument(ctx, "a")
+				return err
+			},
+			err: postgresClient.ErrDocumentNotFound,
+		},
+		{
+			name: "expired document is not found",
+			run: func(s *Store, now *time.Time) error {
+				document := newDocument("a", "alice", *now)
+				expiresAt := now.Add(time.Minute)
+				document.ExpiresAt = &expiresAt
+
+				if err := s.SaveDocument(ctx, document); err != nil {
+					return err
+				}
+
+				*now = now.Add(time.Minute)
+
+				_, err := s.GetDocument(ctx, "a")
+				return err
+			},
+			err: postgresClient.ErrDocumentNotFound,
+		},
+		{
+			name: "folder moved into its subfolder",
+			run: func(s *Store, now *time.Time) error {
+				if err := s.CreateFolder(ctx, &documents.Folder{Id: "p", Login: "alice", Name: "p", CreatedAt: *now}); err != nil {
+					return err
+				}
+
+				if err := s.CreateFolder(ctx, &documents.Folder{Id: "c", Login: "alice", ParentId: "p", Name: "c", CreatedAt: *now}); err != nil {
+					return err
+				}
+
+				return s.UpdateFolder(ctx, &documents.Folder{Id: "p", ParentId: "c", Name: "p"})
+			},
+			err: postgresClient.ErrFolderCycle,
+		},
+		{
+			name: "folder name taken",
+			run: func(s *Store, now *time.Time) error {
+				if err := s.CreateFolder(ctx, &documents.Folder{Id: "a", Login: "alice", Name: "docs", CreatedAt: *now}); err != nil {
+					return err
+				}
+
+				return s.CreateFolder(ctx, &documents.Folder{Id: "b", Login: "alice", Name: "docs", CreatedAt: *now})
+			},
+			err: postgresClient.ErrFolderExists,
+		},
+		{
+			name: "restore unknown version",
+			run: func(s *Store, now *time.Time) error {
+				if err := s.SaveDocument(ctx, newDocument("a", "alice", *now)); err != nil {
+					return err
+				}
+
+				_, err := s.RestoreVersion(ctx, "a", 7)
+				return err
+			},
+			err: postgresClient.ErrVersionNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, now := newStore(t, &tt.config)
+
+			err := tt.run(s, now)
+			if tt.err == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestStoreVersionsAndUsage(t *testing.T) {
+	ctx := context.Background()
+	s, now := newStore(t, &postgresClient.Config{VersionsKeepLast: 2})
+
+	require.NoError(t, s.SaveDocument(ctx, newDocument("a", "alice", *now)))
+
+	for range 3 {
+		require.NoError(t, s.UpdateDocument(ctx, newDocument("a", "alice", *now), 0))
+	}
+
+	versions, err := s.ListVersions(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, []int{3, 2}, []int{versions[0].Version, versions[1].Version})
+
+	version, err := s.RestoreVersion(ctx, "a", 2)
+	require.NoError(t, err)
+	assert.Equal(t, 5, version)
+
+	usage, err := s.GetUsage(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), usage.Documents)
+
+	require.NoError(t, s.DeleteDocument(ctx, "a"))
+
+	purged, err := s.PurgeDocuments(ctx, now.Add(time.Second), 10)
+	require.NoError(t, err)
+	assert.Equal(t, []documents.Document{{Id: "a", Login: "alice"}}, purged)
+
+	usage, err = s.GetUsage(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, documents.Usage{}, *usage)
+}
+
+func TestStoreKeepsHeldVersions(t *testing.T) {
+	ctx := context.Background()
+	s, now := newStore(t, &postgresClient.Config{VersionsKeepLast: 1})
+
+	core, logs := observer.New(zap.InfoLevel)
+	s.logger = zap.New(core)
+
+	require.NoError(t, s.SaveDocument(ctx, newDocument("a", "alice", *now)))
+	require.NoError(t, s.CreateHold(ctx, &documents.Hold{Id: "h", DocId: "a", Reason: "audit"}))
+
+	for range 3 {
+		require.NoError(t, s.UpdateDocument(ctx, newDocument("a", "alice", *now), 0))
+	}
+
+	versions, err := s.ListVersions(ctx, "a")
+	require.NoError(t, err)
+	assert.Len(t, versions, 3)
+
+	kept := logs.FilterMessage("pruneVersions: kept expired versions of a held document").All()
+	require.Len(t, kept, 2)
+	assert.Equal(t, int64(2), kept[1].ContextMap()["count"])
+}
+
+func TestListDocuments(t *testing.T) {
+	ctx := context.Background()
+	s, now := newStore(t, &postgresClient.Config{})
+
+	require.NoError(t, s.CreateFolder(ctx, &documents.Folder{Id: "shared", Login: "alice", Name: "shared", CreatedAt: *now}))
+	require.NoError(t, s.CreateFolder(ctx, &documents.Folder{Id: "inner", Login: "alice", ParentId: "shared", Name: "inner", CreatedAt: *now}))
+	require.NoError(t, s.SetFolderGrants(ctx, "shared", []string{"bob"}))
+
+	private := newDocument("private", "alice", *now)
+	private.Tags = []string{"draft"}
+	private.JSON = []byte(`{"status":"draft","total":10,"items":[{"sku":"x"}]}`)
+
+	granted := newDocument("granted", "alice", now.Add(time.Second))
+	granted.Grant = []string{"carol"}
+
+	inFolder := newDocument("in-folder", "alice", now.Add(2*time.Second))
+	inFolder.FolderId = "inner"
+	inFolder.Text = "quarterly report for the board"
+
+	public := newDocument("public", "carol", now.Add(3*time.Second))
+	public.Public = true
+
+	for _, document := range []*documents.Document{private, granted, inFolder, public} {
+		require.NoError(t, s.SaveDocument(ctx, document))
+	}
+
+	folder := "inner"
+
+	tests := []struct {
+		name  string
+		query documents.ListQuery
+		ids   []string
+	}{
+		{
+			name:  "owner sees everything newest first",
+			query: documents.ListQuery{Login: "alice"},
+			ids:   []string{"public", "in-folder", "granted", "private"},
+		},
+		{
+			name:  "shared folder is readable with subfolders",
+			query: documents.ListQuery{Login: "bob"},
+			ids:   []string{"public", "in-folder"},
+		},
+		{
+			name:  "direct grant",
+			query: documents.ListQuery{Login: "carol", Owner: "alice"},
+			ids:   []string{"granted"},
+		},
+		{
+			name:  "folder",
+			query: documents.ListQuery{Login: "alice", Folder: &folder},
+			ids:   []string{"in-folder"},
+		},
+		{
+			name:  "tags",
+			query: documents.ListQuery{Login: "alice", Tags: []string{"draft"}},
+			ids:   []string{"private"},
+		},
+		{
+			name:  "json containment",
+			query: documents.ListQuery{Login: "alice", JSONContains: []byte(`{"items":[{"sku":"x"}]}`)},
+			ids:   []string{"private"},
+		},
+		{
+			name:  "jsonpath comparison",
+			query: documents.ListQuery{Login: "alice", JSONPath: []string{`$."total" > 9.5`, `$."items"[*]."sku" == "x"`}},
+			ids:   []string{"private"},
+		},
+		{
+			name:  "jsonpath without match",
+			query: documents.ListQuery{Login: "alice", JSONPath: []string{`exists($."missing")`}},
+			ids:   []string{},
+		},
+		{
+			name:  "limit and offset",
+			query: documents.ListQuery{Login: "alice", Limit: 2, Offset: 1},
+			ids:   []string{"in-folder", "granted"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.query.Limit == 0 {
+				tt.query.Limit = 100
+			}
+
+			docs, err := s.ListDocuments(ctx, &tt.query)
+			require.NoError(t, err)
+
+			ids := make([]string, 0, len(docs))
+			for _, document := range docs {
+				assert.Nil(t, document.Content)
+				ids = append(ids, document.Id)
+			}
+
+			assert.Equal(t, tt.ids, ids)
+		})
+	}
+
+	results, err := s.SearchDocuments(ctx, &documents.ListQuery{Login: "bob", Search: `"quarterly report" -draft`, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "in-folder", results[0].Id)
+	assert.Contains(t, results[0].Snippet, "<b>quarterly</b> <b>report</b>")
+}
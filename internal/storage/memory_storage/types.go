@@ -0,0 +1,106 @@
+package memoryStorage
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+
+	"astral/internal/documents"
+)
+
+// Store keeps users, documents, folders, holds and schemas in memory. It implements
+// postgresClient.PostgresClient with the same errors and rules as the Postgres schema:
+// foreign keys, unique names, quotas, holds, versions and the trash.
+type Store struct {
+	logger *zap.Logger
+	now    func() time.Time
+
+	versionsKeepLast int
+	versionsKeepDays int
+
+	quotaDocuments int64
+	quotaBytes     int64
+
+	mu      sync.RWMutex
+	users   map[string]*user
+	docs    map[string]*storedDocument
+	folders map[string]*storedFolder
+	holds   map[string]documents.Hold
+	rules   map[string]documents.RetentionRule
+	schemas map[schemaKey][]byte
+}
+
+type user struct {
+	passwordHash string
+	usage        documents.Usage
+	maxDocuments *int64
+	maxBytes     *int64
+}
+
+// storedDocument is a document with its direct grants and archived versions.
+// DeletedAt is zero unless the document is in the trash.
+type storedDocument struct {
+	documents.Document
+	usageBytes int64
+	versions   []storedVersion
+}
+
+type storedVersion struct {
+	documents.Document
+	usageBytes int64
+	archivedAt time.Time
+}
+
+// storedFolder is a folder with its direct grants.
+type storedFolder struct {
+	documents.Folder
+}
+
+type schemaKey struct {
+	login string
+	name  string
+}
+
+// Cache keeps tokens, locks and cached listings and contents in memory. It implements
+// redisClient.RedisClient with the same TTLs and errors as the Redis implementation.
+type Cache struct {
+	logger *zap.Logger
+	now    func() time.Time
+
+	tokenTTL time.Duration
+	cacheTTL time.Duration
+
+	lockTTL    time.Duration
+	lockMaxTTL time.Duration
+
+	contentCacheSize int
+	contentHits      atomic.Int64
+	contentMisses    atomic.Int64
+
+	group singleflight.Group
+
+	mu       sync.Mutex
+	tokens   map[string]expiring[string]
+	locks    map[string]documents.Lock
+	listings map[string]expiring[[]byte]
+	infos    map[string]expiring[[]byte]
+	contents map[string]expiring[cachedContent]
+}
+
+// expiring is a value that is gone after expiresAt.
+type expiring[T any] struct {
+	value     T
+	expiresAt time.Time
+}
+
+func (e expiring[T]) alive(now time.Time) bool {
+	return now.Before(e.expiresAt)
+}
+
+type cachedContent struct {
+	version int
+	content []byte
+}
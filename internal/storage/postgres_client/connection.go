@@ -0,0 +1,203 @@
+package postgresClient
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// defaultSchema is where the tables lived before the schema became configurable,
+// its migrations table was kept in public back then.
+const defaultSchema = "schema_astral"
+
+// schemaName is what Config.Schema accepts. Such a name needs no quoting, so it
+// goes into search_path as is and means the same to Postgres quoted or not.
+var schemaName = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+func validateConfig(config *Config) error {
+	if config.URL == "" && config.Host == "" {
+		return fmt.Errorf("%w: either DATABASE_URL or POSTGRES_HOST is required", ErrInvalidConfig)
+	}
+
+	if !schemaName.MatchString(config.Schema) {
+		return fmt.Errorf("%w: schema %q, use lowercase letters, digits and _", ErrInvalidConfig, config.Schema)
+	}
+
+	return nil
+}
+
+// connectionURL returns config.URL or a URL assembled from the separate settings,
+// with user, password and database escaped, plus the SSL settings the URL does not set itself.
+func connectionURL(config *Config) (*url.URL, error) {
+	if config.URL != "" {
+		u, err := url.Parse(config.URL)
+		if err != nil {
+			// The parse error contains the URL together with the password.
+			return nil, fmt.Errorf("%w: DATABASE_URL is not a valid URL", ErrInvalidConfig)
+		}
+
+		return withSSL(u, config), nil
+	}
+
+	u := &url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(config.User, config.Password),
+		Host:   net.JoinHostPort(config.Host, config.Port),
+		Path:   "/" + config.Database,
+	}
+
+	return withSSL(u, config), nil
+}
+
+// withSSL adds SSLMode and SSLRootCert to u unless it sets them itself.
+func withSSL(u *url.URL, config *Config) *url.URL {
+	query := u.Query()
+
+	if !query.Has("sslmode") && config.SSLMode != "" {
+		query.Set("sslmode", config.SSLMode)
+	}
+
+	if !query.Has("sslrootcert") && config.SSLRootCert != "" {
+		query.Set("sslrootcert", config.SSLRootCert)
+	}
+
+	u.RawQuery = query.Encode()
+
+	return u
+}
+
+// poolConfig returns the pool settings of the primary.
+func poolConfig(config *Config) (*pgxpool.Config, error) {
+	u, err := connectionURL(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return parsePoolConfig(config, u)
+}
+
+// replicaPoolConfig returns the pool settings of the replica at config.ReplicaURLs[i].
+func replicaPoolConfig(config *Config, i int) (*pgxpool.Config, error) {
+	u, err := url.Parse(config.ReplicaURLs[i])
+	if err != nil {
+		return nil, fmt.Errorf("%w: replica URL %d is not a valid URL", ErrInvalidConfig, i+1)
+	}
+
+	return parsePoolConfig(config, withSSL(u, config))
+}
+
+// parsePoolConfig makes every connection of the pool use the configured schema and statement timeout.
+func parsePoolConfig(config *Config, u *url.URL) (*pgxpool.Config, error) {
+	pc, err := pgxpool.ParseConfig(u.String())
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to parse connection string of %s", ErrInvalidConfig, u.Redacted())
+	}
+
+	if config.MaxConns > 0 {
+		pc.MaxConns = int32(config.MaxConns)
+	}
+
+	if config.MinConns > 0 {
+		pc.MinConns = int32(config.MinConns)
+	}
+
+	pc.ConnConfig.RuntimeParams["search_path"] = config.Schema
+
+	if config.StatementTimeout > 0 {
+		pc.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(config.StatementTimeout.Milliseconds(), 10)
+	}
+
+	return pc, nil
+}
+
+// migrationURL returns the URL golang-migrate connects with. Migrations run in the configured
+// schema without a statement timeout, pool settings only make sense to pgx and are dropped.
+func migrationURL(config *Config) (string, error) {
+	u, err := connectionURL(config)
+	if err != nil {
+		return "", err
+	}
+
+	query := u.Query()
+
+	for key := range query {
+		if strings.HasPrefix(key, "pool_") {
+			query.Del(key)
+		}
+	}
+
+	query.Set("search_path", config.Schema)
+	query.Set("x-migrations-table", `"`+config.Schema+`"."schema_migrations"`)
+	query.Set("x-migrations-table-quoted", "1")
+
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// ensureSchema creates the configured schema unless it exists. The check comes first,
+// so that a user without the CREATE privilege can still work in a prepared schema.
+// An existing default schema also gets its migrations table moved in from public.
+func ensureSchema(ctx context.Context, config *Config) error {
+	u, err := connectionURL(config)
+	if err != nil {
+		return err
+	}
+
+	conn, err := pgx.Connect(ctx, u.String())
+	if err != nil {
+		return fmt.Errorf("ensureSchema: failed to connect: %w", err)
+	}
+	defer conn.Close(ctx)
+
+	var exists bool
+
+	err = conn.QueryRow(ctx, querySchemaExists, config.Schema).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("ensureSchema: failed to check schema: %w", err)
+	}
+
+	if exists {
+		return adoptMigrationsTable(ctx, conn, config)
+	}
+
+	_, err = conn.Exec(ctx, "CREATE SCHEMA IF NOT EXISTS "+pgx.Identifier{config.Schema}.Sanitize())
+	if err != nil {
+		return fmt.Errorf("ensureSchema: failed to create schema %s: %w", config.Schema, err)
+	}
+
+	return nil
+}
+
+// adoptMigrationsTable moves public.schema_migrations of a deployment made before the schema
+// became configurable into the default schema, so that its applied migrations are not run again.
+func adoptMigrationsTable(ctx context.Context, conn *pgx.Conn, config *Config) error {
+	if config.Schema != defaultSchema {
+		return nil
+	}
+
+	var legacy bool
+
+	err := conn.QueryRow(ctx, queryLegacyMigrationsTable, defaultSchema).Scan(&legacy)
+	if err != nil {
+		return fmt.Errorf("ensureSchema: failed to check migrations table: %w", err)
+	}
+
+	if !legacy {
+		return nil
+	}
+
+	_, err = conn.Exec(ctx, "ALTER TABLE public.schema_migrations SET SCHEMA "+pgx.Identifier{defaultSchema}.Sanitize())
+	if err != nil {
+		return fmt.Errorf("ensureSchema: failed to move migrations table: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package postgresClient
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig() *Config {
+	return &Config{
+		Host:     "localhost",
+		Port:     "5432",
+		User:     "astral",
+		Password: "p@ss:w/rd?#%",
+		Database: "astral",
+		SSLMode:  "disable",
+		Schema:   defaultSchema,
+		MaxConns: 10,
+		MinConns: 2,
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		valid  bool
+	}{
+		{name: "separate settings", modify: func(c *Config) {}, valid: true},
+		{name: "url only", modify: func(c *Config) { c.Host, c.URL = "", "postgres://db/astral" }, valid: true},
+		{name: "no host and no url", modify: func(c *Config) { c.Host = "" }},
+		{name: "custom schema", modify: func(c *Config) { c.Schema = "tenant_1" }, valid: true},
+		{name: "uppercase schema", modify: func(c *Config) { c.Schema = "Astral" }},
+		{name: "quoted schema", modify: func(c *Config) { c.Schema = `a"; DROP SCHEMA public; --` }},
+		{name: "empty schema", modify: func(c *Config) { c.Schema = "" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testConfig()
+			tt.modify(config)
+
+			err := validateConfig(config)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidConfig)
+			}
+		})
+	}
+}
+
+func TestPoolConfig(t *testing.T) {
+	config := testConfig()
+	config.StatementTimeout = 15 * time.Second
+
+	pc, err := poolConfig(config)
+	require.NoError(t, err)
+
+	assert.Equal(t, "localhost", pc.ConnConfig.Host)
+	assert.Equal(t, uint16(5432), pc.ConnConfig.Port)
+	assert.Equal(t, "astral", pc.ConnConfig.User)
+	assert.Equal(t, "p@ss:w/rd?#%", pc.ConnConfig.Password)
+	assert.Equal(t, "astral", pc.ConnConfig.Database)
+	assert.Nil(t, pc.ConnConfig.TLSConfig)
+	assert.Equal(t, int32(10), pc.MaxConns)
+	assert.Equal(t, int32(2), pc.MinConns)
+	assert.Equal(t, defaultSchema, pc.ConnConfig.RuntimeParams["search_path"])
+	assert.Equal(t, "15000", pc.ConnConfig.RuntimeParams["statement_timeout"])
+}
+
+func TestPoolConfigURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		sslMode string
+		tls     bool
+	}{
+		{name: "url sets sslmode", url: "postgres://u:p@db:6432/astral?sslmode=require", sslMode: "disable", tls: true},
+		{name: "sslmode from settings", url: "postgres://u:p@db:6432/astral", sslMode: "require", tls: true},
+		{name: "no tls", url: "postgres://u:p@db:6432/astral", sslMode: "disable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testConfig()
+			config.URL = tt.url
+			config.SSLMode = tt.sslMode
+
+			pc, err := poolConfig(config)
+			require.NoError(t, err)
+
+			assert.Equal(t, "db", pc.ConnConfig.Host)
+			assert.Equal(t, uint16(6432), pc.ConnConfig.Port)
+			assert.Equal(t, "u", pc.ConnConfig.User)
+			assert.Equal(t, tt.tls, pc.ConnConfig.TLSConfig != nil)
+			assert.NotContains(t, pc.ConnConfig.RuntimeParams, "statement_timeout")
+		})
+	}
+}
+
+func TestPoolConfigHidesPassword(t *testing.T) {
+	config := testConfig()
+	config.URL = "postgres://u:secret@db:bad-port/astral"
+
+	_, err := poolConfig(config)
+	require.ErrorIs(t, err, ErrInvalidConfig)
+	assert.NotContains(t, err.Error(), "secret")
+}
+
+func TestMigrationURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		table  string
+	}{
+		{name: "default schema", schema: defaultSchema, table: `"schema_astral"."schema_migrations"`},
+		{name: "custom schema", schema: "tenant_1", table: `"tenant_1"."schema_migrations"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testConfig()
+			config.URL = "postgres://u:p@db/astral?pool_max_conns=5"
+			config.Schema = tt.schema
+
+			raw, err := migrationURL(config)
+			require.NoError(t, err)
+
+			u, err := url.Parse(raw)
+			require.NoError(t, err)
+
+			query := u.Query()
+			assert.Equal(t, tt.schema, query.Get("search_path"))
+			assert.Equal(t, tt.table, query.Get("x-migrations-table"))
+			assert.Equal(t, "1", query.Get("x-migrations-table-quoted"))
+			assert.Equal(t, "disable", query.Get("sslmode"))
+			assert.False(t, query.Has("pool_max_conns"))
+		})
+	}
+}
+
+// The escaped URL must reach pgx unchanged for any password.
+func TestConnectionURLEscaping(t *testing.T) {
+	config := testConfig()
+	config.User = "user@corp"
+	config.Database = "astral db"
+
+	u, err := connectionURL(config)
+	require.NoError(t, err)
+
+	pc, err := pgxpool.ParseConfig(u.String())
+	require.NoError(t, err)
+
+	assert.Equal(t, "user@corp", pc.ConnConfig.User)
+	assert.Equal(t, "p@ss:w/rd?#%", pc.ConnConfig.Password)
+	assert.Equal(t, "astral db", pc.ConnConfig.Database)
+}
@@ -0,0 +1,63 @@
+package postgresClient
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	"astral/internal/documents"
+)
+
+// UpdateExpiry sets when a document expires, nil keeps it forever. Held documents can not get an expiry.
+func (ps *PostgresService) UpdateExpiry(ctx context.Context, id string, expiresAt *time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	ps.wrote(ctx, "")
+
+	tag, err := ps.pool.Exec(ctx, queryUpdateExpiry, id, expiresAt)
+	if err != nil {
+		ps.logger.Error("UpdateExpiry: failed to update expiry", zap.Error(err))
+		return fmt.Errorf("UpdateExpiry: failed to update expiry: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ps.notChanged(ctx, "UpdateExpiry", id, "", false)
+	}
+
+	ps.logger.Info("UpdateExpiry: successfully update expiry", zap.String("id", id))
+	return nil
+}
+
+// PurgeExpired removes up to limit documents that expired by now and returns their ids and owners.
+func (ps *PostgresService) PurgeExpired(ctx context.Context, now time.Time, limit int) ([]documents.Document, error) {
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	rows, err := ps.pool.Query(ctx, queryPurgeExpired, now, limit)
+	if err != nil {
+		ps.logger.Error("PurgeExpired: failed to purge documents", zap.Error(err))
+		return nil, fmt.Errorf("PurgeExpired: failed to purge documents: %w", err)
+	}
+
+	purged, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (documents.Document, error) {
+		var document documents.Document
+
+		err := row.Scan(&document.Id, &document.Login)
+
+		return document, err
+	})
+	if err != nil {
+		ps.logger.Error("PurgeExpired: failed to collect purged documents", zap.Error(err))
+		return nil, fmt.Errorf("PurgeExpired: failed to collect purged documents: %w", err)
+	}
+
+	if len(purged) > 0 {
+		ps.logger.Info("PurgeExpired: purged documents", zap.Int("count", len(purged)))
+	}
+
+	return purged, nil
+}
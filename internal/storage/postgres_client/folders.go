@@ -0,0 +1,284 @@
+package postgresClient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+
+	"astral/internal/documents"
+)
+
+// MoveDocument puts a document into a folder, an empty folderId moves it to the root.
+func (ps *PostgresService) MoveDocument(ctx context.Context, id string, folderId string) error {
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	ps.wrote(ctx, "")
+
+	tag, err := ps.pool.Exec(ctx, queryMoveDocument, id, folderId)
+	if err != nil {
+		if isViolation(err, "23503") {
+			ps.logger.Warn("MoveDocument: folder not found", zap.String("folder", folderId))
+			return ErrFolderNotFound
+		}
+
+		ps.logger.Error("MoveDocument: failed to move document", zap.Error(err))
+		return fmt.Errorf("MoveDocument: failed to move document: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		ps.logger.Warn("MoveDocument: document not found", zap.String("id", id))
+		return ErrDocumentNotFound
+	}
+
+	ps.logger.Info("MoveDocument: successfully move document", zap.String("id", id), zap.String("folder", folderId))
+	return nil
+}
+
+func (ps *PostgresService) CreateFolder(ctx context.Context, folder *documents.Folder) error {
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	ps.wrote(ctx, folder.Login)
+
+	_, err := ps.pool.Exec(ctx, queryCreateFolder, folder.Id, folder.Login, folder.ParentId, folder.Name, folder.CreatedAt)
+	if err != nil {
+		switch {
+		case isViolation(err, "23505"):
+			ps.logger.Warn("CreateFolder: folder already exists", zap.String("name", folder.Name))
+			return ErrFolderExists
+
+		case isViolation(err, "23503"):
+			ps.logger.Warn("CreateFolder: parent folder not found", zap.String("parent", folder.ParentId))
+			return ErrFolderNotFound
+
+		default:
+			ps.logger.Error("CreateFolder: failed to create folder", zap.Error(err))
+			return fmt.Errorf("CreateFolder: failed to create folder: %w", err)
+		}
+	}
+
+	ps.logger.Info("CreateFolder: successfully create folder", zap.String("id", folder.Id))
+	return nil
+}
+
+// GetFolder returns a folder with the grants it has directly or through its parents.
+func (ps *PostgresService) GetFolder(ctx context.Context, id string) (*documents.Folder, error) {
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	var folder documents.Folder
+
+	err := ps.pool.QueryRow(ctx, queryGetFolder, id).Scan(
+		&folder.Id,
+		&folder.Login,
+		&folder.ParentId,
+		&folder.Name,
+		&folder.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ps.logger.Warn("GetFolder: folder not found", zap.String("id", id))
+			return nil, ErrFolderNotFound
+		}
+
+		ps.logger.Error("GetFolder: failed to get folder", zap.Error(err))
+		return nil, fmt.Errorf("GetFolder: failed to get folder: %w", err)
+	}
+
+	rows, err := ps.pool.Query(ctx, queryGetFolderGrants, id)
+	if err != nil {
+		ps.logger.Error("GetFolder: failed to get folder grants", zap.Error(err))
+		return nil, fmt.Errorf("GetFolder: failed to get folder grants: %w", err)
+	}
+
+	folder.Grant, err = pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		ps.logger.Error("GetFolder: failed to collect folder grants", zap.Error(err))
+		return nil, fmt.Errorf("GetFolder: failed to collect folder grants: %w", err)
+	}
+
+	return &folder, nil
+}
+
+// ResolveFolderPath returns the id of the folder of login found by following names
+// from the root. No names resolve to the root itself, which has an empty id.
+func (ps *PostgresService) ResolveFolderPath(ctx context.Context, login string, names []string) (string, error) {
+	if len(names) == 0 {
+		return "", nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	var id string
+
+	err := ps.pool.QueryRow(ctx, queryResolveFolderPath, login, names).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ps.logger.Warn("ResolveFolderPath: folder not found", zap.Strings("path", names))
+			return "", ErrFolderNotFound
+		}
+
+		ps.logger.Error("ResolveFolderPath: failed to resolve path", zap.Error(err))
+		return "", fmt.Errorf("ResolveFolderPath: failed to resolve path: %w", err)
+	}
+
+	return id, nil
+}
+
+// ListFolders returns the folders of login directly inside parentId, or in the root for an empty parentId.
+func (ps *PostgresService) ListFolders(ctx context.Context, login string, parentId string) ([]documents.Folder, error) {
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	rows, err := ps.reader(ctx).Query(ctx, queryListFolders, login, parentId)
+	if err != nil {
+		ps.logger.Error("ListFolders: failed to list folders", zap.Error(err))
+		return nil, fmt.Errorf("ListFolders: failed to list folders: %w", err)
+	}
+
+	folders, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (documents.Folder, error) {
+		var folder documents.Folder
+
+		err := row.Scan(
+			&folder.Id,
+			&folder.Login,
+			&folder.ParentId,
+			&folder.Name,
+			&folder.CreatedAt,
+		)
+
+		return folder, err
+	})
+	if err != nil {
+		ps.logger.Error("ListFolders: failed to collect folders", zap.Error(err))
+		return nil, fmt.Errorf("ListFolders: failed to collect folders: %w", err)
+	}
+
+	return folders, nil
+}
+
+// UpdateFolder renames a folder and moves it under folder.ParentId. A folder can not
+// be moved into itself or into one of its subfolders.
+func (ps *PostgresService) UpdateFolder(ctx context.Context, folder *documents.Folder) error {
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	ps.wrote(ctx, "")
+
+	tx, err := ps.pool.Begin(ctx)
+	if err != nil {
+		ps.logger.Error("UpdateFolder: failed to begin transaction", zap.Error(err))
+		return fmt.Errorf("UpdateFolder: failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err = tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			ps.logger.Warn("UpdateFolder: rollback failed", zap.Error(err))
+		}
+	}()
+
+	if folder.ParentId != "" {
+		var cycle bool
+
+		err = tx.QueryRow(ctx, queryFolderIsAncestor, folder.ParentId, folder.Id).Scan(&cycle)
+		if err != nil {
+			ps.logger.Error("UpdateFolder: failed to check parent", zap.Error(err))
+			return fmt.Errorf("UpdateFolder: failed to check parent: %w", err)
+		}
+
+		if cycle {
+			ps.logger.Warn("UpdateFolder: folder moved into itself", zap.String("id", folder.Id))
+			return ErrFolderCycle
+		}
+	}
+
+	tag, err := tx.Exec(ctx, queryUpdateFolder, folder.Id, folder.ParentId, folder.Name)
+	if err != nil {
+		switch {
+		case isViolation(err, "23505"):
+			ps.logger.Warn("UpdateFolder: folder already exists", zap.String("name", folder.Name))
+			return ErrFolderExists
+
+		case isViolation(err, "23503"):
+			ps.logger.Warn("UpdateFolder: parent folder not found", zap.String("parent", folder.ParentId))
+			return ErrFolderNotFound
+
+		default:
+			ps.logger.Error("UpdateFolder: failed to update folder", zap.Error(err))
+			return fmt.Errorf("UpdateFolder: failed to update folder: %w", err)
+		}
+	}
+
+	if tag.RowsAffected() == 0 {
+		ps.logger.Warn("UpdateFolder: folder not found", zap.String("id", folder.Id))
+		return ErrFolderNotFound
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		ps.logger.Error("UpdateFolder: failed to commit transaction", zap.Error(err))
+		return fmt.Errorf("UpdateFolder: failed to commit transaction: %w", err)
+	}
+
+	ps.logger.Info("UpdateFolder: successfully update folder", zap.String("id", folder.Id))
+	return nil
+}
+
+// SetFolderGrants replaces the logins a folder is shared with.
+func (ps *PostgresService) SetFolderGrants(ctx context.Context, id string, grant []string) error {
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	ps.wrote(ctx, "")
+
+	tx, err := ps.pool.Begin(ctx)
+	if err != nil {
+		ps.logger.Error("SetFolderGrants: failed to begin transaction", zap.Error(err))
+		return fmt.Errorf("SetFolderGrants: failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err = tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			ps.logger.Warn("SetFolderGrants: rollback failed", zap.Error(err))
+		}
+	}()
+
+	_, err = tx.Exec(ctx, queryDeleteFolderGrants, id)
+	if err != nil {
+		ps.logger.Error("SetFolderGrants: failed to delete grants", zap.Error(err))
+		return fmt.Errorf("SetFolderGrants: failed to delete grants: %w", err)
+	}
+
+	for _, grantee := range grant {
+		_, err = tx.Exec(ctx, querySaveFolderGrant, id, grantee)
+		if err != nil {
+			if isViolation(err, "23503") {
+				ps.logger.Warn("SetFolderGrants: unknown grantee", zap.String("grantee", grantee))
+				return ErrUnknownGrantee
+			}
+
+			ps.logger.Error("SetFolderGrants: failed to save grant", zap.Error(err))
+			return fmt.Errorf("SetFolderGrants: failed to save grant: %w", err)
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		ps.logger.Error("SetFolderGrants: failed to commit transaction", zap.Error(err))
+		return fmt.Errorf("SetFolderGrants: failed to commit transaction: %w", err)
+	}
+
+	ps.logger.Info("SetFolderGrants: successfully set folder grants", zap.String("id", id), zap.Int("count", len(grant)))
+	return nil
+}
+
+// isViolation reports whether err is a Postgres error with the given SQLSTATE code.
+func isViolation(err error, code string) bool {
+	var pgErr *pgconn.PgError
+
+	return errors.As(err, &pgErr) && pgErr.Code == code
+}
@@ -0,0 +1,162 @@
+package postgresClient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	"astral/internal/documents"
+)
+
+func (ps *PostgresService) CreateHold(ctx context.Context, hold *documents.Hold) error {
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	_, err := ps.pool.Exec(ctx, queryCreateHold, hold.Id, hold.DocId, hold.Login, hold.Reason, hold.CreatedAt)
+	if err != nil {
+		if isViolation(err, "23503") {
+			if hold.DocId != "" {
+				ps.logger.Warn("CreateHold: document not found", zap.String("doc", hold.DocId))
+				return ErrDocumentNotFound
+			}
+
+			ps.logger.Warn("CreateHold: user not found", zap.String("login", hold.Login))
+			return ErrUserNotFound
+		}
+
+		ps.logger.Error("CreateHold: failed to create hold", zap.Error(err))
+		return fmt.Errorf("CreateHold: failed to create hold: %w", err)
+	}
+
+	ps.logger.Info("CreateHold: successfully create hold", zap.String("id", hold.Id))
+	return nil
+}
+
+func (ps *PostgresService) ListHolds(ctx context.Context) ([]documents.Hold, error) {
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	rows, err := ps.pool.Query(ctx, queryListHolds)
+	if err != nil {
+		ps.logger.Error("ListHolds: failed to list holds", zap.Error(err))
+		return nil, fmt.Errorf("ListHolds: failed to list holds: %w", err)
+	}
+
+	holds, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (documents.Hold, error) {
+		var hold documents.Hold
+
+		err := row.Scan(&hold.Id, &hold.DocId, &hold.Login, &hold.Reason, &hold.CreatedAt)
+
+		return hold, err
+	})
+	if err != nil {
+		ps.logger.Error("ListHolds: failed to collect holds", zap.Error(err))
+		return nil, fmt.Errorf("ListHolds: failed to collect holds: %w", err)
+	}
+
+	return holds, nil
+}
+
+// DeleteHold releases a legal hold.
+func (ps *PostgresService) DeleteHold(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	tag, err := ps.pool.Exec(ctx, queryDeleteHold, id)
+	if err != nil {
+		ps.logger.Error("DeleteHold: failed to delete hold", zap.Error(err))
+		return fmt.Errorf("DeleteHold: failed to delete hold: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		ps.logger.Warn("DeleteHold: hold not found", zap.String("id", id))
+		return ErrHoldNotFound
+	}
+
+	ps.logger.Info("DeleteHold: successfully release hold", zap.String("id", id))
+	return nil
+}
+
+// SaveRetentionRule creates a retention rule or replaces the one with the same name.
+func (ps *PostgresService) SaveRetentionRule(ctx context.Context, rule *documents.RetentionRule) error {
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	_, err := ps.pool.Exec(ctx, querySaveRetentionRule, rule.Name, rule.Tag, rule.Schema, rule.KeepDays, rule.CreatedAt)
+	if err != nil {
+		ps.logger.Error("SaveRetentionRule: failed to save rule", zap.Error(err))
+		return fmt.Errorf("SaveRetentionRule: failed to save rule: %w", err)
+	}
+
+	ps.logger.Info("SaveRetentionRule: successfully save rule", zap.String("name", rule.Name))
+	return nil
+}
+
+func (ps *PostgresService) ListRetentionRules(ctx context.Context) ([]documents.RetentionRule, error) {
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	rows, err := ps.pool.Query(ctx, queryListRetentionRules)
+	if err != nil {
+		ps.logger.Error("ListRetentionRules: failed to list rules", zap.Error(err))
+		return nil, fmt.Errorf("ListRetentionRules: failed to list rules: %w", err)
+	}
+
+	rules, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (documents.RetentionRule, error) {
+		var rule documents.RetentionRule
+
+		err := row.Scan(&rule.Name, &rule.Tag, &rule.Schema, &rule.KeepDays, &rule.CreatedAt)
+
+		return rule, err
+	})
+	if err != nil {
+		ps.logger.Error("ListRetentionRules: failed to collect rules", zap.Error(err))
+		return nil, fmt.Errorf("ListRetentionRules: failed to collect rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+func (ps *PostgresService) DeleteRetentionRule(ctx context.Context, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	tag, err := ps.pool.Exec(ctx, queryDeleteRetentionRule, name)
+	if err != nil {
+		ps.logger.Error("DeleteRetentionRule: failed to delete rule", zap.Error(err))
+		return fmt.Errorf("DeleteRetentionRule: failed to delete rule: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		ps.logger.Warn("DeleteRetentionRule: rule not found", zap.String("name", name))
+		return ErrRuleNotFound
+	}
+
+	ps.logger.Info("DeleteRetentionRule: successfully delete rule", zap.String("name", name))
+	return nil
+}
+
+// notChanged explains why a guarded statement of caller did not touch document id:
+// a *HoldError when the document is held, ErrDocumentNotFound otherwise. Only a document
+// of login, any owner when it is empty, that is in the trash exactly when trashed is set
+// reports its hold.
+func (ps *PostgresService) notChanged(ctx context.Context, caller string, id string, login string, trashed bool) error {
+	var reason string
+
+	err := ps.pool.QueryRow(ctx, queryHoldReason, id, login, trashed).Scan(&reason)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ps.logger.Warn(caller+": document not found", zap.String("id", id))
+			return ErrDocumentNotFound
+		}
+
+		ps.logger.Error(caller+": failed to check holds", zap.Error(err))
+		return fmt.Errorf("%s: failed to check holds: %w", caller, err)
+	}
+
+	ps.logger.Warn(caller+": document is held", zap.String("id", id), zap.String("reason", reason))
+	return &HoldError{Reason: reason}
+}
@@ -0,0 +1,31 @@
+package postgresClient
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// UpdateLabels replaces tags and metadata of a document. Labels are not versioned,
+// so the document version stays the same.
+func (ps *PostgresService) UpdateLabels(ctx context.Context, id string, tags []string, metadata map[string]string) error {
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	ps.wrote(ctx, "")
+
+	tag, err := ps.pool.Exec(ctx, queryUpdateLabels, id, tags, metadata)
+	if err != nil {
+		ps.logger.Error("UpdateLabels: failed to update labels", zap.Error(err))
+		return fmt.Errorf("UpdateLabels: failed to update labels: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		ps.logger.Warn("UpdateLabels: document not found", zap.String("id", id))
+		return ErrDocumentNotFound
+	}
+
+	ps.logger.Info("UpdateLabels: successfully update labels", zap.String("id", id))
+	return nil
+}
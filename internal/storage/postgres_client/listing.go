@@ -0,0 +1,209 @@
+package postgresClient
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	"astral/internal/documents"
+)
+
+// ListDocuments returns documents matching query without their content, newest first.
+// Sealed documents have no plain JSON, so JSON filters are refused with encryption enabled.
+func (ps *PostgresService) ListDocuments(ctx context.Context, query *documents.ListQuery) ([]documents.Document, error) {
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	if ps.keyring.Enabled() && query.ReadsContent() {
+		return nil, fmt.Errorf("ListDocuments: %w", ErrEncryptedQuery)
+	}
+
+	sql, args := buildListQuery(query)
+
+	rows, err := ps.reader(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		ps.logger.Error("ListDocuments: failed to list documents", zap.Error(err))
+		return nil, fmt.Errorf("ListDocuments: failed to list documents: %w", err)
+	}
+
+	docs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (documents.Document, error) {
+		var document documents.Document
+
+		err := row.Scan(
+			&document.Id,
+			&document.Login,
+			&document.Name,
+			&document.Mime,
+			&document.DetectedMime,
+			&document.File,
+			&document.Public,
+			&document.CreatedAt,
+			&document.Codec,
+			&document.Size,
+			&document.Version,
+			&document.UpdatedAt,
+			&document.Schema,
+			&document.Tags,
+			&document.Metadata,
+			&document.FolderId,
+			&document.ExpiresAt,
+			&document.Grant,
+		)
+
+		return document, err
+	})
+	if err != nil {
+		ps.logger.Error("ListDocuments: failed to collect documents", zap.Error(err))
+		return nil, fmt.Errorf("ListDocuments: failed to collect documents: %w", err)
+	}
+
+	return docs, nil
+}
+
+// SearchDocuments runs the full-text query.Search over names and extracted text
+// and returns the matching documents ranked, with highlighted snippets.
+// Sealed documents have no extracted text, so it is refused with encryption enabled.
+func (ps *PostgresService) SearchDocuments(ctx context.Context, query *documents.ListQuery) ([]documents.SearchResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	if ps.keyring.Enabled() && query.ReadsContent() {
+		return nil, fmt.Errorf("SearchDocuments: %w", ErrEncryptedQuery)
+	}
+
+	sql, args := buildSearchQuery(query)
+
+	rows, err := ps.reader(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		ps.logger.Error("SearchDocuments: failed to search documents", zap.Error(err))
+		return nil, fmt.Errorf("SearchDocuments: failed to search documents: %w", err)
+	}
+
+	results, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (documents.SearchResult, error) {
+		var result documents.SearchResult
+
+		err := row.Scan(
+			&result.Id,
+			&result.Login,
+			&result.Name,
+			&result.Mime,
+			&result.DetectedMime,
+			&result.File,
+			&result.Public,
+			&result.CreatedAt,
+			&result.Codec,
+			&result.Size,
+			&result.Version,
+			&result.UpdatedAt,
+			&result.Schema,
+			&result.Tags,
+			&result.Metadata,
+			&result.FolderId,
+			&result.ExpiresAt,
+			&result.Grant,
+			&result.Rank,
+			&result.Snippet,
+		)
+
+		return result, err
+	})
+	if err != nil {
+		ps.logger.Error("SearchDocuments: failed to collect documents", zap.Error(err))
+		return nil, fmt.Errorf("SearchDocuments: failed to collect documents: %w", err)
+	}
+
+	return results, nil
+}
+
+// queryBuilder collects WHERE conditions together with their positional arguments.
+type queryBuilder struct {
+	conds []string
+	args  []any
+}
+
+// arg adds a query argument and returns its placeholder.
+func (qb *queryBuilder) arg(value any) string {
+	qb.args = append(qb.args, value)
+	return "$" + strconv.Itoa(len(qb.args))
+}
+
+// where adds a condition, cond is formatted with the placeholders of args.
+func (qb *queryBuilder) where(cond string, args ...any) {
+	placeholders := make([]any, 0, len(args))
+	for _, value := range args {
+		placeholders = append(placeholders, qb.arg(value))
+	}
+
+	qb.conds = append(qb.conds, fmt.Sprintf(cond, placeholders...))
+}
+
+func buildListQuery(query *documents.ListQuery) (string, []any) {
+	var qb queryBuilder
+
+	qb.filter(query)
+
+	return qb.build(queryListDocuments, "d.created_at DESC, d.id", query), qb.args
+}
+
+func buildSearchQuery(query *documents.ListQuery) (string, []any) {
+	var qb queryBuilder
+
+	sql := fmt.Sprintf(querySearchDocuments, qb.arg(query.Search))
+
+	qb.conds = append(qb.conds, "d.search_vector @@ q.query")
+	qb.filter(query)
+
+	return qb.build(sql, "rank DESC, d.created_at DESC, d.id", query), qb.args
+}
+
+// filter adds the conditions shared by listing and search.
+func (qb *queryBuilder) filter(query *documents.ListQuery) {
+	qb.conds = append(qb.conds, "d.deleted_at IS NULL")
+	qb.where("(d.expires_at IS NULL OR d.expires_at > %s)", time.Now())
+	qb.where(condDocumentReadable, query.Login)
+
+	if query.Owner != "" {
+		qb.where("d.login = %s", query.Owner)
+	}
+
+	if query.Folder != nil {
+		if *query.Folder == "" {
+			qb.conds = append(qb.conds, "d.folder_id IS NULL")
+		} else {
+			qb.where("d.folder_id = %s::uuid", *query.Folder)
+		}
+	}
+
+	if len(query.JSONContains) > 0 {
+		qb.where("d.json @> %s::jsonb", string(query.JSONContains))
+	}
+
+	for _, predicate := range query.JSONPath {
+		qb.where("d.json @@ %s::jsonpath", predicate)
+	}
+
+	if len(query.Tags) > 0 {
+		qb.where("d.tags @> %s::text[]", query.Tags)
+	}
+
+	if len(query.Metadata) > 0 {
+		qb.where("d.metadata @> %s::jsonb", query.Metadata)
+	}
+}
+
+func (qb *queryBuilder) build(sql string, order string, query *documents.ListQuery) string {
+	var sb strings.Builder
+
+	sb.WriteString(sql)
+	sb.WriteString("\n\tWHERE ")
+	sb.WriteString(strings.Join(qb.conds, "\n\tAND "))
+	sb.WriteString("\n\tORDER BY " + order)
+	sb.WriteString("\n\tLIMIT " + qb.arg(query.Limit) + " OFFSET " + qb.arg(query.Offset))
+
+	return sb.String()
+}
@@ -0,0 +1,179 @@
+package postgresClient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"go.uber.org/zap"
+)
+
+// migrationName is what create accepts as the description part of a file name.
+var migrationName = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// NewMigrator connects to the database of config with the migrations found at migrationsPath,
+// a source URL such as file://./database/migrations. The configured schema is created when missing.
+func NewMigrator(ctx context.Context, config *Config, migrationsPath string, logger *zap.Logger) (*Migrator, error) {
+	err := validateConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ensureSchema(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("NewMigrator: %w", err)
+	}
+
+	databaseURL, err := migrationURL(config)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := migrate.New(migrationsPath, databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("NewMigrator: failed to create migration: %w", err)
+	}
+
+	return &Migrator{
+		migrate: m,
+		source:  migrationsPath,
+		logger:  logger,
+	}, nil
+}
+
+// Up applies the next steps migrations, or all pending ones when steps is zero.
+func (m *Migrator) Up(steps int) error {
+	var err error
+
+	if steps > 0 {
+		err = m.migrate.Steps(steps)
+	} else {
+		err = m.migrate.Up()
+	}
+
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("Up: failed to apply migrations: %w", err)
+	}
+
+	m.logVersion("Up")
+	return nil
+}
+
+// Down reverts the last steps migrations. Reverting everything takes the number of
+// applied migrations, so that dropping the whole schema is never a default.
+func (m *Migrator) Down(steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("Down: steps must be positive, got %d", steps)
+	}
+
+	err := m.migrate.Steps(-steps)
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("Down: failed to revert migrations: %w", err)
+	}
+
+	m.logVersion("Down")
+	return nil
+}
+
+// Force sets the schema version without running migrations and clears the dirty flag.
+// It is the way out after a migration failed halfway and the schema was fixed by hand.
+func (m *Migrator) Force(version int) error {
+	err := m.migrate.Force(version)
+	if err != nil {
+		return fmt.Errorf("Force: failed to force version %d: %w", version, err)
+	}
+
+	m.logger.Warn("Force: forced schema version", zap.Int("version", version))
+	return nil
+}
+
+// Status returns the applied version together with the latest one available.
+func (m *Migrator) Status() (*MigrationStatus, error) {
+	var status MigrationStatus
+
+	version, dirty, err := m.migrate.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return nil, fmt.Errorf("Status: failed to get version: %w", err)
+	}
+
+	status.Version, status.Dirty = version, dirty
+
+	src, err := source.Open(m.source)
+	if err != nil {
+		return nil, fmt.Errorf("Status: failed to open migrations: %w", err)
+	}
+	defer src.Close()
+
+	next, err := src.First()
+	for err == nil {
+		status.Latest = next
+		if next > status.Version {
+			status.Pending++
+		}
+
+		next, err = src.Next(next)
+	}
+
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("Status: failed to list migrations: %w", err)
+	}
+
+	return &status, nil
+}
+
+func (m *Migrator) Close() {
+	sourceErr, databaseErr := m.migrate.Close()
+	if err := errors.Join(sourceErr, databaseErr); err != nil {
+		m.logger.Warn("Close: failed to close migrator", zap.Error(err))
+	}
+}
+
+func (m *Migrator) logVersion(caller string) {
+	version, dirty, err := m.migrate.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		m.logger.Warn(caller+": failed to get version", zap.Error(err))
+		return
+	}
+
+	m.logger.Info(caller+": schema is at version", zap.Uint("version", version), zap.Bool("dirty", dirty))
+}
+
+// CreateMigration writes an empty pair of up and down files named after the time of creation,
+// so that migrations written on different branches do not collide. dir is a directory
+// or a file:// source URL. Returns the paths of the new files.
+func CreateMigration(dir string, name string, now time.Time) ([]string, error) {
+	if !migrationName.MatchString(name) {
+		return nil, fmt.Errorf("CreateMigration: %w: %q, use lowercase letters, digits and _", ErrInvalidMigrationName, name)
+	}
+
+	dir = strings.TrimPrefix(dir, "file://")
+	base := now.UTC().Format("20060102150405") + "_" + name
+
+	paths := []string{
+		filepath.Join(dir, base+".up.sql"),
+		filepath.Join(dir, base+".down.sql"),
+	}
+
+	for _, path := range paths {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("CreateMigration: failed to create %s: %w", path, err)
+		}
+
+		err = file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("CreateMigration: failed to close %s: %w", path, err)
+		}
+	}
+
+	return paths, nil
+}
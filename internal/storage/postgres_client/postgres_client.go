@@ -4,41 +4,62 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
-	"github.com/golang-migrate/migrate"
-	_ "github.com/golang-migrate/migrate/database/postgres"
-	_ "github.com/golang-migrate/migrate/source/file"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.uber.org/zap"
 
 	"astral/internal/documents"
+	"astral/internal/keyring"
+	"astral/internal/sealing"
 )
 
 // TODO: добавить проверки на закрытый контекст, в частности в SaveDocument
 
-func New(ctx context.Context, config *Config, logger *zap.Logger, migrationsPath string) (*PostgresService, error) {
-	url := buildURL(config)
-	dsn := buildDSN(config)
+func New(ctx context.Context, config *Config, kr *keyring.Keyring, logger *zap.Logger, migrationsPath string) (*PostgresService, error) {
+	err := validateConfig(config)
+	if err != nil {
+		return nil, err
+	}
 
-	pool, err := pgxpool.New(ctx, dsn)
+	pc, err := poolConfig(config)
+	if err != nil {
+		return nil, err
+	}
 
+	pool, err := pgxpool.NewWithConfig(ctx, pc)
 	if err != nil {
 		return nil, err
 	}
 
-	err = upMigration(url, migrationsPath)
+	if config.AutoMigrate {
+		err = upMigration(ctx, config, migrationsPath, logger)
+		if err != nil {
+			pool.Close()
+			return nil, err
+		}
+	}
+
+	replicas, err := openReplicas(ctx, config)
 	if err != nil {
+		pool.Close()
 		return nil, err
 	}
 
 	return &PostgresService{
-		pool:    pool,
-		logger:  logger,
-		timeout: config.Timeout,
+		pool:             pool,
+		replicas:         replicas,
+		pins:             newPins(config.PinPrimary),
+		replicaCooldown:  config.ReplicaCooldown,
+		keyring:          kr,
+		logger:           logger,
+		timeout:          config.Timeout,
+		versionsKeepLast: config.VersionsKeepLast,
+		versionsKeepDays: config.VersionsKeepDays,
+		quotaDocuments:   config.QuotaDocuments,
+		quotaBytes:       config.QuotaBytes,
 	}, nil
 }
 
@@ -95,6 +116,14 @@ func (ps *PostgresService) SaveDocument(ctx context.Context, document *documents
 	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
 	defer cancel()
 
+	ps.wrote(ctx, document.Login)
+
+	sealed, err := sealing.Seal(ps.keyring, document)
+	if err != nil {
+		ps.logger.Error("SaveDocument: failed to seal document", zap.Error(err))
+		return fmt.Errorf("SaveDocument: failed to seal document: %w", err)
+	}
+
 	tx, err := ps.pool.Begin(ctx)
 	if err != nil {
 		ps.logger.Error("SaveDocument: failed to begin transaction", zap.Error(err))
@@ -106,6 +135,11 @@ func (ps *PostgresService) SaveDocument(ctx context.Context, document *documents
 		}
 	}()
 
+	err = ps.chargeUsage(ctx, tx, document.Login, document.UsageBytes(), 1)
+	if err != nil {
+		return fmt.Errorf("SaveDocument: %w", err)
+	}
+
 	tag, err := tx.Exec(ctx, querySaveDocument,
 		document.Id,
 		document.Login,
@@ -113,9 +147,22 @@ func (ps *PostgresService) SaveDocument(ctx context.Context, document *documents
 		document.Mime,
 		document.File,
 		document.Public,
-		document.Content,
-		document.JSON,
+		sealed.Content,
+		sealed.JSON,
 		document.CreatedAt,
+		document.Codec,
+		document.Size,
+		sealed.DataKey,
+		sealed.KeyID,
+		sealed.SealedJSON,
+		document.Schema,
+		sealed.Text,
+		document.Tags,
+		document.Metadata,
+		document.FolderId,
+		document.UsageBytes(),
+		document.ExpiresAt,
+		document.DetectedMime,
 	)
 	if err != nil {
 		ps.logger.Error("SaveDocument: failed to save document", zap.Error(err))
@@ -130,6 +177,11 @@ func (ps *PostgresService) SaveDocument(ctx context.Context, document *documents
 	for _, grantee := range document.Grant {
 		tag, err = tx.Exec(ctx, querySaveDocumentGrant, document.Id, grantee)
 		if err != nil {
+			if isViolation(err, "23503") {
+				ps.logger.Warn("SaveDocument: unknown grantee", zap.String("grantee", grantee))
+				return fmt.Errorf("SaveDocument: failed to save grant: %w", ErrUnknownGrantee)
+			}
+
 			return fmt.Errorf("SaveDocument: failed to save grant: %w", err)
 		}
 
@@ -149,46 +201,274 @@ func (ps *PostgresService) SaveDocument(ctx context.Context, document *documents
 	return nil
 }
 
-func (ps *PostgresService) Close() {
-	ps.pool.Close()
+func (ps *PostgresService) GetDocument(ctx context.Context, id string) (*documents.Document, error) {
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	return ps.getDocument(ctx, ps.reader(ctx), id)
 }
 
-func buildURL(config *Config) string {
-	url := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		config.User,
-		config.Password,
-		config.Host,
-		config.Port,
-		config.Database,
+// GetDocumentForWrite returns a document like GetDocument for the access check of a change.
+// It always reads the primary: a lagging replica could still show a revoked grant or an old version.
+func (ps *PostgresService) GetDocumentForWrite(ctx context.Context, id string) (*documents.Document, error) {
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	return ps.getDocument(ctx, ps.pool, id)
+}
+
+func (ps *PostgresService) getDocument(ctx context.Context, reader querier, id string) (*documents.Document, error) {
+	var (
+		document documents.Document
+		sealed   sealing.Content
+	)
+
+	err := reader.QueryRow(ctx, queryGetDocument, id).Scan(
+		&document.Id,
+		&document.Login,
+		&document.Name,
+		&document.Mime,
+		&document.DetectedMime,
+		&document.File,
+		&document.Public,
+		&sealed.Content,
+		&sealed.JSON,
+		&document.CreatedAt,
+		&document.Codec,
+		&document.Size,
+		&sealed.DataKey,
+		&sealed.KeyID,
+		&sealed.SealedJSON,
+		&document.Version,
+		&document.UpdatedAt,
+		&document.Schema,
+		&document.Tags,
+		&document.Metadata,
+		&document.FolderId,
+		&document.ExpiresAt,
 	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ps.logger.Warn("GetDocument: document not found", zap.String("id", id))
+			return nil, ErrDocumentNotFound
+		}
+
+		ps.logger.Error("GetDocument: failed to get document", zap.Error(err))
+		return nil, fmt.Errorf("GetDocument: failed to get document: %w", err)
+	}
+
+	if document.Expired(time.Now()) {
+		ps.logger.Warn("GetDocument: document expired", zap.String("id", id))
+		return nil, ErrDocumentNotFound
+	}
+
+	err = sealing.Open(ps.keyring, &document, &sealed)
+	if err != nil {
+		ps.logger.Error("GetDocument: failed to open document", zap.Error(err))
+		return nil, fmt.Errorf("GetDocument: failed to open document: %w", err)
+	}
+
+	document.Grant, err = ps.documentGrants(ctx, reader, id)
+	if err != nil {
+		return nil, fmt.Errorf("GetDocument: %w", err)
+	}
 
-	return url
+	ps.logger.Info("GetDocument: successfully get document", zap.String("id", id))
+	return &document, nil
 }
 
-func buildDSN(config *Config) string {
-	dsn := fmt.Sprintf("user=%s password=%s host=%s port=%s dbname=%s pool_max_conns=%d pool_min_conns=%d",
-		config.User,
-		config.Password,
-		config.Host,
-		config.Port,
-		config.Database,
-		config.MaxConns,
-		config.MinConns,
+// GetDocumentInfo returns a document with its grants like GetDocument, but without content and JSON.
+func (ps *PostgresService) GetDocumentInfo(ctx context.Context, id string) (*documents.Document, error) {
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	var document documents.Document
+
+	err := ps.pool.QueryRow(ctx, queryGetDocumentInfo, id).Scan(
+		&document.Id,
+		&document.Login,
+		&document.Name,
+		&document.Mime,
+		&document.DetectedMime,
+		&document.File,
+		&document.Public,
+		&document.CreatedAt,
+		&document.Codec,
+		&document.Size,
+		&document.Sealed,
+		&document.Version,
+		&document.UpdatedAt,
+		&document.Schema,
+		&document.Tags,
+		&document.Metadata,
+		&document.FolderId,
+		&document.ExpiresAt,
 	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ps.logger.Warn("GetDocumentInfo: document not found", zap.String("id", id))
+			return nil, ErrDocumentNotFound
+		}
+
+		ps.logger.Error("GetDocumentInfo: failed to get document", zap.Error(err))
+		return nil, fmt.Errorf("GetDocumentInfo: failed to get document: %w", err)
+	}
+
+	if document.Expired(time.Now()) {
+		ps.logger.Warn("GetDocumentInfo: document expired", zap.String("id", id))
+		return nil, ErrDocumentNotFound
+	}
 
-	return dsn
+	document.Grant, err = ps.documentGrants(ctx, ps.pool, id)
+	if err != nil {
+		return nil, fmt.Errorf("GetDocumentInfo: %w", err)
+	}
+
+	return &document, nil
 }
 
-func upMigration(url string, path string) error {
-	migration, err := migrate.New(path, url)
+// documentGrants returns the logins a document is shared with directly or through its folders.
+func (ps *PostgresService) documentGrants(ctx context.Context, q querier, id string) ([]string, error) {
+	rows, err := q.Query(ctx, queryGetDocumentGrants, id)
 	if err != nil {
-		return fmt.Errorf("failed to create migration: %w", err)
+		ps.logger.Error("documentGrants: failed to get grants", zap.Error(err))
+		return nil, fmt.Errorf("documentGrants: failed to get grants: %w", err)
 	}
 
-	err = migration.Up()
-	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
-		return fmt.Errorf("failed to run migration: %w", err)
+	grant, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		ps.logger.Error("documentGrants: failed to collect grants", zap.Error(err))
+		return nil, fmt.Errorf("documentGrants: failed to collect grants: %w", err)
 	}
 
-	return nil
+	return grant, nil
+}
+
+// GetStats counts the documents of login that are neither trashed nor expired.
+func (ps *PostgresService) GetStats(ctx context.Context, login string) (*documents.Stats, error) {
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	var stats documents.Stats
+
+	err := ps.reader(ctx).QueryRow(ctx, queryGetStats, login, time.Now()).Scan(&stats.Documents, &stats.Size, &stats.StoredSize)
+	if err != nil {
+		ps.logger.Error("GetStats: failed to get stats", zap.Error(err))
+		return nil, fmt.Errorf("GetStats: failed to get stats: %w", err)
+	}
+
+	return &stats, nil
+}
+
+// RewrapDataKeys re-wraps data keys of all documents under the active master key
+// in batches and returns the number of updated documents. Content is not re-encrypted.
+func (ps *PostgresService) RewrapDataKeys(ctx context.Context) (int, error) {
+	if !ps.keyring.Enabled() {
+		ps.logger.Error("RewrapDataKeys: encryption is disabled")
+		return 0, fmt.Errorf("RewrapDataKeys: %w", keyring.ErrNoActiveKey)
+	}
+
+	total := 0
+
+	batches := []struct {
+		selectQuery string
+		updateQuery string
+	}{
+		{querySelectKeysToRewrap, queryUpdateDataKey},
+		{querySelectVersionKeysToRewrap, queryUpdateVersionDataKey},
+	}
+
+	for _, batch := range batches {
+		for {
+			count, err := ps.rewrapBatch(ctx, batch.selectQuery, batch.updateQuery)
+			if err != nil {
+				return total, err
+			}
+
+			if count == 0 {
+				break
+			}
+
+			total += count
+		}
+	}
+
+	ps.logger.Info("RewrapDataKeys: successfully rewrapped data keys", zap.Int("count", total))
+	return total, nil
+}
+
+func (ps *PostgresService) rewrapBatch(ctx context.Context, selectQuery string, updateQuery string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	tx, err := ps.pool.Begin(ctx)
+	if err != nil {
+		ps.logger.Error("rewrapBatch: failed to begin transaction", zap.Error(err))
+		return 0, fmt.Errorf("rewrapBatch: failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err = tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			ps.logger.Warn("rewrapBatch: rollback failed", zap.Error(err))
+		}
+	}()
+
+	type wrappedKey struct {
+		id      string
+		version int
+		keyID   string
+		dataKey []byte
+	}
+
+	rows, err := tx.Query(ctx, selectQuery, ps.keyring.ActiveKeyID(), rewrapBatchSize)
+	if err != nil {
+		ps.logger.Error("rewrapBatch: failed to select data keys", zap.Error(err))
+		return 0, fmt.Errorf("rewrapBatch: failed to select data keys: %w", err)
+	}
+
+	keys, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (wrappedKey, error) {
+		var key wrappedKey
+		err := row.Scan(&key.id, &key.version, &key.keyID, &key.dataKey)
+		return key, err
+	})
+	if err != nil {
+		ps.logger.Error("rewrapBatch: failed to collect data keys", zap.Error(err))
+		return 0, fmt.Errorf("rewrapBatch: failed to collect data keys: %w", err)
+	}
+
+	for _, key := range keys {
+		rewrapped, keyID, err := ps.keyring.RewrapDataKey(key.keyID, key.dataKey, key.id)
+		if err != nil {
+			ps.logger.Error("rewrapBatch: failed to rewrap data key", zap.String("id", key.id), zap.Error(err))
+			return 0, fmt.Errorf("rewrapBatch: failed to rewrap data key of %s: %w", key.id, err)
+		}
+
+		_, err = tx.Exec(ctx, updateQuery, key.id, key.version, rewrapped, keyID)
+		if err != nil {
+			ps.logger.Error("rewrapBatch: failed to update data key", zap.Error(err))
+			return 0, fmt.Errorf("rewrapBatch: failed to update data key: %w", err)
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		ps.logger.Error("rewrapBatch: failed to commit transaction", zap.Error(err))
+		return 0, fmt.Errorf("rewrapBatch: failed to commit transaction: %w", err)
+	}
+
+	return len(keys), nil
+}
+
+func (ps *PostgresService) Close() {
+	closeReplicas(ps.replicas)
+	ps.pool.Close()
+}
+
+func upMigration(ctx context.Context, config *Config, path string, logger *zap.Logger) error {
+	migrator, err := NewMigrator(ctx, config, path, logger)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	return migrator.Up(0)
 }
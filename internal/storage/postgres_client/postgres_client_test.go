@@ -0,0 +1,81 @@
+package postgresClient_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ilyakaznacheev/cleanenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"astral/internal/storage/conformance"
+	"astral/internal/storage/postgres_client"
+)
+
+// TestConformance needs a Postgres database configured through the POSTGRES_* variables
+// of config.env or DATABASE_URL, it is skipped when neither POSTGRES_HOST nor DATABASE_URL is set.
+func TestConformance(t *testing.T) {
+	if os.Getenv("POSTGRES_HOST") == "" && os.Getenv("DATABASE_URL") == "" {
+		t.Skip("neither POSTGRES_HOST nor DATABASE_URL is set")
+	}
+
+	var config postgresClient.Config
+
+	require.NoError(t, cleanenv.ReadEnv(&config))
+
+	conformance.Run(t, func(t *testing.T) postgresClient.PostgresClient {
+		ps, err := postgresClient.New(context.Background(), &config, nil, zap.NewNop(), "file://../../../database/migrations")
+		require.NoError(t, err)
+
+		return ps
+	})
+}
+
+func TestCreateMigration(t *testing.T) {
+	now := time.Date(2026, 10, 19, 8, 30, 15, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		want []string
+		err  error
+	}{
+		{
+			name: "add_index",
+			want: []string{"20261019083015_add_index.up.sql", "20261019083015_add_index.down.sql"},
+		},
+		{
+			name: "Add Index",
+			err:  postgresClient.ErrInvalidMigrationName,
+		},
+		{
+			name: "../escape",
+			err:  postgresClient.ErrInvalidMigrationName,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			paths, err := postgresClient.CreateMigration("file://"+dir, tt.name, now)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+
+			require.NoError(t, err)
+
+			for i, path := range paths {
+				assert.Equal(t, filepath.Join(dir, tt.want[i]), path)
+				assert.FileExists(t, path)
+			}
+
+			_, err = postgresClient.CreateMigration(dir, tt.name, now)
+			assert.Error(t, err, "existing migrations are never overwritten")
+		})
+	}
+}
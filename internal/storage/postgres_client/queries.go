@@ -1,13 +1,330 @@
 package postgresClient
 
+// Table names are not qualified: every connection sets search_path to Config.Schema.
 const (
-	querySaveUser = `INSERT INTO schema_astral.users (login, password_hash) VALUES ($1, $2)`
+	querySchemaExists = `SELECT EXISTS (SELECT 1 FROM pg_namespace WHERE nspname = $1)`
 
-	queryGetPasswordHash = `SELECT password_hash FROM schema_astral.users WHERE login = $1`
+	queryLegacyMigrationsTable = `SELECT to_regclass('public.schema_migrations') IS NOT NULL
+		AND to_regclass(quote_ident($1) || '.schema_migrations') IS NULL`
 
-	querySaveDocument = `INSERT INTO schema_astral.documents
-    (id, login, name, mime, is_file, is_public, content, json, created_at)
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+	querySaveUser = `INSERT INTO users (login, password_hash) VALUES ($1, $2)`
 
-	querySaveDocumentGrant = `INSERT INTO schema_astral.documents_grants (doc_id, grantee_login) VALUES ($1,$2)`
+	queryGetPasswordHash = `SELECT password_hash FROM users WHERE login = $1`
+
+	querySaveDocument = `INSERT INTO documents
+    (id, login, name, mime, is_file, is_public, content, json, created_at, codec, size, data_key, key_id, json_sealed,
+    schema_name, search_text, tags, metadata, folder_id, usage_bytes, expires_at, detected_mime)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, NULLIF($15, ''), $16,
+	COALESCE($17::text[], '{}'), COALESCE($18::jsonb, '{}'), NULLIF($19, '')::uuid, $20, $21, NULLIF($22, ''))`
+
+	querySaveDocumentGrant = `INSERT INTO documents_grants (doc_id, grantee_login) VALUES ($1,$2)`
+
+	queryGetDocument = `SELECT id, login, COALESCE(name, ''), COALESCE(mime, ''), COALESCE(detected_mime, ''), is_file, is_public,
+    content, json, COALESCE(created_at, now()), codec, COALESCE(size, 0), data_key, key_id, json_sealed,
+    version, COALESCE(updated_at, created_at, now()), COALESCE(schema_name, ''), tags, metadata,
+    COALESCE(folder_id::text, ''), expires_at
+	FROM documents WHERE id = $1 AND deleted_at IS NULL`
+
+	queryGetDocumentInfo = `SELECT id, login, COALESCE(name, ''), COALESCE(mime, ''), COALESCE(detected_mime, ''), is_file, is_public,
+    COALESCE(created_at, now()), codec, COALESCE(size, 0), key_id IS NOT NULL,
+    version, COALESCE(updated_at, created_at, now()), COALESCE(schema_name, ''), tags, metadata,
+    COALESCE(folder_id::text, ''), expires_at
+	FROM documents WHERE id = $1 AND deleted_at IS NULL`
+
+	// queryGetDocumentGrants returns direct grants of a document together with
+	// the grants of its folder and all parents of that folder.
+	queryGetDocumentGrants = `WITH RECURSIVE chain AS (
+    SELECT f.id, f.parent_id FROM folders f
+    JOIN documents d ON d.folder_id = f.id WHERE d.id = $1
+    UNION
+    SELECT f.id, f.parent_id FROM folders f JOIN chain c ON f.id = c.parent_id
+	)
+	SELECT grantee_login FROM documents_grants WHERE doc_id = $1
+	UNION
+	SELECT g.grantee_login FROM folder_grants g JOIN chain c ON g.folder_id = c.id`
+
+	queryGetStats = `SELECT count(*), COALESCE(sum(size), 0), COALESCE(sum(octet_length(content)), 0)
+	FROM documents WHERE login = $1 AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > $2)`
+
+	querySelectKeysToRewrap = `SELECT id, version, key_id, data_key FROM documents
+	WHERE key_id IS NOT NULL AND key_id <> $1
+	LIMIT $2 FOR UPDATE SKIP LOCKED`
+
+	queryUpdateDataKey = `UPDATE documents SET data_key = $3, key_id = $4 WHERE id = $1 AND version = $2`
+
+	querySelectVersionKeysToRewrap = `SELECT doc_id, version, key_id, data_key FROM document_versions
+	WHERE key_id IS NOT NULL AND key_id <> $1
+	LIMIT $2 FOR UPDATE SKIP LOCKED`
+
+	queryUpdateVersionDataKey = `UPDATE document_versions SET data_key = $3, key_id = $4
+	WHERE doc_id = $1 AND version = $2`
+
+	queryLockDocument = `SELECT version, login FROM documents
+	WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+
+	// queryArchiveDocument keeps the current content as a version. Its bytes move to versions_bytes,
+	// so they stay charged to the owner until the version is pruned or the document purged.
+	queryArchiveDocument = `WITH archived AS (
+	    INSERT INTO document_versions
+	    (doc_id, version, name, mime, is_file, content, json, json_sealed, codec, size, data_key, key_id, created_at,
+	    schema_name, search_text, usage_bytes, detected_mime)
+	    SELECT id, version, name, mime, is_file, content, json, json_sealed, codec, size, data_key, key_id,
+	    COALESCE(updated_at, created_at), schema_name, search_text, usage_bytes, detected_mime
+	    FROM documents WHERE id = $1
+	    RETURNING usage_bytes
+	)
+	UPDATE documents d SET versions_bytes = d.versions_bytes + a.usage_bytes
+	FROM archived a WHERE d.id = $1`
+
+	queryUpdateDocument = `UPDATE documents
+	SET name = $2, mime = $3, is_file = $4, content = $5, json = $6, codec = $7, size = $8,
+	data_key = $9, key_id = $10, json_sealed = $11, version = version + 1, updated_at = $12,
+	schema_name = NULLIF($13, ''), search_text = $14,
+	tags = COALESCE($15::text[], '{}'), metadata = COALESCE($16::jsonb, '{}'), usage_bytes = $17,
+	detected_mime = NULLIF($18, '')
+	WHERE id = $1
+	RETURNING version`
+
+	queryRestoreVersion = `UPDATE documents d
+	SET name = v.name, mime = v.mime, is_file = v.is_file, content = v.content, json = v.json,
+	json_sealed = v.json_sealed, codec = v.codec, size = v.size, data_key = v.data_key, key_id = v.key_id,
+	schema_name = v.schema_name, search_text = v.search_text, usage_bytes = v.usage_bytes,
+	detected_mime = v.detected_mime, version = d.version + 1, updated_at = $3
+	FROM document_versions v
+	WHERE d.id = $1 AND v.doc_id = $1 AND v.version = $2
+	RETURNING d.version, d.usage_bytes`
+
+	// queryPruneVersions removes the versions outside the retention and returns how many
+	// there were, how many bytes they took and how many were kept because the document is held.
+	queryPruneVersions = `WITH held AS (
+	    SELECT EXISTS (SELECT 1 FROM documents d WHERE d.id = $1 AND ` + condDocumentHeld + `) AS held
+	), expired AS (
+	    SELECT version FROM document_versions
+	    WHERE doc_id = $1
+	    AND (($2::int > 0 AND version < $3::int - $2::int)
+	    OR ($4::int > 0 AND archived_at < now() - make_interval(days => $4::int)))
+	), pruned AS (
+	    DELETE FROM document_versions
+	    WHERE doc_id = $1 AND version IN (SELECT version FROM expired) AND NOT (SELECT held FROM held)
+	    RETURNING usage_bytes
+	), freed AS (
+	    SELECT count(*) AS versions, COALESCE(sum(usage_bytes), 0)::bigint AS bytes FROM pruned
+	), released AS (
+	    UPDATE documents d SET versions_bytes = d.versions_bytes - f.bytes
+	    FROM freed f WHERE d.id = $1 AND f.bytes > 0
+	)
+	SELECT f.versions, f.bytes, CASE WHEN h.held THEN (SELECT count(*) FROM expired) ELSE 0 END
+	FROM freed f, held h`
+
+	queryListVersions = `SELECT version, COALESCE(name, ''), COALESCE(mime, ''), is_file, codec, COALESCE(size, 0),
+    COALESCE(created_at, archived_at)
+	FROM document_versions WHERE doc_id = $1
+	ORDER BY version DESC`
+
+	queryGetVersion = `SELECT COALESCE(name, ''), COALESCE(mime, ''), is_file, content, json, codec, COALESCE(size, 0),
+    data_key, key_id, json_sealed, COALESCE(created_at, archived_at)
+	FROM document_versions WHERE doc_id = $1 AND version = $2`
+
+	querySaveSchema = `INSERT INTO json_schemas (login, name, schema) VALUES ($1, $2, $3)
+	ON CONFLICT (login, name) DO UPDATE SET schema = EXCLUDED.schema, updated_at = now()`
+
+	queryGetSchema = `SELECT schema FROM json_schemas WHERE login = $1 AND name = $2`
+
+	queryListDocuments = `SELECT d.id, d.login, COALESCE(d.name, ''), COALESCE(d.mime, ''), COALESCE(d.detected_mime, ''), d.is_file, d.is_public,
+    COALESCE(d.created_at, now()), d.codec, COALESCE(d.size, 0), d.version, COALESCE(d.updated_at, d.created_at, now()),
+    COALESCE(d.schema_name, ''), d.tags, d.metadata, COALESCE(d.folder_id::text, ''), d.expires_at,
+    ARRAY(SELECT g.grantee_login FROM documents_grants g WHERE g.doc_id = d.id ORDER BY g.grantee_login)
+	FROM documents d`
+
+	condDocumentReadable = `(d.login = %[1]s OR d.is_public OR EXISTS
+	(SELECT 1 FROM documents_grants g WHERE g.doc_id = d.id AND g.grantee_login = %[1]s)
+	OR d.folder_id IN (WITH RECURSIVE shared AS (
+    SELECT folder_id AS id FROM folder_grants WHERE grantee_login = %[1]s
+    UNION
+    SELECT f.id FROM folders f JOIN shared s ON f.parent_id = s.id
+	) SELECT id FROM shared))`
+
+	querySearchDocuments = `SELECT d.id, d.login, COALESCE(d.name, ''), COALESCE(d.mime, ''), COALESCE(d.detected_mime, ''), d.is_file, d.is_public,
+    COALESCE(d.created_at, now()), d.codec, COALESCE(d.size, 0), d.version, COALESCE(d.updated_at, d.created_at, now()),
+    COALESCE(d.schema_name, ''), d.tags, d.metadata, COALESCE(d.folder_id::text, ''), d.expires_at,
+    ARRAY(SELECT g.grantee_login FROM documents_grants g WHERE g.doc_id = d.id ORDER BY g.grantee_login),
+    ts_rank_cd(d.search_vector, q.query) AS rank,
+    ts_headline('simple', COALESCE(d.name, '') || E'\n' || COALESCE(d.search_text, ''), q.query,
+    'MaxFragments=2, MinWords=5, MaxWords=20, FragmentDelimiter=" ... "')
+	FROM documents d, websearch_to_tsquery('simple', %s) q(query)`
+
+	queryUpdateLabels = `UPDATE documents
+	SET tags = COALESCE($2::text[], '{}'), metadata = COALESCE($3::jsonb, '{}')
+	WHERE id = $1 AND deleted_at IS NULL`
+
+	queryMoveDocument = `UPDATE documents SET folder_id = NULLIF($2, '')::uuid
+	WHERE id = $1 AND deleted_at IS NULL`
+
+	queryCreateFolder = `INSERT INTO folders (id, login, parent_id, name, created_at)
+	VALUES ($1, $2, NULLIF($3, '')::uuid, $4, $5)`
+
+	queryGetFolder = `SELECT id, login, COALESCE(parent_id::text, ''), name, created_at
+	FROM folders WHERE id = $1`
+
+	// queryGetFolderGrants returns grants of a folder and of all its parents.
+	queryGetFolderGrants = `WITH RECURSIVE chain AS (
+    SELECT id, parent_id FROM folders WHERE id = $1
+    UNION
+    SELECT f.id, f.parent_id FROM folders f JOIN chain c ON f.id = c.parent_id
+	)
+	SELECT DISTINCT g.grantee_login FROM folder_grants g JOIN chain c ON g.folder_id = c.id`
+
+	// queryResolveFolderPath walks the names in $2 from the root folders of $1.
+	queryResolveFolderPath = `WITH RECURSIVE walk AS (
+    SELECT id, 1 AS depth FROM folders
+    WHERE login = $1 AND parent_id IS NULL AND name = ($2::text[])[1]
+    UNION ALL
+    SELECT f.id, w.depth + 1 FROM folders f JOIN walk w ON f.parent_id = w.id
+    WHERE f.name = ($2::text[])[w.depth + 1]
+	)
+	SELECT id FROM walk WHERE depth = cardinality($2::text[])`
+
+	queryListFolders = `SELECT id, login, COALESCE(parent_id::text, ''), name, created_at
+	FROM folders
+	WHERE login = $1 AND parent_id IS NOT DISTINCT FROM NULLIF($2, '')::uuid
+	ORDER BY name`
+
+	queryUpdateFolder = `UPDATE folders SET parent_id = NULLIF($2, '')::uuid, name = $3 WHERE id = $1`
+
+	// queryFolderIsAncestor reports whether folder $2 is $1 or one of its parents.
+	queryFolderIsAncestor = `WITH RECURSIVE chain AS (
+    SELECT id, parent_id FROM folders WHERE id = $1
+    UNION
+    SELECT f.id, f.parent_id FROM folders f JOIN chain c ON f.id = c.parent_id
+	)
+	SELECT EXISTS (SELECT 1 FROM chain WHERE id = $2)`
+
+	queryDeleteFolderGrants = `DELETE FROM folder_grants WHERE folder_id = $1`
+
+	querySaveFolderGrant = `INSERT INTO folder_grants (folder_id, grantee_login) VALUES ($1, $2)
+	ON CONFLICT DO NOTHING`
+
+	queryDeleteDocument = `UPDATE documents d SET deleted_at = $2
+	WHERE d.id = $1 AND d.deleted_at IS NULL AND NOT ` + condDocumentHeld
+
+	queryListTrash = `SELECT d.id, d.login, COALESCE(d.name, ''), COALESCE(d.mime, ''), COALESCE(d.detected_mime, ''), d.is_file, d.is_public,
+    COALESCE(d.created_at, now()), d.codec, COALESCE(d.size, 0), d.version, COALESCE(d.updated_at, d.created_at, now()),
+    COALESCE(d.schema_name, ''), d.tags, d.metadata, COALESCE(d.folder_id::text, ''), d.deleted_at
+	FROM documents d
+	WHERE d.login = $1 AND d.deleted_at IS NOT NULL
+	ORDER BY d.deleted_at DESC, d.id`
+
+	queryRestoreDocument = `UPDATE documents SET deleted_at = NULL
+	WHERE id = $1 AND login = $2 AND deleted_at IS NOT NULL`
+
+	// queryPurgeDocument removes a trashed document and gives its storage back to the owner.
+	queryPurgeDocument = `WITH purged AS (
+	    DELETE FROM documents d
+	    WHERE d.id = $1 AND d.login = $2 AND d.deleted_at IS NOT NULL AND NOT ` + condDocumentHeld + `
+	    RETURNING login, usage_bytes + versions_bytes AS usage_bytes
+	), released AS (
+	    UPDATE user_usage u SET documents = u.documents - 1, bytes = u.bytes - p.usage_bytes
+	    FROM purged p WHERE u.login = p.login
+	)
+	SELECT login FROM purged`
+
+	// queryPurgeDocuments removes a batch of documents trashed before $1 and gives their
+	// storage back to the owners. Versions and grants go with them through ON DELETE CASCADE.
+	// Held documents stay in the trash.
+	queryPurgeDocuments = `WITH purged AS (
+	    DELETE FROM documents
+	    WHERE id IN (SELECT d.id FROM documents d
+	    WHERE d.deleted_at < $1 AND NOT ` + condDocumentHeld + `
+	    LIMIT $2 FOR UPDATE OF d SKIP LOCKED)
+	    RETURNING id, login, usage_bytes + versions_bytes AS usage_bytes
+	), released AS (
+	    UPDATE user_usage u SET documents = u.documents - p.documents, bytes = u.bytes - p.bytes
+	    FROM (SELECT login, count(*) AS documents, sum(usage_bytes) AS bytes FROM purged GROUP BY login) p
+	    WHERE u.login = p.login
+	)
+	SELECT id, login FROM purged`
+
+	// queryUpdateExpiry can always clear the expiry, but only set it on documents that are not held.
+	queryUpdateExpiry = `UPDATE documents d SET expires_at = $2
+	WHERE d.id = $1 AND d.deleted_at IS NULL AND ($2::timestamp IS NULL OR NOT ` + condDocumentHeld + `)`
+
+	// queryPurgeExpired removes a batch of documents that expired by $1, trashed or not,
+	// and gives their storage back to the owners. Held documents are kept.
+	queryPurgeExpired = `WITH purged AS (
+	    DELETE FROM documents
+	    WHERE id IN (SELECT d.id FROM documents d
+	    WHERE d.expires_at <= $1 AND NOT ` + condDocumentHeld + `
+	    LIMIT $2 FOR UPDATE OF d SKIP LOCKED)
+	    RETURNING id, login, usage_bytes + versions_bytes AS usage_bytes
+	), released AS (
+	    UPDATE user_usage u SET documents = u.documents - p.documents, bytes = u.bytes - p.bytes
+	    FROM (SELECT login, count(*) AS documents, sum(usage_bytes) AS bytes FROM purged GROUP BY login) p
+	    WHERE u.login = p.login
+	)
+	SELECT id, login FROM purged`
+
+	queryEnsureUsage = `INSERT INTO user_usage (login) VALUES ($1) ON CONFLICT (login) DO NOTHING`
+
+	queryLockUsage = `SELECT documents, bytes, max_documents, max_bytes FROM user_usage
+	WHERE login = $1 FOR UPDATE`
+
+	queryChargeUsage = `UPDATE user_usage SET bytes = bytes + $2, documents = documents + $3
+	WHERE login = $1`
+
+	queryGetUsage = `SELECT COALESCE(u.documents, 0), COALESCE(u.bytes, 0), u.max_documents, u.max_bytes
+	FROM users us
+	LEFT JOIN user_usage u ON u.login = us.login
+	WHERE us.login = $1`
+
+	querySetQuota = `INSERT INTO user_usage (login, max_documents, max_bytes) VALUES ($1, $2, $3)
+	ON CONFLICT (login) DO UPDATE SET max_documents = EXCLUDED.max_documents, max_bytes = EXCLUDED.max_bytes`
+
+	// condDocumentHeld is true for a document d under a legal hold, directly or through its owner,
+	// or kept by a retention rule. A rule with a tag and a schema needs both to match.
+	condDocumentHeld = `(EXISTS (SELECT 1 FROM legal_holds h WHERE h.doc_id = d.id OR h.login = d.login)
+	OR EXISTS (SELECT 1 FROM retention_rules r
+	WHERE (r.tag IS NULL OR r.tag = ANY(d.tags)) AND (r.schema_name IS NULL OR r.schema_name = d.schema_name)
+	AND d.created_at + make_interval(days => r.keep_days) > LOCALTIMESTAMP))`
+
+	// condHoldCaller limits queryHoldReason to the documents the caller of a guarded change could
+	// have touched: owned by $2 unless it is empty, and trashed exactly when $3 is true. Anything
+	// else is reported as not found, so the reason of a hold does not leak to other users.
+	condHoldCaller = `($2 = '' OR d.login = $2) AND (d.deleted_at IS NOT NULL) = $3`
+
+	// queryHoldReason explains why a document is held, legal holds go first.
+	queryHoldReason = `SELECT reason FROM (
+	    SELECT 'legal hold: ' || h.reason AS reason, 0 AS kind, h.created_at
+	    FROM documents d
+	    JOIN legal_holds h ON h.doc_id = d.id OR h.login = d.login
+	    WHERE d.id = $1 AND ` + condHoldCaller + `
+	    UNION ALL
+	    SELECT 'retention rule ' || r.name || ' keeps the document until '
+	    || to_char(d.created_at + make_interval(days => r.keep_days), 'YYYY-MM-DD'), 1, r.created_at
+	    FROM documents d
+	    JOIN retention_rules r ON (r.tag IS NULL OR r.tag = ANY(d.tags))
+	    AND (r.schema_name IS NULL OR r.schema_name = d.schema_name)
+	    WHERE d.id = $1 AND ` + condHoldCaller + `
+	    AND d.created_at + make_interval(days => r.keep_days) > LOCALTIMESTAMP
+	) held
+	ORDER BY kind, created_at
+	LIMIT 1`
+
+	queryCreateHold = `INSERT INTO legal_holds (id, doc_id, login, reason, created_at)
+	VALUES ($1, NULLIF($2, '')::uuid, NULLIF($3, ''), $4, $5)`
+
+	queryListHolds = `SELECT id, COALESCE(doc_id::text, ''), COALESCE(login, ''), reason, created_at
+	FROM legal_holds
+	ORDER BY created_at, id`
+
+	queryDeleteHold = `DELETE FROM legal_holds WHERE id = $1`
+
+	querySaveRetentionRule = `INSERT INTO retention_rules (name, tag, schema_name, keep_days, created_at)
+	VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), $4, $5)
+	ON CONFLICT (name) DO UPDATE SET tag = EXCLUDED.tag, schema_name = EXCLUDED.schema_name, keep_days = EXCLUDED.keep_days`
+
+	queryListRetentionRules = `SELECT name, COALESCE(tag, ''), COALESCE(schema_name, ''), keep_days, created_at
+	FROM retention_rules
+	ORDER BY name`
+
+	queryDeleteRetentionRule = `DELETE FROM retention_rules WHERE name = $1`
 )
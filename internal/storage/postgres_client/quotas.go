@@ -0,0 +1,109 @@
+package postgresClient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	"astral/internal/documents"
+)
+
+// GetUsage returns the storage login uses together with its effective quota.
+func (ps *PostgresService) GetUsage(ctx context.Context, login string) (*documents.Usage, error) {
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	var (
+		usage                  documents.Usage
+		maxDocuments, maxBytes *int64
+	)
+
+	err := ps.pool.QueryRow(ctx, queryGetUsage, login).Scan(&usage.Documents, &usage.Bytes, &maxDocuments, &maxBytes)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ps.logger.Warn("GetUsage: user not found", zap.String("login", login))
+			return nil, ErrUserNotFound
+		}
+
+		ps.logger.Error("GetUsage: failed to get usage", zap.Error(err))
+		return nil, fmt.Errorf("GetUsage: failed to get usage: %w", err)
+	}
+
+	ps.applyQuota(&usage, maxDocuments, maxBytes)
+
+	return &usage, nil
+}
+
+// SetQuota overrides the default quota of login. A nil limit falls back to the default, zero means unlimited.
+func (ps *PostgresService) SetQuota(ctx context.Context, login string, maxDocuments *int64, maxBytes *int64) error {
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	_, err := ps.pool.Exec(ctx, querySetQuota, login, maxDocuments, maxBytes)
+	if err != nil {
+		if isViolation(err, "23503") {
+			ps.logger.Warn("SetQuota: user not found", zap.String("login", login))
+			return ErrUserNotFound
+		}
+
+		ps.logger.Error("SetQuota: failed to set quota", zap.Error(err))
+		return fmt.Errorf("SetQuota: failed to set quota: %w", err)
+	}
+
+	ps.logger.Info("SetQuota: successfully set quota", zap.String("login", login))
+	return nil
+}
+
+// chargeUsage adds bytes and documents to the usage of login inside tx, or returns
+// documents.ErrQuotaExceeded or documents.ErrDocumentTooLarge when they do not fit.
+// The usage row stays locked until tx ends, so concurrent uploads of one login are serialized.
+func (ps *PostgresService) chargeUsage(ctx context.Context, tx pgx.Tx, login string, bytes int64, docs int64) error {
+	_, err := tx.Exec(ctx, queryEnsureUsage, login)
+	if err != nil {
+		ps.logger.Error("chargeUsage: failed to create usage", zap.Error(err))
+		return fmt.Errorf("chargeUsage: failed to create usage: %w", err)
+	}
+
+	var (
+		usage                  documents.Usage
+		maxDocuments, maxBytes *int64
+	)
+
+	err = tx.QueryRow(ctx, queryLockUsage, login).Scan(&usage.Documents, &usage.Bytes, &maxDocuments, &maxBytes)
+	if err != nil {
+		ps.logger.Error("chargeUsage: failed to lock usage", zap.Error(err))
+		return fmt.Errorf("chargeUsage: failed to lock usage: %w", err)
+	}
+
+	ps.applyQuota(&usage, maxDocuments, maxBytes)
+
+	err = usage.Check(bytes, docs)
+	if err != nil {
+		ps.logger.Warn("chargeUsage: quota exceeded", zap.String("login", login), zap.Error(err))
+		return err
+	}
+
+	_, err = tx.Exec(ctx, queryChargeUsage, login, bytes, docs)
+	if err != nil {
+		ps.logger.Error("chargeUsage: failed to update usage", zap.Error(err))
+		return fmt.Errorf("chargeUsage: failed to update usage: %w", err)
+	}
+
+	return nil
+}
+
+// applyQuota sets the limits of usage from the per-user overrides or the configured defaults.
+func (ps *PostgresService) applyQuota(usage *documents.Usage, maxDocuments *int64, maxBytes *int64) {
+	usage.MaxDocuments = ps.quotaDocuments
+	if maxDocuments != nil {
+		usage.MaxDocuments = *maxDocuments
+	}
+
+	usage.MaxBytes = ps.quotaBytes
+	if maxBytes != nil {
+		usage.MaxBytes = *maxBytes
+	}
+}
@@ -0,0 +1,283 @@
+package postgresClient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	"astral/internal/caller"
+)
+
+// querier is what reads need from a pool, so that they run on the primary and on replicas alike.
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// openReplicas creates a pool per replica URL. Pools connect lazily, so a replica
+// that is down at start only costs fallbacks to the primary.
+func openReplicas(ctx context.Context, config *Config) ([]*replica, error) {
+	replicas := make([]*replica, 0, len(config.ReplicaURLs))
+
+	for i := range config.ReplicaURLs {
+		pc, err := replicaPoolConfig(config, i)
+		if err != nil {
+			closeReplicas(replicas)
+			return nil, err
+		}
+
+		pool, err := pgxpool.NewWithConfig(ctx, pc)
+		if err != nil {
+			closeReplicas(replicas)
+			return nil, fmt.Errorf("openReplicas: failed to create pool of replica %d: %w", i+1, err)
+		}
+
+		replicas = append(replicas, &replica{
+			name: net.JoinHostPort(pc.ConnConfig.Host, strconv.Itoa(int(pc.ConnConfig.Port))),
+			pool: pool,
+		})
+	}
+
+	return replicas, nil
+}
+
+func closeReplicas(replicas []*replica) {
+	for _, r := range replicas {
+		r.pool.Close()
+	}
+}
+
+type primaryKey struct{}
+
+// WithPrimary sends the reads of ctx to the primary, for results that must not lag behind a recent write.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// reader returns where a read goes: the next healthy replica, or the primary when there are
+// no replicas, all of them are down, ctx asks for it with WithPrimary or the login of ctx
+// wrote within the pin window.
+func (ps *PostgresService) reader(ctx context.Context) querier {
+	if len(ps.replicas) == 0 {
+		ps.counters.reads.Add(1)
+		return ps.pool
+	}
+
+	now := time.Now()
+
+	if primary, _ := ctx.Value(primaryKey{}).(bool); primary {
+		ps.counters.reads.Add(1)
+		ps.counters.pinned.Add(1)
+		return ps.pool
+	}
+
+	if login := caller.Login(ctx); login != "" && ps.pins.pinned(login, now) {
+		ps.counters.reads.Add(1)
+		ps.counters.pinned.Add(1)
+		return ps.pool
+	}
+
+	r := ps.pickReplica(now)
+	if r == nil {
+		ps.counters.reads.Add(1)
+		ps.counters.fallbacks.Add(1)
+		return ps.pool
+	}
+
+	r.reads.Add(1)
+
+	return &replicaReader{ps: ps, replica: r}
+}
+
+// pickReplica returns the replicas in turn, skipping those that failed recently.
+func (ps *PostgresService) pickReplica(now time.Time) *replica {
+	start := ps.next.Add(1)
+
+	for i := range ps.replicas {
+		r := ps.replicas[(start+uint64(i))%uint64(len(ps.replicas))]
+
+		if r.downUntil.Load() <= now.UnixNano() {
+			return r
+		}
+	}
+
+	return nil
+}
+
+// wrote pins the reads of login, or of the login of ctx when login is empty, to the primary.
+func (ps *PostgresService) wrote(ctx context.Context, login string) {
+	if len(ps.replicas) == 0 {
+		return
+	}
+
+	if login == "" {
+		login = caller.Login(ctx)
+	}
+
+	if login != "" {
+		ps.pins.pin(login, time.Now())
+	}
+}
+
+// failed takes r out of rotation for the cooldown and counts the read the primary serves instead.
+func (ps *PostgresService) failed(r *replica, err error) {
+	r.failures.Add(1)
+	r.downUntil.Store(time.Now().Add(ps.replicaCooldown).UnixNano())
+
+	ps.counters.reads.Add(1)
+	ps.counters.fallbacks.Add(1)
+
+	ps.logger.Warn("replicaReader: replica failed, reading from the primary", zap.String("replica", r.name), zap.Error(err))
+}
+
+// replicaReader runs a read on a replica and repeats it on the primary when the replica fails.
+// Only errors that occur before the first row are repeated, Query reports failures of
+// CollectRows through the rows like any other read.
+type replicaReader struct {
+	ps      *PostgresService
+	replica *replica
+}
+
+func (rr *replicaReader) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	rows, err := rr.replica.pool.Query(ctx, sql, args...)
+	if err == nil || !replicaFailed(ctx, err) {
+		return rows, err
+	}
+
+	rr.ps.failed(rr.replica, err)
+
+	return rr.ps.pool.Query(ctx, sql, args...)
+}
+
+func (rr *replicaReader) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return &replicaRow{
+		reader: rr,
+		ctx:    ctx,
+		row:    rr.replica.pool.QueryRow(ctx, sql, args...),
+		sql:    sql,
+		args:   args,
+	}
+}
+
+// replicaRow defers the fallback of QueryRow to Scan, where pgx reports the errors of a row.
+type replicaRow struct {
+	reader *replicaReader
+	ctx    context.Context
+	row    pgx.Row
+	sql    string
+	args   []any
+}
+
+func (r *replicaRow) Scan(dest ...any) error {
+	err := r.row.Scan(dest...)
+	if err == nil || !replicaFailed(r.ctx, err) {
+		return err
+	}
+
+	r.reader.ps.failed(r.reader.replica, err)
+
+	return r.reader.ps.pool.QueryRow(r.ctx, r.sql, r.args...).Scan(dest...)
+}
+
+// replicaFailed tells a failure of the replica from an answer the primary would give as well:
+// no rows, a cancelled request or an error in the query.
+func replicaFailed(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, pgx.ErrNoRows) {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+
+	if errors.As(err, &pgErr) {
+		// 40001 is a conflict with recovery, 08 are connection exceptions
+		// and 57 are shutdowns and other operator intervention.
+		return pgErr.Code == "40001" || strings.HasPrefix(pgErr.Code, "08") || strings.HasPrefix(pgErr.Code, "57")
+	}
+
+	return true
+}
+
+func newPins(window time.Duration) *pins {
+	return &pins{
+		window: window,
+		until:  make(map[string]time.Time),
+	}
+}
+
+// pin keeps the reads of login on the primary for the window from now.
+// Expired pins are dropped once per window, so the map holds recent writers only.
+func (p *pins) pin(login string, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.until[login] = now.Add(p.window)
+
+	if now.Sub(p.pruned) < p.window {
+		return
+	}
+
+	for l, until := range p.until {
+		if !until.After(now) {
+			delete(p.until, l)
+		}
+	}
+
+	p.pruned = now
+}
+
+func (p *pins) pinned(login string, now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	until, ok := p.until[login]
+
+	return ok && until.After(now)
+}
+
+// PoolStats returns the state of the primary pool followed by the replicas.
+func (ps *PostgresService) PoolStats() []PoolStats {
+	primary := poolStats("primary", "primary", ps.pool)
+	primary.Reads = ps.counters.reads.Load()
+	primary.Pinned = ps.counters.pinned.Load()
+	primary.Fallbacks = ps.counters.fallbacks.Load()
+
+	stats := []PoolStats{primary}
+
+	now := time.Now().UnixNano()
+
+	for _, r := range ps.replicas {
+		s := poolStats(r.name, "replica", r.pool)
+		s.Reads = r.reads.Load()
+		s.Failures = r.failures.Load()
+		s.Down = r.downUntil.Load() > now
+
+		stats = append(stats, s)
+	}
+
+	return stats
+}
+
+func poolStats(name string, role string, pool *pgxpool.Pool) PoolStats {
+	stat := pool.Stat()
+
+	return PoolStats{
+		Name:            name,
+		Role:            role,
+		TotalConns:      stat.TotalConns(),
+		IdleConns:       stat.IdleConns(),
+		AcquiredConns:   stat.AcquiredConns(),
+		MaxConns:        stat.MaxConns(),
+		AcquireCount:    stat.AcquireCount(),
+		AcquireDuration: stat.AcquireDuration(),
+		EmptyAcquires:   stat.EmptyAcquireCount(),
+	}
+}
@@ -0,0 +1,47 @@
+package postgresClient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// SaveSchema registers a named JSON schema of a user, replacing the schema with the same name.
+func (ps *PostgresService) SaveSchema(ctx context.Context, login string, name string, schema []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	ps.wrote(ctx, login)
+
+	_, err := ps.pool.Exec(ctx, querySaveSchema, login, name, schema)
+	if err != nil {
+		ps.logger.Error("SaveSchema: failed to save schema", zap.Error(err))
+		return fmt.Errorf("SaveSchema: failed to save schema: %w", err)
+	}
+
+	ps.logger.Info("SaveSchema: successfully save schema", zap.String("login", login), zap.String("name", name))
+	return nil
+}
+
+func (ps *PostgresService) GetSchema(ctx context.Context, login string, name string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	var schema []byte
+
+	err := ps.pool.QueryRow(ctx, queryGetSchema, login, name).Scan(&schema)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ps.logger.Warn("GetSchema: schema not found", zap.String("login", login), zap.String("name", name))
+			return nil, ErrSchemaNotFound
+		}
+
+		ps.logger.Error("GetSchema: failed to get schema", zap.Error(err))
+		return nil, fmt.Errorf("GetSchema: failed to get schema: %w", err)
+	}
+
+	return schema, nil
+}
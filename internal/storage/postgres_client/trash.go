@@ -0,0 +1,153 @@
+package postgresClient
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	"astral/internal/documents"
+)
+
+// DeleteDocument moves a document to the trash of its owner. A trashed document is
+// hidden from reads and listings until it is restored or purged. Held documents can not be deleted.
+func (ps *PostgresService) DeleteDocument(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	ps.wrote(ctx, "")
+
+	tag, err := ps.pool.Exec(ctx, queryDeleteDocument, id, time.Now())
+	if err != nil {
+		ps.logger.Error("DeleteDocument: failed to delete document", zap.Error(err))
+		return fmt.Errorf("DeleteDocument: failed to delete document: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ps.notChanged(ctx, "DeleteDocument", id, "", false)
+	}
+
+	ps.logger.Info("DeleteDocument: successfully move document to trash", zap.String("id", id))
+	return nil
+}
+
+// ListTrash returns the trashed documents of login without content, most recently deleted first.
+func (ps *PostgresService) ListTrash(ctx context.Context, login string) ([]documents.Document, error) {
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	rows, err := ps.reader(ctx).Query(ctx, queryListTrash, login)
+	if err != nil {
+		ps.logger.Error("ListTrash: failed to list trash", zap.Error(err))
+		return nil, fmt.Errorf("ListTrash: failed to list trash: %w", err)
+	}
+
+	docs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (documents.Document, error) {
+		var document documents.Document
+
+		err := row.Scan(
+			&document.Id,
+			&document.Login,
+			&document.Name,
+			&document.Mime,
+			&document.DetectedMime,
+			&document.File,
+			&document.Public,
+			&document.CreatedAt,
+			&document.Codec,
+			&document.Size,
+			&document.Version,
+			&document.UpdatedAt,
+			&document.Schema,
+			&document.Tags,
+			&document.Metadata,
+			&document.FolderId,
+			&document.DeletedAt,
+		)
+
+		return document, err
+	})
+	if err != nil {
+		ps.logger.Error("ListTrash: failed to collect documents", zap.Error(err))
+		return nil, fmt.Errorf("ListTrash: failed to collect documents: %w", err)
+	}
+
+	return docs, nil
+}
+
+// RestoreDocument takes a document of login out of the trash.
+func (ps *PostgresService) RestoreDocument(ctx context.Context, id string, login string) error {
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	ps.wrote(ctx, login)
+
+	tag, err := ps.pool.Exec(ctx, queryRestoreDocument, id, login)
+	if err != nil {
+		ps.logger.Error("RestoreDocument: failed to restore document", zap.Error(err))
+		return fmt.Errorf("RestoreDocument: failed to restore document: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		ps.logger.Warn("RestoreDocument: document not found in trash", zap.String("id", id))
+		return ErrDocumentNotFound
+	}
+
+	ps.logger.Info("RestoreDocument: successfully restore document", zap.String("id", id))
+	return nil
+}
+
+// PurgeDocument removes a trashed document of login for good, together with its versions and grants.
+// Held documents can not be purged.
+func (ps *PostgresService) PurgeDocument(ctx context.Context, id string, login string) error {
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	ps.wrote(ctx, login)
+
+	tag, err := ps.pool.Exec(ctx, queryPurgeDocument, id, login)
+	if err != nil {
+		ps.logger.Error("PurgeDocument: failed to purge document", zap.Error(err))
+		return fmt.Errorf("PurgeDocument: failed to purge document: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ps.notChanged(ctx, "PurgeDocument", id, login, true)
+	}
+
+	ps.logger.Info("PurgeDocument: successfully purge document", zap.String("id", id))
+	return nil
+}
+
+// PurgeDocuments removes up to limit documents that were trashed before the given time
+// and returns their ids and owners. Content, versions and grants go with them.
+func (ps *PostgresService) PurgeDocuments(ctx context.Context, before time.Time, limit int) ([]documents.Document, error) {
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	rows, err := ps.pool.Query(ctx, queryPurgeDocuments, before, limit)
+	if err != nil {
+		ps.logger.Error("PurgeDocuments: failed to purge documents", zap.Error(err))
+		return nil, fmt.Errorf("PurgeDocuments: failed to purge documents: %w", err)
+	}
+
+	purged, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (documents.Document, error) {
+		var document documents.Document
+
+		err := row.Scan(&document.Id, &document.Login)
+
+		return document, err
+	})
+	if err != nil {
+		ps.logger.Error("PurgeDocuments: failed to collect purged documents", zap.Error(err))
+		return nil, fmt.Errorf("PurgeDocuments: failed to collect purged documents: %w", err)
+	}
+
+	if len(purged) > 0 {
+		ps.logger.Info("PurgeDocuments: purged documents", zap.Int("count", len(purged)))
+	}
+
+	return purged, nil
+}
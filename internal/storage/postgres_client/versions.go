@@ -0,0 +1,309 @@
+package postgresClient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	"astral/internal/documents"
+	"astral/internal/sealing"
+)
+
+// UpdateDocument replaces the content of a document and keeps the previous one as a version.
+// When expectedVersion is positive the update only succeeds if it is still the current version.
+// On success document.Version holds the new version.
+// The new content is charged to the owner once pruned versions are given back; when it does not
+// fit the quota the update fails with documents.ErrQuotaExceeded and nothing changes.
+func (ps *PostgresService) UpdateDocument(ctx context.Context, document *documents.Document, expectedVersion int) error {
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	ps.wrote(ctx, "")
+
+	sealed, err := sealing.Seal(ps.keyring, document)
+	if err != nil {
+		ps.logger.Error("UpdateDocument: failed to seal document", zap.Error(err))
+		return fmt.Errorf("UpdateDocument: failed to seal document: %w", err)
+	}
+
+	tx, err := ps.pool.Begin(ctx)
+	if err != nil {
+		ps.logger.Error("UpdateDocument: failed to begin transaction", zap.Error(err))
+		return fmt.Errorf("UpdateDocument: failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err = tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			ps.logger.Warn("UpdateDocument: rollback failed", zap.Error(err))
+		}
+	}()
+
+	current, err := ps.lockDocument(ctx, tx, document.Id)
+	if err != nil {
+		return fmt.Errorf("UpdateDocument: %w", err)
+	}
+
+	if expectedVersion > 0 && current.version != expectedVersion {
+		ps.logger.Warn("UpdateDocument: version conflict",
+			zap.Int("expected", expectedVersion),
+			zap.Int("current", current.version),
+		)
+		return ErrVersionConflict
+	}
+
+	_, err = tx.Exec(ctx, queryArchiveDocument, document.Id)
+	if err != nil {
+		ps.logger.Error("UpdateDocument: failed to archive document", zap.Error(err))
+		return fmt.Errorf("UpdateDocument: failed to archive document: %w", err)
+	}
+
+	err = tx.QueryRow(ctx, queryUpdateDocument,
+		document.Id,
+		document.Name,
+		document.Mime,
+		document.File,
+		sealed.Content,
+		sealed.JSON,
+		document.Codec,
+		document.Size,
+		sealed.DataKey,
+		sealed.KeyID,
+		sealed.SealedJSON,
+		document.UpdatedAt,
+		document.Schema,
+		sealed.Text,
+		document.Tags,
+		document.Metadata,
+		document.UsageBytes(),
+		document.DetectedMime,
+	).Scan(&document.Version)
+	if err != nil {
+		ps.logger.Error("UpdateDocument: failed to update document", zap.Error(err))
+		return fmt.Errorf("UpdateDocument: failed to update document: %w", err)
+	}
+
+	freed, err := ps.pruneVersions(ctx, tx, document.Id, document.Version)
+	if err != nil {
+		return fmt.Errorf("UpdateDocument: %w", err)
+	}
+
+	// The replaced content stays charged as a version, the new one comes on top.
+	err = ps.chargeUsage(ctx, tx, current.login, document.UsageBytes()-freed, 0)
+	if err != nil {
+		return fmt.Errorf("UpdateDocument: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		ps.logger.Error("UpdateDocument: failed to commit transaction", zap.Error(err))
+		return fmt.Errorf("UpdateDocument: failed to commit transaction: %w", err)
+	}
+
+	ps.logger.Info("UpdateDocument: successfully update document",
+		zap.String("id", document.Id),
+		zap.Int("version", document.Version),
+	)
+	return nil
+}
+
+// ListVersions returns the archived versions of a document without content, newest first.
+func (ps *PostgresService) ListVersions(ctx context.Context, id string) ([]documents.Document, error) {
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	rows, err := ps.reader(ctx).Query(ctx, queryListVersions, id)
+	if err != nil {
+		ps.logger.Error("ListVersions: failed to list versions", zap.Error(err))
+		return nil, fmt.Errorf("ListVersions: failed to list versions: %w", err)
+	}
+
+	versions, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (documents.Document, error) {
+		version := documents.Document{Id: id}
+
+		err := row.Scan(
+			&version.Version,
+			&version.Name,
+			&version.Mime,
+			&version.File,
+			&version.Codec,
+			&version.Size,
+			&version.CreatedAt,
+		)
+
+		return version, err
+	})
+	if err != nil {
+		ps.logger.Error("ListVersions: failed to collect versions", zap.Error(err))
+		return nil, fmt.Errorf("ListVersions: failed to collect versions: %w", err)
+	}
+
+	return versions, nil
+}
+
+// GetVersion returns an archived version of a document with its content.
+// Ownership and grants are not part of a version and stay empty.
+func (ps *PostgresService) GetVersion(ctx context.Context, id string, version int) (*documents.Document, error) {
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	var sealed sealing.Content
+
+	document := documents.Document{
+		Id:      id,
+		Version: version,
+	}
+
+	err := ps.reader(ctx).QueryRow(ctx, queryGetVersion, id, version).Scan(
+		&document.Name,
+		&document.Mime,
+		&document.File,
+		&sealed.Content,
+		&sealed.JSON,
+		&document.Codec,
+		&document.Size,
+		&sealed.DataKey,
+		&sealed.KeyID,
+		&sealed.SealedJSON,
+		&document.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ps.logger.Warn("GetVersion: version not found", zap.String("id", id), zap.Int("version", version))
+			return nil, ErrVersionNotFound
+		}
+
+		ps.logger.Error("GetVersion: failed to get version", zap.Error(err))
+		return nil, fmt.Errorf("GetVersion: failed to get version: %w", err)
+	}
+
+	err = sealing.Open(ps.keyring, &document, &sealed)
+	if err != nil {
+		ps.logger.Error("GetVersion: failed to open version", zap.Error(err))
+		return nil, fmt.Errorf("GetVersion: failed to open version: %w", err)
+	}
+
+	return &document, nil
+}
+
+// RestoreVersion makes an archived version current again. The replaced content
+// is archived like on any other update. Returns the new version number.
+// The restored copy is charged to the owner like new content, documents.ErrQuotaExceeded
+// is returned when it does not fit.
+func (ps *PostgresService) RestoreVersion(ctx context.Context, id string, version int) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	ps.wrote(ctx, "")
+
+	tx, err := ps.pool.Begin(ctx)
+	if err != nil {
+		ps.logger.Error("RestoreVersion: failed to begin transaction", zap.Error(err))
+		return 0, fmt.Errorf("RestoreVersion: failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err = tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			ps.logger.Warn("RestoreVersion: rollback failed", zap.Error(err))
+		}
+	}()
+
+	locked, err := ps.lockDocument(ctx, tx, id)
+	if err != nil {
+		return 0, fmt.Errorf("RestoreVersion: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, queryArchiveDocument, id)
+	if err != nil {
+		ps.logger.Error("RestoreVersion: failed to archive document", zap.Error(err))
+		return 0, fmt.Errorf("RestoreVersion: failed to archive document: %w", err)
+	}
+
+	var (
+		current    int
+		usageBytes int64
+	)
+
+	err = tx.QueryRow(ctx, queryRestoreVersion, id, version, time.Now()).Scan(&current, &usageBytes)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ps.logger.Warn("RestoreVersion: version not found", zap.String("id", id), zap.Int("version", version))
+			return 0, ErrVersionNotFound
+		}
+
+		ps.logger.Error("RestoreVersion: failed to restore version", zap.Error(err))
+		return 0, fmt.Errorf("RestoreVersion: failed to restore version: %w", err)
+	}
+
+	freed, err := ps.pruneVersions(ctx, tx, id, current)
+	if err != nil {
+		return 0, fmt.Errorf("RestoreVersion: %w", err)
+	}
+
+	// The restored content is a copy of the version, which is kept, so it is charged in full.
+	err = ps.chargeUsage(ctx, tx, locked.login, usageBytes-freed, 0)
+	if err != nil {
+		return 0, fmt.Errorf("RestoreVersion: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		ps.logger.Error("RestoreVersion: failed to commit transaction", zap.Error(err))
+		return 0, fmt.Errorf("RestoreVersion: failed to commit transaction: %w", err)
+	}
+
+	ps.logger.Info("RestoreVersion: successfully restore version",
+		zap.String("id", id),
+		zap.Int("restored", version),
+		zap.Int("version", current),
+	)
+	return current, nil
+}
+
+// lockedDocument is what an update needs to know about the row it locked.
+type lockedDocument struct {
+	version int
+	login   string
+}
+
+// lockDocument locks the document row for the rest of the transaction and returns its version and owner.
+func (ps *PostgresService) lockDocument(ctx context.Context, tx pgx.Tx, id string) (*lockedDocument, error) {
+	var locked lockedDocument
+
+	err := tx.QueryRow(ctx, queryLockDocument, id).Scan(&locked.version, &locked.login)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ps.logger.Warn("lockDocument: document not found", zap.String("id", id))
+			return nil, ErrDocumentNotFound
+		}
+
+		ps.logger.Error("lockDocument: failed to lock document", zap.Error(err))
+		return nil, fmt.Errorf("lockDocument: failed to lock document: %w", err)
+	}
+
+	return &locked, nil
+}
+
+// pruneVersions removes archived versions that fall outside the configured retention
+// and returns the bytes they took, for the caller to give back to the owner.
+// A held document keeps them all, how many were kept is logged.
+func (ps *PostgresService) pruneVersions(ctx context.Context, tx pgx.Tx, id string, current int) (int64, error) {
+	var count, freed, kept int64
+
+	err := tx.QueryRow(ctx, queryPruneVersions, id, ps.versionsKeepLast, current, ps.versionsKeepDays).Scan(&count, &freed, &kept)
+	if err != nil {
+		ps.logger.Error("pruneVersions: failed to prune versions", zap.Error(err))
+		return 0, fmt.Errorf("pruneVersions: failed to prune versions: %w", err)
+	}
+
+	if count > 0 {
+		ps.logger.Info("pruneVersions: pruned versions", zap.String("id", id), zap.Int64("count", count))
+	}
+
+	if kept > 0 {
+		ps.logger.Info("pruneVersions: kept expired versions of a held document", zap.String("id", id), zap.Int64("count", kept))
+	}
+
+	return freed, nil
+}
@@ -2,48 +2,262 @@ package redisClient
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 
 	"astral/internal/documents"
 )
 
+// CacheDocument drops the cached metadata and content of a changed document, they are put back on the next read.
+// The metadata is marked invalidated for the fresh window, so that it is reloaded from the primary.
 func (rs *RedisService) CacheDocument(ctx context.Context, document *documents.Document) error {
 	ctx, cancel := context.WithTimeout(ctx, rs.timeout)
 	defer cancel()
 
-	docForCache := *document
-	docForCache.Content = nil
+	rs.markInvalidated(ctx, docInvalidatedKey(document.Id))
 
-	docBytes, err := json.Marshal(docForCache)
+	err := rs.deleteDocument(ctx, document.Id)
 	if err != nil {
-		rs.logger.Warn("CacheDocument: failed to marshal document for cache", zap.Error(err))
-		return fmt.Errorf("CacheDocument: failed to marshal document for cache: %w", err)
+		rs.logger.Warn("CacheDocument: failed to delete cached document", zap.Error(err))
 	}
 
-	err = rs.cacheDB.Set(ctx, "doc:"+docForCache.Id, docBytes, rs.cacheTTL).Err()
-	if err != nil {
-		rs.logger.Warn("CacheDocument: failed to cache document", zap.Error(err))
-	}
+	rs.invalidateLocal(ctx, docKey(document.Id))
+	rs.invalidateLocal(ctx, contentKey(document.Id))
 
 	rs.logger.Info("CacheDocument: completed cache", zap.String("doc", document.Id))
 	return nil
 }
 
+// GetDocumentInfo returns the metadata of a document, without content, JSON and text, from the cache,
+// calling load on a miss. It is cached like a listing, for REDIS_CACHE_TTL but never past the expiry of the document,
+// and every change of the document, its grants, lock or holds drops it. Load is asked for fresh metadata
+// when the document was invalidated within the fresh window.
+func (rs *RedisService) GetDocumentInfo(ctx context.Context, id string, load func(ctx context.Context, fresh bool) (*documents.Document, error)) (*documents.Document, error) {
+	markers := []string{docInvalidatedKey(""), docInvalidatedKey(id)}
+
+	docBytes, err := rs.fetch(ctx, docKey(id), markers, func(ctx context.Context, fresh bool) ([]byte, time.Time, error) {
+		document, err := load(ctx, fresh)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+
+		info := *document
+		info.Content = nil
+		info.JSON = nil
+		info.Text = ""
+
+		var expiresAt time.Time
+		if info.ExpiresAt != nil {
+			expiresAt = *info.ExpiresAt
+		}
+
+		docBytes, err := json.Marshal(&info)
+		if err != nil {
+			return nil, time.Time{}, fmt.Errorf("failed to marshal document for cache: %w", err)
+		}
+
+		return docBytes, expiresAt, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetDocumentInfo: %w", err)
+	}
+
+	var document documents.Document
+
+	err = json.Unmarshal(docBytes, &document)
+	if err != nil {
+		rs.logger.Warn("GetDocumentInfo: failed to unmarshal cached document", zap.Error(err))
+		return nil, fmt.Errorf("GetDocumentInfo: failed to unmarshal cached document: %w", err)
+	}
+
+	return &document, nil
+}
+
+// GetDocs returns the listing for query.Login from the cache, calling load on a miss. Listings are
+// cached for REDIS_CACHE_TTL but never past the expiry of a listed document, so expired documents drop out in time.
+// Load is asked for a fresh listing when the listings of query.Login were invalidated within the fresh window.
+func (rs *RedisService) GetDocs(ctx context.Context, query *documents.ListQuery, load func(ctx context.Context, fresh bool) ([]documents.Document, error)) ([]documents.Document, error) {
+	key, err := docsKey(query)
+	if err != nil {
+		return nil, fmt.Errorf("GetDocs: %w", err)
+	}
+
+	markers := []string{invalidatedKey(""), invalidatedKey(query.Login)}
+
+	docsBytes, err := rs.fetch(ctx, key, markers, func(ctx context.Context, fresh bool) ([]byte, time.Time, error) {
+		docs, err := load(ctx, fresh)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+
+		var expiresAt time.Time
+
+		for _, document := range docs {
+			if document.ExpiresAt != nil && (expiresAt.IsZero() || document.ExpiresAt.Before(expiresAt)) {
+				expiresAt = *document.ExpiresAt
+			}
+		}
+
+		docsBytes, err := json.Marshal(docs)
+		if err != nil {
+			return nil, time.Time{}, fmt.Errorf("failed to marshal docs for cache: %w", err)
+		}
+
+		return docsBytes, expiresAt, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetDocs: %w", err)
+	}
+
+	var docs []documents.Document
+
+	err = json.Unmarshal(docsBytes, &docs)
+	if err != nil {
+		rs.logger.Warn("GetDocs: failed to unmarshal cached docs", zap.Error(err))
+		return nil, fmt.Errorf("GetDocs: failed to unmarshal cached docs: %w", err)
+	}
+
+	return docs, nil
+}
+
+// LoadDocument calls load for a document, concurrent calls for the same id share one load.
+func (rs *RedisService) LoadDocument(ctx context.Context, id string, load func(ctx context.Context, id string) (*documents.Document, error)) (*documents.Document, error) {
+	result := rs.group.DoChan("load:"+id, func() (any, error) {
+		return load(context.WithoutCancel(ctx), id)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+
+	case res := <-result:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+
+		// Every caller gets its own copy, the content is shared and must not be changed.
+		document := *res.Val.(*documents.Document)
+
+		return &document, nil
+	}
+}
+
+// InvalidateDocs drops all cached listings of login.
 func (rs *RedisService) InvalidateDocs(ctx context.Context, login string) error {
 	ctx, cancel := context.WithTimeout(ctx, rs.timeout)
 	defer cancel()
 
-	pattern := "docs:" + login + ":*"
+	rs.markInvalidated(ctx, invalidatedKey(login))
+
+	err := rs.deleteKeys(ctx, escapeGlob(rs.cacheKey("docs:"+login+":"))+"*")
+	if err != nil {
+		return fmt.Errorf("InvalidateDocs: %w", err)
+	}
+
+	rs.invalidateLocal(ctx, "docs:"+login+":")
+
+	rs.logger.Info("InvalidateDocs: successfully invalidated docs for login", zap.String("login", login))
+	return nil
+}
+
+// InvalidateAllDocs drops cached listings of every user, needed when a public document changes.
+func (rs *RedisService) InvalidateAllDocs(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, rs.timeout)
+	defer cancel()
+
+	rs.markInvalidated(ctx, invalidatedKey(""))
+
+	err := rs.deleteKeys(ctx, escapeGlob(rs.cacheKey("docs:"))+"*")
+	if err != nil {
+		return fmt.Errorf("InvalidateAllDocs: %w", err)
+	}
+
+	rs.invalidateLocal(ctx, "docs:")
+
+	rs.logger.Info("InvalidateAllDocs: successfully invalidated docs for all logins")
+	return nil
+}
+
+// InvalidateDocument drops the cached metadata and content of a single document.
+func (rs *RedisService) InvalidateDocument(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, rs.timeout)
+	defer cancel()
+
+	rs.markInvalidated(ctx, docInvalidatedKey(id))
+
+	err := rs.deleteDocument(ctx, id)
+	if err != nil {
+		rs.logger.Warn("InvalidateDocument: failed to delete cached document", zap.Error(err), zap.String("doc", id))
+		return fmt.Errorf("InvalidateDocument: failed to delete cached document: %w", err)
+	}
+
+	rs.invalidateLocal(ctx, docKey(id))
+	rs.invalidateLocal(ctx, contentKey(id))
+
+	rs.logger.Info("InvalidateDocument: successfully invalidated document", zap.String("doc", id))
+	return nil
+}
+
+// InvalidateAllDocuments drops the cached metadata of every document, needed when folder grants
+// or holds change: they reach documents that are not known by id here.
+func (rs *RedisService) InvalidateAllDocuments(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, rs.timeout)
+	defer cancel()
+
+	rs.markInvalidated(ctx, docInvalidatedKey(""))
+
+	err := rs.deleteKeys(ctx, escapeGlob(rs.cacheKey("doc:"))+"*")
+	if err != nil {
+		return fmt.Errorf("InvalidateAllDocuments: %w", err)
+	}
+
+	rs.invalidateLocal(ctx, "doc:")
+
+	rs.logger.Info("InvalidateAllDocuments: successfully invalidated all documents")
+	return nil
+}
+
+// deleteDocument deletes the cached metadata and content of document id. They live in different
+// hash slots in cluster mode, so each gets its own DEL.
+func (rs *RedisService) deleteDocument(ctx context.Context, id string) error {
+	_, err := rs.cacheDB.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, rs.cacheKey(docKey(id)))
+		pipe.Del(ctx, rs.cacheKey(contentKey(id)))
+
+		return nil
+	})
+
+	return err
+}
+
+// deleteKeys deletes the cache keys matching pattern. In cluster mode every master is scanned.
+func (rs *RedisService) deleteKeys(ctx context.Context, pattern string) error {
+	if cluster, ok := rs.cacheDB.(*redis.ClusterClient); ok {
+		return cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			return rs.deleteNodeKeys(ctx, node, pattern)
+		})
+	}
+
+	return rs.deleteNodeKeys(ctx, rs.cacheDB, pattern)
+}
+
+// deleteNodeKeys deletes the keys matching pattern on one node. Keys are deleted one by one
+// in a pipeline, since a cluster node refuses multi-key commands across hash slots.
+func (rs *RedisService) deleteNodeKeys(ctx context.Context, node redis.Cmdable, pattern string) error {
 	var cursor uint64
 
 	for {
-		keys, next, err := rs.cacheDB.Scan(ctx, cursor, pattern, 100).Result()
+		keys, next, err := node.Scan(ctx, cursor, pattern, 100).Result()
 		if err != nil {
-			rs.logger.Warn("InvalidateDocs: scan failed", zap.Error(err), zap.String("pattern", pattern))
-			return err
+			rs.logger.Warn("deleteKeys: scan failed", zap.Error(err), zap.String("pattern", pattern))
+			return fmt.Errorf("deleteKeys: scan failed: %w", err)
 		}
 
 		for i := 0; i < len(keys); i += batchSize {
@@ -53,12 +267,18 @@ func (rs *RedisService) InvalidateDocs(ctx context.Context, login string) error
 			}
 
 			chunk := keys[i:end]
-			err = rs.cacheDB.Del(ctx, chunk...).Err()
+			_, err = node.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+				for _, key := range chunk {
+					pipe.Del(ctx, key)
+				}
+
+				return nil
+			})
 			if err != nil {
-				rs.logger.Warn("InvalidateDocs: del failed for chunk", zap.Error(err), zap.Int("chunk_size", len(chunk)))
+				rs.logger.Warn("deleteKeys: del failed for chunk", zap.Error(err), zap.Int("chunk_size", len(chunk)))
 
 			} else {
-				rs.logger.Debug("InvalidateDocs: deleted keys chunk", zap.Int("chunk_size", len(chunk)))
+				rs.logger.Debug("deleteKeys: deleted keys chunk", zap.Int("chunk_size", len(chunk)))
 			}
 		}
 
@@ -68,6 +288,53 @@ func (rs *RedisService) InvalidateDocs(ctx context.Context, login string) error
 		}
 	}
 
-	rs.logger.Info("InvalidateDocs: successfully invalidated docs for login", zap.String("login", login))
 	return nil
 }
+
+// globEscaper escapes the characters that are special in a SCAN pattern.
+var globEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+
+// escapeGlob makes s match only itself in a SCAN pattern, so that a login such as "*" or
+// "a?" does not select the keys of other users.
+func escapeGlob(s string) string {
+	return globEscaper.Replace(s)
+}
+
+// docsKey returns the cache key of a listing: docs:<login>:<hash of the rest of the query>.
+// Filters are normalized before they get here, so equal listings share a key.
+func docsKey(query *documents.ListQuery) (string, error) {
+	normalized := *query
+	normalized.Login = ""
+
+	queryBytes, err := json.Marshal(normalized)
+	if err != nil {
+		return "", fmt.Errorf("docsKey: failed to marshal query: %w", err)
+	}
+
+	sum := sha256.Sum256(queryBytes)
+
+	return "docs:" + query.Login + ":" + hex.EncodeToString(sum[:]), nil
+}
+
+// invalidatedKey marks the listings of login as recently invalidated, those of every user for an empty login.
+// It is set before the listings are deleted, so that a load running across the invalidation sees it.
+func invalidatedKey(login string) string {
+	if login == "" {
+		return "docs-invalidated"
+	}
+
+	return "docs-invalidated:" + login
+}
+
+func docKey(id string) string {
+	return "doc:" + id
+}
+
+// docInvalidatedKey marks the metadata of document id as recently invalidated, that of every document for an empty id.
+func docInvalidatedKey(id string) string {
+	if id == "" {
+		return "doc-invalidated"
+	}
+
+	return "doc-invalidated:" + id
+}
@@ -0,0 +1,106 @@
+package redisClient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"astral/internal/documents"
+)
+
+// cachedContent is a file content in the local cache.
+type cachedContent struct {
+	version int
+	content []byte
+}
+
+func contentKey(id string) string {
+	return "content:" + id
+}
+
+// GetContent returns the version and the stored content of a cached file, ErrCacheMiss
+// when it is not cached. The caller compares the version with the current one.
+func (rs *RedisService) GetContent(ctx context.Context, id string) (int, []byte, error) {
+	if rs.contentCacheSize <= 0 {
+		return 0, nil, ErrCacheMiss
+	}
+
+	if value, ok := rs.local.Get(contentKey(id)); ok {
+		cached := value.(*cachedContent)
+
+		rs.contentHits.Add(1)
+		return cached.version, cached.content, nil
+	}
+
+	epoch := rs.local.Epoch()
+
+	ctx, cancel := context.WithTimeout(ctx, rs.timeout)
+	defer cancel()
+
+	values, err := rs.cacheDB.HMGet(ctx, rs.cacheKey(contentKey(id)), "version", "content").Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		rs.logger.Warn("GetContent: failed to get cached content", zap.Error(err))
+		return 0, nil, fmt.Errorf("GetContent: failed to get cached content: %w", err)
+	}
+
+	rawVersion, okVersion := values[0].(string)
+	content, okContent := values[1].(string)
+
+	if !okVersion || !okContent {
+		rs.contentMisses.Add(1)
+		return 0, nil, ErrCacheMiss
+	}
+
+	version, err := strconv.Atoi(rawVersion)
+	if err != nil {
+		rs.contentMisses.Add(1)
+		rs.logger.Warn("GetContent: invalid cached version", zap.String("doc", id), zap.String("version", rawVersion))
+		return 0, nil, ErrCacheMiss
+	}
+
+	cached := &cachedContent{version: version, content: []byte(content)}
+	rs.local.SetSince(contentKey(id), cached, len(cached.content), rs.cacheTTL, epoch)
+
+	rs.contentHits.Add(1)
+	return version, cached.content, nil
+}
+
+// CacheContent caches the stored content of a file document that is at most REDIS_CONTENT_CACHE_SIZE
+// bytes long. Documents stored encrypted are never cached, since the cache would hold them in the clear.
+func (rs *RedisService) CacheContent(ctx context.Context, document *documents.Document) error {
+	if !document.File || document.Sealed || rs.contentCacheSize <= 0 || len(document.Content) > rs.contentCacheSize {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, rs.timeout)
+	defer cancel()
+
+	pipe := rs.cacheDB.TxPipeline()
+	pipe.HSet(ctx, rs.cacheKey(contentKey(document.Id)), "version", document.Version, "content", document.Content)
+	pipe.Expire(ctx, rs.cacheKey(contentKey(document.Id)), rs.cacheTTL)
+
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		rs.logger.Warn("CacheContent: failed to cache content", zap.Error(err))
+		return fmt.Errorf("CacheContent: failed to cache content: %w", err)
+	}
+
+	cached := &cachedContent{version: document.Version, content: document.Content}
+	rs.local.Set(contentKey(document.Id), cached, len(cached.content), rs.cacheTTL)
+
+	rs.logger.Debug("CacheContent: completed cache", zap.String("doc", document.Id), zap.Int("size", len(document.Content)))
+	return nil
+}
+
+// ContentCacheStats returns the hits and misses of GetContent. Writes drop the cached content,
+// so a hit on an outdated version only happens when a download races with an update.
+func (rs *RedisService) ContentCacheStats() CacheStats {
+	return CacheStats{
+		Hits:   rs.contentHits.Load(),
+		Misses: rs.contentMisses.Load(),
+	}
+}
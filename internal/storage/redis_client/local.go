@@ -0,0 +1,56 @@
+package redisClient
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// invalidationChannel carries key prefixes to drop from the local caches of all instances.
+const invalidationChannel = "cache:invalidate"
+
+// invalidateLocal drops keys with prefix from the local cache and tells the other instances to do the same.
+func (rs *RedisService) invalidateLocal(ctx context.Context, prefix string) {
+	if !rs.local.Enabled() {
+		return
+	}
+
+	rs.local.DeletePrefix(prefix)
+
+	err := rs.cacheDB.Publish(ctx, rs.cacheKey(invalidationChannel), prefix).Err()
+	if err != nil {
+		rs.logger.Warn("invalidateLocal: failed to publish invalidation", zap.Error(err), zap.String("prefix", prefix))
+	}
+}
+
+// Listen applies invalidations published by other instances to the local cache until ctx is done.
+// Messages sent while the connection is down are lost, LOCAL_CACHE_TTL bounds how long a missed
+// invalidation leaves a stale value behind.
+func (rs *RedisService) Listen(ctx context.Context) {
+	if !rs.local.Enabled() {
+		return
+	}
+
+	pubsub := rs.cacheDB.Subscribe(ctx, rs.cacheKey(invalidationChannel))
+	defer func() {
+		if err := pubsub.Close(); err != nil {
+			rs.logger.Warn("Listen: failed to close subscription", zap.Error(err))
+		}
+	}()
+
+	messages := pubsub.Channel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case message, ok := <-messages:
+			if !ok {
+				return
+			}
+
+			rs.local.DeletePrefix(message.Payload)
+		}
+	}
+}
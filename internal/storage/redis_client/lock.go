@@ -0,0 +1,140 @@
+package redisClient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"astral/internal/documents"
+)
+
+// lockScript takes the lock with SET NX or, when the caller already holds it, extends it.
+// It returns the holder of the lock.
+var lockScript = redis.NewScript(`
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+	return ARGV[1]
+end
+local holder = redis.call('GET', KEYS[1])
+if holder == ARGV[1] then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return holder
+`)
+
+// unlockScript releases the lock only for its holder and returns the holder.
+var unlockScript = redis.NewScript(`
+local holder = redis.call('GET', KEYS[1])
+if holder == ARGV[1] then
+	redis.call('DEL', KEYS[1])
+end
+return holder
+`)
+
+func lockKey(id string) string {
+	return "lock:" + id
+}
+
+// LockDocument checks the document out to login for ttl, REDIS_LOCK_TTL when ttl is zero
+// and at most REDIS_LOCK_MAX_TTL. Locking again by the holder extends the lock.
+// Returns ErrDocumentLocked together with the current lock when somebody else holds it.
+func (rs *RedisService) LockDocument(ctx context.Context, id string, login string, ttl time.Duration) (*documents.Lock, error) {
+	ctx, cancel := context.WithTimeout(ctx, rs.timeout)
+	defer cancel()
+
+	if ttl <= 0 {
+		ttl = rs.lockTTL
+	}
+
+	ttl = min(ttl, rs.lockMaxTTL)
+
+	holder, err := lockScript.Run(ctx, rs.tokenDB, []string{rs.tokenKey(lockKey(id))}, login, ttl.Milliseconds()).Text()
+	if err != nil {
+		rs.logger.Error("LockDocument: failed to lock document", zap.Error(err))
+		return nil, fmt.Errorf("LockDocument: failed to lock document: %w", err)
+	}
+
+	if holder != login {
+		lock, err := rs.GetDocumentLock(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("LockDocument: %w", err)
+		}
+
+		rs.logger.Warn("LockDocument: document is locked", zap.String("doc", id), zap.String("holder", holder))
+		return lock, ErrDocumentLocked
+	}
+
+	rs.logger.Info("LockDocument: successfully locked document", zap.String("doc", id), zap.Duration("ttl", ttl))
+	return &documents.Lock{DocId: id, Login: login, ExpiresAt: time.Now().Add(ttl)}, nil
+}
+
+// GetDocumentLock returns the current lock of a document, nil when it is not locked.
+func (rs *RedisService) GetDocumentLock(ctx context.Context, id string) (*documents.Lock, error) {
+	ctx, cancel := context.WithTimeout(ctx, rs.timeout)
+	defer cancel()
+
+	pipe := rs.tokenDB.Pipeline()
+	holder := pipe.Get(ctx, rs.tokenKey(lockKey(id)))
+	ttl := pipe.PTTL(ctx, rs.tokenKey(lockKey(id)))
+
+	_, err := pipe.Exec(ctx)
+	if err != nil && !errors.Is(err, redis.Nil) {
+		rs.logger.Error("GetDocumentLock: failed to get lock", zap.Error(err))
+		return nil, fmt.Errorf("GetDocumentLock: failed to get lock: %w", err)
+	}
+
+	if errors.Is(holder.Err(), redis.Nil) {
+		return nil, nil
+	}
+
+	return &documents.Lock{DocId: id, Login: holder.Val(), ExpiresAt: time.Now().Add(ttl.Val())}, nil
+}
+
+// UnlockDocument releases the lock of login. Returns ErrLockNotFound when the document
+// is not locked and ErrDocumentLocked when somebody else holds the lock.
+func (rs *RedisService) UnlockDocument(ctx context.Context, id string, login string) error {
+	ctx, cancel := context.WithTimeout(ctx, rs.timeout)
+	defer cancel()
+
+	holder, err := unlockScript.Run(ctx, rs.tokenDB, []string{rs.tokenKey(lockKey(id))}, login).Text()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			rs.logger.Warn("UnlockDocument: lock not found", zap.String("doc", id))
+			return ErrLockNotFound
+		}
+
+		rs.logger.Error("UnlockDocument: failed to unlock document", zap.Error(err))
+		return fmt.Errorf("UnlockDocument: failed to unlock document: %w", err)
+	}
+
+	if holder != login {
+		rs.logger.Warn("UnlockDocument: lock is held by another user", zap.String("doc", id), zap.String("holder", holder))
+		return ErrDocumentLocked
+	}
+
+	rs.logger.Info("UnlockDocument: successfully unlocked document", zap.String("doc", id))
+	return nil
+}
+
+// BreakDocumentLock removes the lock of a document whoever holds it.
+func (rs *RedisService) BreakDocumentLock(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, rs.timeout)
+	defer cancel()
+
+	deleted, err := rs.tokenDB.Del(ctx, rs.tokenKey(lockKey(id))).Result()
+	if err != nil {
+		rs.logger.Error("BreakDocumentLock: failed to break lock", zap.Error(err))
+		return fmt.Errorf("BreakDocumentLock: failed to break lock: %w", err)
+	}
+
+	if deleted == 0 {
+		rs.logger.Warn("BreakDocumentLock: lock not found", zap.String("doc", id))
+		return ErrLockNotFound
+	}
+
+	rs.logger.Info("BreakDocumentLock: successfully broke lock", zap.String("doc", id))
+	return nil
+}
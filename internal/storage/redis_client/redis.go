@@ -3,27 +3,32 @@ package redisClient
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
+
+	"astral/internal/local_cache"
 )
 
-func New(ctx context.Context, config *Config, logger *zap.Logger) (*RedisService, error) {
-	tokenDB := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%d", config.Host, config.Port),
-		Password: config.Password,
-		DB:       config.TokenDB,
-	})
-	err := tokenDB.Ping(ctx).Err()
+// New connects to Redis. Reads of cached listings and contents go through local first,
+// an in-process cache that is kept in sync between instances by Listen.
+func New(ctx context.Context, config *Config, local localCache.LocalCache, logger *zap.Logger) (*RedisService, error) {
+	tokenDB, err := newClient(config.tokenDeployment(), config.TokenDB)
+	if err != nil {
+		return nil, fmt.Errorf("New: token store: %w", err)
+	}
+
+	err = tokenDB.Ping(ctx).Err()
 	if err != nil {
 		return nil, err
 	}
 
-	cacheDB := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%d", config.Host, config.Port),
-		Password: config.Password,
-		DB:       config.CacheDB,
-	})
+	cacheDB, err := newClient(config.cacheDeployment(), config.CacheDB)
+	if err != nil {
+		return nil, fmt.Errorf("New: cache: %w", err)
+	}
+
 	err = cacheDB.Ping(ctx).Err()
 	if err != nil {
 		return nil, err
@@ -33,9 +38,22 @@ func New(ctx context.Context, config *Config, logger *zap.Logger) (*RedisService
 		tokenDB:  tokenDB,
 		cacheDB:  cacheDB,
 		logger:   logger,
+		local:    local,
 		timeout:  config.Timeout,
 		tokenTTL: config.TokenTTL,
 		cacheTTL: config.CacheTTL,
+
+		tokenPrefix: config.TokenPrefix,
+		cachePrefix: config.CachePrefix,
+
+		staleTTL:    config.CacheStaleTTL,
+		earlyBeta:   config.CacheEarlyBeta,
+		freshWindow: config.FreshWindow,
+
+		lockTTL:    config.LockTTL,
+		lockMaxTTL: config.LockMaxTTL,
+
+		contentCacheSize: config.ContentCacheSize,
 	}, nil
 }
 
@@ -50,3 +68,92 @@ func (rs *RedisService) Close() {
 		rs.logger.Warn("Close: failed to close tokenDB", zap.Error(err))
 	}
 }
+
+func (rs *RedisService) tokenKey(key string) string {
+	return rs.tokenPrefix + key
+}
+
+func (rs *RedisService) cacheKey(key string) string {
+	return rs.cachePrefix + key
+}
+
+// deployment is where a store lives.
+type deployment struct {
+	mode             string
+	addrs            []string
+	masterName       string
+	password         string
+	sentinelPassword string
+}
+
+func (c *Config) tokenDeployment() deployment {
+	addrs := c.Addrs
+	if len(addrs) == 0 {
+		addrs = []string{c.Host + ":" + strconv.Itoa(c.Port)}
+	}
+
+	return deployment{
+		mode:             c.Mode,
+		addrs:            addrs,
+		masterName:       c.MasterName,
+		password:         c.Password,
+		sentinelPassword: c.SentinelPassword,
+	}
+}
+
+// cacheDeployment is the token deployment with the REDIS_CACHE_* settings that are set.
+func (c *Config) cacheDeployment() deployment {
+	d := c.tokenDeployment()
+
+	if c.CacheMode != "" {
+		d.mode = c.CacheMode
+	}
+
+	if len(c.CacheAddrs) > 0 {
+		d.addrs = c.CacheAddrs
+	}
+
+	if c.CacheMasterName != "" {
+		d.masterName = c.CacheMasterName
+	}
+
+	if c.CachePassword != "" {
+		d.password = c.CachePassword
+	}
+
+	return d
+}
+
+// newClient connects to a deployment. db is ignored in cluster mode.
+func newClient(d deployment, db int) (redis.UniversalClient, error) {
+	switch d.mode {
+	case ModeStandalone:
+		return redis.NewClient(&redis.Options{
+			Addr:     d.addrs[0],
+			Password: d.password,
+			DB:       db,
+		}), nil
+
+	case ModeSentinel:
+		if d.masterName == "" {
+			return nil, fmt.Errorf("newClient: %w: sentinel mode needs a master name", ErrInvalidConfig)
+		}
+
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       d.masterName,
+			SentinelAddrs:    d.addrs,
+			SentinelPassword: d.sentinelPassword,
+			Password:         d.password,
+			DB:               db,
+		}), nil
+
+	case ModeCluster:
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:    d.addrs,
+			Password: d.password,
+		}), nil
+
+	default:
+		return nil, fmt.Errorf("newClient: %w: unknown mode %q", ErrInvalidConfig, d.mode)
+	}
+}
@@ -0,0 +1,252 @@
+package redisClient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// cacheEntry is a cached value together with what is needed to refresh it in time.
+type cacheEntry struct {
+	Value      json.RawMessage `json:"value"`
+	FreshUntil time.Time       `json:"fresh_until"`
+	// Delta is how long loading the value took, slow values are refreshed earlier.
+	Delta time.Duration `json:"delta"`
+}
+
+// loadFunc loads a value to cache. A non-zero expiresAt is when the value stops being
+// valid at all; it is neither served fresh nor stale past that. Fresh is set when the value
+// was invalidated within the fresh window and must not be read from a lagging replica.
+type loadFunc func(ctx context.Context, fresh bool) (value []byte, expiresAt time.Time, err error)
+
+// fetch returns the value of key from the cache, loading it on a miss. Concurrent misses of a key
+// share one load. A value past REDIS_CACHE_TTL is served for up to REDIS_CACHE_STALE_TTL more while
+// it is reloaded in the background, and values are reloaded a little early at random, the more
+// likely the closer they are to going stale and the slower they load (XFetch), so that refreshes
+// of popular keys do not all land at the same moment. Markers are the keys that invalidations
+// of key set for the fresh window, see loadEntry.
+func (rs *RedisService) fetch(ctx context.Context, key string, markers []string, load loadFunc) ([]byte, error) {
+	entry, err := rs.getEntry(ctx, key)
+	if err == nil {
+		if rs.refreshDue(entry, time.Now()) {
+			rs.refreshAsync(ctx, key, markers, load)
+		}
+
+		return entry.Value, nil
+	}
+
+	if !errors.Is(err, ErrCacheMiss) {
+		rs.logger.Warn("fetch: failed to get cache entry", zap.Error(err), zap.String("key", key))
+	}
+
+	result := rs.group.DoChan(key, func() (any, error) {
+		return rs.loadEntry(context.WithoutCancel(ctx), key, markers, load)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+
+	case res := <-result:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+
+		return res.Val.([]byte), nil
+	}
+}
+
+// refreshDue reports whether entry should be reloaded: it is stale, or it was picked for an early refresh.
+func (rs *RedisService) refreshDue(entry *cacheEntry, now time.Time) bool {
+	if !now.Before(entry.FreshUntil) {
+		return true
+	}
+
+	if rs.earlyBeta <= 0 || entry.Delta <= 0 {
+		return false
+	}
+
+	// -ln(rand) is exponentially distributed, so an early refresh gets more likely as the entry ages.
+	early := time.Duration(float64(entry.Delta) * rs.earlyBeta * -math.Log(1-rand.Float64()))
+
+	return !now.Add(early).Before(entry.FreshUntil)
+}
+
+// refreshAsync reloads key in the background unless a reload of it is already running.
+func (rs *RedisService) refreshAsync(ctx context.Context, key string, markers []string, load loadFunc) {
+	if _, running := rs.refreshing.LoadOrStore(key, struct{}{}); running {
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+
+	go func() {
+		defer rs.refreshing.Delete(key)
+
+		_, err, _ := rs.group.Do(key, func() (any, error) {
+			return rs.loadEntry(ctx, key, markers, load)
+		})
+		if err != nil {
+			rs.logger.Warn("refreshAsync: failed to refresh cache entry", zap.Error(err), zap.String("key", key))
+		}
+	}()
+}
+
+// loadEntry loads the value of key and caches it. Within the fresh window of an invalidation,
+// marked by one of markers, the value is loaded fresh. A value that was not loaded fresh is
+// not cached when an invalidation arrived meanwhile: it may predate the change and would
+// otherwise be served for the whole REDIS_CACHE_TTL. The local cache likewise drops the value
+// when an invalidation of key reached this instance during the load.
+func (rs *RedisService) loadEntry(ctx context.Context, key string, markers []string, load loadFunc) ([]byte, error) {
+	start := time.Now()
+	epoch := rs.local.Epoch()
+
+	fresh := rs.invalidatedRecently(ctx, markers)
+
+	value, expiresAt, err := load(ctx, fresh)
+	if err != nil {
+		return nil, err
+	}
+
+	if !fresh && rs.invalidatedRecently(ctx, markers) {
+		return value, nil
+	}
+
+	now := time.Now()
+
+	entry := &cacheEntry{
+		Value:      value,
+		FreshUntil: now.Add(rs.cacheTTL),
+		Delta:      now.Sub(start),
+	}
+
+	ttl := rs.cacheTTL + rs.staleTTL
+
+	if !expiresAt.IsZero() {
+		entry.FreshUntil = minTime(entry.FreshUntil, expiresAt)
+		ttl = min(ttl, expiresAt.Sub(now))
+	}
+
+	if ttl > 0 {
+		rs.setEntry(ctx, key, entry, ttl, epoch)
+	}
+
+	return value, nil
+}
+
+func (rs *RedisService) getEntry(ctx context.Context, key string) (*cacheEntry, error) {
+	if value, ok := rs.local.Get(key); ok {
+		return value.(*cacheEntry), nil
+	}
+
+	epoch := rs.local.Epoch()
+
+	ctx, cancel := context.WithTimeout(ctx, rs.timeout)
+	defer cancel()
+
+	pipe := rs.cacheDB.Pipeline()
+	get := pipe.Get(ctx, rs.cacheKey(key))
+	ttl := pipe.PTTL(ctx, rs.cacheKey(key))
+
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrCacheMiss
+		}
+
+		return nil, fmt.Errorf("getEntry: failed to get cache entry: %w", err)
+	}
+
+	entryBytes, _ := get.Bytes()
+
+	var entry cacheEntry
+
+	err = json.Unmarshal(entryBytes, &entry)
+	if err != nil {
+		return nil, fmt.Errorf("getEntry: failed to unmarshal cache entry: %w", err)
+	}
+
+	rs.local.SetSince(key, &entry, len(entryBytes), ttl.Val(), epoch)
+
+	return &entry, nil
+}
+
+// setEntry caches entry in Redis and, unless key was invalidated after epoch, in the local cache.
+func (rs *RedisService) setEntry(ctx context.Context, key string, entry *cacheEntry, ttl time.Duration, epoch uint64) {
+	ctx, cancel := context.WithTimeout(ctx, rs.timeout)
+	defer cancel()
+
+	entryBytes, err := json.Marshal(entry)
+	if err != nil {
+		rs.logger.Warn("setEntry: failed to marshal cache entry", zap.Error(err))
+		return
+	}
+
+	err = rs.cacheDB.Set(ctx, rs.cacheKey(key), entryBytes, ttl).Err()
+	if err != nil {
+		rs.logger.Warn("setEntry: failed to cache entry", zap.Error(err), zap.String("key", key))
+		return
+	}
+
+	rs.local.SetSince(key, entry, len(entryBytes), ttl, epoch)
+}
+
+// markInvalidated sets marker for the fresh window, so that loads that follow an invalidation go to the primary.
+func (rs *RedisService) markInvalidated(ctx context.Context, marker string) {
+	if rs.freshWindow <= 0 {
+		return
+	}
+
+	err := rs.cacheDB.Set(ctx, rs.cacheKey(marker), 1, rs.freshWindow).Err()
+	if err != nil {
+		rs.logger.Warn("markInvalidated: failed to set marker", zap.Error(err), zap.String("marker", marker))
+	}
+}
+
+// invalidatedRecently reports whether one of markers is set. When they can not be read
+// the answer is yes, a needless read of the primary is better than a stale listing.
+func (rs *RedisService) invalidatedRecently(ctx context.Context, markers []string) bool {
+	if rs.freshWindow <= 0 || len(markers) == 0 {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, rs.timeout)
+	defer cancel()
+
+	// One EXISTS per marker: in cluster mode the markers live in different hash slots.
+	pipe := rs.cacheDB.Pipeline()
+
+	exists := make([]*redis.IntCmd, 0, len(markers))
+	for _, marker := range markers {
+		exists = append(exists, pipe.Exists(ctx, rs.cacheKey(marker)))
+	}
+
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		rs.logger.Warn("invalidatedRecently: failed to check markers", zap.Error(err))
+		return true
+	}
+
+	for _, cmd := range exists {
+		if cmd.Val() > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func minTime(a time.Time, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+
+	return a
+}
@@ -11,7 +11,7 @@ func (rs *RedisService) SaveToken(ctx context.Context, login string, token strin
 	ctx, cancel := context.WithTimeout(ctx, rs.timeout)
 	defer cancel()
 
-	err := rs.tokenDB.Set(ctx, token, login, rs.tokenTTL).Err()
+	err := rs.tokenDB.Set(ctx, rs.tokenKey(token), login, rs.tokenTTL).Err()
 	if err != nil {
 		rs.logger.Error("SaveToken: failed to save token", zap.Error(err))
 		return fmt.Errorf("SaveToken: failed to save token: %w", err)
@@ -25,7 +25,7 @@ func (rs *RedisService) GetLoginByToken(ctx context.Context, token string) (stri
 	ctx, cancel := context.WithTimeout(ctx, rs.timeout)
 	defer cancel()
 
-	login, err := rs.tokenDB.Get(ctx, token).Result()
+	login, err := rs.tokenDB.Get(ctx, rs.tokenKey(token)).Result()
 	if err != nil {
 		rs.logger.Error("GetLoginByToken: failed to get token", zap.Error(err))
 		return "", fmt.Errorf("GetLoginByToken: failed to get token: %w", err)
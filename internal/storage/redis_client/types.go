@@ -2,40 +2,114 @@ package redisClient
 
 import (
 	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
 
 	"astral/internal/documents"
+	"astral/internal/local_cache"
 )
 
 const batchSize = 200
 
+const (
+	ModeStandalone = "standalone"
+	ModeSentinel   = "sentinel"
+	ModeCluster    = "cluster"
+)
+
+var (
+	ErrDocumentLocked = errors.New("document is locked")
+	ErrLockNotFound   = errors.New("lock not found")
+	ErrCacheMiss      = errors.New("cache miss")
+	ErrInvalidConfig  = errors.New("invalid redis config")
+)
+
 type Config struct {
-	Host     string        `env:"REDIS_HOST" env-required:"true"`
-	Port     int           `env:"REDIS_PORT" env-required:"true"`
-	TokenDB  int           `env:"REDIS_TOKEN_DB" env-required:"true"`
-	CacheDB  int           `env:"REDIS_CACHE_DB" env-required:"true"`
+	Host     string        `env:"REDIS_HOST" env-default:"localhost"`
+	Port     int           `env:"REDIS_PORT" env-default:"6379"`
+	TokenDB  int           `env:"REDIS_TOKEN_DB" env-default:"0"`
+	CacheDB  int           `env:"REDIS_CACHE_DB" env-default:"1"`
 	Timeout  time.Duration `env:"REDIS_TIMEOUT" env-required:"true"`
 	TokenTTL time.Duration `env:"REDIS_TOKEN_TTL" env-required:"true"`
 	CacheTTL time.Duration `env:"REDIS_CACHE_TTL" env-required:"true"`
 	Password string        `env:"REDIS_PASSWORD" env-required:"true"`
+
+	// Mode is standalone, sentinel or cluster. A cluster has no numbered databases,
+	// there the token store and the cache are kept apart by TokenPrefix and CachePrefix.
+	Mode string `env:"REDIS_MODE" env-default:"standalone"`
+	// Addrs replaces REDIS_HOST:REDIS_PORT: the sentinels in sentinel mode, seed nodes in cluster mode.
+	Addrs            []string `env:"REDIS_ADDRS" env-separator:","`
+	MasterName       string   `env:"REDIS_MASTER_NAME"`
+	SentinelPassword string   `env:"REDIS_SENTINEL_PASSWORD"`
+	TokenPrefix      string   `env:"REDIS_TOKEN_PREFIX"`
+	CachePrefix      string   `env:"REDIS_CACHE_PREFIX"`
+
+	// The cache lives in the same deployment as the tokens unless these point it to another one.
+	CacheMode       string   `env:"REDIS_CACHE_MODE"`
+	CacheAddrs      []string `env:"REDIS_CACHE_ADDRS" env-separator:","`
+	CacheMasterName string   `env:"REDIS_CACHE_MASTER_NAME"`
+	CachePassword   string   `env:"REDIS_CACHE_PASSWORD"`
+
+	// CacheStaleTTL is how long past REDIS_CACHE_TTL a listing or document is still served while it is reloaded.
+	CacheStaleTTL time.Duration `env:"REDIS_CACHE_STALE_TTL" env-default:"1m"`
+	// CacheEarlyBeta scales early refreshes of cached listings and documents, 0 turns them off.
+	CacheEarlyBeta float64 `env:"REDIS_CACHE_EARLY_BETA" env-default:"1"`
+	// FreshWindow is how long after an invalidation listings and documents are loaded fresh, from the primary
+	// instead of a replica that may lag behind. It is not read from the environment: it follows
+	// POSTGRES_PIN_PRIMARY when reads go to replicas and is zero otherwise.
+	FreshWindow time.Duration
+
+	LockTTL    time.Duration `env:"REDIS_LOCK_TTL" env-default:"15m"`
+	LockMaxTTL time.Duration `env:"REDIS_LOCK_MAX_TTL" env-default:"24h"`
+
+	// ContentCacheSize is the largest stored file content in bytes that is cached, 0 disables the content cache.
+	ContentCacheSize int `env:"REDIS_CONTENT_CACHE_SIZE" env-default:"65536"`
 }
 
 type RedisService struct {
-	tokenDB  *redis.Client
-	cacheDB  *redis.Client
+	tokenDB  redis.UniversalClient
+	cacheDB  redis.UniversalClient
 	logger   *zap.Logger
 	timeout  time.Duration
 	tokenTTL time.Duration
 	cacheTTL time.Duration
+
+	tokenPrefix string
+	cachePrefix string
+
+	lockTTL    time.Duration
+	lockMaxTTL time.Duration
+
+	local localCache.LocalCache
+
+	staleTTL    time.Duration
+	earlyBeta   float64
+	freshWindow time.Duration
+	group       singleflight.Group
+	refreshing  sync.Map
+
+	contentCacheSize int
+	contentHits      atomic.Int64
+	contentMisses    atomic.Int64
+}
+
+// CacheStats counts lookups of cached file contents since the start of the process.
+type CacheStats struct {
+	Hits   int64
+	Misses int64
 }
 
 type RedisClient interface {
 	TokenStore
 	DocCache
+	DocLocker
 	Close()
 }
 
@@ -46,10 +120,27 @@ type TokenStore interface {
 
 type DocCache interface {
 	CacheDocument(ctx context.Context, document *documents.Document) error
+	GetDocs(ctx context.Context, query *documents.ListQuery, load func(ctx context.Context, fresh bool) ([]documents.Document, error)) ([]documents.Document, error)
+	GetDocumentInfo(ctx context.Context, id string, load func(ctx context.Context, fresh bool) (*documents.Document, error)) (*documents.Document, error)
+	LoadDocument(ctx context.Context, id string, load func(ctx context.Context, id string) (*documents.Document, error)) (*documents.Document, error)
 	InvalidateDocs(ctx context.Context, login string) error
+	InvalidateAllDocs(ctx context.Context) error
+	GetContent(ctx context.Context, id string) (int, []byte, error)
+	CacheContent(ctx context.Context, document *documents.Document) error
+	ContentCacheStats() CacheStats
+	InvalidateDocument(ctx context.Context, id string) error
+	InvalidateAllDocuments(ctx context.Context) error
 	Close()
 }
 
+// DocLocker keeps check-out locks of documents.
+type DocLocker interface {
+	LockDocument(ctx context.Context, id string, login string, ttl time.Duration) (*documents.Lock, error)
+	GetDocumentLock(ctx context.Context, id string) (*documents.Lock, error)
+	UnlockDocument(ctx context.Context, id string, login string) error
+	BreakDocumentLock(ctx context.Context, id string) error
+}
+
 type MockRedisClient struct {
 	mock.Mock
 }
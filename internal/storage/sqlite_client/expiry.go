@@ -0,0 +1,48 @@
+package sqliteClient
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"astral/internal/documents"
+)
+
+// UpdateExpiry sets when a document expires, nil keeps it forever. Held documents can not get an expiry.
+func (ss *SQLiteService) UpdateExpiry(ctx context.Context, id string, expiresAt *time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	result, err := ss.db.ExecContext(ctx, queryUpdateExpiry, id, nullMicros(expiresAt))
+	if err != nil {
+		ss.logger.Error("UpdateExpiry: failed to update expiry", zap.Error(err))
+		return fmt.Errorf("UpdateExpiry: failed to update expiry: %w", err)
+	}
+
+	if affected(result) == 0 {
+		return ss.notChanged(ctx, "UpdateExpiry", id, "", false)
+	}
+
+	ss.logger.Info("UpdateExpiry: successfully update expiry", zap.String("id", id))
+	return nil
+}
+
+// PurgeExpired removes up to limit documents that expired by now and returns their ids and owners.
+func (ss *SQLiteService) PurgeExpired(ctx context.Context, now time.Time, limit int) ([]documents.Document, error) {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	purged, err := ss.purge(ctx, queryPurgeExpired, toMicros(now), limit)
+	if err != nil {
+		ss.logger.Error("PurgeExpired: failed to purge documents", zap.Error(err))
+		return nil, fmt.Errorf("PurgeExpired: failed to purge documents: %w", err)
+	}
+
+	if len(purged) > 0 {
+		ss.logger.Info("PurgeExpired: purged documents", zap.Int("count", len(purged)))
+	}
+
+	return purged, nil
+}
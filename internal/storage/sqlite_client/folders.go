@@ -0,0 +1,314 @@
+package sqliteClient
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"astral/internal/documents"
+	"astral/internal/storage/postgres_client"
+)
+
+// MoveDocument puts a document into a folder, an empty folderId moves it to the root.
+func (ss *SQLiteService) MoveDocument(ctx context.Context, id string, folderId string) error {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	if folderId != "" {
+		ok, err := exists(ctx, ss.db, queryFolderExists, folderId)
+		if err != nil {
+			ss.logger.Error("MoveDocument: failed to check folder", zap.Error(err))
+			return fmt.Errorf("MoveDocument: failed to check folder: %w", err)
+		}
+
+		if !ok {
+			ss.logger.Warn("MoveDocument: folder not found", zap.String("folder", folderId))
+			return postgresClient.ErrFolderNotFound
+		}
+	}
+
+	result, err := ss.db.ExecContext(ctx, queryMoveDocument, id, folderId)
+	if err != nil {
+		ss.logger.Error("MoveDocument: failed to move document", zap.Error(err))
+		return fmt.Errorf("MoveDocument: failed to move document: %w", err)
+	}
+
+	if affected(result) == 0 {
+		ss.logger.Warn("MoveDocument: document not found", zap.String("id", id))
+		return postgresClient.ErrDocumentNotFound
+	}
+
+	ss.logger.Info("MoveDocument: successfully move document", zap.String("id", id), zap.String("folder", folderId))
+	return nil
+}
+
+func (ss *SQLiteService) CreateFolder(ctx context.Context, folder *documents.Folder) error {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	tx, err := ss.db.BeginTx(ctx, nil)
+	if err != nil {
+		ss.logger.Error("CreateFolder: failed to begin transaction", zap.Error(err))
+		return fmt.Errorf("CreateFolder: failed to begin transaction: %w", err)
+	}
+	defer ss.rollback(tx, "CreateFolder")
+
+	err = ss.checkFolderPlace(ctx, tx, "CreateFolder", folder)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, queryCreateFolder, folder.Id, folder.Login, folder.ParentId, folder.Name, toMicros(folder.CreatedAt))
+	if err != nil {
+		ss.logger.Error("CreateFolder: failed to create folder", zap.Error(err))
+		return fmt.Errorf("CreateFolder: failed to create folder: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		ss.logger.Error("CreateFolder: failed to commit transaction", zap.Error(err))
+		return fmt.Errorf("CreateFolder: failed to commit transaction: %w", err)
+	}
+
+	ss.logger.Info("CreateFolder: successfully create folder", zap.String("id", folder.Id))
+	return nil
+}
+
+// GetFolder returns a folder with the grants it has directly or through its parents.
+func (ss *SQLiteService) GetFolder(ctx context.Context, id string) (*documents.Folder, error) {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	var (
+		folder    documents.Folder
+		createdAt int64
+	)
+
+	err := ss.db.QueryRowContext(ctx, queryGetFolder, id).Scan(
+		&folder.Id,
+		&folder.Login,
+		&folder.ParentId,
+		&folder.Name,
+		&createdAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ss.logger.Warn("GetFolder: folder not found", zap.String("id", id))
+			return nil, postgresClient.ErrFolderNotFound
+		}
+
+		ss.logger.Error("GetFolder: failed to get folder", zap.Error(err))
+		return nil, fmt.Errorf("GetFolder: failed to get folder: %w", err)
+	}
+
+	folder.CreatedAt = fromMicros(createdAt)
+
+	folder.Grant, err = queryStrings(ctx, ss.db, queryGetFolderGrants, id)
+	if err != nil {
+		ss.logger.Error("GetFolder: failed to get folder grants", zap.Error(err))
+		return nil, fmt.Errorf("GetFolder: failed to get folder grants: %w", err)
+	}
+
+	return &folder, nil
+}
+
+// ResolveFolderPath returns the id of the folder of login found by following names
+// from the root. No names resolve to the root itself, which has an empty id.
+func (ss *SQLiteService) ResolveFolderPath(ctx context.Context, login string, names []string) (string, error) {
+	if len(names) == 0 {
+		return "", nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	path, err := json.Marshal(names)
+	if err != nil {
+		return "", fmt.Errorf("ResolveFolderPath: failed to marshal path: %w", err)
+	}
+
+	var id string
+
+	err = ss.db.QueryRowContext(ctx, queryResolveFolderPath, login, string(path)).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ss.logger.Warn("ResolveFolderPath: folder not found", zap.Strings("path", names))
+			return "", postgresClient.ErrFolderNotFound
+		}
+
+		ss.logger.Error("ResolveFolderPath: failed to resolve path", zap.Error(err))
+		return "", fmt.Errorf("ResolveFolderPath: failed to resolve path: %w", err)
+	}
+
+	return id, nil
+}
+
+// ListFolders returns the folders of login directly inside parentId, or in the root for an empty parentId.
+func (ss *SQLiteService) ListFolders(ctx context.Context, login string, parentId string) ([]documents.Folder, error) {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	rows, err := ss.db.QueryContext(ctx, queryListFolders, login, parentId)
+	if err != nil {
+		ss.logger.Error("ListFolders: failed to list folders", zap.Error(err))
+		return nil, fmt.Errorf("ListFolders: failed to list folders: %w", err)
+	}
+
+	folders, err := collectRows(rows, func(rows *sql.Rows) (documents.Folder, error) {
+		var (
+			folder    documents.Folder
+			createdAt int64
+		)
+
+		err := rows.Scan(
+			&folder.Id,
+			&folder.Login,
+			&folder.ParentId,
+			&folder.Name,
+			&createdAt,
+		)
+		folder.CreatedAt = fromMicros(createdAt)
+
+		return folder, err
+	})
+	if err != nil {
+		ss.logger.Error("ListFolders: failed to collect folders", zap.Error(err))
+		return nil, fmt.Errorf("ListFolders: failed to collect folders: %w", err)
+	}
+
+	return folders, nil
+}
+
+// UpdateFolder renames a folder and moves it under folder.ParentId. A folder can not
+// be moved into itself or into one of its subfolders.
+func (ss *SQLiteService) UpdateFolder(ctx context.Context, folder *documents.Folder) error {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	tx, err := ss.db.BeginTx(ctx, nil)
+	if err != nil {
+		ss.logger.Error("UpdateFolder: failed to begin transaction", zap.Error(err))
+		return fmt.Errorf("UpdateFolder: failed to begin transaction: %w", err)
+	}
+	defer ss.rollback(tx, "UpdateFolder")
+
+	if folder.ParentId != "" {
+		cycle, err := exists(ctx, tx, queryFolderIsAncestor, folder.ParentId, folder.Id)
+		if err != nil {
+			ss.logger.Error("UpdateFolder: failed to check parent", zap.Error(err))
+			return fmt.Errorf("UpdateFolder: failed to check parent: %w", err)
+		}
+
+		if cycle {
+			ss.logger.Warn("UpdateFolder: folder moved into itself", zap.String("id", folder.Id))
+			return postgresClient.ErrFolderCycle
+		}
+	}
+
+	err = ss.checkFolderPlace(ctx, tx, "UpdateFolder", folder)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, queryUpdateFolder, folder.Id, folder.ParentId, folder.Name)
+	if err != nil {
+		ss.logger.Error("UpdateFolder: failed to update folder", zap.Error(err))
+		return fmt.Errorf("UpdateFolder: failed to update folder: %w", err)
+	}
+
+	if affected(result) == 0 {
+		ss.logger.Warn("UpdateFolder: folder not found", zap.String("id", folder.Id))
+		return postgresClient.ErrFolderNotFound
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		ss.logger.Error("UpdateFolder: failed to commit transaction", zap.Error(err))
+		return fmt.Errorf("UpdateFolder: failed to commit transaction: %w", err)
+	}
+
+	ss.logger.Info("UpdateFolder: successfully update folder", zap.String("id", folder.Id))
+	return nil
+}
+
+// SetFolderGrants replaces the logins a folder is shared with.
+func (ss *SQLiteService) SetFolderGrants(ctx context.Context, id string, grant []string) error {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	tx, err := ss.db.BeginTx(ctx, nil)
+	if err != nil {
+		ss.logger.Error("SetFolderGrants: failed to begin transaction", zap.Error(err))
+		return fmt.Errorf("SetFolderGrants: failed to begin transaction: %w", err)
+	}
+	defer ss.rollback(tx, "SetFolderGrants")
+
+	_, err = tx.ExecContext(ctx, queryDeleteFolderGrants, id)
+	if err != nil {
+		ss.logger.Error("SetFolderGrants: failed to delete grants", zap.Error(err))
+		return fmt.Errorf("SetFolderGrants: failed to delete grants: %w", err)
+	}
+
+	for _, grantee := range grant {
+		ok, err := exists(ctx, tx, queryUserExists, grantee)
+		if err != nil {
+			ss.logger.Error("SetFolderGrants: failed to check grantee", zap.Error(err))
+			return fmt.Errorf("SetFolderGrants: failed to check grantee: %w", err)
+		}
+
+		if !ok {
+			ss.logger.Warn("SetFolderGrants: unknown grantee", zap.String("grantee", grantee))
+			return postgresClient.ErrUnknownGrantee
+		}
+
+		_, err = tx.ExecContext(ctx, querySaveFolderGrant, id, grantee)
+		if err != nil {
+			ss.logger.Error("SetFolderGrants: failed to save grant", zap.Error(err))
+			return fmt.Errorf("SetFolderGrants: failed to save grant: %w", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		ss.logger.Error("SetFolderGrants: failed to commit transaction", zap.Error(err))
+		return fmt.Errorf("SetFolderGrants: failed to commit transaction: %w", err)
+	}
+
+	ss.logger.Info("SetFolderGrants: successfully set folder grants", zap.String("id", id), zap.Int("count", len(grant)))
+	return nil
+}
+
+// checkFolderPlace returns ErrFolderNotFound when the parent of folder does not exist
+// and ErrFolderExists when the parent already has another folder with the same name.
+// Postgres reports both through constraint violations.
+func (ss *SQLiteService) checkFolderPlace(ctx context.Context, tx *sql.Tx, caller string, folder *documents.Folder) error {
+	if folder.ParentId != "" {
+		ok, err := exists(ctx, tx, queryFolderExists, folder.ParentId)
+		if err != nil {
+			ss.logger.Error(caller+": failed to check parent", zap.Error(err))
+			return fmt.Errorf("%s: failed to check parent: %w", caller, err)
+		}
+
+		if !ok {
+			ss.logger.Warn(caller+": parent folder not found", zap.String("parent", folder.ParentId))
+			return postgresClient.ErrFolderNotFound
+		}
+	}
+
+	taken, err := exists(ctx, tx, queryFolderNameTaken, folder.Login, folder.ParentId, folder.Name, folder.Id)
+	if err != nil {
+		ss.logger.Error(caller+": failed to check name", zap.Error(err))
+		return fmt.Errorf("%s: failed to check name: %w", caller, err)
+	}
+
+	if taken {
+		ss.logger.Warn(caller+": folder already exists", zap.String("name", folder.Name))
+		return postgresClient.ErrFolderExists
+	}
+
+	return nil
+}
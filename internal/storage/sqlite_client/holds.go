@@ -0,0 +1,191 @@
+package sqliteClient
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"astral/internal/documents"
+	"astral/internal/storage/postgres_client"
+)
+
+func (ss *SQLiteService) CreateHold(ctx context.Context, hold *documents.Hold) error {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	query, target := queryUserExists, hold.Login
+	if hold.DocId != "" {
+		query, target = queryDocumentExists, hold.DocId
+	}
+
+	ok, err := exists(ctx, ss.db, query, target)
+	if err != nil {
+		ss.logger.Error("CreateHold: failed to check target", zap.Error(err))
+		return fmt.Errorf("CreateHold: failed to check target: %w", err)
+	}
+
+	if !ok {
+		if hold.DocId != "" {
+			ss.logger.Warn("CreateHold: document not found", zap.String("doc", hold.DocId))
+			return postgresClient.ErrDocumentNotFound
+		}
+
+		ss.logger.Warn("CreateHold: user not found", zap.String("login", hold.Login))
+		return postgresClient.ErrUserNotFound
+	}
+
+	_, err = ss.db.ExecContext(ctx, queryCreateHold, hold.Id, hold.DocId, hold.Login, hold.Reason, toMicros(hold.CreatedAt))
+	if err != nil {
+		ss.logger.Error("CreateHold: failed to create hold", zap.Error(err))
+		return fmt.Errorf("CreateHold: failed to create hold: %w", err)
+	}
+
+	ss.logger.Info("CreateHold: successfully create hold", zap.String("id", hold.Id))
+	return nil
+}
+
+func (ss *SQLiteService) ListHolds(ctx context.Context) ([]documents.Hold, error) {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	rows, err := ss.db.QueryContext(ctx, queryListHolds)
+	if err != nil {
+		ss.logger.Error("ListHolds: failed to list holds", zap.Error(err))
+		return nil, fmt.Errorf("ListHolds: failed to list holds: %w", err)
+	}
+
+	holds, err := collectRows(rows, func(rows *sql.Rows) (documents.Hold, error) {
+		var (
+			hold      documents.Hold
+			createdAt int64
+		)
+
+		err := rows.Scan(&hold.Id, &hold.DocId, &hold.Login, &hold.Reason, &createdAt)
+		hold.CreatedAt = fromMicros(createdAt)
+
+		return hold, err
+	})
+	if err != nil {
+		ss.logger.Error("ListHolds: failed to collect holds", zap.Error(err))
+		return nil, fmt.Errorf("ListHolds: failed to collect holds: %w", err)
+	}
+
+	return holds, nil
+}
+
+// DeleteHold releases a legal hold.
+func (ss *SQLiteService) DeleteHold(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	result, err := ss.db.ExecContext(ctx, queryDeleteHold, id)
+	if err != nil {
+		ss.logger.Error("DeleteHold: failed to delete hold", zap.Error(err))
+		return fmt.Errorf("DeleteHold: failed to delete hold: %w", err)
+	}
+
+	if affected(result) == 0 {
+		ss.logger.Warn("DeleteHold: hold not found", zap.String("id", id))
+		return postgresClient.ErrHoldNotFound
+	}
+
+	ss.logger.Info("DeleteHold: successfully release hold", zap.String("id", id))
+	return nil
+}
+
+// SaveRetentionRule creates a retention rule or replaces the one with the same name.
+func (ss *SQLiteService) SaveRetentionRule(ctx context.Context, rule *documents.RetentionRule) error {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	_, err := ss.db.ExecContext(ctx, querySaveRetentionRule, rule.Name, rule.Tag, rule.Schema, rule.KeepDays, toMicros(rule.CreatedAt))
+	if err != nil {
+		ss.logger.Error("SaveRetentionRule: failed to save rule", zap.Error(err))
+		return fmt.Errorf("SaveRetentionRule: failed to save rule: %w", err)
+	}
+
+	ss.logger.Info("SaveRetentionRule: successfully save rule", zap.String("name", rule.Name))
+	return nil
+}
+
+func (ss *SQLiteService) ListRetentionRules(ctx context.Context) ([]documents.RetentionRule, error) {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	rows, err := ss.db.QueryContext(ctx, queryListRetentionRules)
+	if err != nil {
+		ss.logger.Error("ListRetentionRules: failed to list rules", zap.Error(err))
+		return nil, fmt.Errorf("ListRetentionRules: failed to list rules: %w", err)
+	}
+
+	rules, err := collectRows(rows, func(rows *sql.Rows) (documents.RetentionRule, error) {
+		var (
+			rule      documents.RetentionRule
+			createdAt int64
+		)
+
+		err := rows.Scan(&rule.Name, &rule.Tag, &rule.Schema, &rule.KeepDays, &createdAt)
+		rule.CreatedAt = fromMicros(createdAt)
+
+		return rule, err
+	})
+	if err != nil {
+		ss.logger.Error("ListRetentionRules: failed to collect rules", zap.Error(err))
+		return nil, fmt.Errorf("ListRetentionRules: failed to collect rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+func (ss *SQLiteService) DeleteRetentionRule(ctx context.Context, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	result, err := ss.db.ExecContext(ctx, queryDeleteRetentionRule, name)
+	if err != nil {
+		ss.logger.Error("DeleteRetentionRule: failed to delete rule", zap.Error(err))
+		return fmt.Errorf("DeleteRetentionRule: failed to delete rule: %w", err)
+	}
+
+	if affected(result) == 0 {
+		ss.logger.Warn("DeleteRetentionRule: rule not found", zap.String("name", name))
+		return postgresClient.ErrRuleNotFound
+	}
+
+	ss.logger.Info("DeleteRetentionRule: successfully delete rule", zap.String("name", name))
+	return nil
+}
+
+// notChanged explains why a guarded statement of caller did not touch document id:
+// a *HoldError when the document is held, ErrDocumentNotFound otherwise. Only a document
+// of login, any owner when it is empty, that is in the trash exactly when trashed is set
+// reports its hold.
+func (ss *SQLiteService) notChanged(ctx context.Context, caller string, id string, login string, trashed bool) error {
+	var (
+		reason, rule string
+		until        int64
+	)
+
+	err := ss.db.QueryRowContext(ctx, queryHoldReason, id, login, trashed).Scan(&reason, &rule, &until)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ss.logger.Warn(caller+": document not found", zap.String("id", id))
+			return postgresClient.ErrDocumentNotFound
+		}
+
+		ss.logger.Error(caller+": failed to check holds", zap.Error(err))
+		return fmt.Errorf("%s: failed to check holds: %w", caller, err)
+	}
+
+	if rule != "" {
+		reason = "retention rule " + rule + " keeps the document until " + fromMicros(until).Format("2006-01-02")
+	} else {
+		reason = "legal hold: " + reason
+	}
+
+	ss.logger.Warn(caller+": document is held", zap.String("id", id), zap.String("reason", reason))
+	return &postgresClient.HoldError{Reason: reason}
+}
@@ -0,0 +1,36 @@
+package sqliteClient
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"astral/internal/storage/postgres_client"
+)
+
+// UpdateLabels replaces tags and metadata of a document. Labels are not versioned,
+// so the document version stays the same.
+func (ss *SQLiteService) UpdateLabels(ctx context.Context, id string, tags []string, metadata map[string]string) error {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	tagsJSON, metadataJSON, err := encodeLabels(tags, metadata)
+	if err != nil {
+		return fmt.Errorf("UpdateLabels: %w", err)
+	}
+
+	result, err := ss.db.ExecContext(ctx, queryUpdateLabels, id, tagsJSON, metadataJSON)
+	if err != nil {
+		ss.logger.Error("UpdateLabels: failed to update labels", zap.Error(err))
+		return fmt.Errorf("UpdateLabels: failed to update labels: %w", err)
+	}
+
+	if affected(result) == 0 {
+		ss.logger.Warn("UpdateLabels: document not found", zap.String("id", id))
+		return postgresClient.ErrDocumentNotFound
+	}
+
+	ss.logger.Info("UpdateLabels: successfully update labels", zap.String("id", id))
+	return nil
+}
@@ -0,0 +1,260 @@
+package sqliteClient
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"astral/internal/documents"
+	"astral/internal/storage/matcher"
+	"astral/internal/storage/postgres_client"
+)
+
+// ListDocuments returns documents matching query without their content, newest first.
+// SQLite has no jsonb operators, so JSON filters run on the selected rows and the page
+// is cut afterwards. Like in Postgres they are refused with encryption enabled.
+func (ss *SQLiteService) ListDocuments(ctx context.Context, query *documents.ListQuery) ([]documents.Document, error) {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	if ss.keyring.Enabled() && query.ReadsContent() {
+		return nil, fmt.Errorf("ListDocuments: %w", postgresClient.ErrEncryptedQuery)
+	}
+
+	jsonFilter, err := matcher.NewJSONFilter(query.JSONContains, query.JSONPath)
+	if err != nil {
+		ss.logger.Error("ListDocuments: failed to list documents", zap.Error(err))
+		return nil, fmt.Errorf("ListDocuments: failed to list documents: %w", err)
+	}
+
+	var qb queryBuilder
+
+	qb.filter(query)
+
+	columns := "NULL, NULL"
+	if !jsonFilter.Empty() {
+		columns = "d.json, NULL"
+	}
+
+	listed, err := ss.listRows(ctx, qb.build(columns, "d.created_at DESC, d.id", query, jsonFilter.Empty()), qb.args)
+	if err != nil {
+		ss.logger.Error("ListDocuments: failed to list documents", zap.Error(err))
+		return nil, fmt.Errorf("ListDocuments: failed to list documents: %w", err)
+	}
+
+	docs := make([]documents.Document, 0)
+
+	for _, row := range listed {
+		if jsonFilter.Match(row.json) {
+			docs = append(docs, row.document)
+		}
+	}
+
+	if !jsonFilter.Empty() {
+		docs = append([]documents.Document{}, page(docs, query)...)
+	}
+
+	return docs, nil
+}
+
+// SearchDocuments runs the full-text query.Search over names and extracted text
+// and returns the matching documents ranked, with highlighted snippets.
+// Matching and ranking follow the in-memory storage, not the Postgres text search.
+func (ss *SQLiteService) SearchDocuments(ctx context.Context, query *documents.ListQuery) ([]documents.SearchResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	if ss.keyring.Enabled() && query.ReadsContent() {
+		return nil, fmt.Errorf("SearchDocuments: %w", postgresClient.ErrEncryptedQuery)
+	}
+
+	jsonFilter, err := matcher.NewJSONFilter(query.JSONContains, query.JSONPath)
+	if err != nil {
+		ss.logger.Error("SearchDocuments: failed to search documents", zap.Error(err))
+		return nil, fmt.Errorf("SearchDocuments: failed to search documents: %w", err)
+	}
+
+	search := matcher.ParseSearch(query.Search)
+
+	var qb queryBuilder
+
+	qb.filter(query)
+
+	columns := "NULL, COALESCE(d.search_text, '')"
+	if !jsonFilter.Empty() {
+		columns = "d.json, COALESCE(d.search_text, '')"
+	}
+
+	listed, err := ss.listRows(ctx, qb.build(columns, "d.created_at DESC, d.id", query, false), qb.args)
+	if err != nil {
+		ss.logger.Error("SearchDocuments: failed to search documents", zap.Error(err))
+		return nil, fmt.Errorf("SearchDocuments: failed to search documents: %w", err)
+	}
+
+	results := make([]documents.SearchResult, 0)
+
+	for _, row := range listed {
+		if !jsonFilter.Match(row.json) {
+			continue
+		}
+
+		rank, ok := search.Rank(row.document.Name, row.text)
+		if !ok {
+			continue
+		}
+
+		results = append(results, documents.SearchResult{
+			Document: row.document,
+			Rank:     rank,
+			Snippet:  search.Headline(row.document.Name + "\n" + row.text),
+		})
+	}
+
+	// Rows come newest first, a stable sort keeps that order among equal ranks.
+	slices.SortStableFunc(results, func(a, b documents.SearchResult) int {
+		return cmp.Compare(b.Rank, a.Rank)
+	})
+
+	return append([]documents.SearchResult{}, page(results, query)...), nil
+}
+
+// listedRow is a document row of a listing with the extra columns the caller filters on.
+type listedRow struct {
+	document documents.Document
+	json     []byte
+	text     string
+}
+
+func (ss *SQLiteService) listRows(ctx context.Context, query string, args []any) ([]listedRow, error) {
+	rows, err := ss.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return collectRows(rows, func(rows *sql.Rows) (listedRow, error) {
+		var (
+			row        listedRow
+			values     rowValues
+			jsonColumn sql.NullString
+			text       sql.NullString
+		)
+
+		err := rows.Scan(
+			&row.document.Id,
+			&row.document.Login,
+			&row.document.Name,
+			&row.document.Mime,
+			&row.document.DetectedMime,
+			&row.document.File,
+			&row.document.Public,
+			&values.createdAt,
+			&row.document.Codec,
+			&row.document.Size,
+			&row.document.Version,
+			&values.updatedAt,
+			&row.document.Schema,
+			&values.tags,
+			&values.metadata,
+			&row.document.FolderId,
+			&values.expiresAt,
+			&values.grant,
+			&jsonColumn,
+			&text,
+		)
+		if err != nil {
+			return row, err
+		}
+
+		if jsonColumn.Valid {
+			row.json = []byte(jsonColumn.String)
+		}
+
+		row.text = text.String
+
+		return row, values.fill(&row.document)
+	})
+}
+
+// queryBuilder collects WHERE conditions together with their positional arguments.
+type queryBuilder struct {
+	conds []string
+	args  []any
+}
+
+// arg adds a query argument and returns its placeholder.
+func (qb *queryBuilder) arg(value any) string {
+	qb.args = append(qb.args, value)
+	return "?" + strconv.Itoa(len(qb.args))
+}
+
+// where adds a condition, cond is formatted with the placeholders of args.
+func (qb *queryBuilder) where(cond string, args ...any) {
+	placeholders := make([]any, 0, len(args))
+	for _, value := range args {
+		placeholders = append(placeholders, qb.arg(value))
+	}
+
+	qb.conds = append(qb.conds, fmt.Sprintf(cond, placeholders...))
+}
+
+// filter adds the conditions shared by listing and search that SQLite can evaluate.
+func (qb *queryBuilder) filter(query *documents.ListQuery) {
+	qb.conds = append(qb.conds, "d.deleted_at IS NULL")
+	qb.where("(d.expires_at IS NULL OR d.expires_at > %s)", toMicros(time.Now()))
+	qb.where(condDocumentReadable, query.Login)
+
+	if query.Owner != "" {
+		qb.where("d.login = %s", query.Owner)
+	}
+
+	if query.Folder != nil {
+		if *query.Folder == "" {
+			qb.conds = append(qb.conds, "d.folder_id IS NULL")
+		} else {
+			qb.where("d.folder_id = %s", *query.Folder)
+		}
+	}
+
+	if len(query.Tags) > 0 {
+		tags, _ := json.Marshal(query.Tags)
+		qb.where(condTagsContained, string(tags))
+	}
+
+	if len(query.Metadata) > 0 {
+		metadata, _ := json.Marshal(query.Metadata)
+		qb.where(condMetadataContained, string(metadata))
+	}
+}
+
+// build returns the listing query with columns as the extra columns. The page
+// is only cut in SQL when paged is set, otherwise the caller cuts it.
+func (qb *queryBuilder) build(columns string, order string, query *documents.ListQuery, paged bool) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf(queryListDocuments, columns))
+	sb.WriteString("\n\tWHERE ")
+	sb.WriteString(strings.Join(qb.conds, "\n\tAND "))
+	sb.WriteString("\n\tORDER BY " + order)
+
+	if paged {
+		sb.WriteString("\n\tLIMIT " + qb.arg(query.Limit) + " OFFSET " + qb.arg(query.Offset))
+	}
+
+	return sb.String()
+}
+
+// page applies the limit and offset of query.
+func page[T any](items []T, query *documents.ListQuery) []T {
+	start := min(max(query.Offset, 0), len(items))
+	end := min(start+max(query.Limit, 0), len(items))
+
+	return items[start:end]
+}
@@ -0,0 +1,99 @@
+package sqliteClient
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// migrations are compiled into the binary, so a single binary is all a deployment needs.
+//
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// upMigration applies the migrations newer than the user_version of the database,
+// each in its own transaction together with the version bump.
+func (ss *SQLiteService) upMigration(ctx context.Context) error {
+	var current int
+
+	err := ss.db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&current)
+	if err != nil {
+		return fmt.Errorf("upMigration: failed to get schema version: %w", err)
+	}
+
+	names, err := fs.Glob(migrations, "migrations/*.sql")
+	if err != nil {
+		return fmt.Errorf("upMigration: failed to list migrations: %w", err)
+	}
+
+	slices.Sort(names)
+
+	for _, name := range names {
+		version, err := migrationVersion(name)
+		if err != nil {
+			return fmt.Errorf("upMigration: %w", err)
+		}
+
+		if version <= current {
+			continue
+		}
+
+		script, err := migrations.ReadFile(name)
+		if err != nil {
+			return fmt.Errorf("upMigration: failed to read %s: %w", name, err)
+		}
+
+		err = ss.migrate(ctx, version, string(script))
+		if err != nil {
+			return fmt.Errorf("upMigration: failed to apply %s: %w", name, err)
+		}
+
+		ss.logger.Info("upMigration: applied migration", zap.String("name", name))
+	}
+
+	return nil
+}
+
+func (ss *SQLiteService) migrate(ctx context.Context, version int, script string) error {
+	tx, err := ss.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer ss.rollback(tx, "migrate")
+
+	_, err = tx.ExecContext(ctx, script)
+	if err != nil {
+		return err
+	}
+
+	// PRAGMA does not take parameters, version is a number parsed from the file name.
+	_, err = tx.ExecContext(ctx, "PRAGMA user_version = "+strconv.Itoa(version))
+	if err != nil {
+		return fmt.Errorf("failed to set schema version: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// migrationVersion returns the number a migration file name starts with.
+func migrationVersion(name string) (int, error) {
+	base := strings.TrimPrefix(name, "migrations/")
+
+	prefix, _, ok := strings.Cut(base, "_")
+	if !ok {
+		return 0, fmt.Errorf("migrationVersion: invalid migration name %s", name)
+	}
+
+	version, err := strconv.Atoi(prefix)
+	if err != nil {
+		return 0, fmt.Errorf("migrationVersion: invalid migration name %s: %w", name, err)
+	}
+
+	return version, nil
+}
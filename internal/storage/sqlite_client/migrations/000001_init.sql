@@ -0,0 +1,138 @@
+-- Times are stored as microseconds since the Unix epoch in UTC, tags and metadata as JSON.
+
+CREATE TABLE users
+(
+    login TEXT PRIMARY KEY,
+    password_hash TEXT
+);
+
+CREATE TABLE folders
+(
+    id TEXT PRIMARY KEY,
+    login TEXT NOT NULL REFERENCES users(login) ON DELETE CASCADE,
+    parent_id TEXT REFERENCES folders(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    created_at INTEGER NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_folders_unique_name ON folders(login, COALESCE(parent_id, ''), name);
+CREATE INDEX idx_folders_parent_id ON folders(parent_id);
+
+CREATE TABLE folder_grants
+(
+    folder_id TEXT NOT NULL REFERENCES folders(id) ON DELETE CASCADE,
+    grantee_login TEXT NOT NULL REFERENCES users(login) ON DELETE CASCADE,
+    PRIMARY KEY (folder_id, grantee_login)
+);
+
+CREATE INDEX idx_folder_grants_grantee ON folder_grants(grantee_login);
+
+CREATE TABLE documents
+(
+    id TEXT PRIMARY KEY,
+    login TEXT NOT NULL REFERENCES users(login) ON DELETE CASCADE,
+    name TEXT,
+    mime TEXT,
+    is_file INTEGER NOT NULL DEFAULT 0,
+    is_public INTEGER NOT NULL DEFAULT 0,
+    content BLOB,
+    json TEXT,
+    created_at INTEGER NOT NULL,
+    codec TEXT NOT NULL DEFAULT 'identity',
+    size INTEGER NOT NULL DEFAULT 0,
+    data_key BLOB,
+    key_id TEXT,
+    json_sealed BLOB,
+    version INTEGER NOT NULL DEFAULT 1,
+    updated_at INTEGER,
+    schema_name TEXT,
+    search_text TEXT,
+    tags TEXT NOT NULL DEFAULT '[]',
+    metadata TEXT NOT NULL DEFAULT '{}',
+    folder_id TEXT REFERENCES folders(id) ON DELETE SET NULL,
+    deleted_at INTEGER,
+    usage_bytes INTEGER NOT NULL DEFAULT 0,
+    expires_at INTEGER
+);
+
+CREATE INDEX idx_documents_owner ON documents(login);
+CREATE INDEX idx_documents_created_at ON documents(created_at DESC, id);
+CREATE INDEX idx_documents_folder_id ON documents(folder_id);
+CREATE INDEX idx_documents_deleted_at ON documents(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_documents_expires_at ON documents(expires_at) WHERE expires_at IS NOT NULL;
+CREATE INDEX idx_documents_key_id ON documents(key_id) WHERE key_id IS NOT NULL;
+
+CREATE TABLE documents_grants
+(
+    doc_id TEXT NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+    grantee_login TEXT NOT NULL REFERENCES users(login) ON DELETE CASCADE,
+    PRIMARY KEY (doc_id, grantee_login)
+);
+
+CREATE INDEX idx_doc_grants_grantee ON documents_grants(grantee_login);
+
+CREATE TABLE document_versions
+(
+    doc_id TEXT NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    name TEXT,
+    mime TEXT,
+    is_file INTEGER NOT NULL DEFAULT 0,
+    content BLOB,
+    json TEXT,
+    json_sealed BLOB,
+    codec TEXT NOT NULL DEFAULT 'identity',
+    size INTEGER NOT NULL DEFAULT 0,
+    data_key BLOB,
+    key_id TEXT,
+    created_at INTEGER,
+    archived_at INTEGER NOT NULL,
+    schema_name TEXT,
+    search_text TEXT,
+    usage_bytes INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (doc_id, version)
+);
+
+CREATE INDEX idx_document_versions_key_id ON document_versions(key_id) WHERE key_id IS NOT NULL;
+
+CREATE TABLE json_schemas
+(
+    login TEXT NOT NULL REFERENCES users(login) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    schema TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+    updated_at INTEGER,
+    PRIMARY KEY (login, name)
+);
+
+CREATE TABLE user_usage
+(
+    login TEXT PRIMARY KEY REFERENCES users(login) ON DELETE CASCADE,
+    documents INTEGER NOT NULL DEFAULT 0,
+    bytes INTEGER NOT NULL DEFAULT 0,
+    max_documents INTEGER,
+    max_bytes INTEGER
+);
+
+CREATE TABLE legal_holds
+(
+    id TEXT PRIMARY KEY,
+    doc_id TEXT REFERENCES documents(id) ON DELETE CASCADE,
+    login TEXT REFERENCES users(login) ON DELETE CASCADE,
+    reason TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+    CHECK ((doc_id IS NULL) <> (login IS NULL))
+);
+
+CREATE INDEX idx_legal_holds_doc_id ON legal_holds(doc_id) WHERE doc_id IS NOT NULL;
+CREATE INDEX idx_legal_holds_login ON legal_holds(login) WHERE login IS NOT NULL;
+
+CREATE TABLE retention_rules
+(
+    name TEXT PRIMARY KEY,
+    tag TEXT,
+    schema_name TEXT,
+    keep_days INTEGER NOT NULL CHECK (keep_days > 0),
+    created_at INTEGER NOT NULL,
+    CHECK (tag IS NOT NULL OR schema_name IS NOT NULL)
+);
@@ -0,0 +1,8 @@
+-- Archived versions count against the quota of the owner until they are pruned or purged.
+ALTER TABLE documents ADD COLUMN versions_bytes INTEGER NOT NULL DEFAULT 0;
+
+UPDATE documents
+SET versions_bytes = COALESCE((SELECT sum(v.usage_bytes) FROM document_versions v WHERE v.doc_id = documents.id), 0);
+
+UPDATE user_usage
+SET bytes = COALESCE((SELECT sum(d.usage_bytes + d.versions_bytes) FROM documents d WHERE d.login = user_usage.login), 0);
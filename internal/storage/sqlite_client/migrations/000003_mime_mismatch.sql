@@ -0,0 +1,4 @@
+-- detected_mime is set when the declared mime did not match the content and MIME_POLICY=flag let it through.
+ALTER TABLE documents ADD COLUMN detected_mime TEXT;
+
+ALTER TABLE document_versions ADD COLUMN detected_mime TEXT;
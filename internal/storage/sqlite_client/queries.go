@@ -0,0 +1,313 @@
+package sqliteClient
+
+const (
+	// sqlNow is the current time in microseconds, the unit all times are stored in.
+	sqlNow = `CAST((julianday('now') - 2440587.5) * 86400000000.0 AS INTEGER)`
+
+	microsPerDay = `86400000000`
+
+	querySaveUser = `INSERT INTO users (login, password_hash) VALUES (?1, ?2) ON CONFLICT (login) DO NOTHING`
+
+	queryGetPasswordHash = `SELECT password_hash FROM users WHERE login = ?1`
+
+	queryUserExists = `SELECT EXISTS (SELECT 1 FROM users WHERE login = ?1)`
+
+	querySaveDocument = `INSERT INTO documents
+    (id, login, name, mime, is_file, is_public, content, json, created_at, codec, size, data_key, key_id, json_sealed,
+    schema_name, search_text, tags, metadata, folder_id, usage_bytes, expires_at, updated_at, detected_mime)
+	VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?11, ?12, ?13, ?14, NULLIF(?15, ''), ?16,
+	?17, ?18, NULLIF(?19, ''), ?20, ?21, ?9, NULLIF(?22, ''))`
+
+	querySaveDocumentGrant = `INSERT INTO documents_grants (doc_id, grantee_login) VALUES (?1, ?2)
+	ON CONFLICT DO NOTHING`
+
+	queryGetDocument = `SELECT id, login, COALESCE(name, ''), COALESCE(mime, ''), COALESCE(detected_mime, ''), is_file, is_public,
+    content, json, created_at, codec, size, data_key, key_id, json_sealed,
+    version, COALESCE(updated_at, created_at), COALESCE(schema_name, ''), tags, metadata,
+    COALESCE(folder_id, ''), expires_at
+	FROM documents WHERE id = ?1 AND deleted_at IS NULL`
+
+	queryGetDocumentInfo = `SELECT id, login, COALESCE(name, ''), COALESCE(mime, ''), COALESCE(detected_mime, ''), is_file, is_public,
+    created_at, codec, size, key_id IS NOT NULL,
+    version, COALESCE(updated_at, created_at), COALESCE(schema_name, ''), tags, metadata,
+    COALESCE(folder_id, ''), expires_at
+	FROM documents WHERE id = ?1 AND deleted_at IS NULL`
+
+	// queryGetDocumentGrants returns direct grants of a document together with
+	// the grants of its folder and all parents of that folder.
+	queryGetDocumentGrants = `WITH RECURSIVE chain(id, parent_id) AS (
+    SELECT f.id, f.parent_id FROM folders f
+    JOIN documents d ON d.folder_id = f.id WHERE d.id = ?1
+    UNION
+    SELECT f.id, f.parent_id FROM folders f JOIN chain c ON f.id = c.parent_id
+	)
+	SELECT grantee_login FROM documents_grants WHERE doc_id = ?1
+	UNION
+	SELECT g.grantee_login FROM folder_grants g JOIN chain c ON g.folder_id = c.id
+	ORDER BY 1`
+
+	queryGetStats = `SELECT count(*), COALESCE(sum(size), 0), COALESCE(sum(length(content)), 0)
+	FROM documents WHERE login = ?1 AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > ?2)`
+
+	querySelectKeysToRewrap = `SELECT id, version, key_id, data_key FROM documents
+	WHERE key_id IS NOT NULL AND key_id <> ?1
+	LIMIT ?2`
+
+	queryUpdateDataKey = `UPDATE documents SET data_key = ?3, key_id = ?4 WHERE id = ?1 AND version = ?2`
+
+	querySelectVersionKeysToRewrap = `SELECT doc_id, version, key_id, data_key FROM document_versions
+	WHERE key_id IS NOT NULL AND key_id <> ?1
+	LIMIT ?2`
+
+	queryUpdateVersionDataKey = `UPDATE document_versions SET data_key = ?3, key_id = ?4
+	WHERE doc_id = ?1 AND version = ?2`
+
+	queryLockDocument = `SELECT version, login FROM documents
+	WHERE id = ?1 AND deleted_at IS NULL`
+
+	// queryArchiveDocument keeps the current content as a version, queryArchiveUsage moves its
+	// bytes to versions_bytes, so they stay charged until the version is pruned or the document purged.
+	queryArchiveDocument = `INSERT INTO document_versions
+    (doc_id, version, name, mime, is_file, content, json, json_sealed, codec, size, data_key, key_id, created_at,
+    archived_at, schema_name, search_text, usage_bytes, detected_mime)
+	SELECT id, version, name, mime, is_file, content, json, json_sealed, codec, size, data_key, key_id,
+	COALESCE(updated_at, created_at), ?2, schema_name, search_text, usage_bytes, detected_mime
+	FROM documents WHERE id = ?1`
+
+	queryArchiveUsage = `UPDATE documents SET versions_bytes = versions_bytes + usage_bytes WHERE id = ?1`
+
+	queryUpdateDocument = `UPDATE documents
+	SET name = ?2, mime = ?3, is_file = ?4, content = ?5, json = ?6, codec = ?7, size = ?8,
+	data_key = ?9, key_id = ?10, json_sealed = ?11, version = version + 1, updated_at = ?12,
+	schema_name = NULLIF(?13, ''), search_text = ?14, tags = ?15, metadata = ?16, usage_bytes = ?17,
+	detected_mime = NULLIF(?18, '')
+	WHERE id = ?1
+	RETURNING version`
+
+	queryRestoreVersion = `UPDATE documents
+	SET (name, mime, is_file, content, json, json_sealed, codec, size, data_key, key_id,
+	schema_name, search_text, usage_bytes, detected_mime) = (
+	    SELECT v.name, v.mime, v.is_file, v.content, v.json, v.json_sealed, v.codec, v.size, v.data_key, v.key_id,
+	    v.schema_name, v.search_text, v.usage_bytes, v.detected_mime
+	    FROM document_versions v WHERE v.doc_id = ?1 AND v.version = ?2
+	), version = version + 1, updated_at = ?3
+	WHERE id = ?1 AND EXISTS (SELECT 1 FROM document_versions v WHERE v.doc_id = ?1 AND v.version = ?2)
+	RETURNING version, usage_bytes`
+
+	condVersionExpired = `((?2 > 0 AND version < ?3 - ?2)
+	OR (?4 > 0 AND archived_at < ` + sqlNow + ` - ?4 * ` + microsPerDay + `))`
+
+	queryPruneVersions = `DELETE FROM document_versions
+	WHERE doc_id = ?1 AND ` + condVersionExpired + `
+	AND NOT EXISTS (SELECT 1 FROM documents d WHERE d.id = ?1 AND ` + condDocumentHeld + `)
+	RETURNING usage_bytes`
+
+	// queryCountHeldVersions counts the versions queryPruneVersions kept because the document is held.
+	queryCountHeldVersions = `SELECT count(*) FROM document_versions
+	WHERE doc_id = ?1 AND ` + condVersionExpired + `
+	AND EXISTS (SELECT 1 FROM documents d WHERE d.id = ?1 AND ` + condDocumentHeld + `)`
+
+	queryReleaseVersions = `UPDATE documents SET versions_bytes = versions_bytes - ?2 WHERE id = ?1`
+
+	queryListVersions = `SELECT version, COALESCE(name, ''), COALESCE(mime, ''), is_file, codec, size,
+    COALESCE(created_at, archived_at)
+	FROM document_versions WHERE doc_id = ?1
+	ORDER BY version DESC`
+
+	queryGetVersion = `SELECT COALESCE(name, ''), COALESCE(mime, ''), is_file, content, json, codec, size,
+    data_key, key_id, json_sealed, COALESCE(created_at, archived_at)
+	FROM document_versions WHERE doc_id = ?1 AND version = ?2`
+
+	querySaveSchema = `INSERT INTO json_schemas (login, name, schema, created_at) VALUES (?1, ?2, ?3, ?4)
+	ON CONFLICT (login, name) DO UPDATE SET schema = excluded.schema, updated_at = excluded.created_at`
+
+	queryGetSchema = `SELECT schema FROM json_schemas WHERE login = ?1 AND name = ?2`
+
+	// queryListDocuments selects what listings show, %s is an extra column the caller filters on.
+	queryListDocuments = `SELECT d.id, d.login, COALESCE(d.name, ''), COALESCE(d.mime, ''), COALESCE(d.detected_mime, ''), d.is_file, d.is_public,
+    d.created_at, d.codec, d.size, d.version, COALESCE(d.updated_at, d.created_at),
+    COALESCE(d.schema_name, ''), d.tags, d.metadata, COALESCE(d.folder_id, ''), d.expires_at,
+    (SELECT json_group_array(grantee_login) FROM
+    (SELECT g.grantee_login FROM documents_grants g WHERE g.doc_id = d.id ORDER BY g.grantee_login)),
+    %s
+	FROM documents d`
+
+	condDocumentReadable = `(d.login = %[1]s OR d.is_public OR EXISTS
+	(SELECT 1 FROM documents_grants g WHERE g.doc_id = d.id AND g.grantee_login = %[1]s)
+	OR d.folder_id IN (WITH RECURSIVE shared(id) AS (
+    SELECT folder_id FROM folder_grants WHERE grantee_login = %[1]s
+    UNION
+    SELECT f.id FROM folders f JOIN shared s ON f.parent_id = s.id
+	) SELECT id FROM shared))`
+
+	// condTagsContained is true when every tag of the JSON array %s is set on d.
+	condTagsContained = `NOT EXISTS (SELECT 1 FROM json_each(%s) w
+	WHERE w.value NOT IN (SELECT value FROM json_each(d.tags)))`
+
+	// condMetadataContained is true when every entry of the JSON object %s is set on d with the same value.
+	condMetadataContained = `NOT EXISTS (SELECT 1 FROM json_each(%s) w
+	WHERE NOT EXISTS (SELECT 1 FROM json_each(d.metadata) m WHERE m.key = w.key AND m.value = w.value))`
+
+	queryUpdateLabels = `UPDATE documents SET tags = ?2, metadata = ?3
+	WHERE id = ?1 AND deleted_at IS NULL`
+
+	queryMoveDocument = `UPDATE documents SET folder_id = NULLIF(?2, '')
+	WHERE id = ?1 AND deleted_at IS NULL`
+
+	queryFolderExists = `SELECT EXISTS (SELECT 1 FROM folders WHERE id = ?1)`
+
+	// queryFolderNameTaken reports whether the owner of folder ?4, or login ?1 for a new folder,
+	// has another folder named ?3 in parent ?2.
+	queryFolderNameTaken = `SELECT EXISTS (SELECT 1 FROM folders
+	WHERE login = COALESCE((SELECT login FROM folders WHERE id = ?4), ?1)
+	AND COALESCE(parent_id, '') = ?2 AND name = ?3 AND id <> ?4)`
+
+	queryCreateFolder = `INSERT INTO folders (id, login, parent_id, name, created_at)
+	VALUES (?1, ?2, NULLIF(?3, ''), ?4, ?5)`
+
+	queryGetFolder = `SELECT id, login, COALESCE(parent_id, ''), name, created_at
+	FROM folders WHERE id = ?1`
+
+	// queryGetFolderGrants returns grants of a folder and of all its parents.
+	queryGetFolderGrants = `WITH RECURSIVE chain(id, parent_id) AS (
+    SELECT id, parent_id FROM folders WHERE id = ?1
+    UNION
+    SELECT f.id, f.parent_id FROM folders f JOIN chain c ON f.id = c.parent_id
+	)
+	SELECT DISTINCT g.grantee_login FROM folder_grants g JOIN chain c ON g.folder_id = c.id
+	ORDER BY 1`
+
+	// queryResolveFolderPath walks the names of the JSON array ?2 from the root folders of ?1.
+	queryResolveFolderPath = `WITH RECURSIVE walk(id, depth) AS (
+    SELECT id, 1 FROM folders
+    WHERE login = ?1 AND parent_id IS NULL AND name = json_extract(?2, '$[0]')
+    UNION ALL
+    SELECT f.id, w.depth + 1 FROM folders f JOIN walk w ON f.parent_id = w.id
+    WHERE f.name = json_extract(?2, '$[' || w.depth || ']')
+	)
+	SELECT id FROM walk WHERE depth = json_array_length(?2)`
+
+	queryListFolders = `SELECT id, login, COALESCE(parent_id, ''), name, created_at
+	FROM folders
+	WHERE login = ?1 AND COALESCE(parent_id, '') = ?2
+	ORDER BY name`
+
+	queryUpdateFolder = `UPDATE folders SET parent_id = NULLIF(?2, ''), name = ?3 WHERE id = ?1`
+
+	// queryFolderIsAncestor reports whether folder ?2 is ?1 or one of its parents.
+	queryFolderIsAncestor = `WITH RECURSIVE chain(id, parent_id) AS (
+    SELECT id, parent_id FROM folders WHERE id = ?1
+    UNION
+    SELECT f.id, f.parent_id FROM folders f JOIN chain c ON f.id = c.parent_id
+	)
+	SELECT EXISTS (SELECT 1 FROM chain WHERE id = ?2)`
+
+	queryDeleteFolderGrants = `DELETE FROM folder_grants WHERE folder_id = ?1`
+
+	querySaveFolderGrant = `INSERT INTO folder_grants (folder_id, grantee_login) VALUES (?1, ?2)
+	ON CONFLICT DO NOTHING`
+
+	queryDeleteDocument = `UPDATE documents AS d SET deleted_at = ?2
+	WHERE d.id = ?1 AND d.deleted_at IS NULL AND NOT ` + condDocumentHeld
+
+	queryListTrash = `SELECT d.id, d.login, COALESCE(d.name, ''), COALESCE(d.mime, ''), COALESCE(d.detected_mime, ''), d.is_file, d.is_public,
+    d.created_at, d.codec, d.size, d.version, COALESCE(d.updated_at, d.created_at),
+    COALESCE(d.schema_name, ''), d.tags, d.metadata, COALESCE(d.folder_id, ''), d.deleted_at
+	FROM documents d
+	WHERE d.login = ?1 AND d.deleted_at IS NOT NULL
+	ORDER BY d.deleted_at DESC, d.id`
+
+	queryRestoreDocument = `UPDATE documents SET deleted_at = NULL
+	WHERE id = ?1 AND login = ?2 AND deleted_at IS NOT NULL`
+
+	// queryPurgeDocument removes a trashed document, the caller gives its storage back to the owner.
+	queryPurgeDocument = `DELETE FROM documents AS d
+	WHERE d.id = ?1 AND d.login = ?2 AND d.deleted_at IS NOT NULL AND NOT ` + condDocumentHeld + `
+	RETURNING id, login, usage_bytes + versions_bytes`
+
+	// queryPurgeDocuments removes a batch of documents trashed before ?1. Versions and grants
+	// go with them through ON DELETE CASCADE. Held documents stay in the trash.
+	queryPurgeDocuments = `DELETE FROM documents
+	WHERE id IN (SELECT d.id FROM documents d
+	WHERE d.deleted_at < ?1 AND NOT ` + condDocumentHeld + `
+	LIMIT ?2)
+	RETURNING id, login, usage_bytes + versions_bytes`
+
+	// queryUpdateExpiry can always clear the expiry, but only set it on documents that are not held.
+	queryUpdateExpiry = `UPDATE documents AS d SET expires_at = ?2
+	WHERE d.id = ?1 AND d.deleted_at IS NULL AND (?2 IS NULL OR NOT ` + condDocumentHeld + `)`
+
+	// queryPurgeExpired removes a batch of documents that expired by ?1, trashed or not. Held documents are kept.
+	queryPurgeExpired = `DELETE FROM documents
+	WHERE id IN (SELECT d.id FROM documents d
+	WHERE d.expires_at <= ?1 AND NOT ` + condDocumentHeld + `
+	LIMIT ?2)
+	RETURNING id, login, usage_bytes + versions_bytes`
+
+	queryEnsureUsage = `INSERT INTO user_usage (login) VALUES (?1) ON CONFLICT (login) DO NOTHING`
+
+	queryGetUsageRow = `SELECT documents, bytes, max_documents, max_bytes FROM user_usage WHERE login = ?1`
+
+	queryChargeUsage = `UPDATE user_usage SET bytes = bytes + ?2, documents = documents + ?3
+	WHERE login = ?1`
+
+	queryGetUsage = `SELECT COALESCE(u.documents, 0), COALESCE(u.bytes, 0), u.max_documents, u.max_bytes
+	FROM users us
+	LEFT JOIN user_usage u ON u.login = us.login
+	WHERE us.login = ?1`
+
+	querySetQuota = `INSERT INTO user_usage (login, max_documents, max_bytes) VALUES (?1, ?2, ?3)
+	ON CONFLICT (login) DO UPDATE SET max_documents = excluded.max_documents, max_bytes = excluded.max_bytes`
+
+	// condDocumentHeld is true for a document d under a legal hold, directly or through its owner,
+	// or kept by a retention rule. A rule with a tag and a schema needs both to match.
+	condDocumentHeld = `(EXISTS (SELECT 1 FROM legal_holds h WHERE h.doc_id = d.id OR h.login = d.login)
+	OR EXISTS (SELECT 1 FROM retention_rules r
+	WHERE (r.tag IS NULL OR r.tag IN (SELECT value FROM json_each(d.tags)))
+	AND (r.schema_name IS NULL OR r.schema_name = d.schema_name)
+	AND d.created_at + r.keep_days * ` + microsPerDay + ` > ` + sqlNow + `))`
+
+	// condHoldCaller limits queryHoldReason to the documents the caller of a guarded change could
+	// have touched: owned by ?2 unless it is empty, and trashed exactly when ?3 is true. Anything
+	// else is reported as not found, so the reason of a hold does not leak to other users.
+	condHoldCaller = `(?2 = '' OR d.login = ?2) AND (d.deleted_at IS NOT NULL) = ?3`
+
+	// queryHoldReason explains why a document is held, legal holds go first. The date
+	// a retention rule keeps the document until is returned for the caller to format.
+	queryHoldReason = `SELECT reason, name, until FROM (
+	    SELECT h.reason, '' AS name, 0 AS until, 0 AS kind, h.created_at
+	    FROM documents d
+	    JOIN legal_holds h ON h.doc_id = d.id OR h.login = d.login
+	    WHERE d.id = ?1 AND ` + condHoldCaller + `
+	    UNION ALL
+	    SELECT '', r.name, d.created_at + r.keep_days * ` + microsPerDay + `, 1, r.created_at
+	    FROM documents d
+	    JOIN retention_rules r ON (r.tag IS NULL OR r.tag IN (SELECT value FROM json_each(d.tags)))
+	    AND (r.schema_name IS NULL OR r.schema_name = d.schema_name)
+	    WHERE d.id = ?1 AND ` + condHoldCaller + `
+	    AND d.created_at + r.keep_days * ` + microsPerDay + ` > ` + sqlNow + `
+	) held
+	ORDER BY kind, created_at
+	LIMIT 1`
+
+	queryDocumentExists = `SELECT EXISTS (SELECT 1 FROM documents WHERE id = ?1)`
+
+	queryCreateHold = `INSERT INTO legal_holds (id, doc_id, login, reason, created_at)
+	VALUES (?1, NULLIF(?2, ''), NULLIF(?3, ''), ?4, ?5)`
+
+	queryListHolds = `SELECT id, COALESCE(doc_id, ''), COALESCE(login, ''), reason, created_at
+	FROM legal_holds
+	ORDER BY created_at, id`
+
+	queryDeleteHold = `DELETE FROM legal_holds WHERE id = ?1`
+
+	querySaveRetentionRule = `INSERT INTO retention_rules (name, tag, schema_name, keep_days, created_at)
+	VALUES (?1, NULLIF(?2, ''), NULLIF(?3, ''), ?4, ?5)
+	ON CONFLICT (name) DO UPDATE SET tag = excluded.tag, schema_name = excluded.schema_name, keep_days = excluded.keep_days`
+
+	queryListRetentionRules = `SELECT name, COALESCE(tag, ''), COALESCE(schema_name, ''), keep_days, created_at
+	FROM retention_rules
+	ORDER BY name`
+
+	queryDeleteRetentionRule = `DELETE FROM retention_rules WHERE name = ?1`
+)
@@ -0,0 +1,128 @@
+package sqliteClient
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"astral/internal/documents"
+	"astral/internal/storage/postgres_client"
+)
+
+// GetUsage returns the storage login uses together with its effective quota.
+func (ss *SQLiteService) GetUsage(ctx context.Context, login string) (*documents.Usage, error) {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	var (
+		usage                  documents.Usage
+		maxDocuments, maxBytes sql.NullInt64
+	)
+
+	err := ss.db.QueryRowContext(ctx, queryGetUsage, login).Scan(&usage.Documents, &usage.Bytes, &maxDocuments, &maxBytes)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ss.logger.Warn("GetUsage: user not found", zap.String("login", login))
+			return nil, postgresClient.ErrUserNotFound
+		}
+
+		ss.logger.Error("GetUsage: failed to get usage", zap.Error(err))
+		return nil, fmt.Errorf("GetUsage: failed to get usage: %w", err)
+	}
+
+	ss.applyQuota(&usage, maxDocuments, maxBytes)
+
+	return &usage, nil
+}
+
+// SetQuota overrides the default quota of login. A nil limit falls back to the default, zero means unlimited.
+func (ss *SQLiteService) SetQuota(ctx context.Context, login string, maxDocuments *int64, maxBytes *int64) error {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	ok, err := exists(ctx, ss.db, queryUserExists, login)
+	if err != nil {
+		ss.logger.Error("SetQuota: failed to check user", zap.Error(err))
+		return fmt.Errorf("SetQuota: failed to check user: %w", err)
+	}
+
+	if !ok {
+		ss.logger.Warn("SetQuota: user not found", zap.String("login", login))
+		return postgresClient.ErrUserNotFound
+	}
+
+	_, err = ss.db.ExecContext(ctx, querySetQuota, login, maxDocuments, maxBytes)
+	if err != nil {
+		ss.logger.Error("SetQuota: failed to set quota", zap.Error(err))
+		return fmt.Errorf("SetQuota: failed to set quota: %w", err)
+	}
+
+	ss.logger.Info("SetQuota: successfully set quota", zap.String("login", login))
+	return nil
+}
+
+// chargeUsage adds bytes and documents to the usage of login inside tx, or returns
+// documents.ErrQuotaExceeded or documents.ErrDocumentTooLarge when they do not fit.
+func (ss *SQLiteService) chargeUsage(ctx context.Context, tx *sql.Tx, login string, bytes int64, docs int64) error {
+	_, err := tx.ExecContext(ctx, queryEnsureUsage, login)
+	if err != nil {
+		ss.logger.Error("chargeUsage: failed to create usage", zap.Error(err))
+		return fmt.Errorf("chargeUsage: failed to create usage: %w", err)
+	}
+
+	var (
+		usage                  documents.Usage
+		maxDocuments, maxBytes sql.NullInt64
+	)
+
+	err = tx.QueryRowContext(ctx, queryGetUsageRow, login).Scan(&usage.Documents, &usage.Bytes, &maxDocuments, &maxBytes)
+	if err != nil {
+		ss.logger.Error("chargeUsage: failed to get usage", zap.Error(err))
+		return fmt.Errorf("chargeUsage: failed to get usage: %w", err)
+	}
+
+	ss.applyQuota(&usage, maxDocuments, maxBytes)
+
+	err = usage.Check(bytes, docs)
+	if err != nil {
+		ss.logger.Warn("chargeUsage: quota exceeded", zap.String("login", login), zap.Error(err))
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, queryChargeUsage, login, bytes, docs)
+	if err != nil {
+		ss.logger.Error("chargeUsage: failed to update usage", zap.Error(err))
+		return fmt.Errorf("chargeUsage: failed to update usage: %w", err)
+	}
+
+	return nil
+}
+
+// releaseUsage gives the storage of purged documents back to their owners.
+func (ss *SQLiteService) releaseUsage(ctx context.Context, tx *sql.Tx, purged []purgedDocument) error {
+	for _, document := range purged {
+		_, err := tx.ExecContext(ctx, queryChargeUsage, document.Login, -document.usageBytes, -1)
+		if err != nil {
+			ss.logger.Error("releaseUsage: failed to update usage", zap.Error(err))
+			return fmt.Errorf("releaseUsage: failed to update usage: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// applyQuota sets the limits of usage from the per-user overrides or the configured defaults.
+func (ss *SQLiteService) applyQuota(usage *documents.Usage, maxDocuments sql.NullInt64, maxBytes sql.NullInt64) {
+	usage.MaxDocuments = ss.quotaDocuments
+	if maxDocuments.Valid {
+		usage.MaxDocuments = maxDocuments.Int64
+	}
+
+	usage.MaxBytes = ss.quotaBytes
+	if maxBytes.Valid {
+		usage.MaxBytes = maxBytes.Int64
+	}
+}
@@ -0,0 +1,59 @@
+package sqliteClient
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"astral/internal/storage/postgres_client"
+)
+
+// SaveSchema registers a named JSON schema of a user, replacing the schema with the same name.
+func (ss *SQLiteService) SaveSchema(ctx context.Context, login string, name string, schema []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	ok, err := exists(ctx, ss.db, queryUserExists, login)
+	if err != nil {
+		ss.logger.Error("SaveSchema: failed to check user", zap.Error(err))
+		return fmt.Errorf("SaveSchema: failed to check user: %w", err)
+	}
+
+	if !ok {
+		ss.logger.Error("SaveSchema: user not found", zap.String("login", login))
+		return fmt.Errorf("SaveSchema: failed to save schema: %w", postgresClient.ErrUserNotFound)
+	}
+
+	_, err = ss.db.ExecContext(ctx, querySaveSchema, login, name, string(schema), toMicros(time.Now()))
+	if err != nil {
+		ss.logger.Error("SaveSchema: failed to save schema", zap.Error(err))
+		return fmt.Errorf("SaveSchema: failed to save schema: %w", err)
+	}
+
+	ss.logger.Info("SaveSchema: successfully save schema", zap.String("login", login), zap.String("name", name))
+	return nil
+}
+
+func (ss *SQLiteService) GetSchema(ctx context.Context, login string, name string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	var schema string
+
+	err := ss.db.QueryRowContext(ctx, queryGetSchema, login, name).Scan(&schema)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ss.logger.Warn("GetSchema: schema not found", zap.String("login", login), zap.String("name", name))
+			return nil, postgresClient.ErrSchemaNotFound
+		}
+
+		ss.logger.Error("GetSchema: failed to get schema", zap.Error(err))
+		return nil, fmt.Errorf("GetSchema: failed to get schema: %w", err)
+	}
+
+	return []byte(schema), nil
+}
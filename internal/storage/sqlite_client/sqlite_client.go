@@ -0,0 +1,648 @@
+package sqliteClient
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+	_ "modernc.org/sqlite"
+
+	"astral/internal/documents"
+	"astral/internal/keyring"
+	"astral/internal/sealing"
+	"astral/internal/storage/postgres_client"
+)
+
+// pragmas are set on the connection before anything else runs. Foreign keys carry
+// the cascades of the schema, WAL lets a crash lose at most the last transaction.
+var pragmas = []string{
+	"PRAGMA foreign_keys = ON",
+	"PRAGMA journal_mode = WAL",
+	"PRAGMA busy_timeout = 5000",
+}
+
+// New opens the database at config.Path, creating it when needed, and applies pending migrations.
+// Versions and quotas follow the same settings as the Postgres client.
+func New(ctx context.Context, config *Config, postgres *postgresClient.Config, kr *keyring.Keyring, logger *zap.Logger) (*SQLiteService, error) {
+	err := os.MkdirAll(filepath.Dir(config.Path), 0o750)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create database directory: %w", err)
+	}
+
+	db, err := sql.Open(driverName, config.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// One connection that is never recycled: pragmas stay in effect and
+	// transactions are serialized like rows locked with FOR UPDATE.
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxLifetime(0)
+
+	ss := &SQLiteService{
+		db:               db,
+		keyring:          kr,
+		logger:           logger,
+		timeout:          config.Timeout,
+		versionsKeepLast: postgres.VersionsKeepLast,
+		versionsKeepDays: postgres.VersionsKeepDays,
+		quotaDocuments:   postgres.QuotaDocuments,
+		quotaBytes:       postgres.QuotaBytes,
+	}
+
+	for _, pragma := range pragmas {
+		_, err = db.ExecContext(ctx, pragma)
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to configure database: %w", err)
+		}
+	}
+
+	err = ss.upMigration(ctx)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return ss, nil
+}
+
+func (ss *SQLiteService) SaveUser(ctx context.Context, login string, passwordHash string) error {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	result, err := ss.db.ExecContext(ctx, querySaveUser, login, passwordHash)
+	if err != nil {
+		ss.logger.Error("SaveUser: failed to save user:", zap.Error(err))
+		return fmt.Errorf("SaveUser: failed to save user: %w", err)
+	}
+
+	if affected(result) == 0 {
+		ss.logger.Warn("SaveUser: duplicate login")
+		return postgresClient.ErrDuplicateLogin
+	}
+
+	ss.logger.Info("SaveUser: successfully save user")
+	return nil
+}
+
+// GetPasswordHash returns pgx.ErrNoRows for an unknown login, like the Postgres client.
+func (ss *SQLiteService) GetPasswordHash(ctx context.Context, login string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	var passwordHash string
+
+	err := ss.db.QueryRowContext(ctx, queryGetPasswordHash, login).Scan(&passwordHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ss.logger.Warn("GetPasswordHash: no password hash found")
+			return "", pgx.ErrNoRows
+		}
+
+		ss.logger.Error("GetPasswordHash: failed to get password hash", zap.Error(err))
+		return "", fmt.Errorf("GetPasswordHash: failed to get password hash: %w", err)
+	}
+
+	ss.logger.Info("GetPasswordHash: successfully get password hash")
+	return passwordHash, nil
+}
+
+func (ss *SQLiteService) SaveDocument(ctx context.Context, document *documents.Document) error {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	sealed, err := sealing.Seal(ss.keyring, document)
+	if err != nil {
+		ss.logger.Error("SaveDocument: failed to seal document", zap.Error(err))
+		return fmt.Errorf("SaveDocument: failed to seal document: %w", err)
+	}
+
+	tags, metadata, err := encodeLabels(document.Tags, document.Metadata)
+	if err != nil {
+		return fmt.Errorf("SaveDocument: %w", err)
+	}
+
+	tx, err := ss.db.BeginTx(ctx, nil)
+	if err != nil {
+		ss.logger.Error("SaveDocument: failed to begin transaction", zap.Error(err))
+		return fmt.Errorf("SaveDocument: failed to begin transaction: %w", err)
+	}
+	defer ss.rollback(tx, "SaveDocument")
+
+	if document.FolderId != "" {
+		ok, err := exists(ctx, tx, queryFolderExists, document.FolderId)
+		if err != nil {
+			ss.logger.Error("SaveDocument: failed to check folder", zap.Error(err))
+			return fmt.Errorf("SaveDocument: failed to check folder: %w", err)
+		}
+
+		if !ok {
+			ss.logger.Error("SaveDocument: folder not found", zap.String("folder", document.FolderId))
+			return fmt.Errorf("SaveDocument: failed to save document: %w", postgresClient.ErrFolderNotFound)
+		}
+	}
+
+	err = ss.chargeUsage(ctx, tx, document.Login, document.UsageBytes(), 1)
+	if err != nil {
+		return fmt.Errorf("SaveDocument: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, querySaveDocument,
+		document.Id,
+		document.Login,
+		document.Name,
+		document.Mime,
+		document.File,
+		document.Public,
+		sealed.Content,
+		jsonText(sealed.JSON),
+		toMicros(document.CreatedAt),
+		document.Codec,
+		document.Size,
+		sealed.DataKey,
+		sealed.KeyID,
+		sealed.SealedJSON,
+		document.Schema,
+		sealed.Text,
+		tags,
+		metadata,
+		document.FolderId,
+		document.UsageBytes(),
+		nullMicros(document.ExpiresAt),
+		document.DetectedMime,
+	)
+	if err != nil {
+		ss.logger.Error("SaveDocument: failed to save document", zap.Error(err))
+		return fmt.Errorf("SaveDocument: failed to save document: %w", err)
+	}
+
+	for _, grantee := range document.Grant {
+		ok, err := exists(ctx, tx, queryUserExists, grantee)
+		if err != nil {
+			return fmt.Errorf("SaveDocument: failed to save grant: %w", err)
+		}
+
+		if !ok {
+			return fmt.Errorf("SaveDocument: failed to save grant: %w", postgresClient.ErrUnknownGrantee)
+		}
+
+		_, err = tx.ExecContext(ctx, querySaveDocumentGrant, document.Id, grantee)
+		if err != nil {
+			return fmt.Errorf("SaveDocument: failed to save grant: %w", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		ss.logger.Error("SaveDocument: failed to commit transaction", zap.Error(err))
+		return fmt.Errorf("SaveDocument: failed to commit transaction: %w", err)
+	}
+
+	ss.logger.Info("SaveDocument: successfully save document")
+	return nil
+}
+
+func (ss *SQLiteService) GetDocument(ctx context.Context, id string) (*documents.Document, error) {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	var (
+		document documents.Document
+		sealed   sealing.Content
+		values   rowValues
+		jsonDoc  sql.NullString
+	)
+
+	err := ss.db.QueryRowContext(ctx, queryGetDocument, id).Scan(
+		&document.Id,
+		&document.Login,
+		&document.Name,
+		&document.Mime,
+		&document.DetectedMime,
+		&document.File,
+		&document.Public,
+		&sealed.Content,
+		&jsonDoc,
+		&values.createdAt,
+		&document.Codec,
+		&document.Size,
+		&sealed.DataKey,
+		&sealed.KeyID,
+		&sealed.SealedJSON,
+		&document.Version,
+		&values.updatedAt,
+		&document.Schema,
+		&values.tags,
+		&values.metadata,
+		&document.FolderId,
+		&values.expiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ss.logger.Warn("GetDocument: document not found", zap.String("id", id))
+			return nil, postgresClient.ErrDocumentNotFound
+		}
+
+		ss.logger.Error("GetDocument: failed to get document", zap.Error(err))
+		return nil, fmt.Errorf("GetDocument: failed to get document: %w", err)
+	}
+
+	err = values.fill(&document)
+	if err != nil {
+		ss.logger.Error("GetDocument: failed to decode document", zap.Error(err))
+		return nil, fmt.Errorf("GetDocument: failed to decode document: %w", err)
+	}
+
+	if document.Expired(time.Now()) {
+		ss.logger.Warn("GetDocument: document expired", zap.String("id", id))
+		return nil, postgresClient.ErrDocumentNotFound
+	}
+
+	if jsonDoc.Valid {
+		sealed.JSON = []byte(jsonDoc.String)
+	}
+
+	err = sealing.Open(ss.keyring, &document, &sealed)
+	if err != nil {
+		ss.logger.Error("GetDocument: failed to open document", zap.Error(err))
+		return nil, fmt.Errorf("GetDocument: failed to open document: %w", err)
+	}
+
+	document.Grant, err = ss.documentGrants(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("GetDocument: %w", err)
+	}
+
+	ss.logger.Info("GetDocument: successfully get document", zap.String("id", id))
+	return &document, nil
+}
+
+// GetDocumentForWrite is GetDocument, the database has no replicas to lag behind.
+func (ss *SQLiteService) GetDocumentForWrite(ctx context.Context, id string) (*documents.Document, error) {
+	return ss.GetDocument(ctx, id)
+}
+
+// GetDocumentInfo returns a document with its grants like GetDocument, but without content and JSON.
+func (ss *SQLiteService) GetDocumentInfo(ctx context.Context, id string) (*documents.Document, error) {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	var (
+		document documents.Document
+		values   rowValues
+	)
+
+	err := ss.db.QueryRowContext(ctx, queryGetDocumentInfo, id).Scan(
+		&document.Id,
+		&document.Login,
+		&document.Name,
+		&document.Mime,
+		&document.DetectedMime,
+		&document.File,
+		&document.Public,
+		&values.createdAt,
+		&document.Codec,
+		&document.Size,
+		&document.Sealed,
+		&document.Version,
+		&values.updatedAt,
+		&document.Schema,
+		&values.tags,
+		&values.metadata,
+		&document.FolderId,
+		&values.expiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ss.logger.Warn("GetDocumentInfo: document not found", zap.String("id", id))
+			return nil, postgresClient.ErrDocumentNotFound
+		}
+
+		ss.logger.Error("GetDocumentInfo: failed to get document", zap.Error(err))
+		return nil, fmt.Errorf("GetDocumentInfo: failed to get document: %w", err)
+	}
+
+	err = values.fill(&document)
+	if err != nil {
+		ss.logger.Error("GetDocumentInfo: failed to decode document", zap.Error(err))
+		return nil, fmt.Errorf("GetDocumentInfo: failed to decode document: %w", err)
+	}
+
+	if document.Expired(time.Now()) {
+		ss.logger.Warn("GetDocumentInfo: document expired", zap.String("id", id))
+		return nil, postgresClient.ErrDocumentNotFound
+	}
+
+	document.Grant, err = ss.documentGrants(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("GetDocumentInfo: %w", err)
+	}
+
+	return &document, nil
+}
+
+// documentGrants returns the logins a document is shared with directly or through its folders.
+func (ss *SQLiteService) documentGrants(ctx context.Context, id string) ([]string, error) {
+	grant, err := queryStrings(ctx, ss.db, queryGetDocumentGrants, id)
+	if err != nil {
+		ss.logger.Error("documentGrants: failed to get grants", zap.Error(err))
+		return nil, fmt.Errorf("documentGrants: failed to get grants: %w", err)
+	}
+
+	return grant, nil
+}
+
+// GetStats counts the documents of login that are neither trashed nor expired.
+func (ss *SQLiteService) GetStats(ctx context.Context, login string) (*documents.Stats, error) {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	var stats documents.Stats
+
+	err := ss.db.QueryRowContext(ctx, queryGetStats, login, toMicros(time.Now())).Scan(&stats.Documents, &stats.Size, &stats.StoredSize)
+	if err != nil {
+		ss.logger.Error("GetStats: failed to get stats", zap.Error(err))
+		return nil, fmt.Errorf("GetStats: failed to get stats: %w", err)
+	}
+
+	return &stats, nil
+}
+
+// RewrapDataKeys re-wraps data keys of all documents under the active master key
+// in batches and returns the number of updated documents. Content is not re-encrypted.
+func (ss *SQLiteService) RewrapDataKeys(ctx context.Context) (int, error) {
+	if !ss.keyring.Enabled() {
+		ss.logger.Error("RewrapDataKeys: encryption is disabled")
+		return 0, fmt.Errorf("RewrapDataKeys: %w", keyring.ErrNoActiveKey)
+	}
+
+	total := 0
+
+	batches := []struct {
+		selectQuery string
+		updateQuery string
+	}{
+		{querySelectKeysToRewrap, queryUpdateDataKey},
+		{querySelectVersionKeysToRewrap, queryUpdateVersionDataKey},
+	}
+
+	for _, batch := range batches {
+		for {
+			count, err := ss.rewrapBatch(ctx, batch.selectQuery, batch.updateQuery)
+			if err != nil {
+				return total, err
+			}
+
+			if count == 0 {
+				break
+			}
+
+			total += count
+		}
+	}
+
+	ss.logger.Info("RewrapDataKeys: successfully rewrapped data keys", zap.Int("count", total))
+	return total, nil
+}
+
+func (ss *SQLiteService) rewrapBatch(ctx context.Context, selectQuery string, updateQuery string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	tx, err := ss.db.BeginTx(ctx, nil)
+	if err != nil {
+		ss.logger.Error("rewrapBatch: failed to begin transaction", zap.Error(err))
+		return 0, fmt.Errorf("rewrapBatch: failed to begin transaction: %w", err)
+	}
+	defer ss.rollback(tx, "rewrapBatch")
+
+	type wrappedKey struct {
+		id      string
+		version int
+		keyID   string
+		dataKey []byte
+	}
+
+	rows, err := tx.QueryContext(ctx, selectQuery, ss.keyring.ActiveKeyID(), rewrapBatchSize)
+	if err != nil {
+		ss.logger.Error("rewrapBatch: failed to select data keys", zap.Error(err))
+		return 0, fmt.Errorf("rewrapBatch: failed to select data keys: %w", err)
+	}
+
+	keys, err := collectRows(rows, func(rows *sql.Rows) (wrappedKey, error) {
+		var key wrappedKey
+		err := rows.Scan(&key.id, &key.version, &key.keyID, &key.dataKey)
+		return key, err
+	})
+	if err != nil {
+		ss.logger.Error("rewrapBatch: failed to collect data keys", zap.Error(err))
+		return 0, fmt.Errorf("rewrapBatch: failed to collect data keys: %w", err)
+	}
+
+	for _, key := range keys {
+		rewrapped, keyID, err := ss.keyring.RewrapDataKey(key.keyID, key.dataKey, key.id)
+		if err != nil {
+			ss.logger.Error("rewrapBatch: failed to rewrap data key", zap.String("id", key.id), zap.Error(err))
+			return 0, fmt.Errorf("rewrapBatch: failed to rewrap data key of %s: %w", key.id, err)
+		}
+
+		_, err = tx.ExecContext(ctx, updateQuery, key.id, key.version, rewrapped, keyID)
+		if err != nil {
+			ss.logger.Error("rewrapBatch: failed to update data key", zap.Error(err))
+			return 0, fmt.Errorf("rewrapBatch: failed to update data key: %w", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		ss.logger.Error("rewrapBatch: failed to commit transaction", zap.Error(err))
+		return 0, fmt.Errorf("rewrapBatch: failed to commit transaction: %w", err)
+	}
+
+	return len(keys), nil
+}
+
+// PoolStats returns nothing, the database is a file opened by the process.
+func (ss *SQLiteService) PoolStats() []postgresClient.PoolStats {
+	return nil
+}
+
+func (ss *SQLiteService) Close() {
+	err := ss.db.Close()
+	if err != nil {
+		ss.logger.Warn("Close: failed to close database", zap.Error(err))
+	}
+}
+
+// rollback ends tx unless it was committed, it is deferred right after a transaction begins.
+func (ss *SQLiteService) rollback(tx *sql.Tx, caller string) {
+	err := tx.Rollback()
+	if err != nil && !errors.Is(err, sql.ErrTxDone) {
+		ss.logger.Warn(caller+": rollback failed", zap.Error(err))
+	}
+}
+
+// queryer is implemented by both *sql.DB and *sql.Tx.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// exists runs a SELECT EXISTS query.
+func exists(ctx context.Context, q queryer, query string, args ...any) (bool, error) {
+	var ok bool
+
+	err := q.QueryRowContext(ctx, query, args...).Scan(&ok)
+
+	return ok, err
+}
+
+// queryStrings returns the first column of all rows.
+func queryStrings(ctx context.Context, q queryer, query string, args ...any) ([]string, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return collectRows(rows, func(rows *sql.Rows) (string, error) {
+		var value string
+		err := rows.Scan(&value)
+		return value, err
+	})
+}
+
+// collectRows scans every row with scan and closes rows, like pgx.CollectRows.
+func collectRows[T any](rows *sql.Rows, scan func(rows *sql.Rows) (T, error)) ([]T, error) {
+	defer rows.Close()
+
+	values := make([]T, 0)
+
+	for rows.Next() {
+		value, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+	}
+
+	return values, rows.Err()
+}
+
+// affected returns the number of rows a statement changed, drivers that can not tell count as one.
+func affected(result sql.Result) int64 {
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 1
+	}
+
+	return count
+}
+
+// rowValues holds the columns of a document row that are stored in another form than the Document fields.
+type rowValues struct {
+	createdAt int64
+	updatedAt int64
+	expiresAt sql.NullInt64
+	deletedAt sql.NullInt64
+	tags      string
+	metadata  string
+	grant     sql.NullString
+}
+
+func (v *rowValues) fill(document *documents.Document) error {
+	document.CreatedAt = fromMicros(v.createdAt)
+	document.UpdatedAt = fromMicros(v.updatedAt)
+	document.ExpiresAt = nil
+
+	if v.expiresAt.Valid {
+		expiresAt := fromMicros(v.expiresAt.Int64)
+		document.ExpiresAt = &expiresAt
+	}
+
+	if v.deletedAt.Valid {
+		document.DeletedAt = fromMicros(v.deletedAt.Int64)
+	}
+
+	document.Tags = make([]string, 0)
+
+	err := json.Unmarshal([]byte(v.tags), &document.Tags)
+	if err != nil {
+		return fmt.Errorf("fill: invalid tags: %w", err)
+	}
+
+	document.Metadata = make(map[string]string)
+
+	err = json.Unmarshal([]byte(v.metadata), &document.Metadata)
+	if err != nil {
+		return fmt.Errorf("fill: invalid metadata: %w", err)
+	}
+
+	if v.grant.Valid {
+		document.Grant = make([]string, 0)
+
+		err = json.Unmarshal([]byte(v.grant.String), &document.Grant)
+		if err != nil {
+			return fmt.Errorf("fill: invalid grants: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// encodeLabels returns tags and metadata in their stored form, nil is stored empty.
+func encodeLabels(tags []string, metadata map[string]string) (string, string, error) {
+	if tags == nil {
+		tags = []string{}
+	}
+
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+
+	tagsBytes, err := json.Marshal(tags)
+	if err != nil {
+		return "", "", fmt.Errorf("encodeLabels: failed to marshal tags: %w", err)
+	}
+
+	metadataBytes, err := json.Marshal(metadata)
+	if err != nil {
+		return "", "", fmt.Errorf("encodeLabels: failed to marshal metadata: %w", err)
+	}
+
+	return string(tagsBytes), string(metadataBytes), nil
+}
+
+// jsonText stores document JSON as text, so that the JSON functions of SQLite can read it.
+func jsonText(raw []byte) any {
+	if raw == nil {
+		return nil
+	}
+
+	return string(raw)
+}
+
+func toMicros(t time.Time) int64 {
+	return t.UnixMicro()
+}
+
+func nullMicros(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+
+	return t.UnixMicro()
+}
+
+func fromMicros(micros int64) time.Time {
+	return time.UnixMicro(micros).UTC()
+}
@@ -0,0 +1,89 @@
+package sqliteClient
+
+import (
+	"context"
+	"encoding/base64"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"astral/internal/documents"
+	"astral/internal/keyring"
+	"astral/internal/storage/conformance"
+	"astral/internal/storage/postgres_client"
+)
+
+func newService(t *testing.T) *SQLiteService {
+	config := &Config{Path: filepath.Join(t.TempDir(), "astral.db"), Timeout: 5 * time.Second}
+
+	ss, err := New(context.Background(), config, &postgresClient.Config{}, nil, zap.NewNop())
+	require.NoError(t, err)
+
+	return ss
+}
+
+func TestConformance(t *testing.T) {
+	conformance.Run(t, func(t *testing.T) postgresClient.PostgresClient {
+		return newService(t)
+	})
+}
+
+func TestEncryptedQuery(t *testing.T) {
+	key := "k1:" + base64.StdEncoding.EncodeToString([]byte(strings.Repeat("a", 32)))
+
+	kr, err := keyring.New(&keyring.Config{Keys: []string{key}, ActiveKey: "k1"}, zap.NewNop())
+	require.NoError(t, err)
+
+	config := &Config{Path: filepath.Join(t.TempDir(), "astral.db"), Timeout: 5 * time.Second}
+
+	ss, err := New(context.Background(), config, &postgresClient.Config{}, kr, zap.NewNop())
+	require.NoError(t, err)
+	defer ss.Close()
+
+	ctx := context.Background()
+
+	_, err = ss.ListDocuments(ctx, &documents.ListQuery{Login: "owner123", Limit: 10})
+	require.NoError(t, err)
+
+	_, err = ss.ListDocuments(ctx, &documents.ListQuery{Login: "owner123", JSONContains: []byte(`{"a":1}`), Limit: 10})
+	require.ErrorIs(t, err, postgresClient.ErrEncryptedQuery)
+
+	_, err = ss.SearchDocuments(ctx, &documents.ListQuery{Login: "owner123", Search: "invoice", Limit: 10})
+	require.ErrorIs(t, err, postgresClient.ErrEncryptedQuery)
+}
+
+func TestMigrationsAreIdempotent(t *testing.T) {
+	ss := newService(t)
+	defer ss.Close()
+
+	require.NoError(t, ss.upMigration(context.Background()))
+}
+
+func TestMigrationVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		want    int
+		wantErr bool
+	}{
+		{name: "numbered", file: "000001_init.sql", want: 1},
+		{name: "no number", file: "init.sql", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, err := migrationVersion(tt.file)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, version)
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package sqliteClient
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"astral/internal/documents"
+	"astral/internal/storage/postgres_client"
+)
+
+// purgedDocument is a removed document together with the storage it gives back.
+type purgedDocument struct {
+	documents.Document
+	usageBytes int64
+}
+
+// DeleteDocument moves a document to the trash of its owner. A trashed document is
+// hidden from reads and listings until it is restored or purged. Held documents can not be deleted.
+func (ss *SQLiteService) DeleteDocument(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	result, err := ss.db.ExecContext(ctx, queryDeleteDocument, id, toMicros(time.Now()))
+	if err != nil {
+		ss.logger.Error("DeleteDocument: failed to delete document", zap.Error(err))
+		return fmt.Errorf("DeleteDocument: failed to delete document: %w", err)
+	}
+
+	if affected(result) == 0 {
+		return ss.notChanged(ctx, "DeleteDocument", id, "", false)
+	}
+
+	ss.logger.Info("DeleteDocument: successfully move document to trash", zap.String("id", id))
+	return nil
+}
+
+// ListTrash returns the trashed documents of login without content, most recently deleted first.
+func (ss *SQLiteService) ListTrash(ctx context.Context, login string) ([]documents.Document, error) {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	rows, err := ss.db.QueryContext(ctx, queryListTrash, login)
+	if err != nil {
+		ss.logger.Error("ListTrash: failed to list trash", zap.Error(err))
+		return nil, fmt.Errorf("ListTrash: failed to list trash: %w", err)
+	}
+
+	docs, err := collectRows(rows, func(rows *sql.Rows) (documents.Document, error) {
+		var (
+			document documents.Document
+			values   rowValues
+		)
+
+		err := rows.Scan(
+			&document.Id,
+			&document.Login,
+			&document.Name,
+			&document.Mime,
+			&document.DetectedMime,
+			&document.File,
+			&document.Public,
+			&values.createdAt,
+			&document.Codec,
+			&document.Size,
+			&document.Version,
+			&values.updatedAt,
+			&document.Schema,
+			&values.tags,
+			&values.metadata,
+			&document.FolderId,
+			&values.deletedAt,
+		)
+		if err != nil {
+			return document, err
+		}
+
+		return document, values.fill(&document)
+	})
+	if err != nil {
+		ss.logger.Error("ListTrash: failed to collect documents", zap.Error(err))
+		return nil, fmt.Errorf("ListTrash: failed to collect documents: %w", err)
+	}
+
+	return docs, nil
+}
+
+// RestoreDocument takes a document of login out of the trash.
+func (ss *SQLiteService) RestoreDocument(ctx context.Context, id string, login string) error {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	result, err := ss.db.ExecContext(ctx, queryRestoreDocument, id, login)
+	if err != nil {
+		ss.logger.Error("RestoreDocument: failed to restore document", zap.Error(err))
+		return fmt.Errorf("RestoreDocument: failed to restore document: %w", err)
+	}
+
+	if affected(result) == 0 {
+		ss.logger.Warn("RestoreDocument: document not found in trash", zap.String("id", id))
+		return postgresClient.ErrDocumentNotFound
+	}
+
+	ss.logger.Info("RestoreDocument: successfully restore document", zap.String("id", id))
+	return nil
+}
+
+// PurgeDocument removes a trashed document of login for good, together with its versions and grants.
+// Held documents can not be purged.
+func (ss *SQLiteService) PurgeDocument(ctx context.Context, id string, login string) error {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	purged, err := ss.purge(ctx, queryPurgeDocument, id, login)
+	if err != nil {
+		ss.logger.Error("PurgeDocument: failed to purge document", zap.Error(err))
+		return fmt.Errorf("PurgeDocument: failed to purge document: %w", err)
+	}
+
+	if len(purged) == 0 {
+		return ss.notChanged(ctx, "PurgeDocument", id, login, true)
+	}
+
+	ss.logger.Info("PurgeDocument: successfully purge document", zap.String("id", id))
+	return nil
+}
+
+// PurgeDocuments removes up to limit documents that were trashed before the given time
+// and returns their ids and owners. Content, versions and grants go with them.
+func (ss *SQLiteService) PurgeDocuments(ctx context.Context, before time.Time, limit int) ([]documents.Document, error) {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	purged, err := ss.purge(ctx, queryPurgeDocuments, toMicros(before), limit)
+	if err != nil {
+		ss.logger.Error("PurgeDocuments: failed to purge documents", zap.Error(err))
+		return nil, fmt.Errorf("PurgeDocuments: failed to purge documents: %w", err)
+	}
+
+	if len(purged) > 0 {
+		ss.logger.Info("PurgeDocuments: purged documents", zap.Int("count", len(purged)))
+	}
+
+	return purged, nil
+}
+
+// purge runs a DELETE ... RETURNING query and gives the storage of the removed documents
+// back to their owners in the same transaction. Returns the ids and owners of the removed documents.
+func (ss *SQLiteService) purge(ctx context.Context, query string, args ...any) ([]documents.Document, error) {
+	tx, err := ss.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("purge: failed to begin transaction: %w", err)
+	}
+	defer ss.rollback(tx, "purge")
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("purge: failed to delete documents: %w", err)
+	}
+
+	purged, err := collectRows(rows, func(rows *sql.Rows) (purgedDocument, error) {
+		var document purgedDocument
+
+		err := rows.Scan(&document.Id, &document.Login, &document.usageBytes)
+
+		return document, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("purge: failed to collect purged documents: %w", err)
+	}
+
+	err = ss.releaseUsage(ctx, tx, purged)
+	if err != nil {
+		return nil, fmt.Errorf("purge: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("purge: failed to commit transaction: %w", err)
+	}
+
+	docs := make([]documents.Document, 0, len(purged))
+	for _, document := range purged {
+		docs = append(docs, documents.Document{Id: document.Id, Login: document.Login})
+	}
+
+	return docs, nil
+}
@@ -0,0 +1,38 @@
+package sqliteClient
+
+import (
+	"database/sql"
+	"time"
+
+	"go.uber.org/zap"
+
+	"astral/internal/keyring"
+)
+
+const (
+	// driverName is the database/sql name of the pure-Go driver modernc.org/sqlite.
+	driverName = "sqlite"
+
+	rewrapBatchSize = 100
+)
+
+type Config struct {
+	Path    string        `env:"SQLITE_PATH" env-default:"./data/astral.db"`
+	Timeout time.Duration `env:"SQLITE_TIMEOUT" env-default:"5s"`
+}
+
+// SQLiteService implements postgresClient.PostgresClient on an embedded SQLite database
+// with the same errors and rules as the Postgres schema. All statements go through
+// one connection, so a transaction sees no concurrent writes.
+type SQLiteService struct {
+	db      *sql.DB
+	keyring *keyring.Keyring
+	logger  *zap.Logger
+	timeout time.Duration
+
+	versionsKeepLast int
+	versionsKeepDays int
+
+	quotaDocuments int64
+	quotaBytes     int64
+}
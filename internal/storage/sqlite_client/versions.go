@@ -0,0 +1,373 @@
+package sqliteClient
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"astral/internal/documents"
+	"astral/internal/sealing"
+	"astral/internal/storage/postgres_client"
+)
+
+// UpdateDocument replaces the content of a document and keeps the previous one as a version.
+// When expectedVersion is positive the update only succeeds if it is still the current version.
+// On success document.Version holds the new version.
+// The new content is charged to the owner once pruned versions are given back; when it does not
+// fit the quota the update fails with documents.ErrQuotaExceeded and nothing changes.
+func (ss *SQLiteService) UpdateDocument(ctx context.Context, document *documents.Document, expectedVersion int) error {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	sealed, err := sealing.Seal(ss.keyring, document)
+	if err != nil {
+		ss.logger.Error("UpdateDocument: failed to seal document", zap.Error(err))
+		return fmt.Errorf("UpdateDocument: failed to seal document: %w", err)
+	}
+
+	tags, metadata, err := encodeLabels(document.Tags, document.Metadata)
+	if err != nil {
+		return fmt.Errorf("UpdateDocument: %w", err)
+	}
+
+	tx, err := ss.db.BeginTx(ctx, nil)
+	if err != nil {
+		ss.logger.Error("UpdateDocument: failed to begin transaction", zap.Error(err))
+		return fmt.Errorf("UpdateDocument: failed to begin transaction: %w", err)
+	}
+	defer ss.rollback(tx, "UpdateDocument")
+
+	current, err := ss.lockDocument(ctx, tx, document.Id)
+	if err != nil {
+		return fmt.Errorf("UpdateDocument: %w", err)
+	}
+
+	if expectedVersion > 0 && current.version != expectedVersion {
+		ss.logger.Warn("UpdateDocument: version conflict",
+			zap.Int("expected", expectedVersion),
+			zap.Int("current", current.version),
+		)
+		return postgresClient.ErrVersionConflict
+	}
+
+	err = ss.archive(ctx, tx, document.Id, toMicros(time.Now()))
+	if err != nil {
+		return fmt.Errorf("UpdateDocument: %w", err)
+	}
+
+	err = tx.QueryRowContext(ctx, queryUpdateDocument,
+		document.Id,
+		document.Name,
+		document.Mime,
+		document.File,
+		sealed.Content,
+		jsonText(sealed.JSON),
+		document.Codec,
+		document.Size,
+		sealed.DataKey,
+		sealed.KeyID,
+		sealed.SealedJSON,
+		toMicros(document.UpdatedAt),
+		document.Schema,
+		sealed.Text,
+		tags,
+		metadata,
+		document.UsageBytes(),
+		document.DetectedMime,
+	).Scan(&document.Version)
+	if err != nil {
+		ss.logger.Error("UpdateDocument: failed to update document", zap.Error(err))
+		return fmt.Errorf("UpdateDocument: failed to update document: %w", err)
+	}
+
+	freed, err := ss.pruneVersions(ctx, tx, document.Id, document.Version)
+	if err != nil {
+		return fmt.Errorf("UpdateDocument: %w", err)
+	}
+
+	// The replaced content stays charged as a version, the new one comes on top.
+	err = ss.chargeUsage(ctx, tx, current.login, document.UsageBytes()-freed, 0)
+	if err != nil {
+		return fmt.Errorf("UpdateDocument: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		ss.logger.Error("UpdateDocument: failed to commit transaction", zap.Error(err))
+		return fmt.Errorf("UpdateDocument: failed to commit transaction: %w", err)
+	}
+
+	ss.logger.Info("UpdateDocument: successfully update document",
+		zap.String("id", document.Id),
+		zap.Int("version", document.Version),
+	)
+	return nil
+}
+
+// ListVersions returns the archived versions of a document without content, newest first.
+func (ss *SQLiteService) ListVersions(ctx context.Context, id string) ([]documents.Document, error) {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	rows, err := ss.db.QueryContext(ctx, queryListVersions, id)
+	if err != nil {
+		ss.logger.Error("ListVersions: failed to list versions", zap.Error(err))
+		return nil, fmt.Errorf("ListVersions: failed to list versions: %w", err)
+	}
+
+	versions, err := collectRows(rows, func(rows *sql.Rows) (documents.Document, error) {
+		var createdAt int64
+
+		version := documents.Document{Id: id}
+
+		err := rows.Scan(
+			&version.Version,
+			&version.Name,
+			&version.Mime,
+			&version.File,
+			&version.Codec,
+			&version.Size,
+			&createdAt,
+		)
+		version.CreatedAt = fromMicros(createdAt)
+
+		return version, err
+	})
+	if err != nil {
+		ss.logger.Error("ListVersions: failed to collect versions", zap.Error(err))
+		return nil, fmt.Errorf("ListVersions: failed to collect versions: %w", err)
+	}
+
+	return versions, nil
+}
+
+// GetVersion returns an archived version of a document with its content.
+// Ownership and grants are not part of a version and stay empty.
+func (ss *SQLiteService) GetVersion(ctx context.Context, id string, version int) (*documents.Document, error) {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	var (
+		sealed    sealing.Content
+		jsonDoc   sql.NullString
+		createdAt int64
+	)
+
+	document := documents.Document{
+		Id:      id,
+		Version: version,
+	}
+
+	err := ss.db.QueryRowContext(ctx, queryGetVersion, id, version).Scan(
+		&document.Name,
+		&document.Mime,
+		&document.File,
+		&sealed.Content,
+		&jsonDoc,
+		&document.Codec,
+		&document.Size,
+		&sealed.DataKey,
+		&sealed.KeyID,
+		&sealed.SealedJSON,
+		&createdAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ss.logger.Warn("GetVersion: version not found", zap.String("id", id), zap.Int("version", version))
+			return nil, postgresClient.ErrVersionNotFound
+		}
+
+		ss.logger.Error("GetVersion: failed to get version", zap.Error(err))
+		return nil, fmt.Errorf("GetVersion: failed to get version: %w", err)
+	}
+
+	document.CreatedAt = fromMicros(createdAt)
+
+	if jsonDoc.Valid {
+		sealed.JSON = []byte(jsonDoc.String)
+	}
+
+	err = sealing.Open(ss.keyring, &document, &sealed)
+	if err != nil {
+		ss.logger.Error("GetVersion: failed to open version", zap.Error(err))
+		return nil, fmt.Errorf("GetVersion: failed to open version: %w", err)
+	}
+
+	return &document, nil
+}
+
+// RestoreVersion makes an archived version current again. The replaced content
+// is archived like on any other update. Returns the new version number.
+// The restored copy is charged to the owner like new content, documents.ErrQuotaExceeded
+// is returned when it does not fit.
+func (ss *SQLiteService) RestoreVersion(ctx context.Context, id string, version int) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, ss.timeout)
+	defer cancel()
+
+	tx, err := ss.db.BeginTx(ctx, nil)
+	if err != nil {
+		ss.logger.Error("RestoreVersion: failed to begin transaction", zap.Error(err))
+		return 0, fmt.Errorf("RestoreVersion: failed to begin transaction: %w", err)
+	}
+	defer ss.rollback(tx, "RestoreVersion")
+
+	locked, err := ss.lockDocument(ctx, tx, id)
+	if err != nil {
+		return 0, fmt.Errorf("RestoreVersion: %w", err)
+	}
+
+	now := toMicros(time.Now())
+
+	err = ss.archive(ctx, tx, id, now)
+	if err != nil {
+		return 0, fmt.Errorf("RestoreVersion: %w", err)
+	}
+
+	var (
+		current    int
+		usageBytes int64
+	)
+
+	err = tx.QueryRowContext(ctx, queryRestoreVersion, id, version, now).Scan(&current, &usageBytes)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ss.logger.Warn("RestoreVersion: version not found", zap.String("id", id), zap.Int("version", version))
+			return 0, postgresClient.ErrVersionNotFound
+		}
+
+		ss.logger.Error("RestoreVersion: failed to restore version", zap.Error(err))
+		return 0, fmt.Errorf("RestoreVersion: failed to restore version: %w", err)
+	}
+
+	freed, err := ss.pruneVersions(ctx, tx, id, current)
+	if err != nil {
+		return 0, fmt.Errorf("RestoreVersion: %w", err)
+	}
+
+	// The restored content is a copy of the version, which is kept, so it is charged in full.
+	err = ss.chargeUsage(ctx, tx, locked.login, usageBytes-freed, 0)
+	if err != nil {
+		return 0, fmt.Errorf("RestoreVersion: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		ss.logger.Error("RestoreVersion: failed to commit transaction", zap.Error(err))
+		return 0, fmt.Errorf("RestoreVersion: failed to commit transaction: %w", err)
+	}
+
+	ss.logger.Info("RestoreVersion: successfully restore version",
+		zap.String("id", id),
+		zap.Int("restored", version),
+		zap.Int("version", current),
+	)
+	return current, nil
+}
+
+// lockedDocument is what an update needs to know about the document it changes.
+type lockedDocument struct {
+	version int
+	login   string
+}
+
+// lockDocument returns the version and owner of a live document. The single
+// connection keeps other writers out until tx ends, like FOR UPDATE does in Postgres.
+func (ss *SQLiteService) lockDocument(ctx context.Context, tx *sql.Tx, id string) (*lockedDocument, error) {
+	var locked lockedDocument
+
+	err := tx.QueryRowContext(ctx, queryLockDocument, id).Scan(&locked.version, &locked.login)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ss.logger.Warn("lockDocument: document not found", zap.String("id", id))
+			return nil, postgresClient.ErrDocumentNotFound
+		}
+
+		ss.logger.Error("lockDocument: failed to lock document", zap.Error(err))
+		return nil, fmt.Errorf("lockDocument: failed to lock document: %w", err)
+	}
+
+	return &locked, nil
+}
+
+// archive keeps the current content of a document as a version, archived at now.
+func (ss *SQLiteService) archive(ctx context.Context, tx *sql.Tx, id string, now int64) error {
+	_, err := tx.ExecContext(ctx, queryArchiveDocument, id, now)
+	if err != nil {
+		ss.logger.Error("archive: failed to archive document", zap.Error(err))
+		return fmt.Errorf("archive: failed to archive document: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, queryArchiveUsage, id)
+	if err != nil {
+		ss.logger.Error("archive: failed to move usage to versions", zap.Error(err))
+		return fmt.Errorf("archive: failed to move usage to versions: %w", err)
+	}
+
+	return nil
+}
+
+// pruneVersions removes archived versions that fall outside the configured retention
+// and returns the bytes they took, for the caller to give back to the owner.
+// A held document keeps them all, how many were kept is logged.
+func (ss *SQLiteService) pruneVersions(ctx context.Context, tx *sql.Tx, id string, current int) (int64, error) {
+	rows, err := tx.QueryContext(ctx, queryPruneVersions, id, ss.versionsKeepLast, current, ss.versionsKeepDays)
+	if err != nil {
+		ss.logger.Error("pruneVersions: failed to prune versions", zap.Error(err))
+		return 0, fmt.Errorf("pruneVersions: failed to prune versions: %w", err)
+	}
+
+	pruned, err := collectRows(rows, func(rows *sql.Rows) (int64, error) {
+		var usageBytes int64
+
+		err := rows.Scan(&usageBytes)
+
+		return usageBytes, err
+	})
+	if err != nil {
+		ss.logger.Error("pruneVersions: failed to collect pruned versions", zap.Error(err))
+		return 0, fmt.Errorf("pruneVersions: failed to collect pruned versions: %w", err)
+	}
+
+	if len(pruned) == 0 {
+		ss.logHeldVersions(ctx, tx, id, current)
+		return 0, nil
+	}
+
+	var freed int64
+	for _, usageBytes := range pruned {
+		freed += usageBytes
+	}
+
+	_, err = tx.ExecContext(ctx, queryReleaseVersions, id, freed)
+	if err != nil {
+		ss.logger.Error("pruneVersions: failed to release usage", zap.Error(err))
+		return 0, fmt.Errorf("pruneVersions: failed to release usage: %w", err)
+	}
+
+	ss.logger.Info("pruneVersions: pruned versions", zap.String("id", id), zap.Int("count", len(pruned)))
+
+	return freed, nil
+}
+
+// logHeldVersions logs the versions pruneVersions had to keep because the document is held.
+func (ss *SQLiteService) logHeldVersions(ctx context.Context, tx *sql.Tx, id string, current int) {
+	if ss.versionsKeepLast <= 0 && ss.versionsKeepDays <= 0 {
+		return
+	}
+
+	var kept int64
+
+	err := tx.QueryRowContext(ctx, queryCountHeldVersions, id, ss.versionsKeepLast, current, ss.versionsKeepDays).Scan(&kept)
+	if err != nil {
+		ss.logger.Warn("logHeldVersions: failed to count held versions", zap.Error(err))
+		return
+	}
+
+	if kept > 0 {
+		ss.logger.Info("pruneVersions: kept expired versions of a held document", zap.String("id", id), zap.Int64("count", kept))
+	}
+}
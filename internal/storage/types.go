@@ -0,0 +1,14 @@
+package storage
+
+const (
+	BackendPostgres = "postgres"
+	BackendMemory   = "memory"
+	BackendSQLite   = "sqlite"
+)
+
+type Config struct {
+	// Backend is postgres to keep data in Postgres and Redis, sqlite to keep it in one
+	// database file for single-binary installs, or memory to keep everything in the process
+	// for development and tests. Memory data is lost on restart.
+	Backend string `env:"STORAGE" env-default:"postgres"`
+}
@@ -0,0 +1,152 @@
+package textExtractor
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+var markdownSyntax = regexp.MustCompile("(?m)^\\s{0,3}(#{1,6}|>|[-*+]|\\d+\\.)\\s+|[*_`~]+|!?\\[([^\\]]*)\\]\\([^)]*\\)")
+
+func New(config *Config, logger *zap.Logger) *Extractor {
+	return &Extractor{
+		config: config,
+		logger: logger,
+	}
+}
+
+// Extract returns the searchable text of a document: the values of its JSON payload
+// and the text of file content for text-like types (text/*, JSON, CSV and Markdown).
+// Content of other types is ignored. The result is cut to the configured size.
+func (e *Extractor) Extract(mimeType string, content []byte, jsonData []byte) string {
+	var parts []string
+
+	if len(jsonData) > 0 {
+		parts = append(parts, jsonText(jsonData))
+	}
+
+	if len(content) > 0 {
+		if text, ok := e.contentText(mimeType, content); ok {
+			parts = append(parts, text)
+		}
+	}
+
+	return truncate(strings.Join(parts, "\n"), e.config.MaxSize)
+}
+
+func (e *Extractor) contentText(mimeType string, content []byte) (string, bool) {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(mimeType))
+	}
+
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return jsonText(content), true
+
+	case mediaType == "text/csv":
+		return csvText(content), true
+
+	case mediaType == "text/markdown" || mediaType == "text/x-markdown":
+		return markdownSyntax.ReplaceAllString(plainText(content), "$2"), true
+
+	case strings.HasPrefix(mediaType, "text/"):
+		return plainText(content), true
+
+	default:
+		e.logger.Debug("contentText: type is not indexed", zap.String("mime", mediaType))
+		return "", false
+	}
+}
+
+// jsonText joins the string and number values of a JSON document and falls back
+// to the raw text when it cannot be parsed. Object keys are not included.
+func jsonText(data []byte) string {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var doc interface{}
+
+	if err := dec.Decode(&doc); err != nil {
+		return plainText(data)
+	}
+
+	var values []string
+	collectValues(doc, &values)
+
+	return strings.Join(values, " ")
+}
+
+func collectValues(value interface{}, values *[]string) {
+	switch v := value.(type) {
+	case string:
+		*values = append(*values, plainText([]byte(v)))
+	case json.Number:
+		*values = append(*values, v.String())
+	case []interface{}:
+		for _, item := range v {
+			collectValues(item, values)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			collectValues(v[key], values)
+		}
+	}
+}
+
+// csvText joins all fields of a CSV file, keeping one line per record.
+func csvText(data []byte) string {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	var sb strings.Builder
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return plainText(data)
+		}
+
+		sb.WriteString(strings.Join(record, " "))
+		sb.WriteByte('\n')
+	}
+
+	return sb.String()
+}
+
+// plainText returns data as a string without invalid UTF-8 and NUL bytes, which Postgres text does not accept.
+func plainText(data []byte) string {
+	return strings.ReplaceAll(strings.ToValidUTF8(string(data), ""), "\x00", "")
+}
+
+func truncate(s string, size int) string {
+	if size <= 0 || len(s) <= size {
+		return s
+	}
+
+	s = s[:size]
+	for len(s) > 0 && !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+
+	return s
+}
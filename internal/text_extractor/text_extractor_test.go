@@ -0,0 +1,76 @@
+package textExtractor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name     string
+		mime     string
+		content  string
+		jsonData string
+		want     string
+	}{
+		{
+			name:    "plain text",
+			mime:    "text/plain; charset=utf-8",
+			content: "quarterly report\x00",
+			want:    "quarterly report",
+		},
+		{
+			name:    "json file values without keys",
+			mime:    "application/json",
+			content: `{"customer": {"name": "Apollo"}, "amount": 120.5, "paid": true, "lines": ["bolts", "nuts"]}`,
+			want:    "120.5 Apollo bolts nuts",
+		},
+		{
+			name:    "csv",
+			mime:    "text/csv",
+			content: "sku,title\nA-1,\"steel, bolts\"\n",
+			want:    "sku title\nA-1 steel, bolts\n",
+		},
+		{
+			name:    "markdown",
+			mime:    "text/markdown",
+			content: "# Release notes\n- **fast** [search](http://example.com)\n",
+			want:    "Release notes\nfast search\n",
+		},
+		{
+			name:    "binary file is ignored",
+			mime:    "image/png",
+			content: "\x89PNG",
+			want:    "",
+		},
+		{
+			name:     "json payload and file",
+			mime:     "text/plain",
+			content:  "body",
+			jsonData: `{"title": "invoice"}`,
+			want:     "invoice\nbody",
+		},
+		{
+			name:     "broken json payload",
+			jsonData: `{"title": "inv`,
+			want:     `{"title": "inv`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New(&Config{MaxSize: 1024}, zap.NewNop())
+			require.Equal(t, tt.want, e.Extract(tt.mime, []byte(tt.content), []byte(tt.jsonData)))
+		})
+	}
+}
+
+func TestExtractTruncates(t *testing.T) {
+	e := New(&Config{MaxSize: 5}, zap.NewNop())
+
+	text := e.Extract("text/plain", []byte(strings.Repeat("я", 10)), nil)
+	require.Equal(t, "яя", text)
+}
@@ -0,0 +1,23 @@
+package textExtractor
+
+import (
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type Config struct {
+	MaxSize int `env:"SEARCH_MAX_TEXT_SIZE" env-default:"262144"`
+}
+
+type Extractor struct {
+	config *Config
+	logger *zap.Logger
+}
+
+type TextExtractor interface {
+	Extract(mime string, content []byte, jsonData []byte) string
+}
+
+type MockTextExtractor struct {
+	mock.Mock
+}
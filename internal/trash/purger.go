@@ -0,0 +1,47 @@
+package trash
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"astral/internal/documents"
+	"astral/internal/purge"
+)
+
+func New(config *Config, store Store, cache purge.Cache, logger *zap.Logger) *Purger {
+	return &Purger{
+		config: config,
+		store:  store,
+		cache:  cache,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// Run purges expired trash every PurgeInterval until ctx is done.
+func (p *Purger) Run(ctx context.Context) {
+	purge.Every(ctx, p.config.PurgeInterval, p.PurgeOnce, p.logger)
+}
+
+// PurgeOnce removes every document that has been in the trash for longer than
+// Retention, batch by batch, and drops its cache entries. It returns the number
+// of purged documents.
+func (p *Purger) PurgeOnce(ctx context.Context) (int, error) {
+	before := p.now().Add(-p.config.Retention)
+
+	total, err := purge.Batches(ctx, func(ctx context.Context, limit int) ([]documents.Document, error) {
+		return p.store.PurgeDocuments(ctx, before, limit)
+	}, p.config.PurgeBatch, p.cache, p.logger)
+	if err != nil {
+		return total, fmt.Errorf("PurgeOnce: failed to purge documents: %w", err)
+	}
+
+	if total > 0 {
+		p.logger.Info("PurgeOnce: purged trash", zap.Int("count", total))
+	}
+
+	return total, nil
+}
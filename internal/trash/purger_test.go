@@ -0,0 +1,46 @@
+package trash
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"astral/internal/documents"
+)
+
+type fakeStore struct {
+	before time.Time
+	err    error
+}
+
+func (s *fakeStore) PurgeDocuments(_ context.Context, before time.Time, _ int) ([]documents.Document, error) {
+	s.before = before
+	return nil, s.err
+}
+
+type nopCache struct{}
+
+func (nopCache) InvalidateDocument(context.Context, string) error { return nil }
+func (nopCache) InvalidateDocs(context.Context, string) error     { return nil }
+
+func TestPurgeOnce(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	store := &fakeStore{}
+
+	p := New(&Config{Retention: 24 * time.Hour, PurgeBatch: 2}, store, nopCache{}, zap.NewNop())
+	p.now = func() time.Time { return now }
+
+	count, err := p.PurgeOnce(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, count)
+	require.Equal(t, now.Add(-24*time.Hour), store.before, "documents trashed before the retention are purged")
+
+	store.err = errors.New("connection refused")
+
+	_, err = p.PurgeOnce(context.Background())
+	require.ErrorIs(t, err, store.err)
+}
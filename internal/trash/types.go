@@ -0,0 +1,40 @@
+package trash
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+
+	"astral/internal/documents"
+	"astral/internal/purge"
+)
+
+type Config struct {
+	Retention     time.Duration `env:"TRASH_RETENTION" env-default:"720h"`
+	PurgeInterval time.Duration `env:"TRASH_PURGE_INTERVAL" env-default:"1h"`
+	PurgeBatch    int           `env:"TRASH_PURGE_BATCH" env-default:"500"`
+}
+
+// Store removes trashed documents for good.
+type Store interface {
+	PurgeDocuments(ctx context.Context, before time.Time, limit int) ([]documents.Document, error)
+}
+
+type Purger struct {
+	config *Config
+	store  Store
+	cache  purge.Cache
+	logger *zap.Logger
+	now    func() time.Time
+}
+
+type TrashPurger interface {
+	Run(ctx context.Context)
+	PurgeOnce(ctx context.Context) (int, error)
+}
+
+type MockTrashPurger struct {
+	mock.Mock
+}